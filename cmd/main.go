@@ -9,9 +9,14 @@ import (
 	"syscall"
 	"time"
 
+	"donationbars/internal/ai"
 	"donationbars/internal/config"
+	"donationbars/internal/events"
 	"donationbars/internal/handlers"
 	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+	"donationbars/internal/render"
 	"donationbars/internal/repository"
 	"donationbars/internal/services"
 
@@ -42,35 +47,26 @@ func main() {
 		os.Exit(1)
 	}
 
+	// "donationbars migrate [up|status]" applies schema migrations and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
+	}
+
+	// "donationbars plan <email> <plan>" assigns a plan to a user and exits
+	if len(os.Args) > 1 && os.Args[1] == "plan" {
+		os.Exit(runPlan(cfg, os.Args[2:]))
+	}
+
 	slog.Info("Configuration loaded successfully",
 		"port", cfg.Port,
+		"storage_driver", cfg.Storage.Driver,
+		"auto_migrate", cfg.Storage.AutoMigrate,
 		"db_name", cfg.DBName,
-		"max_bars_per_user", cfg.MaxBarsPerUser,
-		"rate_limit_per_day", cfg.RateLimitPerDay,
+		"default_plan", cfg.DefaultPlan,
+		"rate_limit_per_day", cfg.RateLimit.BarsPerDay,
+		"rate_limit_timezone", cfg.RateLimit.Timezone,
 		"redis_enabled", cfg.Redis.Enabled)
 
-	// Initialize database with improved connection handling
-	db, err := config.InitDB(cfg.MongoURI, cfg.Timeouts)
-	if err != nil {
-		slog.Error("Critical: Failed to connect to database",
-			"error", err.Error(),
-			"mongo_uri", cfg.MongoURI)
-		slog.Error("Application cannot start without database connection")
-		os.Exit(1)
-	}
-	slog.Info("Database initialized successfully")
-
-	// Ensure database cleanup on exit
-	defer func() {
-		if db != nil {
-			if err := db.Disconnect(); err != nil {
-				slog.Error("Failed to disconnect from database", "error", err.Error())
-			} else {
-				slog.Info("Database connection closed")
-			}
-		}
-	}()
-
 	// Initialize Redis connection
 	redisClient, err := config.InitRedis(cfg.Redis, cfg.Timeouts.RedisOperation)
 	if err != nil {
@@ -90,30 +86,103 @@ func main() {
 		}
 	}()
 
+	// Open the storage backend (MongoDB or embedded SQLite)
+	stores, err := repository.OpenStores(cfg, redisClient)
+	if err != nil {
+		slog.Error("Critical: Failed to open storage",
+			"error", err.Error(),
+			"driver", cfg.Storage.Driver)
+		slog.Error("Application cannot start without database connection")
+		os.Exit(1)
+	}
+	slog.Info("Database initialized successfully", "driver", stores.Driver)
+
+	// Ensure database cleanup on exit
+	defer func() {
+		if err := stores.Close(); err != nil {
+			slog.Error("Failed to close database", "error", err.Error())
+		} else {
+			slog.Info("Database connection closed")
+		}
+	}()
+
+	// Root context for background workers, cancelled on shutdown
+	appCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
 	// Initialize dependencies with interface-based dependency injection
-	var barRepo interfaces.BarRepositoryInterface
 	var barService interfaces.BarServiceInterface
 	var aiService interfaces.AIServiceInterface
-
-	// Initialize repository
-	barRepo = repository.NewBarRepository(db, cfg.Timeouts)
-	slog.Info("Repository initialized")
+	var donationService interfaces.DonationServiceInterface
+	var authService interfaces.AuthServiceInterface
+	var jobService interfaces.JobServiceInterface
+	var apiKeyService interfaces.APIKeyServiceInterface
+	var quotaService interfaces.QuotaServiceInterface
+	var webhookService interfaces.WebhookServiceInterface
+	var eventService interfaces.EventServiceInterface
+
+	// Initialize repositories
+	barRepo := stores.Bars
+	donationRepo := stores.Donations
+	revisionRepo := stores.Revisions
+	userRepo := stores.Users
+	apiKeyRepo := stores.APIKeys
+	sessionStore := stores.Sessions
+	jobStore := stores.Jobs
+	slog.Info("Repositories initialized", "driver", stores.Driver)
+
+	// Initialize event broker for live overlays (Redis pub/sub across instances)
+	broker := events.NewBroker(appCtx, redisClient, cfg.Timeouts.RedisOperation)
+
+	// Per-user limits, shared across instances when Redis is enabled
+	limits := ratelimit.NewGuard(
+		ratelimit.NewLimiter(redisClient, cfg.Timeouts.RedisOperation),
+		ratelimit.PoliciesFromConfig(cfg.RateLimit),
+	)
 
 	// Initialize services with dependency injection
-	barService = services.NewBarService(barRepo, redisClient, cfg)
-	aiService = services.NewAIService(cfg.OpenAIKey, cfg.Timeouts.AI)
+	quotaService = services.NewQuotaService(userRepo, barRepo, stores.Usage, cfg)
+	eventService = services.NewEventService(barRepo, stores.Events, stores.Hooks, stores.Deliveries, cfg)
+	eventService.Start(appCtx)
+	barService = services.NewBarService(barRepo, revisionRepo, quotaService, limits, broker, eventService, cfg)
+	aiProvider, err := ai.NewProvider(cfg)
+	if err != nil {
+		slog.Error("Failed to initialize AI provider", "error", err.Error())
+	}
+	aiService = services.NewAIService(aiProvider, cfg.Timeouts.AI)
+	donationService = services.NewDonationService(donationRepo, barRepo, broker, eventService, limits, cfg)
+	authService = services.NewAuthService(userRepo, sessionStore, cfg)
+	apiKeyService = services.NewAPIKeyService(apiKeyRepo, userRepo, cfg)
+	webhookService = services.NewWebhookService(barService, donationRepo, limits, cfg)
+	jobService = services.NewJobService(jobStore, aiService, barService, quotaService, limits, cfg)
+	jobService.Start(appCtx)
 	slog.Info("Services initialized",
 		"redis_rate_limiting", redisClient.IsEnabled(),
-		"ai_service_ready", cfg.OpenAIKey != "")
+		"ai_provider", cfg.AI.Provider,
+		"ai_service_ready", aiProvider != nil)
 
 	// Initialize handlers with service interfaces
-	h := handlers.New(barService, aiService)
+	h := handlers.New(handlers.Dependencies{
+		BarService:      barService,
+		AIService:       aiService,
+		DonationService: donationService,
+		AuthService:     authService,
+		JobService:      jobService,
+		APIKeyService:   apiKeyService,
+		WebhookService:  webhookService,
+		EventService:    eventService,
+		QuotaService:    quotaService,
+		Broker:          broker,
+		RateLimits:      limits,
+		Session:         cfg.Session,
+	})
 	slog.Info("Handlers initialized")
 
 	// Setup router
 	r := gin.Default()
 
 	// Load HTML templates
+	r.SetFuncMap(render.TemplateFuncs())
 	r.LoadHTMLGlob("templates/*.html")
 
 	// CORS middleware
@@ -142,29 +211,74 @@ func main() {
 		return ""
 	}))
 
-	// API routes
-	api := r.Group("/api/v1")
+	// API routes (session cookie or "Authorization: Bearer" API key required,
+	// API keys are limited to the scope of each route)
+	api := r.Group("/api/v1", h.Authenticate(), h.AuthenticateAPIKey(), h.RequireAPIUser())
+	{
+		api.POST("/bars", h.RequireScope(models.ScopeBarsWrite), h.CreateBar)
+		api.GET("/bars", h.RequireScope(models.ScopeBarsRead), h.GetUserBars)
+		api.GET("/bars/:id", h.RequireScope(models.ScopeBarsRead), h.GetBar)
+		api.PUT("/bars/:id", h.RequireScope(models.ScopeBarsWrite), h.UpdateBar)
+		api.DELETE("/bars/:id", h.RequireScope(models.ScopeBarsWrite), h.DeleteBar)
+		api.POST("/bars/:id/increment", h.RequireScope(models.ScopeBarsWrite), h.IncrementBar)
+		api.POST("/bars/generate", h.RequireScope(models.ScopeAIGenerate), h.GenerateBarWithAI)
+		api.POST("/bars/:id/donations", h.RequireScope(models.ScopeDonationsWrite), h.AddDonation)
+		api.GET("/bars/:id/donations", h.RequireScope(models.ScopeBarsRead), h.GetDonations)
+		api.POST("/bars/:id/overlay-token", h.RequireScope(models.ScopeBarsWrite), h.RegenerateOverlayToken)
+		api.POST("/bars/:id/webhook", h.RequireScope(models.ScopeBarsWrite), h.RegenerateWebhook)
+		api.GET("/bars/:id/hooks", h.RequireScope(models.ScopeBarsRead), h.GetOutgoingWebhooks)
+		api.POST("/bars/:id/hooks", h.RequireScope(models.ScopeBarsWrite), h.CreateOutgoingWebhook)
+		api.DELETE("/bars/:id/hooks/:hook", h.RequireScope(models.ScopeBarsWrite), h.DeleteOutgoingWebhook)
+		api.GET("/bars/:id/deliveries", h.RequireScope(models.ScopeBarsRead), h.GetWebhookDeliveries)
+		api.POST("/bars/:id/refine", h.RequireScope(models.ScopeAIGenerate), h.RequireScope(models.ScopeBarsWrite), h.RefineBar)
+		api.GET("/bars/:id/revisions", h.RequireScope(models.ScopeBarsRead), h.GetBarRevisions)
+		api.POST("/bars/:id/revisions/:rev/restore", h.RequireScope(models.ScopeBarsWrite), h.RestoreBarRevision)
+		api.GET("/jobs/:id", h.RequireScope(models.ScopeAIGenerate), h.GetJob)
+		api.GET("/me/quota", h.RequireScope(models.ScopeBarsRead), h.GetQuota)
+	}
+
+	// Account routes
+	account := r.Group("/", h.Authenticate())
 	{
-		api.POST("/bars", h.CreateBar)
-		api.GET("/bars", h.GetUserBars)
-		api.GET("/bars/:id", h.GetBar)
-		api.PUT("/bars/:id", h.UpdateBar)
-		api.DELETE("/bars/:id", h.DeleteBar)
-		api.POST("/bars/generate", h.GenerateBarWithAI)
+		account.GET("/login", h.LoginPage)
+		account.POST("/login", h.Login)
+		account.GET("/signup", h.SignupPage)
+		account.POST("/signup", h.Signup)
+		account.POST("/logout", h.Logout)
 	}
 
-	// Web routes (Server-Side Rendering)
-	r.GET("/", h.HomePage)
-	r.GET("/create", h.CreatePage)
-	r.POST("/create", h.CreateBarForm)
-	r.POST("/create/ai", h.CreateBarAIForm)
-	r.POST("/create/ai/save", h.SaveAIBarForm)
-	r.GET("/edit/:id", h.EditPage)
-	r.POST("/edit/:id", h.EditBarForm)
-	r.GET("/manage", h.ManagePage)
-	r.POST("/manage/:id/toggle", h.ToggleBarStatus)
-	r.POST("/manage/:id/delete", h.DeleteBarForm)
-	r.GET("/preview/:id", h.PreviewBar)
+	// Web routes (Server-Side Rendering, login required)
+	web := r.Group("/", h.Authenticate(), h.RequireUser())
+	{
+		web.GET("/", h.HomePage)
+		web.GET("/create", h.CreatePage)
+		web.POST("/create", h.CreateBarForm)
+		web.POST("/create/ai", h.CreateBarAIForm)
+		web.GET("/create/ai/jobs/:id", h.AIJobPage)
+		web.POST("/create/ai/save", h.SaveAIBarForm)
+		web.GET("/edit/:id", h.EditPage)
+		web.POST("/edit/:id", h.EditBarForm)
+		web.GET("/manage", h.ManagePage)
+		web.POST("/manage/:id/toggle", h.ToggleBarStatus)
+		web.POST("/manage/:id/delete", h.DeleteBarForm)
+		web.POST("/edit/:id/overlay-token", h.RegenerateOverlayTokenForm)
+		web.POST("/edit/:id/webhook", h.RegenerateWebhookForm)
+		web.POST("/edit/:id/hooks", h.CreateOutgoingWebhookForm)
+		web.POST("/edit/:id/hooks/:hook/delete", h.DeleteOutgoingWebhookForm)
+		web.POST("/edit/:id/refine", h.RefineBarForm)
+		web.POST("/edit/:id/revisions/:rev/restore", h.RestoreBarRevisionForm)
+		web.GET("/preview/:id", h.PreviewBar)
+		web.GET("/account/api-keys", h.APIKeysPage)
+		web.POST("/account/api-keys", h.CreateAPIKey)
+		web.POST("/account/api-keys/:id/revoke", h.RevokeAPIKey)
+	}
+
+	// Public OBS overlay (no user identity, keyed by secret token)
+	r.GET("/overlay/:token", h.OverlayBar)
+	r.GET("/overlay/:token/events", h.OverlayEvents)
+
+	// Donation platform webhooks (no user identity, keyed by token and signed with the bar's secret)
+	r.POST("/webhooks/:provider/:token", h.ReceiveWebhook)
 
 	// Static files (CSS only, no JS)
 	r.Static("/static", "./static")
@@ -174,7 +288,8 @@ func main() {
 		status := "healthy"
 		checks := map[string]interface{}{
 			"database": map[string]interface{}{
-				"connected": db != nil,
+				"driver":    stores.Driver,
+				"connected": true,
 				"status":    "ok",
 			},
 			"redis": map[string]interface{}{
@@ -187,15 +302,17 @@ func main() {
 					return "disabled"
 				}(),
 			},
-			"openai": map[string]interface{}{
-				"configured": cfg.OpenAIKey != "" && cfg.OpenAIKey != "your_openai_api_key_here",
+			"ai": map[string]interface{}{
+				"provider":   cfg.AI.Provider,
+				"configured": aiProvider != nil,
 				"status":     "ok",
 			},
 		}
 
 		// Check if any critical service is down
-		if db == nil {
+		if err := stores.Ping(c.Request.Context()); err != nil {
 			status = "unhealthy"
+			checks["database"].(map[string]interface{})["connected"] = false
 			checks["database"].(map[string]interface{})["status"] = "error"
 		}
 
@@ -237,6 +354,7 @@ func main() {
 	<-quit
 
 	slog.Info("Shutting down server...")
+	stopWorkers()
 
 	// Give outstanding requests configured timeout to complete
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.ServerShutdown)
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"donationbars/internal/config"
+	"donationbars/internal/migrations"
+	"donationbars/internal/repository"
+)
+
+const migrateUsage = "usage: donationbars migrate [up|status]"
+
+// runMigrate implements the "migrate" subcommand and returns the exit code
+func runMigrate(cfg *config.Config, args []string) int {
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	if len(args) > 1 || (action != "up" && action != "status") {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Migration)
+	defer cancel()
+
+	var err error
+	switch cfg.Storage.Driver {
+	case config.StorageDriverMongo:
+		err = migrateMongo(ctx, cfg, action)
+	case config.StorageDriverSQLite:
+		err = migrateSQLite(ctx, cfg, action)
+	default:
+		fmt.Printf("The %s storage driver has no schema to migrate\n", cfg.Storage.Driver)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+	return 0
+}
+
+func migrateMongo(ctx context.Context, cfg *config.Config, action string) error {
+	db, err := config.InitDB(cfg.MongoURI, cfg.Timeouts)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	defer db.Disconnect()
+
+	migrator := migrations.New(db)
+
+	if action == "status" {
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-28s  %s\n", status.Version, applied, status.Description)
+		}
+		return nil
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("MongoDB schema is up to date")
+		return nil
+	}
+	fmt.Printf("Applied MongoDB migrations %v\n", applied)
+	return nil
+}
+
+func migrateSQLite(ctx context.Context, cfg *config.Config, action string) error {
+	if action == "status" {
+		return fmt.Errorf("status is only supported by the mongo driver")
+	}
+
+	db, err := config.InitSQLite(cfg.Storage.SQLitePath, cfg.Timeouts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := repository.MigrateSQLite(ctx, db); err != nil {
+		return err
+	}
+	fmt.Println("SQLite schema is up to date")
+	return nil
+}
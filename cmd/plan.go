@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"donationbars/internal/config"
+	"donationbars/internal/models"
+	"donationbars/internal/repository"
+)
+
+const planUsage = "usage: donationbars plan <email> <free|pro|team>"
+
+// runPlan implements the "plan" subcommand and returns the exit code
+func runPlan(cfg *config.Config, args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, planUsage)
+		return 2
+	}
+	email, planID := strings.ToLower(strings.TrimSpace(args[0])), args[1]
+
+	plan, ok := models.FindPlan(planID)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "plan: unknown plan %q\n%s\n", planID, planUsage)
+		return 2
+	}
+
+	if cfg.Storage.Driver == config.StorageDriverMemory {
+		fmt.Println("The memory storage driver has no persistent users")
+		return 0
+	}
+
+	// Sessions and rate limits are not touched, so Redis stays disabled
+	stores, err := repository.OpenStores(cfg, &config.RedisClient{Enabled: false})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "plan:", err)
+		return 1
+	}
+	defer stores.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	user, err := stores.Users.FindByEmail(ctx, email)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "plan: find user %s: %v\n", email, err)
+		return 1
+	}
+	if err := stores.Users.SetPlan(ctx, user.ID.Hex(), plan.ID); err != nil {
+		fmt.Fprintln(os.Stderr, "plan:", err)
+		return 1
+	}
+
+	fmt.Printf("%s is now on the %s plan\n", email, plan.Name)
+	return 0
+}
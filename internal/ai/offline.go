@@ -0,0 +1,255 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"donationbars/internal/models"
+)
+
+// palette is a colour scheme of the offline generator
+type palette struct {
+	name       string
+	keywords   []string
+	background string // Card background
+	text       string
+	trackColor string
+	fillFrom   string
+	fillTo     string
+	accent     string
+}
+
+// palettes are matched against the theme and prompt in order; the first one
+// is also the fallback family when nothing matches
+var palettes = []palette{
+	{
+		name:       "classic",
+		keywords:   []string{"modern", "classic", "klasik", "mor", "purple"},
+		background: "#ffffff",
+		text:       "#2d3748",
+		trackColor: "#e9ecef",
+		fillFrom:   "#667eea",
+		fillTo:     "#764ba2",
+		accent:     "#764ba2",
+	},
+	{
+		name:       "neon",
+		keywords:   []string{"neon", "cyberpunk", "synthwave", "gamer", "oyun"},
+		background: "#0d0221",
+		text:       "#e0fbfc",
+		trackColor: "#241734",
+		fillFrom:   "#00f5d4",
+		fillTo:     "#f15bb5",
+		accent:     "#00f5d4",
+	},
+	{
+		name:       "gold",
+		keywords:   []string{"gold", "altın", "luxury", "lüks", "premium"},
+		background: "#1c1a17",
+		text:       "#f8f1e5",
+		trackColor: "#3a352c",
+		fillFrom:   "#f6d365",
+		fillTo:     "#fda085",
+		accent:     "#f6d365",
+	},
+	{
+		name:       "nature",
+		keywords:   []string{"nature", "doğa", "green", "yeşil", "forest", "orman"},
+		background: "#f1f8f4",
+		text:       "#1b4332",
+		trackColor: "#d8f3dc",
+		fillFrom:   "#52b788",
+		fillTo:     "#2d6a4f",
+		accent:     "#2d6a4f",
+	},
+	{
+		name:       "ocean",
+		keywords:   []string{"ocean", "okyanus", "deniz", "sea", "blue", "mavi"},
+		background: "#f0f7ff",
+		text:       "#0b3c5d",
+		trackColor: "#d6e9f8",
+		fillFrom:   "#4facfe",
+		fillTo:     "#00c6fb",
+		accent:     "#1565c0",
+	},
+	{
+		name:       "fire",
+		keywords:   []string{"fire", "ateş", "red", "kırmızı", "lava"},
+		background: "#1f1111",
+		text:       "#fff1e6",
+		trackColor: "#3d1f1f",
+		fillFrom:   "#ff512f",
+		fillTo:     "#f09819",
+		accent:     "#ff7b54",
+	},
+	{
+		name:       "pastel",
+		keywords:   []string{"pastel", "pink", "pembe", "cute", "sevimli"},
+		background: "#fff5f8",
+		text:       "#6d4c5c",
+		trackColor: "#fde2ea",
+		fillFrom:   "#fbc2eb",
+		fillTo:     "#a6c1ee",
+		accent:     "#d6689c",
+	},
+}
+
+// OfflineProvider builds bars from fixed templates without calling any model.
+// The same request always yields the same design, which makes it suitable
+// for development and tests without an API key.
+type OfflineProvider struct{}
+
+// NewOfflineProvider creates a deterministic template-based provider
+func NewOfflineProvider() *OfflineProvider {
+	return &OfflineProvider{}
+}
+
+// Name returns the provider name used in logs
+func (p *OfflineProvider) Name() string {
+	return "offline"
+}
+
+// Complete renders a template bar in the JSON format the prompt asks for
+func (p *OfflineProvider) Complete(ctx context.Context, req *models.AICompletionRequest) (*models.AICompletion, error) {
+	if req.Bar == nil {
+		return nil, errors.New("offline provider needs the bar request")
+	}
+
+	pal := choosePalette(req.Bar.Theme, req.Bar.Prompt, req.Variation)
+	content, err := json.Marshal(models.AIGenerateResponse{
+		HTML: offlineHTML(req.Bar.Language, len(req.Bar.Goals) > 0),
+		CSS:  offlineCSS(pal),
+		Metadata: models.AIGenerateMetadata{
+			Language:      req.Bar.Language,
+			Theme:         req.Bar.Theme,
+			HasInjections: true,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AICompletion{
+		Content: string(content),
+		Model:   "offline-" + pal.name,
+	}, nil
+}
+
+// choosePalette picks the palette whose keywords appear in the theme or prompt,
+// falling back to a hash of the prompt so different prompts still vary.
+// Later variations of the same request step through the following palettes.
+func choosePalette(theme, prompt string, variation int) palette {
+	return palettes[(matchPalette(theme, prompt)+variation)%len(palettes)]
+}
+
+// matchPalette returns the index of the palette for a theme and prompt
+func matchPalette(theme, prompt string) int {
+	text := strings.ToLower(theme + " " + prompt)
+	for i, pal := range palettes {
+		for _, keyword := range pal.keywords {
+			if strings.Contains(text, keyword) {
+				return i
+			}
+		}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(text))
+	return int(h.Sum32() % uint32(len(palettes)))
+}
+
+// offlineHTML returns the bar markup with every injection field; bars with
+// stretch goals get a line naming the current stage
+func offlineHTML(language string, staged bool) string {
+	percentage, remaining, goal, stage := "%{percentage}", "Kalan", "Hedef", "Aşama"
+	if language == "en" {
+		percentage, remaining, goal, stage = "{percentage}%", "Remaining", "Goal", "Stage"
+	}
+
+	stageLine := ""
+	if staged {
+		stageLine = `<div class="stage">` + stage + ` {goal_index}/{goals_total} · {goal_label}</div>`
+	}
+
+	return `<div class="donation-bar">` +
+		`<div class="description">{description}</div>` +
+		stageLine +
+		`<div class="progress-track">` +
+		`<div class="progress-fill" style="width: {percentage}%"></div>` +
+		`<div class="center-info"><span class="amount">{total_formatted}</span> ` +
+		`<span class="percentage">` + percentage + `</span></div>` +
+		`</div>` +
+		`<div class="amounts-row">` +
+		`<span class="remaining">` + remaining + `: {remaining_formatted}</span>` +
+		`<span class="goal-amount">` + goal + `: {goal_formatted}</span>` +
+		`</div>` +
+		`</div>`
+}
+
+// offlineCSS returns the bar styles in the given palette
+func offlineCSS(pal palette) string {
+	return fmt.Sprintf(`.donation-bar {
+  max-width: 800px !important;
+  max-height: 200px !important;
+  width: 800px;
+  box-sizing: border-box;
+  padding: 16px 20px;
+  background: %[1]s;
+  color: %[2]s;
+  border-radius: 12px;
+  box-shadow: 0 4px 15px rgba(0, 0, 0, 0.15);
+  font-family: "Segoe UI", Arial, sans-serif;
+}
+.description {
+  text-align: center;
+  font-size: 16px;
+  font-weight: 600;
+  margin-bottom: 10px;
+}
+.stage {
+  text-align: center;
+  font-size: 13px;
+  color: %[6]s;
+  margin: -6px 0 8px;
+}
+.progress-track {
+  position: relative;
+  width: 100%%;
+  height: 32px;
+  background: %[3]s;
+  border-radius: 16px;
+  overflow: hidden;
+}
+.progress-fill {
+  height: 32px;
+  background: linear-gradient(90deg, %[4]s, %[5]s);
+  border-radius: 16px;
+  transition: width 0.6s ease;
+}
+.center-info {
+  position: absolute;
+  top: 0;
+  left: 0;
+  right: 0;
+  line-height: 32px;
+  text-align: center;
+  font-size: 14px;
+  font-weight: 700;
+  color: %[2]s;
+}
+.amounts-row {
+  display: flex;
+  justify-content: space-between;
+  margin-top: 8px;
+  font-size: 14px;
+}
+.goal-amount {
+  color: %[6]s;
+  font-weight: 600;
+}
+`, pal.background, pal.text, pal.trackColor, pal.fillFrom, pal.fillTo, pal.accent)
+}
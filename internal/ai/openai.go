@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"errors"
+
+	"donationbars/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OpenAIProvider talks to the OpenAI chat completions API or any server
+// implementing it (Ollama, llama.cpp server, vLLM)
+type OpenAIProvider struct {
+	client *openai.Client
+	model  string
+	name   string
+}
+
+// NewOpenAIProvider creates a provider for api.openai.com
+func NewOpenAIProvider(apiKey, model string) *OpenAIProvider {
+	return &OpenAIProvider{
+		client: openai.NewClient(apiKey),
+		model:  modelOrDefault(model),
+		name:   "openai",
+	}
+}
+
+// NewOpenAICompatibleProvider creates a provider for a self-hosted OpenAI compatible
+// server, e.g. "http://localhost:11434/v1" for Ollama. The API key may be empty.
+func NewOpenAICompatibleProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	clientConfig := openai.DefaultConfig(apiKey)
+	clientConfig.BaseURL = baseURL
+
+	return &OpenAIProvider{
+		client: openai.NewClientWithConfig(clientConfig),
+		model:  model,
+		name:   "openai-compatible",
+	}
+}
+
+// Name returns the provider name used in logs
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+// Complete sends the prompt as a single user message
+func (p *OpenAIProvider) Complete(ctx context.Context, req *models.AICompletionRequest) (*models.AICompletion, error) {
+	resp, err := p.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: p.model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: req.Prompt,
+				},
+			},
+			MaxTokens:   req.MaxTokens,
+			Temperature: req.Temperature,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("provider returned no choices")
+	}
+
+	return &models.AICompletion{
+		Content:    resp.Choices[0].Message.Content,
+		Model:      resp.Model,
+		TokensUsed: resp.Usage.TotalTokens,
+	}, nil
+}
+
+// modelOrDefault falls back to gpt-4o-mini for the hosted OpenAI API
+func modelOrDefault(model string) string {
+	if model == "" {
+		return openai.GPT4oMini
+	}
+	return model
+}
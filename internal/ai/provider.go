@@ -0,0 +1,29 @@
+package ai
+
+import (
+	"fmt"
+	"log/slog"
+
+	"donationbars/internal/config"
+	"donationbars/internal/interfaces"
+)
+
+// NewProvider returns the AI backend selected by AI_PROVIDER
+func NewProvider(cfg *config.Config) (interfaces.AIProviderInterface, error) {
+	switch cfg.AI.Provider {
+	case config.AIProviderOpenAI:
+		slog.Info("AI provider initialized", "provider", cfg.AI.Provider, "model", modelOrDefault(cfg.AI.Model))
+		return NewOpenAIProvider(cfg.OpenAIKey, cfg.AI.Model), nil
+	case config.AIProviderOpenAICompatible:
+		slog.Info("AI provider initialized",
+			"provider", cfg.AI.Provider,
+			"model", cfg.AI.Model,
+			"base_url", cfg.AI.BaseURL)
+		return NewOpenAICompatibleProvider(cfg.AI.BaseURL, cfg.OpenAIKey, cfg.AI.Model), nil
+	case config.AIProviderOffline:
+		slog.Info("AI provider initialized", "provider", cfg.AI.Provider)
+		return NewOfflineProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown AI provider %q", cfg.AI.Provider)
+	}
+}
@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"donationbars/internal/config"
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		ai       config.AIConfig
+		expected string
+		wantErr  bool
+	}{
+		{name: "OpenAI", ai: config.AIConfig{Provider: config.AIProviderOpenAI}, expected: "openai"},
+		{
+			name:     "OpenAI compatible",
+			ai:       config.AIConfig{Provider: config.AIProviderOpenAICompatible, BaseURL: "http://localhost:11434/v1", Model: "llama3.1"},
+			expected: "openai-compatible",
+		},
+		{name: "Offline", ai: config.AIConfig{Provider: config.AIProviderOffline}, expected: "offline"},
+		{name: "Unknown", ai: config.AIConfig{Provider: "gemini"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(&config.Config{OpenAIKey: "test-key", AI: tt.ai})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, provider)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, provider.Name())
+		})
+	}
+}
+
+func TestOfflineProvider_ChoosesPaletteFromTheme(t *testing.T) {
+	provider := NewOfflineProvider()
+
+	completion, err := provider.Complete(context.Background(), &models.AICompletionRequest{
+		Bar: &models.GenerateBarRequest{Prompt: "Altın rengi şık bir bar", Language: "tr", Theme: "luxury"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "offline-gold", completion.Model)
+	assert.Contains(t, completion.Content, "{remaining_formatted}")
+}
+
+func TestOfflineProvider_RequiresBarRequest(t *testing.T) {
+	completion, err := NewOfflineProvider().Complete(context.Background(), &models.AICompletionRequest{Prompt: "x"})
+
+	assert.Error(t, err)
+	assert.Nil(t, completion)
+}
@@ -7,6 +7,9 @@ import (
 	"os"
 	"strconv"
 	"time"
+	_ "time/tzdata" // RATE_LIMIT_TIMEZONE must resolve on images without zoneinfo
+
+	"donationbars/internal/models"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -19,6 +22,7 @@ type TimeoutConfig struct {
 	AI             time.Duration
 	ServerShutdown time.Duration
 	RedisOperation time.Duration
+	Migration      time.Duration
 }
 
 // RedisConfig holds Redis connection configuration
@@ -29,21 +33,98 @@ type RedisConfig struct {
 	Enabled  bool
 }
 
+// Storage drivers accepted by STORAGE_DRIVER
+const (
+	StorageDriverMongo  = "mongo"
+	StorageDriverSQLite = "sqlite"
+	StorageDriverMemory = "memory" // Process memory, lost on restart; for development and tests
+)
+
+// RateLimitConfig sets how often each user may repeat the limited actions
+type RateLimitConfig struct {
+	BarsPerDay         int
+	AIPerDay           int
+	DonationsPerMinute int
+	Timezone           string // IANA name; daily counts start at midnight here
+}
+
+// Location returns the time zone of daily limits, UTC when it cannot be loaded
+func (c RateLimitConfig) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// StorageConfig selects where bars, accounts and jobs are stored
+type StorageConfig struct {
+	Driver      string // "mongo", "sqlite" or "memory"
+	SQLitePath  string // Database file of the sqlite driver
+	AutoMigrate bool   // Apply pending schema migrations at startup
+}
+
+// AI provider names accepted by AI_PROVIDER
+const (
+	AIProviderOpenAI           = "openai"
+	AIProviderOpenAICompatible = "openai-compatible"
+	AIProviderOffline          = "offline"
+)
+
+// AIConfig selects and configures the AI generation backend
+type AIConfig struct {
+	Provider string // "openai", "openai-compatible" or "offline"
+	Model    string
+	BaseURL  string // Only used by openai-compatible (Ollama, llama.cpp server, vLLM)
+}
+
+// JobsConfig sizes the background AI generation worker pool
+type JobsConfig struct {
+	Workers   int
+	QueueSize int
+}
+
+// OutgoingWebhooksConfig tunes the delivery of bar events to user-configured URLs
+type OutgoingWebhooksConfig struct {
+	Workers      int
+	MaxAttempts  int
+	BackoffBase  time.Duration // Wait before the second attempt, doubled after each failure
+	BackoffMax   time.Duration
+	Timeout      time.Duration // Per attempt
+	AllowPrivate bool          // Allow loopback and private network targets, for development
+}
+
+// SessionConfig holds login session and cookie settings
+type SessionConfig struct {
+	TTL          time.Duration
+	CookieName   string
+	CookieSecure bool
+}
+
 type Config struct {
 	// Database
+	Storage  StorageConfig
 	MongoURI string
 	DBName   string
 
 	// External services
 	OpenAIKey string
+	AI        AIConfig
+	Jobs      JobsConfig
 	Redis     RedisConfig
 
+	// Outgoing webhooks
+	OutgoingWebhooks OutgoingWebhooksConfig
+
 	// Server
 	Port string
 
+	// Authentication
+	Session SessionConfig
+
 	// Business rules
-	MaxBarsPerUser  int
-	RateLimitPerDay int
+	DefaultPlan string // Plan of users without an assigned one
+	RateLimit   RateLimitConfig
 
 	// Timeouts
 	Timeouts TimeoutConfig
@@ -56,13 +137,43 @@ type Database struct {
 
 func Load() *Config {
 	return &Config{
-		MongoURI:        getEnv("MONGO_URI", "mongodb://localhost:27017"),
-		DBName:          getEnv("DB_NAME", "donationbars"),
-		OpenAIKey:       getEnv("OPENAI_API_KEY", ""),
-		Port:            getEnv("PORT", "8080"),
-		MaxBarsPerUser:  getEnvInt("MAX_BARS_PER_USER", 5),
-		RateLimitPerDay: getEnvInt("RATE_LIMIT_PER_DAY", 5),
+		Storage: StorageConfig{
+			Driver:      getEnv("STORAGE_DRIVER", StorageDriverMongo),
+			SQLitePath:  getEnv("SQLITE_PATH", "donationbars.db"),
+			AutoMigrate: getEnvBool("AUTO_MIGRATE", true),
+		},
+		MongoURI:    getEnv("MONGO_URI", "mongodb://localhost:27017"),
+		DBName:      getEnv("DB_NAME", "donationbars"),
+		OpenAIKey:   getEnv("OPENAI_API_KEY", ""),
+		Port:        getEnv("PORT", "8080"),
+		DefaultPlan: getEnv("DEFAULT_PLAN", models.PlanFree),
+
+		RateLimit: RateLimitConfig{
+			BarsPerDay:         getEnvInt("RATE_LIMIT_PER_DAY", 5),
+			AIPerDay:           getEnvInt("RATE_LIMIT_AI_PER_DAY", 20),
+			DonationsPerMinute: getEnvInt("RATE_LIMIT_DONATIONS_PER_MINUTE", 60),
+			Timezone:           getEnv("RATE_LIMIT_TIMEZONE", "UTC"),
+		},
 
+		AI: AIConfig{
+			Provider: getEnv("AI_PROVIDER", defaultAIProvider(getEnv("OPENAI_API_KEY", ""))),
+			Model:    getEnv("AI_MODEL", ""),
+			BaseURL:  getEnv("AI_BASE_URL", ""),
+		},
+
+		Jobs: JobsConfig{
+			Workers:   getEnvInt("AI_WORKERS", 2),
+			QueueSize: getEnvInt("AI_QUEUE_SIZE", 100),
+		},
+
+		OutgoingWebhooks: OutgoingWebhooksConfig{
+			Workers:      getEnvInt("OUTGOING_WEBHOOK_WORKERS", 2),
+			MaxAttempts:  getEnvInt("OUTGOING_WEBHOOK_MAX_ATTEMPTS", 6),
+			BackoffBase:  getEnvDuration("OUTGOING_WEBHOOK_BACKOFF", 30*time.Second),
+			BackoffMax:   getEnvDuration("OUTGOING_WEBHOOK_BACKOFF_MAX", time.Hour),
+			Timeout:      getEnvDuration("OUTGOING_WEBHOOK_TIMEOUT", 10*time.Second),
+			AllowPrivate: getEnvBool("OUTGOING_WEBHOOK_ALLOW_PRIVATE", false),
+		},
 		Redis: RedisConfig{
 			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
 			Password: getEnv("REDIS_PASSWORD", ""),
@@ -70,40 +181,109 @@ func Load() *Config {
 			Enabled:  getEnvBool("REDIS_ENABLED", false),
 		},
 
+		Session: SessionConfig{
+			TTL:          getEnvDuration("SESSION_TTL", 7*24*time.Hour),
+			CookieName:   getEnv("SESSION_COOKIE_NAME", "donationbars_session"),
+			CookieSecure: getEnvBool("SESSION_COOKIE_SECURE", false),
+		},
+
 		Timeouts: TimeoutConfig{
 			DatabaseRead:   getEnvDuration("DB_READ_TIMEOUT", 5*time.Second),
 			DatabaseWrite:  getEnvDuration("DB_WRITE_TIMEOUT", 10*time.Second),
 			AI:             getEnvDuration("AI_TIMEOUT", 30*time.Second),
 			ServerShutdown: getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 5*time.Second),
 			RedisOperation: getEnvDuration("REDIS_TIMEOUT", 2*time.Second),
+			Migration:      getEnvDuration("MIGRATION_TIMEOUT", 5*time.Minute),
 		},
 	}
 }
 
 // Validate checks if all required configuration values are present
 func (c *Config) Validate() error {
-	if c.OpenAIKey == "" || c.OpenAIKey == "your_openai_api_key_here" {
-		return errors.New("OpenAI API key is required and cannot be placeholder value")
-	}
-
-	if c.MongoURI == "" {
-		return errors.New("MongoDB URI is required")
+	switch c.AI.Provider {
+	case AIProviderOpenAI:
+		if !c.HasOpenAIKey() {
+			return errors.New("OpenAI API key is required and cannot be placeholder value")
+		}
+	case AIProviderOpenAICompatible:
+		if c.AI.BaseURL == "" {
+			return errors.New("AI_BASE_URL is required for the openai-compatible provider")
+		}
+		if c.AI.Model == "" {
+			return errors.New("AI_MODEL is required for the openai-compatible provider")
+		}
+	case AIProviderOffline:
+	default:
+		return errors.New("AI_PROVIDER must be one of openai, openai-compatible, offline")
 	}
 
-	if c.DBName == "" {
-		return errors.New("Database name is required")
+	switch c.Storage.Driver {
+	case StorageDriverMongo:
+		if c.MongoURI == "" {
+			return errors.New("MongoDB URI is required")
+		}
+		if c.DBName == "" {
+			return errors.New("Database name is required")
+		}
+	case StorageDriverSQLite:
+		if c.Storage.SQLitePath == "" {
+			return errors.New("SQLITE_PATH is required for the sqlite storage driver")
+		}
+	case StorageDriverMemory:
+	default:
+		return errors.New("STORAGE_DRIVER must be one of mongo, sqlite, memory")
 	}
 
 	if c.Port == "" {
 		return errors.New("Port is required")
 	}
 
-	if c.MaxBarsPerUser <= 0 {
-		return errors.New("MaxBarsPerUser must be positive")
+	if _, ok := models.FindPlan(c.DefaultPlan); !ok {
+		return errors.New("DEFAULT_PLAN must be one of free, pro, team")
+	}
+
+	if c.RateLimit.BarsPerDay <= 0 {
+		return errors.New("RATE_LIMIT_PER_DAY must be positive")
+	}
+
+	if c.RateLimit.AIPerDay <= 0 {
+		return errors.New("RATE_LIMIT_AI_PER_DAY must be positive")
+	}
+
+	if c.RateLimit.DonationsPerMinute <= 0 {
+		return errors.New("RATE_LIMIT_DONATIONS_PER_MINUTE must be positive")
+	}
+
+	if _, err := time.LoadLocation(c.RateLimit.Timezone); err != nil {
+		return errors.New("RATE_LIMIT_TIMEZONE must be an IANA time zone name")
+	}
+
+	if c.Jobs.Workers <= 0 {
+		return errors.New("AI_WORKERS must be positive")
+	}
+
+	if c.Jobs.QueueSize <= 0 {
+		return errors.New("AI_QUEUE_SIZE must be positive")
+	}
+
+	if c.OutgoingWebhooks.Workers <= 0 {
+		return errors.New("OUTGOING_WEBHOOK_WORKERS must be positive")
+	}
+
+	if c.OutgoingWebhooks.MaxAttempts <= 0 {
+		return errors.New("OUTGOING_WEBHOOK_MAX_ATTEMPTS must be positive")
+	}
+
+	if c.OutgoingWebhooks.BackoffBase <= 0 || c.OutgoingWebhooks.BackoffMax < c.OutgoingWebhooks.BackoffBase {
+		return errors.New("OUTGOING_WEBHOOK_BACKOFF must be positive and at most OUTGOING_WEBHOOK_BACKOFF_MAX")
+	}
+
+	if c.OutgoingWebhooks.Timeout <= 0 {
+		return errors.New("OUTGOING_WEBHOOK_TIMEOUT must be positive")
 	}
 
-	if c.RateLimitPerDay <= 0 {
-		return errors.New("RateLimitPerDay must be positive")
+	if c.Session.TTL <= 0 {
+		return errors.New("Session TTL must be positive")
 	}
 
 	// Timeout validations
@@ -122,6 +302,19 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// HasOpenAIKey reports whether a real OpenAI API key is configured
+func (c *Config) HasOpenAIKey() bool {
+	return c.OpenAIKey != "" && c.OpenAIKey != "your_openai_api_key_here"
+}
+
+// defaultAIProvider uses OpenAI when a key is set and the offline generator otherwise
+func defaultAIProvider(apiKey string) string {
+	if apiKey != "" && apiKey != "your_openai_api_key_here" {
+		return AIProviderOpenAI
+	}
+	return AIProviderOffline
+}
+
 func InitDB(mongoURI string, timeoutConfig TimeoutConfig) (*Database, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeoutConfig.DatabaseWrite)
 	defer cancel()
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	_ "modernc.org/sqlite" // Pure-Go driver, keeps the binary free of cgo
+)
+
+// SQLiteDatabase is the embedded database of the sqlite storage driver
+type SQLiteDatabase struct {
+	DB   *sql.DB
+	Path string
+}
+
+// InitSQLite opens (or creates) the SQLite database file
+func InitSQLite(path string, timeoutConfig TimeoutConfig) (*SQLiteDatabase, error) {
+	// WAL lets readers run during a write; the busy timeout makes writers
+	// wait for each other instead of failing
+	dsn := "file:" + path + "?" + url.Values{
+		"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)", "foreign_keys(1)", "synchronous(NORMAL)"},
+	}.Encode()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer; one connection avoids lock contention
+	// between pooled connections and keeps ":memory:" databases shared
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutConfig.DatabaseWrite)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+
+	slog.Info("Successfully opened SQLite database", "path", path)
+
+	return &SQLiteDatabase{
+		DB:   db,
+		Path: path,
+	}, nil
+}
+
+// Close closes the SQLite database
+func (d *SQLiteDatabase) Close() error {
+	return d.DB.Close()
+}
@@ -0,0 +1,183 @@
+// Package diff compares bar HTML/CSS line by line for revision summaries and
+// the side-by-side revision view.
+package diff
+
+import "strings"
+
+// Kind tells how a line changed; the values double as CSS class names
+type Kind string
+
+const (
+	KindEqual   Kind = "equal"
+	KindAdded   Kind = "added"
+	KindRemoved Kind = "removed"
+	KindEmpty   Kind = "empty" // Filler cell opposite an added or removed line
+)
+
+// maxCells bounds the LCS table; larger inputs are reported as fully replaced
+const maxCells = 4_000_000
+
+// Line is one line of a unified line diff
+type Line struct {
+	Kind Kind
+	Text string
+}
+
+// Cell is one side of a side-by-side row
+type Cell struct {
+	Kind Kind
+	Text string
+}
+
+// Row pairs the old (left) and new (right) version of a line
+type Row struct {
+	Left  Cell
+	Right Cell
+}
+
+// Lines returns the line diff turning a into b
+func Lines(a, b string) []Line {
+	oldLines, newLines := splitLines(a), splitLines(b)
+
+	// Common prefix and suffix need no LCS work
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]Line, 0, len(oldLines)+len(newLines))
+	for _, text := range oldLines[:prefix] {
+		result = append(result, Line{Kind: KindEqual, Text: text})
+	}
+	result = append(result, middle(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])...)
+	for _, text := range oldLines[len(oldLines)-suffix:] {
+		result = append(result, Line{Kind: KindEqual, Text: text})
+	}
+
+	return result
+}
+
+// Count returns the number of added and removed lines of a diff
+func Count(lines []Line) (added, removed int) {
+	for _, line := range lines {
+		switch line.Kind {
+		case KindAdded:
+			added++
+		case KindRemoved:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// SideBySide lays a diff out in two columns; removed and added lines of the
+// same change are paired on one row
+func SideBySide(lines []Line) []Row {
+	var rows []Row
+	var removed, added []string
+
+	flush := func() {
+		for i := 0; i < len(removed) || i < len(added); i++ {
+			row := Row{Left: Cell{Kind: KindEmpty}, Right: Cell{Kind: KindEmpty}}
+			if i < len(removed) {
+				row.Left = Cell{Kind: KindRemoved, Text: removed[i]}
+			}
+			if i < len(added) {
+				row.Right = Cell{Kind: KindAdded, Text: added[i]}
+			}
+			rows = append(rows, row)
+		}
+		removed, added = removed[:0], added[:0]
+	}
+
+	for _, line := range lines {
+		switch line.Kind {
+		case KindRemoved:
+			removed = append(removed, line.Text)
+		case KindAdded:
+			added = append(added, line.Text)
+		default:
+			flush()
+			rows = append(rows, Row{
+				Left:  Cell{Kind: KindEqual, Text: line.Text},
+				Right: Cell{Kind: KindEqual, Text: line.Text},
+			})
+		}
+	}
+	flush()
+
+	return rows
+}
+
+// middle diffs the differing part of two inputs with a longest common subsequence
+func middle(a, b []string) []Line {
+	if len(a)*len(b) > maxCells {
+		return replaceAll(a, b)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	result := make([]Line, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, Line{Kind: KindEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, Line{Kind: KindRemoved, Text: a[i]})
+			i++
+		default:
+			result = append(result, Line{Kind: KindAdded, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, Line{Kind: KindRemoved, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, Line{Kind: KindAdded, Text: b[j]})
+	}
+
+	return result
+}
+
+// replaceAll reports every old line as removed and every new line as added
+func replaceAll(a, b []string) []Line {
+	result := make([]Line, 0, len(a)+len(b))
+	for _, text := range a {
+		result = append(result, Line{Kind: KindRemoved, Text: text})
+	}
+	for _, text := range b {
+		result = append(result, Line{Kind: KindAdded, Text: text})
+	}
+	return result
+}
+
+// splitLines splits text into lines; empty text has no lines
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,43 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines_Changes(t *testing.T) {
+	lines := Lines("a\nb\nc\nd", "a\nx\nc\nd\ne")
+
+	assert.Equal(t, []Line{
+		{Kind: KindEqual, Text: "a"},
+		{Kind: KindRemoved, Text: "b"},
+		{Kind: KindAdded, Text: "x"},
+		{Kind: KindEqual, Text: "c"},
+		{Kind: KindEqual, Text: "d"},
+		{Kind: KindAdded, Text: "e"},
+	}, lines)
+
+	added, removed := Count(lines)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, removed)
+}
+
+func TestLines_EmptyInputs(t *testing.T) {
+	added, removed := Count(Lines("", "one\ntwo\n"))
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 0, removed)
+
+	assert.Empty(t, Lines("", ""))
+}
+
+func TestSideBySide_PairsChanges(t *testing.T) {
+	rows := SideBySide(Lines("a\nold1\nold2\nz", "a\nnew1\nz"))
+
+	assert.Equal(t, []Row{
+		{Left: Cell{KindEqual, "a"}, Right: Cell{KindEqual, "a"}},
+		{Left: Cell{KindRemoved, "old1"}, Right: Cell{KindAdded, "new1"}},
+		{Left: Cell{KindRemoved, "old2"}, Right: Cell{Kind: KindEmpty}},
+		{Left: Cell{KindEqual, "z"}, Right: Cell{KindEqual, "z"}},
+	}, rows)
+}
@@ -12,11 +12,57 @@ var (
 	ErrDatabaseUnavailable  = errors.New("database connection not available")
 	ErrRateLimitExceeded    = errors.New("rate limit exceeded")
 	ErrMaxBarsReached       = errors.New("maximum bars limit reached")
+	ErrQuotaExceeded        = errors.New("plan quota exceeded")
 	ErrInvalidBarID         = errors.New("invalid bar ID format")
 	ErrAIServiceUnavailable = errors.New("AI service unavailable")
 	ErrValidationFailed     = errors.New("validation failed")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrConflict             = errors.New("conflict")
 )
 
+// Error types of AppError, mapped to HTTP status codes by the handlers
+const (
+	TypeNotFound          = "NOT_FOUND"
+	TypeInvalidInput      = "INVALID_INPUT"
+	TypeDatabaseError     = "DATABASE_ERROR"
+	TypeMaxBarsReached    = "MAX_BARS_REACHED"
+	TypeQuotaExceeded     = "QUOTA_EXCEEDED"
+	TypeValidationError   = "VALIDATION_ERROR"
+	TypeRateLimitExceeded = "RATE_LIMIT_EXCEEDED"
+	TypeAIServiceError    = "AI_SERVICE_ERROR"
+	TypeUnauthorized      = "UNAUTHORIZED"
+	TypeForbidden         = "FORBIDDEN"
+	TypeConflict          = "CONFLICT"
+	TypeInternal          = "INTERNAL_ERROR"
+)
+
+// wrappedError gives a sentinel error a more specific message
+type wrappedError struct {
+	message string
+	err     error
+}
+
+func (e *wrappedError) Error() string {
+	return e.message
+}
+
+func (e *wrappedError) Unwrap() error {
+	return e.err
+}
+
+// Wrap returns an error reading message that still matches err with errors.Is,
+// e.g. Wrap(ErrConflict, "email already registered")
+func Wrap(err error, message string) error {
+	return &wrappedError{message: message, err: err}
+}
+
+// Missing reports a resource that does not exist, e.g. Missing("bar") reads
+// "bar not found" and matches ErrNotFound
+func Missing(resource string) error {
+	return Wrap(ErrNotFound, resource+" not found")
+}
+
 // AppError represents an application error with context
 type AppError struct {
 	Type    string `json:"type"`
@@ -39,7 +85,7 @@ func (e *AppError) Unwrap() error {
 // Error constructors
 func NotFound(resource string, id string) *AppError {
 	return &AppError{
-		Type:    "NOT_FOUND",
+		Type:    TypeNotFound,
 		Message: fmt.Sprintf("%s not found", resource),
 		Details: fmt.Sprintf("ID: %s", id),
 		Err:     ErrNotFound,
@@ -48,7 +94,7 @@ func NotFound(resource string, id string) *AppError {
 
 func InvalidInput(field string, value string) *AppError {
 	return &AppError{
-		Type:    "INVALID_INPUT",
+		Type:    TypeInvalidInput,
 		Message: fmt.Sprintf("invalid %s", field),
 		Details: fmt.Sprintf("value: %s", value),
 		Err:     ErrInvalidInput,
@@ -57,7 +103,7 @@ func InvalidInput(field string, value string) *AppError {
 
 func DatabaseError(operation string, err error) *AppError {
 	return &AppError{
-		Type:    "DATABASE_ERROR",
+		Type:    TypeDatabaseError,
 		Message: fmt.Sprintf("database %s failed", operation),
 		Err:     fmt.Errorf("database operation failed: %w", err),
 	}
@@ -65,16 +111,26 @@ func DatabaseError(operation string, err error) *AppError {
 
 func MaxBarsReached(userID string, current, max int64) *AppError {
 	return &AppError{
-		Type:    "MAX_BARS_REACHED",
+		Type:    TypeMaxBarsReached,
 		Message: fmt.Sprintf("maksimum bar sayısına ulaşıldı (%d/%d)", current, max),
 		Details: fmt.Sprintf("user_id: %s", userID),
 		Err:     ErrMaxBarsReached,
 	}
 }
 
+// QuotaExceeded reports a plan quota that is used up, e.g. QuotaExceeded("günlük AI üretim", "free", 5)
+func QuotaExceeded(quota, plan string, limit int) *AppError {
+	return &AppError{
+		Type:    TypeQuotaExceeded,
+		Message: fmt.Sprintf("%s kotası doldu (%d)", quota, limit),
+		Details: fmt.Sprintf("plan: %s", plan),
+		Err:     ErrQuotaExceeded,
+	}
+}
+
 func ValidationError(field string, message string) *AppError {
 	return &AppError{
-		Type:    "VALIDATION_ERROR",
+		Type:    TypeValidationError,
 		Message: fmt.Sprintf("validation failed for %s: %s", field, message),
 		Err:     ErrValidationFailed,
 	}
@@ -82,7 +138,7 @@ func ValidationError(field string, message string) *AppError {
 
 func RateLimitError(userID string, limit int) *AppError {
 	return &AppError{
-		Type:    "RATE_LIMIT_EXCEEDED",
+		Type:    TypeRateLimitExceeded,
 		Message: fmt.Sprintf("günlük maksimum bar oluşturma sınırına ulaşıldı (%d bar/gün)", limit),
 		Details: fmt.Sprintf("user_id: %s", userID),
 		Err:     ErrRateLimitExceeded,
@@ -91,8 +147,33 @@ func RateLimitError(userID string, limit int) *AppError {
 
 func AIServiceError(operation string, err error) *AppError {
 	return &AppError{
-		Type:    "AI_SERVICE_ERROR",
+		Type:    TypeAIServiceError,
 		Message: fmt.Sprintf("AI %s failed", operation),
 		Err:     fmt.Errorf("AI service error: %w", err),
 	}
 }
+
+func Unauthorized(message string) *AppError {
+	return &AppError{
+		Type:    TypeUnauthorized,
+		Message: message,
+		Err:     ErrUnauthorized,
+	}
+}
+
+func Forbidden(message string, details string) *AppError {
+	return &AppError{
+		Type:    TypeForbidden,
+		Message: message,
+		Details: details,
+		Err:     ErrForbidden,
+	}
+}
+
+func Conflict(resource string, message string) *AppError {
+	return &AppError{
+		Type:    TypeConflict,
+		Message: fmt.Sprintf("%s: %s", resource, message),
+		Err:     ErrConflict,
+	}
+}
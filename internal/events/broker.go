@@ -0,0 +1,21 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"donationbars/internal/config"
+	"donationbars/internal/interfaces"
+)
+
+// NewBroker returns a Redis broker when Redis is available, in-process otherwise
+func NewBroker(ctx context.Context, redisClient *config.RedisClient, timeout time.Duration) interfaces.EventBrokerInterface {
+	if redisClient != nil && redisClient.IsEnabled() {
+		slog.Info("Event broker initialized", "backend", "redis")
+		return NewRedisBroker(ctx, redisClient, timeout)
+	}
+
+	slog.Info("Event broker initialized", "backend", "memory")
+	return NewMemoryBroker()
+}
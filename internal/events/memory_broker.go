@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"donationbars/internal/models"
+)
+
+// subscriberBuffer is the number of pending updates kept per subscriber
+const subscriberBuffer = 8
+
+// MemoryBroker fans out bar updates to subscribers of the same process
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan *models.BarUpdate]struct{}
+}
+
+// NewMemoryBroker creates a new in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: make(map[string]map[chan *models.BarUpdate]struct{}),
+	}
+}
+
+// Publish delivers an update to every subscriber of the bar
+func (b *MemoryBroker) Publish(ctx context.Context, update *models.BarUpdate) error {
+	b.deliver(update)
+	return nil
+}
+
+// Subscribe registers a subscriber for a bar and returns its cancel function
+func (b *MemoryBroker) Subscribe(barID string) (<-chan *models.BarUpdate, func()) {
+	ch := make(chan *models.BarUpdate, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[barID] == nil {
+		b.subscribers[barID] = make(map[chan *models.BarUpdate]struct{})
+	}
+	b.subscribers[barID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[barID], ch)
+			if len(b.subscribers[barID]) == 0 {
+				delete(b.subscribers, barID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, cancel
+}
+
+// deliver hands the update to local subscribers without blocking on slow ones
+func (b *MemoryBroker) deliver(update *models.BarUpdate) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[update.BarID] {
+		select {
+		case ch <- update:
+		default:
+			// Subscriber is lagging: drop its oldest update, keep the newest
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- update:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBroker_PublishDeliversToSubscribers(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	first, cancelFirst := broker.Subscribe("bar-1")
+	defer cancelFirst()
+	second, cancelSecond := broker.Subscribe("bar-1")
+	defer cancelSecond()
+
+	err := broker.Publish(context.Background(), &models.BarUpdate{BarID: "bar-1", DonationTotal: 42})
+	assert.NoError(t, err)
+
+	for _, ch := range []<-chan *models.BarUpdate{first, second} {
+		select {
+		case update := <-ch:
+			assert.Equal(t, 42.0, update.DonationTotal)
+		case <-time.After(time.Second):
+			t.Fatal("Expected update to be delivered")
+		}
+	}
+}
+
+func TestMemoryBroker_PublishIgnoresOtherBars(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	updates, cancel := broker.Subscribe("bar-1")
+	defer cancel()
+
+	_ = broker.Publish(context.Background(), &models.BarUpdate{BarID: "bar-2"})
+
+	select {
+	case <-updates:
+		t.Fatal("Expected no update for a different bar")
+	default:
+	}
+}
+
+func TestMemoryBroker_SlowSubscriberKeepsNewestUpdate(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	updates, cancel := broker.Subscribe("bar-1")
+	defer cancel()
+
+	for i := 1; i <= subscriberBuffer+5; i++ {
+		_ = broker.Publish(context.Background(), &models.BarUpdate{BarID: "bar-1", DonationTotal: float64(i)})
+	}
+
+	var last *models.BarUpdate
+	for len(updates) > 0 {
+		last = <-updates
+	}
+	assert.Equal(t, float64(subscriberBuffer+5), last.DonationTotal)
+}
+
+func TestMemoryBroker_CancelClosesChannel(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	updates, cancel := broker.Subscribe("bar-1")
+	cancel()
+	cancel() // Safe to call twice
+
+	_, ok := <-updates
+	assert.False(t, ok)
+	assert.Empty(t, broker.subscribers)
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"donationbars/internal/config"
+	"donationbars/internal/models"
+)
+
+// channelPrefix is the Redis pub/sub channel prefix for bar updates
+const channelPrefix = "bar_updates:"
+
+// RedisBroker fans out bar updates across server instances via Redis pub/sub.
+// Every instance keeps a single pattern subscription and delivers received
+// updates to its local subscribers.
+type RedisBroker struct {
+	redisClient *config.RedisClient
+	timeout     time.Duration
+	local       *MemoryBroker
+}
+
+// NewRedisBroker creates a Redis backed broker and starts its receive loop
+func NewRedisBroker(ctx context.Context, redisClient *config.RedisClient, timeout time.Duration) *RedisBroker {
+	b := &RedisBroker{
+		redisClient: redisClient,
+		timeout:     timeout,
+		local:       NewMemoryBroker(),
+	}
+	go b.receive(ctx)
+	return b
+}
+
+// Publish sends the update to all instances, including this one
+func (b *RedisBroker) Publish(ctx context.Context, update *models.BarUpdate) error {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to encode bar update: %w", err)
+	}
+
+	pubCtx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	return b.redisClient.Client.Publish(pubCtx, channelPrefix+update.BarID, payload).Err()
+}
+
+// Subscribe registers a local subscriber for a bar
+func (b *RedisBroker) Subscribe(barID string) (<-chan *models.BarUpdate, func()) {
+	return b.local.Subscribe(barID)
+}
+
+// receive forwards updates published by any instance to local subscribers
+func (b *RedisBroker) receive(ctx context.Context) {
+	pubsub := b.redisClient.Client.PSubscribe(ctx, channelPrefix+"*")
+	defer pubsub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-pubsub.Channel():
+			if !ok {
+				return
+			}
+
+			var update models.BarUpdate
+			if err := json.Unmarshal([]byte(msg.Payload), &update); err != nil {
+				slog.Warn("Invalid bar update received from Redis",
+					"channel", msg.Channel,
+					"error", err.Error())
+				continue
+			}
+			if update.BarID == "" {
+				update.BarID = strings.TrimPrefix(msg.Channel, channelPrefix)
+			}
+
+			b.local.deliver(&update)
+		}
+	}
+}
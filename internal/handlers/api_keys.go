@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyContextKey holds the *models.APIKey of a bearer authenticated request
+const apiKeyContextKey = "api_key"
+
+// AuthenticateAPIKey authenticates "Authorization: Bearer dbk_..." requests.
+// Requests without the header fall through to the session cookie.
+func (h *Handler) AuthenticateAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		scheme, secret, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			abortWithError(c, apperrors.Unauthorized("invalid authorization header"))
+			return
+		}
+
+		user, key, err := h.apiKeyService.Authenticate(strings.TrimSpace(secret))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		// A bearer key always wins over a session cookie on the same request
+		c.Set(userContextKey, user)
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// RequireScope rejects API key requests whose key lacks a scope.
+// Session authenticated requests act as the user and pass unchanged.
+func (h *Handler) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := currentAPIKey(c); key != nil && !key.HasScope(scope) {
+			abortWithError(c, apperrors.Forbidden("api key is missing the required scope", "scope: "+scope))
+			return
+		}
+		c.Next()
+	}
+}
+
+// APIKeysPage lists the user's API keys
+func (h *Handler) APIKeysPage(c *gin.Context) {
+	h.renderAPIKeys(c, http.StatusOK, gin.H{
+		"Success": c.Query("success"),
+		"Error":   c.Query("error"),
+	})
+}
+
+// CreateAPIKey mints a key and shows it once
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderAPIKeys(c, http.StatusBadRequest, gin.H{
+			"Error": "Anahtar için bir isim ve en az bir yetki seçin",
+			"Name":  req.Name,
+		})
+		return
+	}
+
+	key, secret, err := h.apiKeyService.CreateKey(currentUserID(c), &req)
+	if err != nil {
+		message := "Anahtar oluşturulamadı, lütfen tekrar deneyin"
+		var appErr *apperrors.AppError
+		if errors.As(err, &appErr) && appErr.Type == apperrors.TypeValidationError {
+			message = "Anahtar oluşturulamadı: " + appErr.Message
+		}
+		h.renderAPIKeys(c, httpStatus(err), gin.H{
+			"Error": message,
+			"Name":  req.Name,
+		})
+		return
+	}
+
+	h.renderAPIKeys(c, http.StatusCreated, gin.H{
+		"Success":   "Anahtar oluşturuldu. Bu anahtarı şimdi kopyala, bir daha gösterilmeyecek.",
+		"NewKey":    key,
+		"NewSecret": secret,
+	})
+}
+
+// RevokeAPIKey permanently disables a key
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	if err := h.apiKeyService.RevokeKey(currentUserID(c), c.Param("id")); err != nil {
+		c.Redirect(http.StatusFound, "/account/api-keys?error=Anahtar iptal edilemedi")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/account/api-keys?success=Anahtar iptal edildi")
+}
+
+// renderAPIKeys renders api_keys.html with the key list and extra data
+func (h *Handler) renderAPIKeys(c *gin.Context, status int, data gin.H) {
+	keys, err := h.apiKeyService.ListKeys(currentUserID(c))
+	if err != nil {
+		keys = []*models.APIKey{}
+		if data["Error"] == nil || data["Error"] == "" {
+			data["Error"] = "Anahtarlar yüklenemedi"
+		}
+	}
+
+	data["Title"] = "API Anahtarları - Donation Bars"
+	data["Keys"] = keys
+	data["Scopes"] = models.APIKeyScopes
+	c.HTML(status, "api_keys.html", data)
+}
+
+// currentAPIKey returns the API key of a bearer authenticated request, if any
+func currentAPIKey(c *gin.Context) *models.APIKey {
+	if value, ok := c.Get(apiKeyContextKey); ok {
+		if key, ok := value.(*models.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userContextKey holds the authenticated *models.User in the gin context
+const userContextKey = "user"
+
+// Authenticate loads the user of the session cookie into the context, if any
+func (h *Handler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, err := c.Cookie(h.session.CookieName); err == nil && token != "" {
+			if user, err := h.authService.Authenticate(token); err == nil {
+				c.Set(userContextKey, user)
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireUser redirects anonymous visitors of web pages to the login page
+func (h *Handler) RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if currentUser(c) == nil {
+			c.Redirect(http.StatusFound, "/login?next="+url.QueryEscape(c.Request.URL.RequestURI()))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAPIUser rejects anonymous API requests
+func (h *Handler) RequireAPIUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if currentUser(c) == nil {
+			abortWithError(c, apperrors.Unauthorized("authentication required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// LoginPage renders the login form
+func (h *Handler) LoginPage(c *gin.Context) {
+	if currentUser(c) != nil {
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+
+	c.HTML(http.StatusOK, "login.html", gin.H{
+		"Title":   "Giriş Yap - Donation Bars",
+		"Next":    safeRedirect(c.Query("next")),
+		"Success": c.Query("success"),
+	})
+}
+
+// Login handles the login form submission
+func (h *Handler) Login(c *gin.Context) {
+	next := safeRedirect(c.PostForm("next"))
+
+	var req models.LoginRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.HTML(http.StatusBadRequest, "login.html", gin.H{
+			"Title": "Giriş Yap - Donation Bars",
+			"Next":  next,
+			"Email": req.Email,
+			"Error": "E-posta ve şifre zorunludur",
+		})
+		return
+	}
+
+	_, token, err := h.authService.Login(&req)
+	if err != nil {
+		c.HTML(httpStatus(err), "login.html", gin.H{
+			"Title": "Giriş Yap - Donation Bars",
+			"Next":  next,
+			"Email": req.Email,
+			"Error": authErrorMessage(err),
+		})
+		return
+	}
+
+	h.setSessionCookie(c, token)
+	c.Redirect(http.StatusFound, next)
+}
+
+// SignupPage renders the signup form
+func (h *Handler) SignupPage(c *gin.Context) {
+	if currentUser(c) != nil {
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+
+	c.HTML(http.StatusOK, "signup.html", gin.H{
+		"Title": "Kayıt Ol - Donation Bars",
+	})
+}
+
+// Signup handles the signup form submission and logs the new user in
+func (h *Handler) Signup(c *gin.Context) {
+	var req models.SignupRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.HTML(http.StatusBadRequest, "signup.html", gin.H{
+			"Title":       "Kayıt Ol - Donation Bars",
+			"Email":       req.Email,
+			"DisplayName": req.DisplayName,
+			"Error":       "Geçerli bir e-posta, 2-50 karakterlik bir isim ve en az 8 karakterlik bir şifre girin",
+		})
+		return
+	}
+
+	if _, err := h.authService.Signup(&req); err != nil {
+		c.HTML(http.StatusBadRequest, "signup.html", gin.H{
+			"Title":       "Kayıt Ol - Donation Bars",
+			"Email":       req.Email,
+			"DisplayName": req.DisplayName,
+			"Error":       authErrorMessage(err),
+		})
+		return
+	}
+
+	_, token, err := h.authService.Login(&models.LoginRequest{Email: req.Email, Password: req.Password})
+	if err != nil {
+		c.Redirect(http.StatusFound, "/login?success=Hesabınız oluşturuldu, giriş yapabilirsiniz")
+		return
+	}
+
+	h.setSessionCookie(c, token)
+	c.Redirect(http.StatusFound, "/?success=Hoş geldin! Hesabın oluşturuldu")
+}
+
+// Logout ends the current session
+func (h *Handler) Logout(c *gin.Context) {
+	if token, err := c.Cookie(h.session.CookieName); err == nil {
+		h.authService.Logout(token)
+	}
+
+	h.clearSessionCookie(c)
+	c.Redirect(http.StatusFound, "/login?success=Çıkış yapıldı")
+}
+
+// setSessionCookie stores the session token in an HttpOnly, SameSite=Lax cookie
+func (h *Handler) setSessionCookie(c *gin.Context, token string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(h.session.CookieName, token, int(h.session.TTL.Seconds()), "/", "", h.session.CookieSecure, true)
+}
+
+// clearSessionCookie removes the session cookie from the browser
+func (h *Handler) clearSessionCookie(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(h.session.CookieName, "", -1, "/", "", h.session.CookieSecure, true)
+}
+
+// currentUser returns the authenticated user, or nil for anonymous requests
+func currentUser(c *gin.Context) *models.User {
+	if value, ok := c.Get(userContextKey); ok {
+		if user, ok := value.(*models.User); ok {
+			return user
+		}
+	}
+	return nil
+}
+
+// currentUserID returns the ID of the authenticated user
+func currentUserID(c *gin.Context) string {
+	if user := currentUser(c); user != nil {
+		return user.ID.Hex()
+	}
+	return ""
+}
+
+// safeRedirect only allows local paths as post-login targets
+func safeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// authErrorMessage turns auth service errors into user facing text
+func authErrorMessage(err error) string {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		switch appErr.Type {
+		case apperrors.TypeUnauthorized:
+			return "E-posta veya şifre hatalı"
+		case apperrors.TypeConflict:
+			return "Bu e-posta adresiyle zaten bir hesap var"
+		}
+	}
+	return "İşlem sırasında bir hata oluştu, lütfen tekrar deneyin"
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+
+	"donationbars/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// barListQuery is the listing state the filter forms of the index and
+// manage pages are filled from
+type barListQuery struct {
+	Sort        string
+	Order       string
+	Search      string
+	Active      string // "true", "false" or "" for both
+	AIGenerated string
+	Language    string
+	Theme       string
+	Filtered    bool // Any filter is set, so an empty page is not an empty account
+	NextURL     string
+	FirstURL    string // Set when the page is not the first one
+}
+
+// bindBarListOptions reads the listing options of GET /api/v1/bars and the SSR listing pages
+func bindBarListOptions(c *gin.Context) (*models.BarListOptions, error) {
+	var opts models.BarListOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		return nil, err
+	}
+
+	// The filter form submits "" for "any", which gin binds as false
+	if c.Query("active") == "" {
+		opts.Active = nil
+	}
+	if c.Query("ai_generated") == "" {
+		opts.AIGenerated = nil
+	}
+
+	// Links from before the filter form used show_only_active
+	if opts.Active == nil && c.Query("show_only_active") == "true" {
+		active := true
+		opts.Active = &active
+	}
+
+	return &opts, nil
+}
+
+// barListPage loads the bars and counters of an SSR listing page
+func (h *Handler) barListPage(c *gin.Context, userID string) gin.H {
+	data := gin.H{}
+
+	opts, err := bindBarListOptions(c)
+	if err != nil {
+		data["Error"] = "Geçersiz filtre: " + err.Error()
+		opts = &models.BarListOptions{}
+	}
+
+	page, err := h.barService.ListUserBars(userID, opts)
+	if err != nil {
+		data["Error"] = err.Error()
+		page = &models.BarPage{Bars: []*models.DonationBar{}}
+	}
+
+	active := true
+	totalBars, _ := h.barService.CountUserBars(userID, nil)
+	activeBars, _ := h.barService.CountUserBars(userID, &models.BarFilter{Active: &active})
+
+	query := barListQuery{
+		Sort:        opts.Sort,
+		Order:       opts.Order,
+		Search:      opts.Search,
+		Active:      formatOptionalBool(opts.Active),
+		AIGenerated: formatOptionalBool(opts.AIGenerated),
+		Language:    opts.Language,
+		Theme:       opts.Theme,
+		Filtered:    opts.BarFilter != (models.BarFilter{}),
+	}
+	if page.NextCursor != "" {
+		query.NextURL = listPageURL(c, page.NextCursor)
+	}
+	if opts.Cursor != "" {
+		query.FirstURL = listPageURL(c, "")
+	}
+
+	data["Bars"] = page.Bars
+	data["TotalBars"] = totalBars
+	data["ActiveBars"] = activeBars
+	data["Query"] = query
+	return data
+}
+
+// listPageURL returns the current listing URL at another cursor
+func listPageURL(c *gin.Context, cursor string) string {
+	values := c.Request.URL.Query()
+	values.Del("success")
+	values.Del("error")
+	values.Del("cursor")
+	if cursor != "" {
+		values.Set("cursor", cursor)
+	}
+
+	u := url.URL{Path: c.Request.URL.Path, RawQuery: values.Encode()}
+	return u.String()
+}
+
+func formatOptionalBool(value *bool) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*value)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddDonation records a donation against a bar (API)
+func (h *Handler) AddDonation(c *gin.Context) {
+	var req models.CreateDonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	userID := currentUserID(c)
+
+	barID := c.Param("id")
+	donation, err := h.donationService.AddDonation(userID, barID, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	h.rateLimitHeaders(c, ratelimit.ActionDonation)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    donation,
+	})
+}
+
+// GetDonations returns the donation ledger of a bar (API)
+func (h *Handler) GetDonations(c *gin.Context) {
+	userID := currentUserID(c)
+
+	barID := c.Param("id")
+	donations, err := h.donationService.GetDonations(userID, barID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var total float64
+	for _, donation := range donations {
+		total += donation.Amount
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    donations,
+		"count":   len(donations),
+		"total":   total,
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	apperrors "donationbars/internal/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorStatus maps application error types to HTTP status codes
+var errorStatus = map[string]int{
+	apperrors.TypeNotFound:          http.StatusNotFound,
+	apperrors.TypeInvalidInput:      http.StatusBadRequest,
+	apperrors.TypeValidationError:   http.StatusBadRequest,
+	apperrors.TypeUnauthorized:      http.StatusUnauthorized,
+	apperrors.TypeForbidden:         http.StatusForbidden,
+	apperrors.TypeMaxBarsReached:    http.StatusForbidden,
+	apperrors.TypeQuotaExceeded:     http.StatusForbidden,
+	apperrors.TypeConflict:          http.StatusConflict,
+	apperrors.TypeRateLimitExceeded: http.StatusTooManyRequests,
+	apperrors.TypeAIServiceError:    http.StatusBadGateway,
+	apperrors.TypeDatabaseError:     http.StatusInternalServerError,
+}
+
+// httpStatus returns the HTTP status code for a service error
+func httpStatus(err error) int {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		return http.StatusInternalServerError
+	}
+
+	if errors.Is(err, apperrors.ErrDatabaseUnavailable) {
+		return http.StatusServiceUnavailable
+	}
+	if status, ok := errorStatus[appErr.Type]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// apiError is the body of every API error response:
+// {"success": false, "error": {"type": "NOT_FOUND", "message": "bar not found", ...}}
+func apiError(err error) gin.H {
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) {
+		// Unexpected errors may carry internals, only their type is shown
+		appErr = &apperrors.AppError{Type: apperrors.TypeInternal, Message: "internal server error"}
+	}
+
+	return gin.H{
+		"success": false,
+		"error":   appErr,
+	}
+}
+
+// respondError writes a service error as an API error response
+func respondError(c *gin.Context, err error) {
+	status := httpStatus(err)
+	if status >= http.StatusInternalServerError {
+		slog.Error("API request failed",
+			"method", c.Request.Method,
+			"path", c.FullPath(),
+			"status", status,
+			"error", err.Error())
+	}
+
+	setRetryAfter(c, err)
+	c.JSON(status, apiError(err))
+}
+
+// abortWithError writes a service error as an API error response and stops the handler chain
+func abortWithError(c *gin.Context, err error) {
+	respondError(c, err)
+	c.Abort()
+}
+
+// respondBindError reports a request body that failed binding or validation
+func respondBindError(c *gin.Context, err error) {
+	respondError(c, apperrors.ValidationError("request", err.Error()))
+}
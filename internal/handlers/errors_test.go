@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", apperrors.NotFound("bar", "1"), http.StatusNotFound},
+		{"invalid input", apperrors.InvalidInput("bar ID", "x"), http.StatusBadRequest},
+		{"validation", apperrors.ValidationError("html", "missing fields"), http.StatusBadRequest},
+		{"unauthorized", apperrors.Unauthorized("invalid api key"), http.StatusUnauthorized},
+		{"forbidden", apperrors.Forbidden("missing scope", ""), http.StatusForbidden},
+		{"conflict", apperrors.Conflict("user", "email already registered"), http.StatusConflict},
+		{"rate limit", apperrors.RateLimitError("u", 5), http.StatusTooManyRequests},
+		{"quota", apperrors.QuotaExceeded("günlük AI üretim", "free", 5), http.StatusForbidden},
+		{"database", apperrors.DatabaseError("find bar", errors.New("boom")), http.StatusInternalServerError},
+		{"database unavailable", apperrors.DatabaseError("find bar", apperrors.ErrDatabaseUnavailable), http.StatusServiceUnavailable},
+		{"plain error", errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, httpStatus(tt.err))
+		})
+	}
+}
+
+func TestRespondError_Envelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	respond := func(err error) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/bars/1", nil)
+
+		respondError(c, err)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	status, body := respond(apperrors.NotFound("bar", "1"))
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, false, body["success"])
+	assert.Equal(t, map[string]any{
+		"type":    "NOT_FOUND",
+		"message": "bar not found",
+		"details": "ID: 1",
+	}, body["error"])
+
+	// Unexpected errors do not leak their text
+	status, body = respond(errors.New("mongo: connection refused at 10.0.0.5"))
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, map[string]any{
+		"type":    "INTERNAL_ERROR",
+		"message": "internal server error",
+	}, body["error"])
+}
+
+func TestRespondError_RateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reset := time.Unix(1700000000, 0)
+	policy := ratelimit.Policy{Limit: 5, Window: 24 * time.Hour}
+	err := ratelimit.Exceeded(ratelimit.ActionBarCreate, policy, &ratelimit.Result{
+		Limit:      5,
+		Reset:      reset,
+		RetryAfter: 1500 * time.Millisecond,
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/bars", nil)
+
+	respondError(c, err)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "5", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "1700000000", w.Header().Get("X-RateLimit-Reset"))
+	assert.Equal(t, "2", w.Header().Get("Retry-After"), "rounded up to whole seconds")
+
+	// Other errors carry no rate limit headers
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/bars", nil)
+
+	respondError(c, apperrors.NotFound("bar", "1"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+}
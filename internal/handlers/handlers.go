@@ -1,33 +1,73 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
-	"time"
 
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
 	"donationbars/internal/interfaces"
 	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+	"donationbars/internal/render"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	barService interfaces.BarServiceInterface
-	aiService  interfaces.AIServiceInterface
-	tmpl       *template.Template
+	barService      interfaces.BarServiceInterface
+	aiService       interfaces.AIServiceInterface
+	donationService interfaces.DonationServiceInterface
+	authService     interfaces.AuthServiceInterface
+	jobService      interfaces.JobServiceInterface
+	apiKeyService   interfaces.APIKeyServiceInterface
+	webhookService  interfaces.WebhookServiceInterface
+	eventService    interfaces.EventServiceInterface
+	quotaService    interfaces.QuotaServiceInterface
+	broker          interfaces.EventBrokerInterface
+	limits          *ratelimit.Guard
+	session         config.SessionConfig
+	tmpl            *template.Template
 }
 
-func New(barService interfaces.BarServiceInterface, aiService interfaces.AIServiceInterface) *Handler {
+// Dependencies are the services and settings the handlers are built from
+type Dependencies struct {
+	BarService      interfaces.BarServiceInterface
+	AIService       interfaces.AIServiceInterface
+	DonationService interfaces.DonationServiceInterface
+	AuthService     interfaces.AuthServiceInterface
+	JobService      interfaces.JobServiceInterface
+	APIKeyService   interfaces.APIKeyServiceInterface
+	WebhookService  interfaces.WebhookServiceInterface
+	EventService    interfaces.EventServiceInterface
+	QuotaService    interfaces.QuotaServiceInterface
+	Broker          interfaces.EventBrokerInterface
+	RateLimits      *ratelimit.Guard // Only read for the X-RateLimit headers; services enforce the limits
+	Session         config.SessionConfig
+}
+
+func New(deps Dependencies) *Handler {
 	// Load HTML templates
-	tmpl := template.Must(template.ParseGlob("templates/*.html"))
+	tmpl := template.Must(template.New("").Funcs(render.TemplateFuncs()).ParseGlob("templates/*.html"))
 
 	return &Handler{
-		barService: barService,
-		aiService:  aiService,
-		tmpl:       tmpl,
+		barService:      deps.BarService,
+		aiService:       deps.AIService,
+		donationService: deps.DonationService,
+		authService:     deps.AuthService,
+		jobService:      deps.JobService,
+		apiKeyService:   deps.APIKeyService,
+		webhookService:  deps.WebhookService,
+		eventService:    deps.EventService,
+		quotaService:    deps.QuotaService,
+		broker:          deps.Broker,
+		limits:          deps.RateLimits,
+		session:         deps.Session,
+		tmpl:            tmpl,
 	}
 }
 
@@ -35,46 +75,12 @@ func New(barService interfaces.BarServiceInterface, aiService interfaces.AIServi
 
 // HomePage renders the main page
 func (h *Handler) HomePage(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
-
-	bars, err := h.barService.GetUserBars(userID)
-	if err != nil {
-		bars = []*models.DonationBar{}
-	}
-
-	// Check if user wants to show only active bars
-	showOnlyActive := c.Query("show_only_active") == "true"
+	userID := currentUserID(c)
 
-	activeBars := 0
-	var filteredBars []*models.DonationBar
-
-	for _, bar := range bars {
-		if bar.IsActive {
-			activeBars++
-		}
-
-		// Filter bars based on user preference
-		if showOnlyActive {
-			if bar.IsActive {
-				filteredBars = append(filteredBars, bar)
-			}
-		} else {
-			filteredBars = append(filteredBars, bar)
-		}
-	}
-
-	data := gin.H{
-		"Title":          "Donation Bars - AI Powered OBS Bar Designer",
-		"UserID":         userID,
-		"Bars":           filteredBars,
-		"TotalBars":      len(bars), // Total count should show all bars
-		"ActiveBars":     activeBars,
-		"MaxBars":        5,
-		"ShowOnlyActive": showOnlyActive,
-	}
+	data := h.barListPage(c, userID)
+	data["Title"] = "Donation Bars - AI Powered OBS Bar Designer"
+	data["User"] = currentUser(c)
+	data["Quota"] = h.homeQuota(userID)
 
 	// Handle success/error messages from URL query parameters
 	if success := c.Query("success"); success != "" {
@@ -101,29 +107,10 @@ func (h *Handler) CreatePage(c *gin.Context) {
 
 // ManagePage renders the bar management page
 func (h *Handler) ManagePage(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
-	bars, err := h.barService.GetUserBars(userID)
-	if err != nil {
-		bars = []*models.DonationBar{}
-	}
-
-	activeBars := 0
-	for _, bar := range bars {
-		if bar.IsActive {
-			activeBars++
-		}
-	}
-
-	data := gin.H{
-		"Title":      "Bar Yönetimi - Donation Bars",
-		"Bars":       bars,
-		"TotalBars":  len(bars),
-		"ActiveBars": activeBars,
-	}
+	data := h.barListPage(c, userID)
+	data["Title"] = "Bar Yönetimi - Donation Bars"
 
 	// Handle success/error messages from URL query parameters
 	if success := c.Query("success"); success != "" {
@@ -149,10 +136,18 @@ func (h *Handler) CreateBarForm(c *gin.Context) {
 		return
 	}
 
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
+	goals, ok := parseGoals(c.PostForm("goals"))
+	if !ok {
+		c.HTML(http.StatusBadRequest, "create.html", gin.H{
+			"Title": "Yeni Bar Oluştur - Donation Bars",
+			"Mode":  "manual",
+			"Error": goalsFormError,
+		})
+		return
 	}
+	req.Goals = goals
+
+	userID := currentUserID(c)
 
 	bar, err := h.barService.CreateBar(userID, &req)
 	if err != nil {
@@ -179,74 +174,54 @@ func (h *Handler) CreateBarAIForm(c *gin.Context) {
 		return
 	}
 
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
-
-	// Check daily rate limit (5 bars/day)
-	dailyCount, err := h.barService.GetUserDailyBarCount(userID)
-	if err == nil && dailyCount >= 5 {
+	// The goals go through the job to the result page's save form
+	goals, ok := parseGoals(c.PostForm("goals"))
+	if !ok {
 		c.HTML(http.StatusBadRequest, "create.html", gin.H{
 			"Title": "Yeni Bar Oluştur - Donation Bars",
 			"Mode":  "ai",
-			"Error": "Günlük bar oluşturma limitine ulaştınız (5 bar/gün). Yarın tekrar deneyebilirsiniz.",
+			"Error": goalsFormError,
 		})
 		return
 	}
+	req.Goals = goals
+
+	userID := currentUserID(c)
+
+	// The generated bar is saved from the result page; fail before spending
+	// an AI call on a bar that cannot be saved today
+	if err := h.barService.CheckDailyRateLimit(userID); err != nil {
+		h.renderAIFormError(c, err)
+		return
+	}
 
-	// Generate with AI
-	aiResponse, err := h.aiService.GenerateBar(&req)
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency(req.Language)
+	}
+
+	// Generation runs in the worker pool, the job page polls for the result
+	job, err := h.jobService.Submit(userID, &req, false)
 	if err != nil {
-		c.HTML(http.StatusInternalServerError, "create.html", gin.H{
-			"Title": "Yeni Bar Oluştur - Donation Bars",
-			"Mode":  "ai",
-			"Error": "AI ile bar oluşturulurken hata oluştu: " + err.Error(),
-		})
+		h.renderAIFormError(c, err)
 		return
 	}
 
-	// Create preview HTML with sample data AND embedded CSS using actual bar amounts
-	previewHTML := aiResponse.HTML
-	goalValue := fmt.Sprintf("%.0f", req.GoalAmount)
-	totalValue := fmt.Sprintf("%.0f", req.InitialAmount)
-	var percentageValue, remainingValue string
-	if req.GoalAmount > 0 {
-		percentage := (req.InitialAmount / req.GoalAmount) * 100
-		remaining := req.GoalAmount - req.InitialAmount
-		percentageValue = fmt.Sprintf("%.0f", percentage)
-		remainingValue = fmt.Sprintf("%.0f", remaining)
-	} else {
-		percentageValue = "0"
-		remainingValue = goalValue
-	}
-
-	previewHTML = strings.Replace(previewHTML, "{goal}", goalValue, -1)
-	previewHTML = strings.Replace(previewHTML, "{total}", totalValue, -1)
-	previewHTML = strings.Replace(previewHTML, "{percentage}", percentageValue, -1)
-	previewHTML = strings.Replace(previewHTML, "{remaining}", remainingValue, -1)
-	previewHTML = strings.Replace(previewHTML, "{description}", "Oyun geliştirme için bağış kampanyası", -1)
-
-	// Fix double percentage issue (XX%% -> XX%)
-	previewHTML = strings.Replace(previewHTML, percentageValue+"%%", percentageValue+"%", -1)
-
-	// Create complete preview with embedded CSS for proper rendering
-	completePreviewHTML := `<style>` + aiResponse.CSS + `</style>` + previewHTML
-
-	// Show AI result page
-	c.HTML(http.StatusOK, "ai_result.html", gin.H{
-		"Title":         "AI Bar Sonucu - Donation Bars",
-		"HTML":          template.HTML(aiResponse.HTML),
-		"CSS":           template.HTML(aiResponse.CSS),
-		"PreviewHTML":   template.HTML(completePreviewHTML),
-		"RawHTML":       aiResponse.HTML,
-		"RawCSS":        aiResponse.CSS,
-		"Prompt":        req.Prompt,
-		"Language":      req.Language,
-		"Theme":         req.Theme,
-		"InitialAmount": req.InitialAmount,
-		"GoalAmount":    req.GoalAmount,
-		"CreatedAt":     time.Now().Format("02.01.2006 15:04"),
+	c.Redirect(http.StatusSeeOther, "/create/ai/jobs/"+job.ID.Hex())
+}
+
+// renderAIFormError shows the AI creation form again with a service error
+func (h *Handler) renderAIFormError(c *gin.Context, err error) {
+	message := "AI ile bar oluşturulurken hata oluştu: " + err.Error()
+	var appErr *apperrors.AppError
+	if errors.Is(err, apperrors.ErrRateLimitExceeded) && errors.As(err, &appErr) {
+		message = appErr.Message + ". Daha sonra tekrar deneyebilirsiniz."
+	}
+
+	setRetryAfter(c, err)
+	c.HTML(httpStatus(err), "create.html", gin.H{
+		"Title": "Yeni Bar Oluştur - Donation Bars",
+		"Mode":  "ai",
+		"Error": message,
 	})
 }
 
@@ -254,6 +229,7 @@ func (h *Handler) CreateBarAIForm(c *gin.Context) {
 func (h *Handler) SaveAIBarForm(c *gin.Context) {
 	prompt := c.PostForm("prompt")
 	language := c.PostForm("language")
+	currency := c.PostForm("currency")
 	theme := c.PostForm("theme")
 	html := c.PostForm("html")
 	css := c.PostForm("css")
@@ -282,24 +258,29 @@ func (h *Handler) SaveAIBarForm(c *gin.Context) {
 		}
 	}
 
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
+	// The result pages post one field per goal
+	goals, ok := parseGoals(strings.Join(c.PostFormArray("goals"), "\n"))
+	if !ok {
+		c.Redirect(http.StatusFound, "/?error="+url.QueryEscape(goalsFormError))
+		return
 	}
 
+	userID := currentUserID(c)
+
 	// Create AI response object
 	aiResponse := &models.AIGenerateResponse{
 		HTML: html,
 		CSS:  css,
 		Metadata: models.AIGenerateMetadata{
 			Language:      language,
+			Currency:      currency,
 			Theme:         theme,
 			HasInjections: true, // AI always includes injections
 		},
 	}
 
 	// Save to database using existing service with amounts
-	bar, err := h.barService.CreateBarFromAI(userID, prompt, aiResponse, initialAmount, goalAmount)
+	bar, err := h.barService.CreateBarFromAI(userID, prompt, aiResponse, initialAmount, goalAmount, goals)
 	if err != nil {
 		c.Redirect(http.StatusFound, "/?error="+err.Error())
 		return
@@ -310,10 +291,7 @@ func (h *Handler) SaveAIBarForm(c *gin.Context) {
 
 // EditPage renders the bar edit page
 func (h *Handler) EditPage(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
 	barID := c.Param("id")
 	bar, err := h.barService.GetBar(userID, barID)
@@ -326,8 +304,28 @@ func (h *Handler) EditPage(c *gin.Context) {
 	}
 
 	data := gin.H{
-		"Title": "Bar Düzenle - " + bar.Name,
-		"Bar":   bar,
+		"Title":       "Bar Düzenle - " + bar.Name,
+		"Bar":         bar,
+		"OverlayURL":  overlayURL(c, bar.OverlayToken),
+		"WebhookURLs": webhookURLs(c, bar.WebhookToken),
+	}
+
+	// History is optional on this page; a failure only hides the section
+	if revisions, err := h.barService.GetRevisions(userID, barID); err == nil {
+		data["Revisions"] = revisions
+	}
+	if hooks, err := h.eventService.ListHooks(userID, barID); err == nil {
+		data["Hooks"] = hooks
+	}
+	if deliveries, err := h.eventService.ListDeliveries(userID, barID); err == nil {
+		data["Deliveries"] = deliveries
+	}
+	if compare := c.Query("compare"); compare != "" {
+		if number, err := strconv.Atoi(compare); err == nil {
+			if revision, err := h.barService.GetRevision(userID, barID, number); err == nil {
+				data["Diff"] = compareRevision(bar, revision)
+			}
+		}
 	}
 
 	// Handle success/error messages from URL query parameters
@@ -343,10 +341,7 @@ func (h *Handler) EditPage(c *gin.Context) {
 
 // EditBarForm handles bar editing form submission
 func (h *Handler) EditBarForm(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
 	barID := c.Param("id")
 
@@ -360,6 +355,7 @@ func (h *Handler) EditBarForm(c *gin.Context) {
 	name := c.PostForm("name")
 	description := c.PostForm("description")
 	language := c.PostForm("language")
+	currency := strings.ToUpper(c.PostForm("currency"))
 	theme := c.PostForm("theme")
 	isActiveStr := c.PostForm("is_active")
 	initialAmountStr := c.PostForm("initial_amount")
@@ -382,6 +378,11 @@ func (h *Handler) EditBarForm(c *gin.Context) {
 		return
 	}
 
+	if currency != "" && !render.IsSupportedCurrency(currency) {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error=Geçerli bir para birimi seçmelisiniz")
+		return
+	}
+
 	// Parse amounts
 	var initialAmount, goalAmount *float64
 	var initialAmountValue, goalAmountValue float64 = 0.0, 1000.0 // defaults
@@ -406,6 +407,13 @@ func (h *Handler) EditBarForm(c *gin.Context) {
 		}
 	}
 
+	// Stretch goals replace the goal amount; an empty field keeps a single goal
+	goals, ok := parseGoals(c.PostForm("goals"))
+	if !ok {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error="+url.QueryEscape(goalsFormError))
+		return
+	}
+
 	// Build update request
 	updateReq := &models.UpdateBarRequest{
 		Name:          &name,
@@ -413,6 +421,10 @@ func (h *Handler) EditBarForm(c *gin.Context) {
 		IsActive:      &isActive,
 		InitialAmount: initialAmount,
 		GoalAmount:    goalAmount,
+		Goals:         &goals,
+	}
+	if currency != "" {
+		updateReq.Currency = &currency
 	}
 
 	// For non-AI generated bars, allow HTML/CSS editing
@@ -433,9 +445,11 @@ func (h *Handler) EditBarForm(c *gin.Context) {
 			HTML:          html,
 			CSS:           css,
 			Language:      language,
+			Currency:      currency,
 			Theme:         theme,
 			InitialAmount: initialAmountValue,
 			GoalAmount:    goalAmountValue,
+			Goals:         goals,
 		}
 
 		// Call a new update method that handles HTML/CSS
@@ -456,12 +470,32 @@ func (h *Handler) EditBarForm(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/edit/"+barID+"?success=Bar başarıyla güncellendi")
 }
 
+// goalsFormError explains the format parseGoals accepts
+const goalsFormError = "Aşamalar her satırda bir tutar ve isteğe bağlı bir etiket olmalı, örn: 500 Mikrofon"
+
+// parseGoals parses stretch goals written one per line as an amount and an
+// optional label, e.g. "500 Mikrofon". The result is never nil, so an empty
+// field clears the goals.
+func parseGoals(value string) ([]models.Goal, bool) {
+	goals := []models.Goal{}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		amount, label, _ := strings.Cut(line, " ")
+		parsed, err := strconv.ParseFloat(amount, 64)
+		if err != nil || parsed <= 0 {
+			return nil, false
+		}
+		goals = append(goals, models.Goal{Amount: parsed, Label: strings.TrimSpace(label)})
+	}
+	return goals, true
+}
+
 // ToggleBarStatus toggles bar active status
 func (h *Handler) ToggleBarStatus(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
 	barID := c.Param("id")
 	isActive := c.PostForm("is_active") == "true"
@@ -481,10 +515,7 @@ func (h *Handler) ToggleBarStatus(c *gin.Context) {
 
 // DeleteBarForm deletes a bar
 func (h *Handler) DeleteBarForm(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
 	barID := c.Param("id")
 	err := h.barService.DeleteBar(userID, barID)
@@ -498,10 +529,7 @@ func (h *Handler) DeleteBarForm(c *gin.Context) {
 
 // PreviewBar renders a bar preview
 func (h *Handler) PreviewBar(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
 	barID := c.Param("id")
 	bar, err := h.barService.GetBar(userID, barID)
@@ -513,31 +541,9 @@ func (h *Handler) PreviewBar(c *gin.Context) {
 		return
 	}
 
-	// Use bar's actual data or sample data for preview
-	goalValue := fmt.Sprintf("%.0f", bar.GoalAmount)
-	totalValue := fmt.Sprintf("%.0f", bar.InitialAmount)
-	var percentageValue, remainingValue string
-	if bar.GoalAmount > 0 {
-		percentage := (bar.InitialAmount / bar.GoalAmount) * 100
-		remaining := bar.GoalAmount - bar.InitialAmount
-		percentageValue = fmt.Sprintf("%.0f", percentage)
-		remainingValue = fmt.Sprintf("%.0f", remaining)
-	} else {
-		percentageValue = "0"
-		remainingValue = goalValue
-	}
-	descriptionValue := "Oyun geliştirme için bağış kampanyası"
-
-	// Replace injection fields in HTML
-	html := bar.HTML
-	html = strings.Replace(html, "{goal}", goalValue, -1)
-	html = strings.Replace(html, "{total}", totalValue, -1)
-	html = strings.Replace(html, "{percentage}", percentageValue, -1)
-	html = strings.Replace(html, "{remaining}", remainingValue, -1)
-	html = strings.Replace(html, "{description}", descriptionValue, -1)
-
-	// Fix double percentage issue (75%% -> 75%)
-	html = strings.Replace(html, percentageValue+"%%", percentageValue+"%", -1)
+	// Use bar's actual data for preview
+	state := render.NewState(bar)
+	html := render.Render(bar, state)
 
 	// Create preview HTML with embedded CSS
 	previewHTML := `<!DOCTYPE html>
@@ -545,7 +551,7 @@ func (h *Handler) PreviewBar(c *gin.Context) {
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Bar Önizleme - ` + bar.Name + `</title>
+    <title>Bar Önizleme - ` + template.HTMLEscapeString(bar.Name) + `</title>
     <style>
         body {
             margin: 0;
@@ -592,7 +598,7 @@ func (h *Handler) PreviewBar(c *gin.Context) {
     <div class="preview-container">
         <div class="preview-header">
             <h1>📺 OBS Donation Bar Önizlemesi</h1>
-            <p>Bar: <strong>` + bar.Name + `</strong> | `
+            <p>Bar: <strong>` + template.HTMLEscapeString(bar.Name) + `</strong> | `
 
 	if bar.AIGenerated {
 		previewHTML += `🤖 AI ile Oluşturuldu`
@@ -622,11 +628,18 @@ func (h *Handler) PreviewBar(c *gin.Context) {
         
         <div class="preview-info">
             <strong>📋 Injection Alanları:</strong><br>
-            • {goal} → ` + goalValue + ` ₺<br>
-            • {total} → ` + totalValue + ` ₺<br>
-            • {percentage} → ` + percentageValue + `%<br>
-            • {remaining} → ` + remainingValue + ` ₺<br>
-            • {description} → "` + descriptionValue + `"
+            • {goal} → ` + state.Format(render.FieldGoal) + `<br>
+            • {total} → ` + state.Format(render.FieldTotal) + `<br>
+            • {percentage} → ` + state.Format(render.FieldPercentage) + `%<br>
+            • {remaining} → ` + state.Format(render.FieldRemaining) + `<br>
+            • {description} → "` + template.HTMLEscapeString(state.Description) + `"<br>
+            • {currency} → ` + template.HTMLEscapeString(state.Format(render.FieldCurrency)) + `<br>
+            • {goal_formatted} → ` + template.HTMLEscapeString(state.Format(render.FieldGoalFormatted)) + `<br>
+            • {total_formatted} → ` + template.HTMLEscapeString(state.Format(render.FieldTotalFormatted)) + `<br>
+            • {remaining_formatted} → ` + template.HTMLEscapeString(state.Format(render.FieldRemainingFormatted)) + `<br>
+            • {goal_label} → "` + template.HTMLEscapeString(state.Format(render.FieldGoalLabel)) + `"<br>
+            • {goal_index} → ` + state.Format(render.FieldGoalIndex) + `<br>
+            • {goals_total} → ` + state.Format(render.FieldGoalsTotal) + `
         </div>
         
         <div style="text-align: center; margin-top: 20px;">
@@ -648,57 +661,56 @@ func (h *Handler) PreviewBar(c *gin.Context) {
 func (h *Handler) CreateBar(c *gin.Context) {
 	var req models.CreateBarRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
 	bar, err := h.barService.CreateBar(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
+	h.rateLimitHeaders(c, ratelimit.ActionBarCreate)
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"data":    bar,
 	})
 }
 
-// GetUserBars returns all bars for the authenticated user (API)
+// GetUserBars returns one page of the authenticated user's bars (API)
 func (h *Handler) GetUserBars(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
+	userID := currentUserID(c)
+
+	opts, err := bindBarListOptions(c)
+	if err != nil {
+		respondBindError(c, err)
+		return
 	}
 
-	bars, err := h.barService.GetUserBars(userID)
+	page, err := h.barService.ListUserBars(userID, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    bars,
+		"success":     true,
+		"data":        page.Bars,
+		"next_cursor": page.NextCursor,
 	})
 }
 
 // GetBar returns a specific bar (API)
 func (h *Handler) GetBar(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
 	barID := c.Param("id")
 	bar, err := h.barService.GetBar(userID, barID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -710,22 +722,43 @@ func (h *Handler) GetBar(c *gin.Context) {
 
 // UpdateBar updates a bar (API)
 func (h *Handler) UpdateBar(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
 	barID := c.Param("id")
 
 	var req models.UpdateBarRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
 	bar, err := h.barService.UpdateBar(userID, barID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    bar,
+	})
+}
+
+// IncrementBar adds an amount to a bar's starting amount (API)
+func (h *Handler) IncrementBar(c *gin.Context) {
+	userID := currentUserID(c)
+
+	barID := c.Param("id")
+
+	var req models.IncrementBarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	bar, err := h.barService.IncrementAmount(userID, barID, req.Amount)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -737,15 +770,12 @@ func (h *Handler) UpdateBar(c *gin.Context) {
 
 // DeleteBar deletes a bar (API)
 func (h *Handler) DeleteBar(c *gin.Context) {
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
 	barID := c.Param("id")
 	err := h.barService.DeleteBar(userID, barID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -755,36 +785,31 @@ func (h *Handler) DeleteBar(c *gin.Context) {
 	})
 }
 
-// GenerateBarWithAI generates a new bar using AI (API)
+// GenerateBarWithAI queues an AI generation job that saves the bar (API)
 func (h *Handler) GenerateBarWithAI(c *gin.Context) {
 	var req models.GenerateBarRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
 		return
 	}
 
-	userID := c.GetHeader("X-User-ID")
-	if userID == "" {
-		userID = "test-user"
-	}
+	userID := currentUserID(c)
 
-	// Generate with AI
-	aiResponse, err := h.aiService.GenerateBar(&req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency(req.Language)
 	}
 
-	// Save to database
-	bar, err := h.barService.CreateBarFromAI(userID, req.Prompt, aiResponse, req.InitialAmount, req.GoalAmount)
+	job, err := h.jobService.Submit(userID, &req, true)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"success":      true,
-		"data":         bar,
-		"ai_generated": true,
+	h.rateLimitHeaders(c, ratelimit.ActionAIGenerate)
+	c.Header("Location", "/api/v1/jobs/"+job.ID.Hex())
+	c.JSON(http.StatusAccepted, gin.H{
+		"success":    true,
+		"data":       job,
+		"status_url": "/api/v1/jobs/" + job.ID.Hex(),
 	})
 }
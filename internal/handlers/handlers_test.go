@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGoals(t *testing.T) {
+	goals, ok := parseGoals("500 Mikrofon\n\n1500  Kamera \n3000")
+	assert.True(t, ok)
+	assert.Equal(t, []models.Goal{{Amount: 500, Label: "Mikrofon"}, {Amount: 1500, Label: "Kamera"}, {Amount: 3000}}, goals)
+
+	goals, ok = parseGoals("")
+	assert.True(t, ok)
+	assert.NotNil(t, goals, "an empty field clears the goals")
+	assert.Empty(t, goals)
+
+	for _, value := range []string{"Mikrofon 500", "0 Bedava", "-5 Eksi"} {
+		_, ok = parseGoals(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestParseGoals_ResultPageFields(t *testing.T) {
+	// The AI result pages print each goal into a hidden field the way the
+	// template does, and the save form joins them back into lines
+	want := []models.Goal{{Amount: 500, Label: "Mikrofon"}, {Amount: 1_500_000, Label: "Yeni stüdyo"}}
+	fields := make([]string, 0, len(want))
+	for _, goal := range want {
+		fields = append(fields, fmt.Sprint(goal.Amount)+" "+goal.Label)
+	}
+
+	goals, ok := parseGoals(strings.Join(fields, "\n"))
+	assert.True(t, ok)
+	assert.Equal(t, want, goals)
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+	"donationbars/internal/render"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJob reports the status of an AI generation job (API)
+func (h *Handler) GetJob(c *gin.Context) {
+	userID := currentUserID(c)
+
+	job, err := h.jobService.GetJob(userID, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// RefineBar queues an AI refinement of a bar's design (API)
+func (h *Handler) RefineBar(c *gin.Context) {
+	var req models.RefineBarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	userID := currentUserID(c)
+
+	job, err := h.jobService.SubmitRefine(userID, c.Param("id"), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	h.rateLimitHeaders(c, ratelimit.ActionAIGenerate)
+	c.Header("Location", "/api/v1/jobs/"+job.ID.Hex())
+	c.JSON(http.StatusAccepted, gin.H{
+		"success":    true,
+		"data":       job,
+		"status_url": "/api/v1/jobs/" + job.ID.Hex(),
+	})
+}
+
+// RefineBarForm handles the AI refinement form of the edit page
+func (h *Handler) RefineBarForm(c *gin.Context) {
+	barID := c.Param("id")
+
+	var req models.RefineBarRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error=Düzenleme isteği 3-500 karakter olmalıdır")
+		return
+	}
+
+	userID := currentUserID(c)
+
+	job, err := h.jobService.SubmitRefine(userID, barID, &req)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/create/ai/jobs/"+job.ID.Hex())
+}
+
+// AIJobPage shows a waiting page until the generation job finishes, then the result
+func (h *Handler) AIJobPage(c *gin.Context) {
+	userID := currentUserID(c)
+
+	job, err := h.jobService.GetJob(userID, c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"Title": "İş Bulunamadı",
+			"Error": err.Error(),
+		})
+		return
+	}
+
+	// Refinements are already saved, the edit page shows the outcome
+	if job.Kind == models.JobKindRefine && job.IsFinished() {
+		if job.Status == models.JobStatusFailed {
+			c.Redirect(http.StatusFound, "/edit/"+job.BarID+"?error="+url.QueryEscape("AI düzenlemesi başarısız: "+job.Error))
+			return
+		}
+		c.Redirect(http.StatusFound, "/edit/"+job.BarID+"?success="+url.QueryEscape("AI düzenlemesi uygulandı"))
+		return
+	}
+
+	switch job.Status {
+	case models.JobStatusSucceeded:
+		h.renderAIResult(c, job)
+	case models.JobStatusFailed:
+		c.HTML(http.StatusOK, "create.html", gin.H{
+			"Title": "Yeni Bar Oluştur - Donation Bars",
+			"Mode":  "ai",
+			"Error": "AI ile bar oluşturulurken hata oluştu: " + job.Error,
+		})
+	default:
+		c.HTML(http.StatusOK, "ai_job.html", gin.H{
+			"Title": "Bar Oluşturuluyor - Donation Bars",
+			"Job":   job,
+		})
+	}
+}
+
+// aiVariation is one generated design on the variation chooser page
+type aiVariation struct {
+	Number      int
+	PreviewHTML string // Escaped into the iframe srcdoc by the template
+	RawHTML     string
+	RawCSS      string
+}
+
+// renderAIResult shows the preview of a finished generation with a save form,
+// or a chooser when several variations were generated
+func (h *Handler) renderAIResult(c *gin.Context, job *models.GenerationJob) {
+	req := job.Request
+	aiResponse := job.Result
+
+	generatedAt := job.CreatedAt
+	if job.FinishedAt != nil {
+		generatedAt = *job.FinishedAt
+	}
+
+	if len(job.Results) > 1 {
+		variations := make([]aiVariation, 0, len(job.Results))
+		for i, result := range job.Results {
+			variations = append(variations, aiVariation{
+				Number:      i + 1,
+				PreviewHTML: aiPreviewHTML(&req, result),
+				RawHTML:     result.HTML,
+				RawCSS:      result.CSS,
+			})
+		}
+
+		c.HTML(http.StatusOK, "ai_variations.html", gin.H{
+			"Title":         "AI Varyasyonları - Donation Bars",
+			"Variations":    variations,
+			"Requested":     req.VariationCount(),
+			"Prompt":        req.Prompt,
+			"Language":      req.Language,
+			"Currency":      req.Currency,
+			"Theme":         req.Theme,
+			"InitialAmount": req.InitialAmount,
+			"GoalAmount":    req.GoalAmount,
+			"Goals":         req.Goals,
+			"CreatedAt":     generatedAt.Format("02.01.2006 15:04"),
+		})
+		return
+	}
+
+	completePreviewHTML := aiPreviewHTML(&req, aiResponse)
+
+	c.HTML(http.StatusOK, "ai_result.html", gin.H{
+		"Title":         "AI Bar Sonucu - Donation Bars",
+		"HTML":          template.HTML(aiResponse.HTML),
+		"CSS":           template.HTML(aiResponse.CSS),
+		"PreviewHTML":   template.HTML(completePreviewHTML),
+		"RawHTML":       aiResponse.HTML,
+		"RawCSS":        aiResponse.CSS,
+		"Prompt":        req.Prompt,
+		"Language":      req.Language,
+		"Currency":      req.Currency,
+		"Theme":         req.Theme,
+		"InitialAmount": req.InitialAmount,
+		"GoalAmount":    req.GoalAmount,
+		"Goals":         req.Goals,
+		"CreatedAt":     generatedAt.Format("02.01.2006 15:04"),
+	})
+}
+
+// aiPreviewHTML renders a generated design with the requested amounts and its
+// CSS embedded, so previews render on their own
+func aiPreviewHTML(req *models.GenerateBarRequest, aiResponse *models.AIGenerateResponse) string {
+	previewHTML := render.Render(&models.DonationBar{HTML: aiResponse.HTML}, render.State{
+		Goal:        req.GoalAmount,
+		Total:       req.InitialAmount,
+		Description: models.DefaultAIBarDescription,
+		Currency:    req.Currency,
+		Language:    req.Language,
+	})
+
+	return `<style>` + aiResponse.CSS + `</style>` + previewHTML
+}
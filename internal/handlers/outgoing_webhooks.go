@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"donationbars/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createdOutgoingWebhook is a new outgoing webhook with its signing secret,
+// which is only returned on creation
+type createdOutgoingWebhook struct {
+	*models.OutgoingWebhook
+	Secret string `json:"secret"`
+}
+
+// GetOutgoingWebhooks lists the outgoing webhooks of a bar (API)
+func (h *Handler) GetOutgoingWebhooks(c *gin.Context) {
+	userID := currentUserID(c)
+
+	hooks, err := h.eventService.ListHooks(userID, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    hooks,
+	})
+}
+
+// CreateOutgoingWebhook adds an outgoing webhook to a bar (API)
+func (h *Handler) CreateOutgoingWebhook(c *gin.Context) {
+	var req models.CreateOutgoingWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	userID := currentUserID(c)
+
+	hook, err := h.eventService.CreateHook(userID, c.Param("id"), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    createdOutgoingWebhook{OutgoingWebhook: hook, Secret: hook.Secret},
+	})
+}
+
+// DeleteOutgoingWebhook removes an outgoing webhook of a bar (API)
+func (h *Handler) DeleteOutgoingWebhook(c *gin.Context) {
+	userID := currentUserID(c)
+
+	if err := h.eventService.DeleteHook(userID, c.Param("id"), c.Param("hook")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// GetWebhookDeliveries returns the delivery log of a bar's outgoing webhooks (API)
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	userID := currentUserID(c)
+
+	deliveries, err := h.eventService.ListDeliveries(userID, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    deliveries,
+	})
+}
+
+// CreateOutgoingWebhookForm adds an outgoing webhook from the edit page.
+// Milestones are comma separated percentages; left empty only the goal fires.
+func (h *Handler) CreateOutgoingWebhookForm(c *gin.Context) {
+	barID := c.Param("id")
+
+	milestones, ok := parseMilestones(c.PostForm("milestones"))
+	if !ok {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error="+url.QueryEscape("Eşikler 1 ile 99 arasında, virgülle ayrılmış sayılar olmalı"))
+		return
+	}
+
+	req := &models.CreateOutgoingWebhookRequest{
+		URL:        strings.TrimSpace(c.PostForm("url")),
+		Milestones: milestones,
+		Format:     c.PostForm("format"),
+		Language:   c.PostForm("language"),
+		Template:   c.PostForm("template"),
+	}
+	if _, err := h.eventService.CreateHook(currentUserID(c), barID, req); err != nil {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/edit/"+barID+"?success="+url.QueryEscape("Giden webhook eklendi"))
+}
+
+// DeleteOutgoingWebhookForm removes an outgoing webhook from the edit page
+func (h *Handler) DeleteOutgoingWebhookForm(c *gin.Context) {
+	barID := c.Param("id")
+
+	if err := h.eventService.DeleteHook(currentUserID(c), barID, c.Param("hook")); err != nil {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/edit/"+barID+"?success="+url.QueryEscape("Giden webhook silindi"))
+}
+
+// parseMilestones parses a comma separated list of percentages. The result
+// is never nil, so an empty field means "goal only" rather than the defaults.
+func parseMilestones(value string) ([]int, bool) {
+	milestones := []int{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(field), "%"))
+		if field == "" {
+			continue
+		}
+		milestone, err := strconv.Atoi(field)
+		if err != nil || milestone <= 0 || milestone >= models.GoalMilestone {
+			return nil, false
+		}
+		milestones = append(milestones, milestone)
+	}
+	return milestones, true
+}
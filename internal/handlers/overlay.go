@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"donationbars/internal/models"
+	"donationbars/internal/render"
+
+	"github.com/gin-gonic/gin"
+)
+
+// overlayHeartbeat keeps idle SSE connections alive through proxies
+const overlayHeartbeat = 25 * time.Second
+
+// overlayClientScript subscribes to /overlay/:token/events and patches the
+// rendered bar in place, so CSS transitions still animate between values.
+const overlayClientScript = `<script>
+(function () {
+    var root = document.getElementById('overlay-root');
+    if (!root || !window.EventSource) { return; }
+
+    function patch(live, next) {
+        var a = live.childNodes, b = next.childNodes;
+        if (a.length !== b.length) {
+            while (live.firstChild) { live.removeChild(live.firstChild); }
+            for (var k = 0; k < b.length; k++) { live.appendChild(b[k].cloneNode(true)); }
+            return;
+        }
+        for (var i = 0; i < b.length; i++) {
+            var x = a[i], y = b[i];
+            if (x.nodeType !== y.nodeType || x.nodeName !== y.nodeName) {
+                live.replaceChild(y.cloneNode(true), x);
+                continue;
+            }
+            if (x.nodeType === 3 || x.nodeType === 8) {
+                if (x.nodeValue !== y.nodeValue) { x.nodeValue = y.nodeValue; }
+                continue;
+            }
+            if (x.nodeType !== 1) { continue; }
+            for (var j = 0; j < y.attributes.length; j++) {
+                var attr = y.attributes[j];
+                if (x.getAttribute(attr.name) !== attr.value) { x.setAttribute(attr.name, attr.value); }
+            }
+            for (var m = x.attributes.length - 1; m >= 0; m--) {
+                if (!y.hasAttribute(x.attributes[m].name)) { x.removeAttribute(x.attributes[m].name); }
+            }
+            patch(x, y);
+        }
+    }
+
+    var source = new EventSource(window.location.pathname.replace(/\/$/, '') + '/events');
+    source.addEventListener('update', function (e) {
+        var data = JSON.parse(e.data);
+        var style = document.getElementById('overlay-css');
+        if (style && typeof data.css === 'string' && style.textContent !== data.css) {
+            style.textContent = data.css;
+        }
+        var next = document.createElement('template');
+        next.innerHTML = data.html;
+        patch(root, next.content);
+    });
+})();
+</script>`
+
+// overlayState is the payload pushed to overlays on every update
+type overlayState struct {
+	Goal       string `json:"goal"`
+	Total      string `json:"total"`
+	Percentage string `json:"percentage"`
+	Remaining  string `json:"remaining"`
+	HTML       string `json:"html"`
+	// The bar's CSS, sent only when the design changed
+	CSS *string `json:"css,omitempty"`
+}
+
+// newOverlayState renders the current state of a bar for overlays
+func newOverlayState(tmpl *render.Template, bar *models.DonationBar) overlayState {
+	state := render.NewState(bar)
+	return overlayState{
+		Goal:       state.Format(render.FieldGoal),
+		Total:      state.Format(render.FieldTotal),
+		Percentage: state.Format(render.FieldPercentage),
+		Remaining:  state.Format(render.FieldRemaining),
+		HTML:       tmpl.Execute(state),
+	}
+}
+
+// OverlayBar renders a bare bar for OBS Browser Source (public, keyed by token)
+func (h *Handler) OverlayBar(c *gin.Context) {
+	token := c.Param("token")
+	bar, err := h.barService.GetBarByOverlayToken(token)
+	if err != nil {
+		c.Header("Cache-Control", "no-store")
+		c.String(http.StatusNotFound, "overlay not found")
+		return
+	}
+
+	html := render.Render(bar, render.NewState(bar))
+
+	overlayHTML := `<!DOCTYPE html>
+<html lang="` + template.HTMLEscapeString(bar.Language) + `">
+<head>
+    <meta charset="UTF-8">
+    <meta name="robots" content="noindex, nofollow">
+    <title>` + template.HTMLEscapeString(bar.Name) + `</title>
+    <style>
+        html, body {
+            margin: 0;
+            padding: 0;
+            background: transparent;
+            overflow: hidden;
+        }
+    </style>
+    <style id="overlay-css">
+        ` + bar.CSS + `
+    </style>
+</head>
+<body>
+<div id="overlay-root">` + html + `</div>
+` + overlayClientScript + `
+</body>
+</html>`
+
+	// The token is a secret: keep it out of caches, referrers and search indexes
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.String(http.StatusOK, overlayHTML)
+}
+
+// OverlayEvents streams live bar updates to an overlay (Server-Sent Events)
+func (h *Handler) OverlayEvents(c *gin.Context) {
+	token := c.Param("token")
+	bar, err := h.barService.GetBarByOverlayToken(token)
+	if err != nil {
+		c.Header("Cache-Control", "no-store")
+		c.String(http.StatusNotFound, "overlay not found")
+		return
+	}
+
+	// Live connections count against the bar owner's plan
+	release, err := h.quotaService.AcquireOverlayViewer(bar.UserID)
+	if err != nil {
+		c.Header("Cache-Control", "no-store")
+		c.String(httpStatus(err), err.Error())
+		return
+	}
+	defer release()
+
+	// SSE streams outlive the server's WriteTimeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("Could not clear write deadline for overlay stream", "error", err.Error())
+	}
+
+	// Tokenize once per design, re-render on every update
+	tmpl := render.Parse(bar.HTML)
+	tokenHash := models.HashOverlayToken(token)
+
+	updates, unsubscribe := h.broker.Subscribe(bar.ID.Hex())
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(overlayHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// Send the current state first so reconnecting overlays catch up
+	c.SSEvent("update", newOverlayState(tmpl, bar))
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case update, ok := <-updates:
+			if !ok {
+				return false
+			}
+			// Updates published before the hash existed carry none
+			if update.OverlayTokenHash != "" && update.OverlayTokenHash != tokenHash {
+				return false // The overlay URL was regenerated
+			}
+			update.Apply(bar)
+			if !bar.IsActive {
+				return false
+			}
+
+			if update.Revision == bar.Revision {
+				c.SSEvent("update", newOverlayState(tmpl, bar))
+				return true
+			}
+
+			// The design changed; updates carry amounts only, so load it
+			fresh, err := h.barService.GetBarByOverlayToken(token)
+			if err != nil {
+				return false
+			}
+			bar, tmpl = fresh, render.Parse(fresh.HTML)
+			state := newOverlayState(tmpl, bar)
+			state.CSS = &bar.CSS
+			c.SSEvent("update", state)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
+
+// RegenerateOverlayTokenForm issues a new overlay URL from the edit page
+func (h *Handler) RegenerateOverlayTokenForm(c *gin.Context) {
+	userID := currentUserID(c)
+
+	barID := c.Param("id")
+	if _, err := h.barService.RegenerateOverlayToken(userID, barID); err != nil {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error="+err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/edit/"+barID+"?success=Yeni OBS bağlantısı oluşturuldu. Eski bağlantı artık çalışmaz.")
+}
+
+// RegenerateOverlayToken issues a new overlay URL (API)
+func (h *Handler) RegenerateOverlayToken(c *gin.Context) {
+	userID := currentUserID(c)
+
+	barID := c.Param("id")
+	token, err := h.barService.RegenerateOverlayToken(userID, barID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"overlay_token": token,
+			"overlay_url":   overlayURL(c, token),
+		},
+	})
+}
+
+// overlayURL builds the absolute OBS overlay URL for a token
+func overlayURL(c *gin.Context, token string) string {
+	if token == "" {
+		return ""
+	}
+
+	return baseURL(c) + "/overlay/" + token
+}
+
+// baseURL returns the scheme and host the request was made to
+func baseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + c.Request.Host
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"donationbars/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetQuota returns the user's plan, quotas and current usage (API)
+func (h *Handler) GetQuota(c *gin.Context) {
+	quota, err := h.quotaService.GetQuota(currentUserID(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    quota,
+	})
+}
+
+// homeQuota returns the user's quota for the home page, nil when it can't be read
+func (h *Handler) homeQuota(userID string) *models.QuotaStatus {
+	quota, err := h.quotaService.GetQuota(userID)
+	if err != nil {
+		slog.Warn("Failed to load quota for home page", "user_id", userID, "error", err.Error())
+		return nil
+	}
+	return quota
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"donationbars/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setRateLimitHeaders reports the state of a limited action: the limit, the
+// requests left and when the full limit is back, in Unix seconds
+func setRateLimitHeaders(c *gin.Context, result *ratelimit.Result) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+}
+
+// setRetryAfter adds the rate limit headers and Retry-After when err refused a rate limited request
+func setRetryAfter(c *gin.Context, err error) {
+	var exceeded *ratelimit.ExceededError
+	if !errors.As(err, &exceeded) {
+		return
+	}
+
+	setRateLimitHeaders(c, exceeded.Result)
+	seconds := max(int(math.Ceil(exceeded.Result.RetryAfter.Seconds())), 1)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
+
+// rateLimitHeaders reports the user's remaining budget of an action after a successful request
+func (h *Handler) rateLimitHeaders(c *gin.Context, action ratelimit.Action) {
+	if _, ok := h.limits.Policy(action); !ok {
+		return
+	}
+
+	result, _ := h.limits.Peek(c.Request.Context(), action, currentUserID(c))
+	if result != nil {
+		setRateLimitHeaders(c, result)
+	}
+}
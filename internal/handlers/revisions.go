@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"donationbars/internal/diff"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// revisionDiff is a side-by-side comparison of one revision with the current content
+type revisionDiff struct {
+	Revision *models.BarRevision
+	HTMLRows []diff.Row
+	CSSRows  []diff.Row
+}
+
+// GetBarRevisions returns the content history of a bar (API)
+func (h *Handler) GetBarRevisions(c *gin.Context) {
+	userID := currentUserID(c)
+
+	revisions, err := h.barService.GetRevisions(userID, c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    revisions,
+	})
+}
+
+// RestoreBarRevision rolls a bar's HTML/CSS back to an older revision (API)
+func (h *Handler) RestoreBarRevision(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || number < 0 {
+		respondError(c, apperrors.InvalidInput("revision number", c.Param("rev")))
+		return
+	}
+
+	userID := currentUserID(c)
+
+	bar, err := h.barService.RestoreRevision(userID, c.Param("id"), number)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    bar,
+	})
+}
+
+// RestoreBarRevisionForm handles the restore button of the edit page
+func (h *Handler) RestoreBarRevisionForm(c *gin.Context) {
+	barID := c.Param("id")
+
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || number < 0 {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error=Geçersiz revizyon numarası")
+		return
+	}
+
+	userID := currentUserID(c)
+
+	bar, err := h.barService.RestoreRevision(userID, barID, number)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error="+url.QueryEscape(err.Error()))
+		return
+	}
+
+	message := "Revizyon " + strconv.Itoa(number) + " geri yüklendi (yeni revizyon " + strconv.Itoa(bar.Revision) + ")"
+	c.Redirect(http.StatusFound, "/edit/"+barID+"?success="+url.QueryEscape(message))
+}
+
+// compareRevision diffs a revision against the bar's current content for the edit page
+func compareRevision(bar *models.DonationBar, revision *models.BarRevision) *revisionDiff {
+	return &revisionDiff{
+		Revision: revision,
+		HTMLRows: diff.SideBySide(diff.Lines(revision.HTML, bar.HTML)),
+		CSSRows:  diff.SideBySide(diff.Lines(revision.CSS, bar.CSS)),
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBody bounds a webhook delivery; donation payloads are small
+const maxWebhookBody = 64 << 10
+
+// webhookURL is a bar's webhook address for one provider
+type webhookURL struct {
+	Provider string `json:"provider"`
+	URL      string `json:"url"`
+}
+
+// ReceiveWebhook records the donations of a provider's webhook delivery
+// (public, keyed by the bar's webhook token and signed with its secret)
+func (h *Handler) ReceiveWebhook(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, apiError(apperrors.InvalidInput("webhook payload", "too large")))
+			return
+		}
+		respondError(c, apperrors.InvalidInput("webhook payload", err.Error()))
+		return
+	}
+
+	result, err := h.webhookService.Receive(c.Param("provider"), c.Param("token"), c.Request.Header, body)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// RegenerateWebhookForm issues new webhook credentials from the edit page
+func (h *Handler) RegenerateWebhookForm(c *gin.Context) {
+	userID := currentUserID(c)
+
+	barID := c.Param("id")
+	if _, err := h.barService.RegenerateWebhook(userID, barID); err != nil {
+		c.Redirect(http.StatusFound, "/edit/"+barID+"?error="+err.Error())
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/edit/"+barID+"?success=Yeni webhook bağlantısı ve imza anahtarı oluşturuldu. Eskileri artık çalışmaz.")
+}
+
+// RegenerateWebhook issues new webhook credentials (API). The secret is
+// only returned here and on the edit page.
+func (h *Handler) RegenerateWebhook(c *gin.Context) {
+	userID := currentUserID(c)
+
+	barID := c.Param("id")
+	credentials, err := h.barService.RegenerateWebhook(userID, barID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"webhook_token":  credentials.Token,
+			"webhook_secret": credentials.Secret,
+			"webhook_urls":   webhookURLs(c, credentials.Token),
+		},
+	})
+}
+
+// webhookURLs builds a bar's webhook URL for every provider
+func webhookURLs(c *gin.Context, token string) []webhookURL {
+	if token == "" {
+		return nil
+	}
+
+	var urls []webhookURL
+	for _, provider := range webhooks.Names() {
+		urls = append(urls, webhookURL{
+			Provider: provider,
+			URL:      baseURL(c) + "/webhooks/" + provider + "/" + token,
+		})
+	}
+	return urls
+}
@@ -3,35 +3,203 @@ package interfaces
 import (
 	"context"
 	"donationbars/internal/models"
+	"net/http"
+	"time"
 )
 
 // BarServiceInterface defines the contract for bar operations
 type BarServiceInterface interface {
 	CreateBar(userID string, req *models.CreateBarRequest) (*models.DonationBar, error)
-	CreateBarFromAI(userID, prompt string, aiResponse *models.AIGenerateResponse, initialAmount, goalAmount float64) (*models.DonationBar, error)
-	GetUserBars(userID string) ([]*models.DonationBar, error)
+	CreateBarFromAI(userID, prompt string, aiResponse *models.AIGenerateResponse, initialAmount, goalAmount float64, goals []models.Goal) (*models.DonationBar, error)
+	ListUserBars(userID string, opts *models.BarListOptions) (*models.BarPage, error)
+	CountUserBars(userID string, filter *models.BarFilter) (int64, error)
 	GetBar(userID, barID string) (*models.DonationBar, error)
 	UpdateBar(userID, barID string, req *models.UpdateBarRequest) (*models.DonationBar, error)
+	IncrementAmount(userID, barID string, amount float64) (*models.DonationBar, error)
 	UpdateBarComplete(userID, barID string, req *models.CreateBarRequest, isActive bool) error
+	ApplyRefinement(userID, barID string, aiResponse *models.AIGenerateResponse) (*models.DonationBar, error)
+	GetRevisions(userID, barID string) ([]*models.BarRevision, error)
+	GetRevision(userID, barID string, number int) (*models.BarRevision, error)
+	RestoreRevision(userID, barID string, number int) (*models.DonationBar, error)
 	DeleteBar(userID, barID string) error
 	GetUserBarCount(userID string) (int64, error)
 	GetUserDailyBarCount(userID string) (int64, error)
 	CheckDailyRateLimit(userID string) error
+	GetBarByOverlayToken(token string) (*models.DonationBar, error)
+	RegenerateOverlayToken(userID, barID string) (string, error)
+	GetBarByWebhookToken(token string) (*models.DonationBar, error)
+	RegenerateWebhook(userID, barID string) (*models.WebhookCredentials, error)
+	ApplyDonation(donation *models.Donation) (*models.DonationBar, error)
+}
+
+// DonationServiceInterface defines the contract for donation ledger operations
+type DonationServiceInterface interface {
+	AddDonation(userID, barID string, req *models.CreateDonationRequest) (*models.Donation, error)
+	GetDonations(userID, barID string) ([]*models.Donation, error)
+}
+
+// WebhookServiceInterface defines the contract for incoming donation webhooks
+type WebhookServiceInterface interface {
+	Receive(provider, token string, header http.Header, body []byte) (*models.WebhookResult, error)
+}
+
+// EventServiceInterface defines the contract for bar milestone events and
+// the outgoing webhooks they are delivered to
+type EventServiceInterface interface {
+	Progressed(before, after *models.DonationBar)
+	CreateHook(userID, barID string, req *models.CreateOutgoingWebhookRequest) (*models.OutgoingWebhook, error)
+	ListHooks(userID, barID string) ([]*models.OutgoingWebhook, error)
+	DeleteHook(userID, barID, hookID string) error
+	ListDeliveries(userID, barID string) ([]*models.WebhookDelivery, error)
+	Start(ctx context.Context)
+}
+
+// AuthServiceInterface defines the contract for accounts and login sessions
+type AuthServiceInterface interface {
+	Signup(req *models.SignupRequest) (*models.User, error)
+	Login(req *models.LoginRequest) (*models.User, string, error)
+	Logout(token string) error
+	Authenticate(token string) (*models.User, error)
+}
+
+// APIKeyServiceInterface defines the contract for personal API keys
+type APIKeyServiceInterface interface {
+	CreateKey(userID string, req *models.CreateAPIKeyRequest) (*models.APIKey, string, error)
+	ListKeys(userID string) ([]*models.APIKey, error)
+	RevokeKey(userID, keyID string) error
+	Authenticate(key string) (*models.User, *models.APIKey, error)
+}
+
+// QuotaServiceInterface defines the contract for plan quotas
+type QuotaServiceInterface interface {
+	Plan(userID string) (*models.Plan, error)
+	CheckBarQuota(userID string) error
+	CheckAIQuota(userID string, generations int64) error
+	RecordAIUsage(userID string, generations, tokens int64)
+	AcquireOverlayViewer(userID string) (release func(), err error)
+	GetQuota(userID string) (*models.QuotaStatus, error)
 }
 
 // AIServiceInterface defines the contract for AI operations
 type AIServiceInterface interface {
 	GenerateBar(req *models.GenerateBarRequest) (*models.AIGenerateResponse, error)
+	GenerateVariations(req *models.GenerateBarRequest) ([]*models.AIGenerateResponse, error)
+	RefineBar(bar *models.DonationBar, instruction string) (*models.AIGenerateResponse, error)
+}
+
+// JobServiceInterface defines the contract for asynchronous AI generation jobs
+type JobServiceInterface interface {
+	Submit(userID string, req *models.GenerateBarRequest, saveBar bool) (*models.GenerationJob, error)
+	SubmitRefine(userID, barID string, req *models.RefineBarRequest) (*models.GenerationJob, error)
+	GetJob(userID, jobID string) (*models.GenerationJob, error)
+	Start(ctx context.Context)
+}
+
+// AIProviderInterface defines the contract for AI text generation backends
+type AIProviderInterface interface {
+	Name() string
+	Complete(ctx context.Context, req *models.AICompletionRequest) (*models.AICompletion, error)
+}
+
+// EventBrokerInterface defines the contract for fanning out bar updates
+type EventBrokerInterface interface {
+	Publish(ctx context.Context, update *models.BarUpdate) error
+	Subscribe(barID string) (<-chan *models.BarUpdate, func())
 }
 
 // BarRepositoryInterface defines the contract for bar data operations
 type BarRepositoryInterface interface {
 	Insert(ctx context.Context, bar *models.DonationBar) error
-	FindByUserID(ctx context.Context, userID string) ([]*models.DonationBar, error)
+	ListByUserID(ctx context.Context, userID string, opts *models.BarListOptions) (*models.BarPage, error)
 	FindByID(ctx context.Context, userID, barID string) (*models.DonationBar, error)
 	Update(ctx context.Context, userID, barID string, req *models.UpdateBarRequest) (*models.DonationBar, error)
-	UpdateComplete(ctx context.Context, userID, barID string, req *models.CreateBarRequest, isActive bool) error
+	UpdateComplete(ctx context.Context, userID, barID string, req *models.CreateBarRequest, isActive bool) (*models.DonationBar, error)
+	UpdateContent(ctx context.Context, userID, barID, html, css string) (*models.DonationBar, error)
 	Delete(ctx context.Context, userID, barID string) error
 	CountByUserID(ctx context.Context, userID string) (int64, error)
-	CountByUserIDToday(ctx context.Context, userID string) (int64, error)
+	CountByFilter(ctx context.Context, userID string, filter *models.BarFilter) (int64, error)
+	CountCreatedSince(ctx context.Context, userID string, since time.Time) (int64, error)
+	IncrementAmount(ctx context.Context, userID, barID string, delta float64) (*models.DonationBar, error)
+	AddDonationTotal(ctx context.Context, barID string, amount float64) (*models.DonationBar, error)
+	FindByOverlayToken(ctx context.Context, token string) (*models.DonationBar, error)
+	SetOverlayToken(ctx context.Context, userID, barID, token string) error
+	FindByWebhookToken(ctx context.Context, token string) (*models.DonationBar, error)
+	SetWebhook(ctx context.Context, userID, barID, token, secret string) error
+}
+
+// RevisionRepositoryInterface defines the contract for bar content history
+type RevisionRepositoryInterface interface {
+	Insert(ctx context.Context, revision *models.BarRevision) error
+	FindByBarID(ctx context.Context, barID string) ([]*models.BarRevision, error)
+	FindByNumber(ctx context.Context, barID string, number int) (*models.BarRevision, error)
+	CountByBarID(ctx context.Context, barID string) (int64, error)
+}
+
+// DonationRepositoryInterface defines the contract for donation ledger data operations
+type DonationRepositoryInterface interface {
+	Insert(ctx context.Context, donation *models.Donation) error // ErrConflict for a recorded external ID
+	FindByBarID(ctx context.Context, barID string) ([]*models.Donation, error)
+	SumByBarID(ctx context.Context, barID string) (float64, error)
+	Delete(ctx context.Context, donationID string) error // Rolls back a donation its bar total never got
+}
+
+// UserRepositoryInterface defines the contract for user account data operations
+type UserRepositoryInterface interface {
+	Insert(ctx context.Context, user *models.User) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, userID string) (*models.User, error)
+	SetPlan(ctx context.Context, userID, plan string) error
+}
+
+// UsageRepositoryInterface defines the contract for per-period AI usage counters
+type UsageRepositoryInterface interface {
+	Add(ctx context.Context, userID, period string, generations, tokens int64) error
+	Find(ctx context.Context, userID, period string) (*models.UsagePeriod, error) // Zero usage when the period has none
+}
+
+// SessionStoreInterface defines the contract for login session storage
+type SessionStoreInterface interface {
+	Create(ctx context.Context, session *models.Session) error
+	Find(ctx context.Context, sessionID string) (*models.Session, error)
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// APIKeyRepositoryInterface defines the contract for API key data operations
+type APIKeyRepositoryInterface interface {
+	Insert(ctx context.Context, key *models.APIKey) error
+	FindByUserID(ctx context.Context, userID string) ([]*models.APIKey, error)
+	FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	Revoke(ctx context.Context, userID, keyID string) error
+	TouchLastUsed(ctx context.Context, keyID string) error
+}
+
+// JobStoreInterface defines the contract for generation job storage
+type JobStoreInterface interface {
+	Create(ctx context.Context, job *models.GenerationJob) error
+	Find(ctx context.Context, jobID string) (*models.GenerationJob, error)
+	Claim(ctx context.Context, jobID string, staleBefore time.Time) (*models.GenerationJob, error)
+	Finish(ctx context.Context, job *models.GenerationJob) error
+	FindUnfinished(ctx context.Context) ([]*models.GenerationJob, error)
+}
+
+// BarEventRepositoryInterface defines the contract for bar event storage
+type BarEventRepositoryInterface interface {
+	Insert(ctx context.Context, event *models.BarEvent) error // ErrConflict when the campaign's milestone is recorded
+}
+
+// OutgoingWebhookRepositoryInterface defines the contract for outgoing webhook storage
+type OutgoingWebhookRepositoryInterface interface {
+	Insert(ctx context.Context, hook *models.OutgoingWebhook) error
+	Find(ctx context.Context, hookID string) (*models.OutgoingWebhook, error)
+	FindByBarID(ctx context.Context, barID string) ([]*models.OutgoingWebhook, error)
+	Delete(ctx context.Context, userID, barID, hookID string) error
+}
+
+// WebhookDeliveryRepositoryInterface defines the contract for outgoing webhook delivery storage
+type WebhookDeliveryRepositoryInterface interface {
+	Insert(ctx context.Context, delivery *models.WebhookDelivery) error
+	Claim(ctx context.Context, deliveryID string, now, leaseUntil time.Time) (*models.WebhookDelivery, error)
+	Save(ctx context.Context, delivery *models.WebhookDelivery) error
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+	FindByBarID(ctx context.Context, barID string, limit int) ([]*models.WebhookDelivery, error)
 }
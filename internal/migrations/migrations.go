@@ -0,0 +1,169 @@
+// Package migrations versions the MongoDB schema. Each migration runs once,
+// in order, and is recorded in the schema_migrations collection; migrations
+// create indexes and rewrite documents when the models change.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionName holds one document per applied migration
+const collectionName = "schema_migrations"
+
+// Migration is one versioned schema change. Up must be idempotent: two
+// instances starting together may both run a migration before either
+// records it.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+}
+
+// record is the schema_migrations document of an applied migration
+type record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Migrator applies a list of migrations to a database
+type Migrator struct {
+	db         *mongo.Database
+	migrations []Migration
+}
+
+// New creates a migrator for the application's migrations
+func New(db *config.Database) *Migrator {
+	m := &Migrator{migrations: migrations}
+	if db != nil {
+		m.db = db.DB
+	}
+	return m
+}
+
+// Up applies every pending migration in version order and returns the
+// versions it applied
+func (m *Migrator) Up(ctx context.Context) ([]int, error) {
+	if m.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := m.migrations[len(m.migrations)-1].Version
+	for version := range applied {
+		if version > latest {
+			return nil, fmt.Errorf("database schema version %d is newer than this binary (%d)", version, latest)
+		}
+	}
+
+	var ran []int
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := migration.Up(ctx, m.db); err != nil {
+			return ran, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		rec := record{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+		if _, err := m.db.Collection(collectionName).InsertOne(ctx, rec); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return ran, fmt.Errorf("record migration %d: %w", migration.Version, err)
+		}
+
+		slog.Info("MongoDB migration applied", "version", migration.Version, "description", migration.Description)
+		ran = append(ran, migration.Version)
+	}
+
+	return ran, nil
+}
+
+// Status lists every migration with the time it was applied, if it was
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if m.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Description: migration.Description}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// appliedVersions maps the recorded versions to the time they were applied
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]time.Time, error) {
+	cursor, err := m.db.Collection(collectionName).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("read schema migrations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("read schema migrations: %w", err)
+	}
+
+	applied := make(map[int]time.Time, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec.AppliedAt
+	}
+	return applied, nil
+}
+
+// createIndexes is the Up step of migrations that only add indexes;
+// creating an index that already exists with the same options is a no-op
+func createIndexes(collection string, indexes ...mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		return err
+	}
+}
+
+// each runs several Up steps in order
+func each(steps ...func(ctx context.Context, db *mongo.Database) error) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, step := range steps {
+			if err := step(ctx, db); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ttl expires documents at the time stored in the indexed field
+func ttl() *options.IndexOptions {
+	return options.Index().SetExpireAfterSeconds(0)
+}
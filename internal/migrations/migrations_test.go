@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestMigrations_AreOrdered(t *testing.T) {
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "versions must be consecutive from 1")
+		assert.NotEmpty(t, migration.Description, "version %d", migration.Version)
+		assert.NotNil(t, migration.Up, "version %d", migration.Version)
+	}
+}
+
+func TestMigrator_WithoutDatabase(t *testing.T) {
+	migrator := New(nil)
+
+	_, err := migrator.Up(context.Background())
+	assert.ErrorIs(t, err, apperrors.ErrDatabaseUnavailable)
+
+	_, err = migrator.Status(context.Background())
+	assert.ErrorIs(t, err, apperrors.ErrDatabaseUnavailable)
+}
+
+// openMongoTestDatabase returns a throwaway database on MONGO_TEST_URI
+func openMongoTestDatabase(t *testing.T) *config.Database {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	require.NoError(t, client.Ping(ctx, nil))
+
+	name := fmt.Sprintf("donationbars_test_%s", primitive.NewObjectID().Hex())
+	db := &config.Database{Client: client, DB: client.Database(name)}
+	t.Cleanup(func() {
+		db.DB.Drop(context.Background())
+		db.Disconnect()
+	})
+	return db
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db := openMongoTestDatabase(t)
+	ctx := context.Background()
+	migrator := New(db)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+
+	// A second run finds nothing to do
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, "version %d", status.Version)
+	}
+
+	// The overlay token index rejects duplicates but allows bars without a token
+	bars := db.DB.Collection("donation_bars")
+	_, err = bars.InsertOne(ctx, bson.M{"user_id": "u1", "overlay_token": "tok"})
+	require.NoError(t, err)
+	_, err = bars.InsertOne(ctx, bson.M{"user_id": "u2", "overlay_token": "tok"})
+	assert.True(t, mongo.IsDuplicateKeyError(err))
+	_, err = bars.InsertMany(ctx, []any{bson.M{"user_id": "u3"}, bson.M{"user_id": "u4"}})
+	assert.NoError(t, err)
+}
+
+func TestMigrator_Up_RejectsNewerSchema(t *testing.T) {
+	db := openMongoTestDatabase(t)
+	ctx := context.Background()
+
+	_, err := db.DB.Collection(collectionName).InsertOne(ctx, record{Version: len(migrations) + 1, Description: "from the future"})
+	require.NoError(t, err)
+
+	_, err = New(db).Up(ctx)
+	assert.ErrorContains(t, err, "newer than this binary")
+}
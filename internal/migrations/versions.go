@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrations is the schema history; append new versions, never edit applied ones
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "index donation bars by owner and overlay token",
+		Up: createIndexes("donation_bars",
+			// ListByUserID, CountByUserID and CountCreatedSince
+			mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+			// Bars without a token omit the field, so they stay out of the index
+			mongo.IndexModel{
+				Keys: bson.D{{Key: "overlay_token", Value: 1}},
+				Options: options.Index().
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"overlay_token": bson.M{"$exists": true}}),
+			},
+		),
+	},
+	{
+		Version:     2,
+		Description: "index donations and revisions by bar",
+		Up: each(
+			createIndexes("donations",
+				mongo.IndexModel{Keys: bson.D{{Key: "bar_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			),
+			createIndexes("bar_revisions",
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "bar_id", Value: 1}, {Key: "revision", Value: -1}},
+					Options: options.Index().SetUnique(true),
+				},
+			),
+		),
+	},
+	{
+		Version:     3,
+		Description: "unique user e-mails, api key lookups and session expiry",
+		Up: each(
+			createIndexes("users",
+				mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+			),
+			createIndexes("api_keys",
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "key_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			),
+			createIndexes("sessions",
+				mongo.IndexModel{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: ttl()},
+			),
+		),
+	},
+	{
+		Version:     4,
+		Description: "index queued AI jobs and expire old ones",
+		Up: createIndexes("ai_jobs",
+			mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: ttl()},
+		),
+	},
+	{
+		Version:     5,
+		Description: "one AI usage counter per user and period",
+		Up: createIndexes("ai_usage",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "period", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		),
+	},
+	{
+		Version:     6,
+		Description: "look up webhook tokens and record each webhook event once",
+		Up: each(
+			createIndexes("donation_bars",
+				mongo.IndexModel{
+					Keys: bson.D{{Key: "webhook_token", Value: 1}},
+					Options: options.Index().
+						SetUnique(true).
+						SetPartialFilterExpression(bson.M{"webhook_token": bson.M{"$exists": true}}),
+				},
+			),
+			// Manual donations omit external_id and are never deduplicated
+			createIndexes("donations",
+				mongo.IndexModel{
+					Keys: bson.D{{Key: "bar_id", Value: 1}, {Key: "source", Value: 1}, {Key: "external_id", Value: 1}},
+					Options: options.Index().
+						SetUnique(true).
+						SetPartialFilterExpression(bson.M{"external_id": bson.M{"$exists": true}}),
+				},
+			),
+		),
+	},
+	{
+		Version:     7,
+		Description: "record milestone events once per campaign and index outgoing webhook deliveries",
+		Up: each(
+			createIndexes("bar_events",
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "bar_id", Value: 1}, {Key: "campaign", Value: 1}, {Key: "milestone", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+			),
+			createIndexes("outgoing_webhooks",
+				mongo.IndexModel{Keys: bson.D{{Key: "bar_id", Value: 1}, {Key: "created_at", Value: 1}}},
+			),
+			createIndexes("webhook_deliveries",
+				mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "bar_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			),
+		),
+	},
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"donationbars/internal/models"
 
@@ -18,9 +19,12 @@ func (m *MockBarRepository) Insert(ctx context.Context, bar *models.DonationBar)
 	return args.Error(0)
 }
 
-func (m *MockBarRepository) FindByUserID(ctx context.Context, userID string) ([]*models.DonationBar, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).([]*models.DonationBar), args.Error(1)
+func (m *MockBarRepository) ListByUserID(ctx context.Context, userID string, opts *models.BarListOptions) (*models.BarPage, error) {
+	args := m.Called(ctx, userID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BarPage), args.Error(1)
 }
 
 func (m *MockBarRepository) FindByID(ctx context.Context, userID, barID string) (*models.DonationBar, error) {
@@ -39,9 +43,20 @@ func (m *MockBarRepository) Update(ctx context.Context, userID, barID string, re
 	return args.Get(0).(*models.DonationBar), args.Error(1)
 }
 
-func (m *MockBarRepository) UpdateComplete(ctx context.Context, userID, barID string, req *models.CreateBarRequest, isActive bool) error {
+func (m *MockBarRepository) UpdateComplete(ctx context.Context, userID, barID string, req *models.CreateBarRequest, isActive bool) (*models.DonationBar, error) {
 	args := m.Called(ctx, userID, barID, req, isActive)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DonationBar), args.Error(1)
+}
+
+func (m *MockBarRepository) UpdateContent(ctx context.Context, userID, barID, html, css string) (*models.DonationBar, error) {
+	args := m.Called(ctx, userID, barID, html, css)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DonationBar), args.Error(1)
 }
 
 func (m *MockBarRepository) Delete(ctx context.Context, userID, barID string) error {
@@ -54,7 +69,334 @@ func (m *MockBarRepository) CountByUserID(ctx context.Context, userID string) (i
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockBarRepository) CountByUserIDToday(ctx context.Context, userID string) (int64, error) {
-	args := m.Called(ctx, userID)
+func (m *MockBarRepository) CountByFilter(ctx context.Context, userID string, filter *models.BarFilter) (int64, error) {
+	args := m.Called(ctx, userID, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockBarRepository) CountCreatedSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockBarRepository) IncrementAmount(ctx context.Context, userID, barID string, delta float64) (*models.DonationBar, error) {
+	args := m.Called(ctx, userID, barID, delta)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DonationBar), args.Error(1)
+}
+
+func (m *MockBarRepository) AddDonationTotal(ctx context.Context, barID string, amount float64) (*models.DonationBar, error) {
+	args := m.Called(ctx, barID, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DonationBar), args.Error(1)
+}
+
+func (m *MockBarRepository) FindByOverlayToken(ctx context.Context, token string) (*models.DonationBar, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DonationBar), args.Error(1)
+}
+
+func (m *MockBarRepository) SetOverlayToken(ctx context.Context, userID, barID, token string) error {
+	args := m.Called(ctx, userID, barID, token)
+	return args.Error(0)
+}
+
+func (m *MockBarRepository) FindByWebhookToken(ctx context.Context, token string) (*models.DonationBar, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DonationBar), args.Error(1)
+}
+
+func (m *MockBarRepository) SetWebhook(ctx context.Context, userID, barID, token, secret string) error {
+	args := m.Called(ctx, userID, barID, token, secret)
+	return args.Error(0)
+}
+
+// MockRevisionRepository is a mock implementation of RevisionRepositoryInterface
+type MockRevisionRepository struct {
+	mock.Mock
+}
+
+func (m *MockRevisionRepository) Insert(ctx context.Context, revision *models.BarRevision) error {
+	args := m.Called(ctx, revision)
+	return args.Error(0)
+}
+
+func (m *MockRevisionRepository) FindByBarID(ctx context.Context, barID string) ([]*models.BarRevision, error) {
+	args := m.Called(ctx, barID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.BarRevision), args.Error(1)
+}
+
+func (m *MockRevisionRepository) FindByNumber(ctx context.Context, barID string, number int) (*models.BarRevision, error) {
+	args := m.Called(ctx, barID, number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BarRevision), args.Error(1)
+}
+
+func (m *MockRevisionRepository) CountByBarID(ctx context.Context, barID string) (int64, error) {
+	args := m.Called(ctx, barID)
 	return args.Get(0).(int64), args.Error(1)
 }
+
+// MockDonationRepository is a mock implementation of DonationRepositoryInterface
+type MockDonationRepository struct {
+	mock.Mock
+}
+
+func (m *MockDonationRepository) Insert(ctx context.Context, donation *models.Donation) error {
+	args := m.Called(ctx, donation)
+	return args.Error(0)
+}
+
+func (m *MockDonationRepository) FindByBarID(ctx context.Context, barID string) ([]*models.Donation, error) {
+	args := m.Called(ctx, barID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Donation), args.Error(1)
+}
+
+func (m *MockDonationRepository) SumByBarID(ctx context.Context, barID string) (float64, error) {
+	args := m.Called(ctx, barID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockDonationRepository) Delete(ctx context.Context, donationID string) error {
+	args := m.Called(ctx, donationID)
+	return args.Error(0)
+}
+
+// MockUserRepository is a mock implementation of UserRepositoryInterface
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Insert(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByID(ctx context.Context, userID string) (*models.User, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetPlan(ctx context.Context, userID, plan string) error {
+	args := m.Called(ctx, userID, plan)
+	return args.Error(0)
+}
+
+// MockUsageRepository is a mock implementation of UsageRepositoryInterface
+type MockUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockUsageRepository) Add(ctx context.Context, userID, period string, generations, tokens int64) error {
+	args := m.Called(ctx, userID, period, generations, tokens)
+	return args.Error(0)
+}
+
+func (m *MockUsageRepository) Find(ctx context.Context, userID, period string) (*models.UsagePeriod, error) {
+	args := m.Called(ctx, userID, period)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UsagePeriod), args.Error(1)
+}
+
+// MockSessionStore is a mock implementation of SessionStoreInterface
+type MockSessionStore struct {
+	mock.Mock
+}
+
+func (m *MockSessionStore) Create(ctx context.Context, session *models.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionStore) Find(ctx context.Context, sessionID string) (*models.Session, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionStore) Delete(ctx context.Context, sessionID string) error {
+	args := m.Called(ctx, sessionID)
+	return args.Error(0)
+}
+
+// MockAPIKeyRepository is a mock implementation of APIKeyRepositoryInterface
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Insert(ctx context.Context, key *models.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) FindByUserID(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, userID, keyID string) error {
+	args := m.Called(ctx, userID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, keyID string) error {
+	args := m.Called(ctx, keyID)
+	return args.Error(0)
+}
+
+// MockJobStore is a mock implementation of JobStoreInterface
+type MockJobStore struct {
+	mock.Mock
+}
+
+func (m *MockJobStore) Create(ctx context.Context, job *models.GenerationJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockJobStore) Find(ctx context.Context, jobID string) (*models.GenerationJob, error) {
+	args := m.Called(ctx, jobID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GenerationJob), args.Error(1)
+}
+
+func (m *MockJobStore) Claim(ctx context.Context, jobID string, staleBefore time.Time) (*models.GenerationJob, error) {
+	args := m.Called(ctx, jobID, staleBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GenerationJob), args.Error(1)
+}
+
+func (m *MockJobStore) Finish(ctx context.Context, job *models.GenerationJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockJobStore) FindUnfinished(ctx context.Context) ([]*models.GenerationJob, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*models.GenerationJob), args.Error(1)
+}
+
+// MockBarEventRepository is a mock implementation of BarEventRepositoryInterface
+type MockBarEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockBarEventRepository) Insert(ctx context.Context, event *models.BarEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// MockOutgoingWebhookRepository is a mock implementation of OutgoingWebhookRepositoryInterface
+type MockOutgoingWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutgoingWebhookRepository) Insert(ctx context.Context, hook *models.OutgoingWebhook) error {
+	args := m.Called(ctx, hook)
+	return args.Error(0)
+}
+
+func (m *MockOutgoingWebhookRepository) Find(ctx context.Context, hookID string) (*models.OutgoingWebhook, error) {
+	args := m.Called(ctx, hookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OutgoingWebhook), args.Error(1)
+}
+
+func (m *MockOutgoingWebhookRepository) FindByBarID(ctx context.Context, barID string) ([]*models.OutgoingWebhook, error) {
+	args := m.Called(ctx, barID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.OutgoingWebhook), args.Error(1)
+}
+
+func (m *MockOutgoingWebhookRepository) Delete(ctx context.Context, userID, barID, hookID string) error {
+	args := m.Called(ctx, userID, barID, hookID)
+	return args.Error(0)
+}
+
+// MockWebhookDeliveryRepository is a mock implementation of WebhookDeliveryRepositoryInterface
+type MockWebhookDeliveryRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookDeliveryRepository) Insert(ctx context.Context, delivery *models.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) Claim(ctx context.Context, deliveryID string, now, leaseUntil time.Time) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, deliveryID, now, leaseUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) Save(ctx context.Context, delivery *models.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) FindByBarID(ctx context.Context, barID string, limit int) ([]*models.WebhookDelivery, error) {
+	args := m.Called(ctx, barID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WebhookDelivery), args.Error(1)
+}
@@ -0,0 +1,17 @@
+package models
+
+// AICompletionRequest is what the AI service asks of a provider
+type AICompletionRequest struct {
+	Prompt      string              // Fully built generation prompt
+	Bar         *GenerateBarRequest // Original user request, for providers that do not read prompts
+	Variation   int                 // 0-based index when several designs are generated for one request
+	MaxTokens   int
+	Temperature float32
+}
+
+// AICompletion is the raw text a provider returned for a completion request
+type AICompletion struct {
+	Content    string
+	Model      string
+	TokensUsed int
+}
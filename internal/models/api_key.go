@@ -0,0 +1,57 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// API key scopes
+const (
+	ScopeBarsRead       = "bars:read"
+	ScopeBarsWrite      = "bars:write"
+	ScopeDonationsWrite = "donations:write"
+	ScopeAIGenerate     = "ai:generate"
+)
+
+// APIKeyScopes lists every scope a key can be granted
+var APIKeyScopes = []string{
+	ScopeBarsRead,
+	ScopeBarsWrite,
+	ScopeDonationsWrite,
+	ScopeAIGenerate,
+}
+
+// APIKeyPrefix marks personal API keys, e.g. "dbk_..."
+const APIKeyPrefix = "dbk_"
+
+// APIKey is a personal access key for the /api/v1 routes. Only the SHA-256
+// hash of the key is stored; the key itself is shown once on creation.
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID     string             `bson:"user_id" json:"user_id"`
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // First characters of the key, for display
+	KeyHash    string             `bson:"key_hash" json:"-"`
+	Scopes     []string           `bson:"scopes" json:"scopes"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key was granted a scope
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// IsRevoked reports whether the key can no longer be used
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// CreateAPIKeyRequest represents the request to mint an API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" form:"name" binding:"required,min=1,max=50"`
+	Scopes []string `json:"scopes" form:"scopes" binding:"required,min=1,dive,oneof=bars:read bars:write donations:write ai:generate"`
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"donationbars/internal/sanitize"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -15,14 +17,19 @@ type DonationBar struct {
 	HTML        string             `bson:"html" json:"html"`
 	CSS         string             `bson:"css" json:"css"`
 	Language    string             `bson:"language" json:"language"` // "tr" or "en"
+	Currency    string             `bson:"currency" json:"currency"` // ISO 4217, e.g. "TRY"
 	Theme       string             `bson:"theme" json:"theme"`
 	IsActive    bool               `bson:"is_active" json:"is_active"`
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
 
 	// Donation amounts
-	InitialAmount float64 `bson:"initial_amount" json:"initial_amount"` // Starting amount (current total)
-	GoalAmount    float64 `bson:"goal_amount" json:"goal_amount"`       // Target amount
+	InitialAmount float64 `bson:"initial_amount" json:"initial_amount"` // Starting amount before any recorded donation
+	GoalAmount    float64 `bson:"goal_amount" json:"goal_amount"`       // Target amount, the final goal with stretch goals
+	DonationTotal float64 `bson:"donation_total" json:"donation_total"` // Sum of the donation ledger
+
+	// Stretch goals in ascending order, empty for a single goal
+	Goals []Goal `bson:"goals,omitempty" json:"goals,omitempty"`
 
 	// AI generation metadata
 	Prompt      string `bson:"prompt" json:"prompt"`
@@ -30,27 +37,81 @@ type DonationBar struct {
 
 	// Injection validation
 	HasValidInjections bool `bson:"has_valid_injections" json:"has_valid_injections"`
+
+	// Number of content changes applied to the HTML/CSS, 0 for the original design
+	Revision int `bson:"revision" json:"revision"`
+
+	// Secret token for the public OBS overlay URL (/overlay/:token)
+	OverlayToken string `bson:"overlay_token,omitempty" json:"overlay_token,omitempty"`
+
+	// Donation webhook URL token (/webhooks/:provider/:token) and the secret
+	// its payloads are signed with, both empty until webhooks are enabled
+	WebhookToken  string `bson:"webhook_token,omitempty" json:"webhook_token,omitempty"`
+	WebhookSecret string `bson:"webhook_secret,omitempty" json:"-"`
+
+	// What the sanitizer removed on the last create or update, not persisted
+	SanitizeReport *sanitize.Report `bson:"-" json:"sanitize_report,omitempty"`
+}
+
+// CurrentTotal returns the amount raised so far (starting amount + ledger)
+func (b *DonationBar) CurrentTotal() float64 {
+	return b.InitialAmount + b.DonationTotal
+}
+
+// CurrencyCode returns the bar's currency, falling back to the language default
+func (b *DonationBar) CurrencyCode() string {
+	if b.Currency != "" {
+		return b.Currency
+	}
+	return DefaultCurrency(b.Language)
+}
+
+// DefaultCurrency returns the default ISO 4217 currency of a language
+func DefaultCurrency(language string) string {
+	if language == "en" {
+		return "USD"
+	}
+	return "TRY"
 }
 
 // CreateBarRequest represents the request to create a new bar
 type CreateBarRequest struct {
-	Name          string  `json:"name" binding:"required,min=1,max=100"`
-	Description   string  `json:"description" binding:"max=500"`
-	HTML          string  `json:"html" binding:"required"`
-	CSS           string  `json:"css" binding:"required"`
-	Language      string  `json:"language" binding:"required,oneof=tr en"`
-	Theme         string  `json:"theme" binding:"max=50"`
-	InitialAmount float64 `json:"initial_amount" binding:"gte=0"`
-	GoalAmount    float64 `json:"goal_amount" binding:"gt=0"`
+	Name          string  `json:"name" form:"name" binding:"required,min=1,max=100"`
+	Description   string  `json:"description" form:"description" binding:"max=500"`
+	HTML          string  `json:"html" form:"html" binding:"required"`
+	CSS           string  `json:"css" form:"css" binding:"required"`
+	Language      string  `json:"language" form:"language" binding:"required,oneof=tr en"`
+	Currency      string  `json:"currency" form:"currency" binding:"omitempty,iso4217"`
+	Theme         string  `json:"theme" form:"theme" binding:"max=50"`
+	InitialAmount float64 `json:"initial_amount" form:"initial_amount" binding:"gte=0"`
+	GoalAmount    float64 `json:"goal_amount" form:"goal_amount" binding:"required_without=Goals,gte=0"`
+	Goals         []Goal  `json:"goals,omitempty" form:"-" binding:"omitempty,max=10,dive"` // Stretch goals; the last one becomes the goal amount
 }
 
 // GenerateBarRequest represents the request for AI bar generation
 type GenerateBarRequest struct {
 	Prompt        string  `json:"prompt" form:"prompt" binding:"required,min=10,max=1000"`
 	Language      string  `json:"language" form:"language" binding:"required,oneof=tr en"`
+	Currency      string  `json:"currency" form:"currency" binding:"omitempty,iso4217"`
 	Theme         string  `json:"theme" form:"theme" binding:"max=50"`
 	InitialAmount float64 `json:"initial_amount" form:"initial_amount" binding:"gte=0"`
-	GoalAmount    float64 `json:"goal_amount" form:"goal_amount" binding:"gt=0"`
+	GoalAmount    float64 `json:"goal_amount" form:"goal_amount" binding:"required_without=Goals,gte=0"`
+	Goals         []Goal  `json:"goals,omitempty" form:"-" binding:"omitempty,max=10,dive"`               // Stretch goals the design shows markers for
+	Variations    int     `json:"variations,omitempty" form:"variations" binding:"omitempty,min=1,max=4"` // Number of designs to generate, 1 when empty
+}
+
+// MaxAIVariations is the most designs one generation request can ask for
+const MaxAIVariations = 4
+
+// VariationCount returns how many designs the request asks for, within 1..MaxAIVariations
+func (r *GenerateBarRequest) VariationCount() int {
+	if r.Variations < 1 {
+		return 1
+	}
+	if r.Variations > MaxAIVariations {
+		return MaxAIVariations
+	}
+	return r.Variations
 }
 
 // UpdateBarRequest represents the request to update a bar
@@ -59,22 +120,40 @@ type UpdateBarRequest struct {
 	Description   *string  `json:"description,omitempty"`
 	IsActive      *bool    `json:"is_active,omitempty"`
 	InitialAmount *float64 `json:"initial_amount,omitempty"`
-	GoalAmount    *float64 `json:"goal_amount,omitempty"`
+	GoalAmount    *float64 `json:"goal_amount,omitempty"` // Replaces any stretch goals with a single goal
+	Currency      *string  `json:"currency,omitempty" binding:"omitempty,iso4217"`
+	Goals         *[]Goal  `json:"goals,omitempty" binding:"omitempty,max=10,dive"` // Replaces the stretch goals, empty clears them
+}
+
+// IncrementBarRequest adds an amount to a bar's starting amount
+type IncrementBarRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0,lte=1000000000"` // At most MaxDonationAmount
+}
+
+// RefineBarRequest represents a follow-up instruction for an existing bar's design
+type RefineBarRequest struct {
+	Instruction string `json:"instruction" form:"instruction" binding:"required,min=3,max=500"`
 }
 
 // AIGenerateResponse represents the AI service response
 type AIGenerateResponse struct {
-	HTML     string             `json:"html"`
-	CSS      string             `json:"css"`
-	Metadata AIGenerateMetadata `json:"metadata"`
+	HTML       string             `json:"html"`
+	CSS        string             `json:"css"`
+	Metadata   AIGenerateMetadata `json:"metadata"`
+	Report     *sanitize.Report   `json:"sanitize_report,omitempty"`
+	TokensUsed int                `json:"tokens_used,omitempty"` // Provider tokens spent on this design
 }
 
 type AIGenerateMetadata struct {
 	Language      string `json:"language"`
+	Currency      string `json:"currency"`
 	Theme         string `json:"theme"`
 	HasInjections bool   `json:"injection"`
 }
 
+// DefaultAIBarDescription is the description given to AI generated bars
+const DefaultAIBarDescription = "AI tarafından oluşturulan donation bar"
+
 // Required injection fields that must be present
 var RequiredInjections = []string{
 	"{goal}",
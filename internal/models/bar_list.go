@@ -0,0 +1,225 @@
+package models
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sort keys of bar listings
+const (
+	BarSortCreatedAt = "created_at"
+	BarSortUpdatedAt = "updated_at"
+	BarSortName      = "name"
+	BarSortProgress  = "progress" // Raised amount divided by the goal
+)
+
+// Sort orders of bar listings
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// Page sizes of bar listings
+const (
+	DefaultBarPageSize = 20
+	MaxBarPageSize     = 100
+)
+
+// BarFilter narrows a user's bars; empty fields match every bar
+type BarFilter struct {
+	Active      *bool  `form:"active" json:"active,omitempty"`
+	AIGenerated *bool  `form:"ai_generated" json:"ai_generated,omitempty"`
+	Language    string `form:"language" json:"language,omitempty" binding:"omitempty,oneof=tr en"`
+	Theme       string `form:"theme" json:"theme,omitempty" binding:"max=50"`
+	Search      string `form:"q" json:"q,omitempty" binding:"max=100"` // Case-insensitive text in the name or description
+}
+
+// BarListOptions selects one page of a user's bars
+type BarListOptions struct {
+	BarFilter
+	Sort   string `form:"sort" binding:"omitempty,oneof=created_at updated_at name progress"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor"` // NextCursor of the previous page
+
+	// Position decoded from Cursor by Normalize, nil on the first page
+	After *BarCursor `form:"-"`
+}
+
+// BarPage is one page of a bar listing
+type BarPage struct {
+	Bars       []*DonationBar `json:"bars"`
+	NextCursor string         `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// BarCursor is the position of the last bar of a page: its sort value and ID
+type BarCursor struct {
+	Sort     string             `json:"s"`
+	Order    string             `json:"o"`
+	ID       primitive.ObjectID `json:"id"`
+	Time     time.Time          `json:"t"`
+	Name     string             `json:"n,omitempty"`
+	Progress float64            `json:"p,omitempty"`
+}
+
+// ErrInvalidCursor is returned for cursors that were not issued for the same sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Progress returns the share of the (final) goal raised so far, 0 without a goal
+func (b *DonationBar) Progress() float64 {
+	if b.GoalAmount <= 0 {
+		return 0
+	}
+	return b.CurrentTotal() / b.GoalAmount
+}
+
+// Normalize fills in the default sort, order and page size and decodes the cursor
+func (o *BarListOptions) Normalize() error {
+	if o.Sort == "" {
+		o.Sort = BarSortCreatedAt
+	}
+	if o.Order == "" {
+		o.Order = SortAsc
+	}
+	if o.Limit <= 0 {
+		o.Limit = DefaultBarPageSize
+	}
+	if o.Limit > MaxBarPageSize {
+		o.Limit = MaxBarPageSize
+	}
+	o.Search = strings.TrimSpace(o.Search)
+
+	o.After = nil
+	if o.Cursor == "" {
+		return nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(o.Cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	var cursor BarCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID.IsZero() {
+		return ErrInvalidCursor
+	}
+	if cursor.Sort != o.Sort || cursor.Order != o.Order {
+		return ErrInvalidCursor
+	}
+
+	o.After = &cursor
+	return nil
+}
+
+// Descending reports whether the listing is in descending order
+func (o *BarListOptions) Descending() bool {
+	return o.Order == SortDesc
+}
+
+// Page trims the results of a query for Limit+1 bars to one page and sets
+// the cursor of the next page when there is one
+func (o *BarListOptions) Page(bars []*DonationBar) *BarPage {
+	page := &BarPage{Bars: bars}
+	if page.Bars == nil {
+		page.Bars = []*DonationBar{}
+	}
+
+	if len(bars) > o.Limit {
+		page.Bars = bars[:o.Limit]
+		page.NextCursor = o.cursorAfter(page.Bars[o.Limit-1])
+	}
+	return page
+}
+
+// cursorAfter encodes the position of a bar in this listing
+func (o *BarListOptions) cursorAfter(bar *DonationBar) string {
+	key := o.keyOf(bar)
+	cursor := BarCursor{Sort: o.Sort, Order: o.Order, ID: bar.ID, Time: key.time, Name: key.name, Progress: key.progress}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Matches reports whether a bar passes the filter
+func (f *BarFilter) Matches(bar *DonationBar) bool {
+	if f.Active != nil && bar.IsActive != *f.Active {
+		return false
+	}
+	if f.AIGenerated != nil && bar.AIGenerated != *f.AIGenerated {
+		return false
+	}
+	if f.Language != "" && bar.Language != f.Language {
+		return false
+	}
+	if f.Theme != "" && bar.Theme != f.Theme {
+		return false
+	}
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(bar.Name), search) &&
+			!strings.Contains(strings.ToLower(bar.Description), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare orders two bars by the sort key, then by ID, in the listing's order
+func (o *BarListOptions) Compare(a, b *DonationBar) int {
+	return o.compareKeys(o.keyOf(a), o.keyOf(b))
+}
+
+// IsAfterCursor reports whether a bar comes after the cursor position
+func (o *BarListOptions) IsAfterCursor(bar *DonationBar) bool {
+	if o.After == nil {
+		return true
+	}
+
+	after := barKey{time: o.After.Time, name: o.After.Name, progress: o.After.Progress, id: o.After.ID.Hex()}
+	return o.compareKeys(o.keyOf(bar), after) > 0
+}
+
+// barKey is the position of a bar in a listing
+type barKey struct {
+	time     time.Time
+	name     string
+	progress float64
+	id       string
+}
+
+func (o *BarListOptions) keyOf(bar *DonationBar) barKey {
+	key := barKey{id: bar.ID.Hex()}
+	switch o.Sort {
+	case BarSortUpdatedAt:
+		key.time = bar.UpdatedAt
+	case BarSortName:
+		key.name = bar.Name
+	case BarSortProgress:
+		key.progress = bar.Progress()
+	default:
+		key.time = bar.CreatedAt
+	}
+	return key
+}
+
+func (o *BarListOptions) compareKeys(a, b barKey) int {
+	c := a.time.Compare(b.time)
+	if c == 0 {
+		c = strings.Compare(a.name, b.name)
+	}
+	if c == 0 {
+		c = cmp.Compare(a.progress, b.progress)
+	}
+	if c == 0 {
+		c = strings.Compare(a.id, b.id)
+	}
+	if o.Descending() {
+		return -c
+	}
+	return c
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Donation represents a single donation recorded against a bar
+type Donation struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	BarID     primitive.ObjectID `bson:"bar_id" json:"bar_id"`
+	UserID    string             `bson:"user_id" json:"user_id"` // Owner of the bar
+	DonorName string             `bson:"donor_name" json:"donor_name"`
+	Amount    float64            `bson:"amount" json:"amount"`
+	Currency  string             `bson:"currency" json:"currency"`
+	Message   string             `bson:"message" json:"message"`
+	Source    string             `bson:"source" json:"source"` // "manual", "api", ...
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+
+	// Provider event ID of a webhook donation; unique per bar and source so
+	// redelivered events are recorded once
+	ExternalID string `bson:"external_id,omitempty" json:"external_id,omitempty"`
+}
+
+// CreateDonationRequest represents the request to record a donation
+type CreateDonationRequest struct {
+	DonorName string  `json:"donor_name" form:"donor_name" binding:"max=100"`
+	Amount    float64 `json:"amount" form:"amount" binding:"required,gt=0,lte=1000000000"` // At most MaxDonationAmount
+	Currency  string  `json:"currency" form:"currency" binding:"omitempty,iso4217"`
+	Message   string  `json:"message" form:"message" binding:"max=500"`
+	Source    string  `json:"source" form:"source" binding:"max=50"`
+}
+
+// MaxDonationAmount bounds a single donation; larger amounts are not real
+// donations and would swamp, or overflow, the bar's total
+const MaxDonationAmount = 1_000_000_000
+
+// Donation defaults
+const (
+	DefaultDonationSource = "api"
+	AnonymousDonorName    = "Anonim"
+)
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bar event types
+const (
+	EventMilestoneReached = "milestone_reached"
+	EventGoalReached      = "goal_reached"
+)
+
+// GoalMilestone is the milestone, in percent of the goal, of reaching the goal
+const GoalMilestone = 100
+
+// DefaultMilestones are the milestones an outgoing webhook is notified about
+// when it does not choose its own; the goal is always included
+var DefaultMilestones = []int{25, 50, 75}
+
+// BarEvent records that a bar reached a milestone of a campaign. Each
+// milestone is recorded once per campaign, which is what makes it fire once.
+// With stretch goals every goal is a campaign of its own.
+type BarEvent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	BarID     primitive.ObjectID `bson:"bar_id" json:"bar_id"`
+	UserID    string             `bson:"user_id" json:"-"`
+	Type      string             `bson:"type" json:"type"`
+	Campaign  string             `bson:"campaign" json:"campaign"`
+	Milestone int                `bson:"milestone" json:"milestone"` // Percent of the goal
+	Total     float64            `bson:"total" json:"total"`
+	Goal      float64            `bson:"goal" json:"goal"`
+	Stage     int                `bson:"stage" json:"stage"` // 1-based index of the goal among the bar's goals
+	GoalLabel string             `bson:"goal_label,omitempty" json:"goal_label,omitempty"`
+	Currency  string             `bson:"currency" json:"currency"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// NewBarEvent describes a bar reaching a milestone of one of its goals
+func NewBarEvent(bar *DonationBar, stage Stage, milestone int) *BarEvent {
+	eventType := EventMilestoneReached
+	if milestone >= GoalMilestone {
+		eventType = EventGoalReached
+	}
+	return &BarEvent{
+		ID:        primitive.NewObjectID(),
+		BarID:     bar.ID,
+		UserID:    bar.UserID,
+		Type:      eventType,
+		Campaign:  CampaignKey(stage.Amount),
+		Milestone: milestone,
+		Total:     bar.CurrentTotal(),
+		Goal:      stage.Amount,
+		Stage:     stage.Index,
+		GoalLabel: stage.Label,
+		Currency:  bar.CurrencyCode(),
+		CreatedAt: time.Now(),
+	}
+}
+
+// CampaignKey identifies a bar's campaign by its goal: setting a new goal
+// starts a new campaign whose milestones fire again
+func CampaignKey(goal float64) string {
+	return "goal-" + strconv.FormatFloat(goal, 'f', -1, 64)
+}
+
+// ProgressPercent returns total in percent of goal, 0 without a goal. It is
+// not capped, a bar past its goal is over 100.
+func ProgressPercent(total, goal float64) float64 {
+	if goal <= 0 {
+		return 0
+	}
+	return total * 100 / goal
+}
+
+// CrossedMilestones returns the milestones that progress moving from
+// previous to current reached, in ascending order
+func CrossedMilestones(previous, current float64, milestones []int) []int {
+	var crossed []int
+	for _, m := range milestones {
+		if previous < float64(m) && float64(m) <= current {
+			crossed = append(crossed, m)
+		}
+	}
+	slices.Sort(crossed)
+	return slices.Compact(crossed)
+}
+
+// Outgoing webhook formats, the body shape the receiver expects
+const (
+	HookFormatJSON    = "json"    // The signed EventPayload, for custom integrations
+	HookFormatDiscord = "discord" // Discord webhook with an embed
+	HookFormatSlack   = "slack"   // Slack compatible incoming webhook
+)
+
+// OutgoingWebhook is a user-configured URL notified about a bar's events
+type OutgoingWebhook struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	BarID      primitive.ObjectID `bson:"bar_id" json:"bar_id"`
+	UserID     string             `bson:"user_id" json:"-"`
+	URL        string             `bson:"url" json:"url"`
+	Secret     string             `bson:"secret" json:"-"`              // Signs every delivery, returned on creation only
+	Milestones []int              `bson:"milestones" json:"milestones"` // Percentages below 100; the goal always fires
+	Format     string             `bson:"format,omitempty" json:"format"`
+	Language   string             `bson:"language,omitempty" json:"language"` // Message language, empty follows the bar
+	Template   string             `bson:"template,omitempty" json:"template"` // Custom message, empty uses the localized default
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// BodyFormat returns the webhook's format; webhooks created before formats
+// existed get the JSON payload
+func (h *OutgoingWebhook) BodyFormat() string {
+	if h.Format == "" {
+		return HookFormatJSON
+	}
+	return h.Format
+}
+
+// Wants reports whether the webhook is notified about a milestone
+func (h *OutgoingWebhook) Wants(milestone int) bool {
+	return milestone >= GoalMilestone || slices.Contains(h.Milestones, milestone)
+}
+
+// CreateOutgoingWebhookRequest adds an outgoing webhook to a bar; without
+// milestones the DefaultMilestones are used, an empty list notifies the goal only
+type CreateOutgoingWebhookRequest struct {
+	URL        string `json:"url" binding:"required,url,max=2000"`
+	Milestones []int  `json:"milestones" binding:"omitempty,max=10,dive,gt=0,lt=100"`
+	Format     string `json:"format" binding:"omitempty,oneof=json discord slack"`
+	Language   string `json:"language" binding:"omitempty,oneof=tr en"`
+	Template   string `json:"template" binding:"max=1000"`
+}
+
+// Webhook delivery statuses
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent to one outgoing webhook, with every
+// attempt made so far; it is the bar's delivery log
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	HookID        primitive.ObjectID `bson:"hook_id" json:"hook_id"`
+	BarID         primitive.ObjectID `bson:"bar_id" json:"bar_id"`
+	UserID        string             `bson:"user_id" json:"-"`
+	EventID       primitive.ObjectID `bson:"event_id" json:"event_id"`
+	EventType     string             `bson:"event_type" json:"event_type"`
+	Milestone     int                `bson:"milestone" json:"milestone"`
+	URL           string             `bson:"url" json:"url"`
+	Payload       string             `bson:"payload" json:"payload"` // Same body on every attempt
+	Status        string             `bson:"status" json:"status"`
+	Attempts      []DeliveryAttempt  `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// DeliveryAttempt is the outcome of one POST of a delivery
+type DeliveryAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"` // 0 when no response came back
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMS int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// EventPayload is the signed JSON body outgoing webhooks receive
+type EventPayload struct {
+	ID        string          `json:"id"` // Event ID, the same on every retry
+	Type      string          `json:"type"`
+	Milestone int             `json:"milestone"`
+	Campaign  string          `json:"campaign"`
+	Message   string          `json:"message"` // The webhook's rendered message template
+	CreatedAt time.Time       `json:"created_at"`
+	Bar       EventPayloadBar `json:"bar"`
+}
+
+// EventPayloadBar is the state of the bar when the event happened
+type EventPayloadBar struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Currency   string  `json:"currency"`
+	Total      float64 `json:"total"`
+	Goal       float64 `json:"goal"` // The goal the event is about
+	Percentage float64 `json:"percentage"`
+	GoalLabel  string  `json:"goal_label,omitempty"`
+	GoalIndex  int     `json:"goal_index"`
+	GoalsTotal int     `json:"goals_total"`
+}
+
+// NewEventPayload builds the payload of a bar event
+func NewEventPayload(bar *DonationBar, event *BarEvent) *EventPayload {
+	return &EventPayload{
+		ID:        event.ID.Hex(),
+		Type:      event.Type,
+		Milestone: event.Milestone,
+		Campaign:  event.Campaign,
+		CreatedAt: event.CreatedAt,
+		Bar: EventPayloadBar{
+			ID:         bar.ID.Hex(),
+			Name:       bar.Name,
+			Currency:   event.Currency,
+			Total:      event.Total,
+			Goal:       event.Goal,
+			Percentage: ProgressPercent(event.Total, event.Goal),
+			GoalLabel:  event.GoalLabel,
+			GoalIndex:  event.Stage,
+			GoalsTotal: len(bar.Stages()),
+		},
+	}
+}
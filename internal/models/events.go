@@ -0,0 +1,64 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// BarUpdate is published whenever the displayed state of a bar changes
+type BarUpdate struct {
+	BarID         string    `json:"bar_id"`
+	Description   string    `json:"description"`
+	InitialAmount float64   `json:"initial_amount"`
+	DonationTotal float64   `json:"donation_total"`
+	GoalAmount    float64   `json:"goal_amount"`
+	Goals         []Goal    `json:"goals,omitempty"`
+	Currency      string    `json:"currency"`
+	IsActive      bool      `json:"is_active"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Revision of the bar's HTML/CSS; overlays reload the design when it changes
+	Revision int `json:"revision"`
+	// Hash of the current overlay token; streams opened with another token
+	// close. The token itself stays out of the broker.
+	OverlayTokenHash string `json:"overlay_token_hash,omitempty"`
+}
+
+// NewBarUpdate captures the displayed state of a bar
+func NewBarUpdate(bar *DonationBar) *BarUpdate {
+	return &BarUpdate{
+		BarID:            bar.ID.Hex(),
+		Description:      bar.Description,
+		InitialAmount:    bar.InitialAmount,
+		DonationTotal:    bar.DonationTotal,
+		GoalAmount:       bar.GoalAmount,
+		Goals:            bar.Goals,
+		Currency:         bar.Currency,
+		IsActive:         bar.IsActive,
+		UpdatedAt:        bar.UpdatedAt,
+		Revision:         bar.Revision,
+		OverlayTokenHash: HashOverlayToken(bar.OverlayToken),
+	}
+}
+
+// HashOverlayToken returns the hex SHA-256 of an overlay token, empty for none
+func HashOverlayToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Apply copies the published state onto a bar
+func (u *BarUpdate) Apply(bar *DonationBar) {
+	bar.Description = u.Description
+	bar.InitialAmount = u.InitialAmount
+	bar.DonationTotal = u.DonationTotal
+	bar.GoalAmount = u.GoalAmount
+	bar.Goals = u.Goals
+	bar.Currency = u.Currency
+	bar.IsActive = u.IsActive
+	bar.UpdatedAt = u.UpdatedAt
+}
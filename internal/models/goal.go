@@ -0,0 +1,52 @@
+package models
+
+// MaxGoals bounds the stretch goals of one bar
+const MaxGoals = 10
+
+// MaxGoalLabelLength bounds a stretch goal's label, in characters
+const MaxGoalLabelLength = 50
+
+// Goal is one stage of a bar with stretch goals
+type Goal struct {
+	Amount float64 `bson:"amount" json:"amount" binding:"gt=0"`
+	Label  string  `bson:"label" json:"label" binding:"max=50"`
+}
+
+// Stage is a goal together with its position among the bar's goals
+type Stage struct {
+	Goal
+	Index int // 1-based
+	Count int
+}
+
+// Stages returns the bar's goals in order. A bar without stretch goals has a
+// single unlabeled stage, its goal amount.
+func (b *DonationBar) Stages() []Stage {
+	if len(b.Goals) == 0 {
+		return []Stage{{Goal: Goal{Amount: b.GoalAmount}, Index: 1, Count: 1}}
+	}
+
+	stages := make([]Stage, len(b.Goals))
+	for i, goal := range b.Goals {
+		stages[i] = Stage{Goal: goal, Index: i + 1, Count: len(b.Goals)}
+	}
+	return stages
+}
+
+// CurrentStage returns the first goal the bar has not reached yet; reaching a
+// goal advances the bar to the next one. Past the final goal it stays on it.
+func (b *DonationBar) CurrentStage() Stage {
+	stages := b.Stages()
+	total := b.CurrentTotal()
+	for _, stage := range stages {
+		if total < stage.Amount {
+			return stage
+		}
+	}
+	return stages[len(stages)-1]
+}
+
+// CurrentGoal returns the amount the bar is currently working towards
+func (b *DonationBar) CurrentGoal() float64 {
+	return b.CurrentStage().Amount
+}
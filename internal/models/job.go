@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Generation job kinds
+const (
+	JobKindGenerate = "generate"
+	JobKindRefine   = "refine"
+)
+
+// Generation job statuses
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// GenerationJob is an AI bar generation running in the background worker pool
+type GenerationJob struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID   string             `bson:"user_id" json:"user_id"`
+	Kind     string             `bson:"kind" json:"kind"` // "generate" or "refine"
+	Status   string             `bson:"status" json:"status"`
+	Request  GenerateBarRequest `bson:"request" json:"request"`
+	SaveBar  bool               `bson:"save_bar" json:"save_bar"` // Save the result as a bar (API) or only preview it (web)
+	Attempts int                `bson:"attempts" json:"attempts"`
+
+	// Follow-up instruction of a refine job
+	Instruction string `bson:"instruction,omitempty" json:"instruction,omitempty"`
+
+	// Outcome
+	Result  *AIGenerateResponse   `bson:"result,omitempty" json:"result,omitempty"`   // First successful design
+	Results []*AIGenerateResponse `bson:"results,omitempty" json:"results,omitempty"` // Every successful design when variations were requested
+	BarID   string                `bson:"bar_id,omitempty" json:"bar_id,omitempty"`   // Saved bar, or the bar being refined
+	Error   string                `bson:"error,omitempty" json:"error,omitempty"`
+
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	StartedAt  *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	ExpiresAt  time.Time  `bson:"expires_at" json:"-"` // Jobs are kept for a day
+}
+
+// IsFinished reports whether the job reached a terminal status
+func (j *GenerationJob) IsFinished() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed
+}
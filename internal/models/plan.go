@@ -0,0 +1,115 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Plan IDs
+const (
+	PlanFree = "free"
+	PlanPro  = "pro"
+	PlanTeam = "team"
+)
+
+// Quota bounds what an account may use; a zero field is unlimited
+type Quota struct {
+	MaxBars        int `json:"max_bars"`
+	AIPerDay       int `json:"ai_generations_per_day"`
+	AIPerMonth     int `json:"ai_generations_per_month"`
+	TokensPerMonth int `json:"ai_tokens_per_month"`
+	OverlayViewers int `json:"overlay_viewers"` // Live overlay connections across all bars
+}
+
+// Plan is a named set of quotas assigned to users
+type Plan struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Quota Quota  `json:"quota"`
+}
+
+// Plans lists every plan a user can be assigned, smallest first
+var Plans = []*Plan{
+	{
+		ID:   PlanFree,
+		Name: "Free",
+		Quota: Quota{
+			MaxBars:        5,
+			AIPerDay:       5,
+			AIPerMonth:     50,
+			TokensPerMonth: 100_000,
+			OverlayViewers: 3,
+		},
+	},
+	{
+		ID:   PlanPro,
+		Name: "Pro",
+		Quota: Quota{
+			MaxBars:        25,
+			AIPerDay:       50,
+			AIPerMonth:     1_000,
+			TokensPerMonth: 2_000_000,
+			OverlayViewers: 25,
+		},
+	},
+	{
+		ID:   PlanTeam,
+		Name: "Team",
+		Quota: Quota{
+			MaxBars:        100,
+			AIPerDay:       200,
+			AIPerMonth:     5_000,
+			TokensPerMonth: 10_000_000,
+		},
+	},
+}
+
+// FindPlan returns the plan with the given ID
+func FindPlan(id string) (*Plan, bool) {
+	i := slices.IndexFunc(Plans, func(p *Plan) bool { return p.ID == id })
+	if i < 0 {
+		return nil, false
+	}
+	return Plans[i], true
+}
+
+// Within reports whether one more unit fits a quota limit with used units taken
+func Within(limit int, used int64) bool {
+	return Fits(limit, used, 1)
+}
+
+// Fits reports whether n more units fit a quota limit with used units taken
+func Fits(limit int, used, n int64) bool {
+	return limit <= 0 || used+n <= int64(limit)
+}
+
+// UsagePeriod is one day ("2006-01-02") or month ("2006-01") of a user's AI usage
+type UsagePeriod struct {
+	UserID      string `bson:"user_id" json:"-"`
+	Period      string `bson:"period" json:"period"`
+	Generations int64  `bson:"generations" json:"generations"`
+	Tokens      int64  `bson:"tokens" json:"tokens"`
+}
+
+// Usage period layouts; periods are keyed in the rate limit time zone
+const (
+	DayPeriodLayout   = "2006-01-02"
+	MonthPeriodLayout = "2006-01"
+)
+
+// Usage is what an account has used of its quota
+type Usage struct {
+	Bars            int64 `json:"bars"`
+	AIToday         int64 `json:"ai_generations_today"`
+	AIThisMonth     int64 `json:"ai_generations_this_month"`
+	TokensThisMonth int64 `json:"ai_tokens_this_month"`
+	OverlayViewers  int64 `json:"overlay_viewers"`
+}
+
+// QuotaStatus is a user's plan with its current usage
+type QuotaStatus struct {
+	Plan         *Plan     `json:"plan"`
+	Usage        Usage     `json:"usage"`
+	DailyReset   time.Time `json:"daily_reset"`
+	MonthlyReset time.Time `json:"monthly_reset"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Revision sources
+const (
+	RevisionSourceManual     = "manual"      // Edited by hand
+	RevisionSourceAIGenerate = "ai_generate" // Initial AI design
+	RevisionSourceAIRefine   = "ai_refine"   // AI refinement
+	RevisionSourceImport     = "import"      // Content from before the history was kept
+	RevisionSourceRestore    = "restore"     // Rolled back to an older revision
+)
+
+// BarRevision is an immutable snapshot of a bar's HTML/CSS after a content change
+type BarRevision struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	BarID        primitive.ObjectID `bson:"bar_id" json:"bar_id"`
+	UserID       string             `bson:"user_id" json:"user_id"` // Owner of the bar
+	Revision     int                `bson:"revision" json:"revision"`
+	AuthorID     string             `bson:"author_id" json:"author_id"` // User who made the change
+	Source       string             `bson:"source" json:"source"`
+	RestoredFrom *int               `bson:"restored_from,omitempty" json:"restored_from,omitempty"`
+	HTML         string             `bson:"html" json:"html"`
+	CSS          string             `bson:"css" json:"css"`
+	Summary      RevisionSummary    `bson:"summary" json:"summary"` // Changed lines against the previous revision
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// RevisionSummary counts the lines a revision changed
+type RevisionSummary struct {
+	HTMLAdded   int `bson:"html_added" json:"html_added"`
+	HTMLRemoved int `bson:"html_removed" json:"html_removed"`
+	CSSAdded    int `bson:"css_added" json:"css_added"`
+	CSSRemoved  int `bson:"css_removed" json:"css_removed"`
+}
+
+// String formats the summary for the revision list, e.g. "HTML +2 -1, CSS +0 -0"
+func (s RevisionSummary) String() string {
+	return fmt.Sprintf("HTML +%d -%d, CSS +%d -%d", s.HTMLAdded, s.HTMLRemoved, s.CSSAdded, s.CSSRemoved)
+}
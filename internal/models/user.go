@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// User is an account that owns donation bars
+type User struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Email        string             `bson:"email" json:"email"` // Lower-cased, unique
+	DisplayName  string             `bson:"display_name" json:"display_name"`
+	PasswordHash string             `bson:"password_hash" json:"-"`
+	Plan         string             `bson:"plan,omitempty" json:"plan,omitempty"` // Plan ID; empty is the default plan
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// PlanID returns the user's plan, or defaultPlan when none was assigned
+func (u *User) PlanID(defaultPlan string) string {
+	if u.Plan == "" {
+		return defaultPlan
+	}
+	return u.Plan
+}
+
+// Session is a login session. The cookie carries a random token, only its
+// SHA-256 hash is stored.
+type Session struct {
+	ID        string    `bson:"_id" json:"-"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// SignupRequest represents the request to create an account
+type SignupRequest struct {
+	Email       string `json:"email" form:"email" binding:"required,email,max=254"`
+	DisplayName string `json:"display_name" form:"display_name" binding:"required,min=2,max=50"`
+	Password    string `json:"password" form:"password" binding:"required,min=8,max=72"` // bcrypt limit
+}
+
+// LoginRequest represents the request to start a session
+type LoginRequest struct {
+	Email    string `json:"email" form:"email" binding:"required,email,max=254"`
+	Password string `json:"password" form:"password" binding:"required,max=72"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// WebhookSecretPrefix marks webhook signing secrets so they are recognizable when leaked
+const WebhookSecretPrefix = "whsec_"
+
+// WebhookCredentials are what a donation platform needs to post to a bar
+type WebhookCredentials struct {
+	Token  string `json:"token"`
+	Secret string `json:"secret"`
+}
+
+// WebhookResult reports what a webhook delivery did to the bar's ledger
+type WebhookResult struct {
+	Accepted    int      `json:"accepted"`               // Recorded and added to the bar total
+	AcceptedIDs []string `json:"accepted_ids,omitempty"` // Event IDs of the accepted donations
+	Duplicates  int      `json:"duplicates"`             // Already recorded by an earlier delivery
+	Ignored     int      `json:"ignored"`                // Not donations, or invalid (see the log)
+	Rejected    int      `json:"rejected"`               // Over the donation rate limit, not recorded; redeliver later
+	RejectedIDs []string `json:"rejected_ids,omitempty"` // Event IDs of the rejected donations
+	Total       float64  `json:"total"`                  // Bar total after the delivery
+}
@@ -0,0 +1,197 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"donationbars/internal/models"
+	"donationbars/internal/render"
+)
+
+// Notification is a bar event ready to be formatted for a receiver
+type Notification struct {
+	Payload  *models.EventPayload // Payload.Message holds the rendered template
+	Language string               // "tr" or "en", for labels and number formatting
+}
+
+// Formatter builds the delivery body one kind of receiver expects
+type Formatter interface {
+	Name() string
+	Format(n *Notification) ([]byte, error)
+}
+
+var (
+	mu         sync.RWMutex
+	formatters = map[string]Formatter{}
+)
+
+func init() {
+	RegisterFormatter(JSON{})
+	RegisterFormatter(Discord{})
+	RegisterFormatter(Slack{})
+}
+
+// RegisterFormatter makes a formatter available under its name, replacing any other
+func RegisterFormatter(f Formatter) {
+	mu.Lock()
+	defer mu.Unlock()
+	formatters[f.Name()] = f
+}
+
+// FindFormatter returns the formatter registered under name
+func FindFormatter(name string) (Formatter, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	f, ok := formatters[name]
+	return f, ok
+}
+
+// Formats lists the registered formatters in alphabetical order
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Body builds the delivery body of a bar event in the webhook's format, with
+// its message template rendered in the webhook's language
+func Body(hook *models.OutgoingWebhook, bar *models.DonationBar, event *models.BarEvent) ([]byte, error) {
+	formatter, ok := FindFormatter(hook.BodyFormat())
+	if !ok {
+		return nil, fmt.Errorf("unknown webhook format %q", hook.BodyFormat())
+	}
+
+	language := hook.Language
+	if language == "" {
+		language = bar.Language
+	}
+	language = localeOf(language)
+
+	payload := models.NewEventPayload(bar, event)
+	payload.Message = RenderTemplate(hook.Template, bar, event, language)
+	return formatter.Format(&Notification{Payload: payload, Language: language})
+}
+
+// JSON sends the event payload as is, for custom integrations
+type JSON struct{}
+
+func (JSON) Name() string { return models.HookFormatJSON }
+
+func (JSON) Format(n *Notification) ([]byte, error) {
+	return json.Marshal(n.Payload)
+}
+
+// Discord posts the message as an embed to a Discord channel webhook
+type Discord struct{}
+
+// Embed colors: blurple for milestones, gold for the goal
+const (
+	discordMilestoneColor = 0x5865F2
+	discordGoalColor      = 0xF1C40F
+)
+
+type discordMessage struct {
+	Embeds          []discordEmbed         `json:"embeds"`
+	AllowedMentions discordAllowedMentions `json:"allowed_mentions"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields"`
+	Timestamp   string         `json:"timestamp"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// discordAllowedMentions keeps a template from pinging @everyone
+type discordAllowedMentions struct {
+	Parse []string `json:"parse"`
+}
+
+func (Discord) Name() string { return models.HookFormatDiscord }
+
+func (Discord) Format(n *Notification) ([]byte, error) {
+	p := n.Payload
+	l := labelsFor(n.Language)
+
+	color := discordMilestoneColor
+	if p.Type == models.EventGoalReached {
+		color = discordGoalColor
+	}
+
+	return json.Marshal(discordMessage{
+		Embeds: []discordEmbed{{
+			Title:       p.Bar.Name,
+			Description: p.Message,
+			Color:       color,
+			Fields: []discordField{
+				{Name: l.total, Value: render.FormatMoney(p.Bar.Total, p.Bar.Currency, n.Language), Inline: true},
+				{Name: l.goal, Value: render.FormatMoney(p.Bar.Goal, p.Bar.Currency, n.Language), Inline: true},
+				{Name: l.progress, Value: formatPercent(p.Bar.Percentage, n.Language), Inline: true},
+			},
+			Timestamp: p.CreatedAt.UTC().Format(time.RFC3339),
+		}},
+		AllowedMentions: discordAllowedMentions{Parse: []string{}},
+	})
+}
+
+// Slack posts the message to a Slack compatible incoming webhook; the text
+// field alone is understood by Mattermost, Rocket.Chat and others
+type Slack struct{}
+
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (Slack) Name() string { return models.HookFormatSlack }
+
+func (Slack) Format(n *Notification) ([]byte, error) {
+	p := n.Payload
+	l := labelsFor(n.Language)
+
+	summary := fmt.Sprintf("*%s:* %s · *%s:* %s · *%s:* %s",
+		l.total, render.FormatMoney(p.Bar.Total, p.Bar.Currency, n.Language),
+		l.goal, render.FormatMoney(p.Bar.Goal, p.Bar.Currency, n.Language),
+		l.progress, formatPercent(p.Bar.Percentage, n.Language))
+
+	return json.Marshal(slackMessage{
+		Text: slackEscape(p.Message),
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*" + slackEscape(p.Bar.Name) + "*\n" + slackEscape(p.Message)}},
+			{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: summary}}},
+		},
+	})
+}
+
+// slackEscape escapes the characters Slack reserves for links and mentions
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
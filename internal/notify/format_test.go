@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testEvent returns a TRY bar at 1250 of 2500 and the event of its 50% milestone
+func testEvent() (*models.DonationBar, *models.BarEvent) {
+	bar := &models.DonationBar{
+		ID:            primitive.NewObjectID(),
+		Name:          "Yeni Mikrofon",
+		Description:   "Yayın için",
+		Language:      "tr",
+		Currency:      "TRY",
+		InitialAmount: 1250,
+		GoalAmount:    2500,
+	}
+	event := models.NewBarEvent(bar, bar.CurrentStage(), 50)
+	event.CreatedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return bar, event
+}
+
+func TestRenderTemplate(t *testing.T) {
+	bar, event := testEvent()
+
+	tests := []struct {
+		name     string
+		template string
+		language string
+		want     string
+	}{
+		{"turkish default", "", "tr", "🎯 Yeni Mikrofon hedefin %50 kadarına ulaştı! 1.250 ₺ / 2.500 ₺"},
+		{"english default", "", "en", "🎯 Yeni Mikrofon reached 50% of its goal! ₺1,250 / ₺2,500"},
+		{"unknown language", "", "de", "🎯 Yeni Mikrofon hedefin %50 kadarına ulaştı! 1.250 ₺ / 2.500 ₺"},
+		{"custom", "{description}: {remaining} kaldı (%{percentage}) <{unknown}>", "tr", "Yayın için: 1250 kaldı (%50) <{unknown}>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RenderTemplate(tt.template, bar, event, tt.language))
+		})
+	}
+
+	goal := models.NewBarEvent(bar, bar.CurrentStage(), models.GoalMilestone)
+	assert.Equal(t, "🏁 Yeni Mikrofon reached its goal! ₺1,250 / ₺2,500 🎉", RenderTemplate("", bar, goal, "en"))
+
+	// Placeholders in the bar's name are not fields
+	bar.Name = "Road to {goal} ({total_formatted}, {milestone}%)"
+	assert.Equal(t, "🏁 Road to {goal} ({total_formatted}, {milestone}%) reached its goal! ₺1,250 / ₺2,500 🎉", RenderTemplate("", bar, goal, "en"))
+	assert.Equal(t, "Road to {goal} ({total_formatted}, {milestone}%): 1250/2500", RenderTemplate("{bar}: {total}/{goal}", bar, goal, "en"))
+}
+
+func TestBody_JSON(t *testing.T) {
+	bar, event := testEvent()
+
+	body, err := Body(&models.OutgoingWebhook{}, bar, event)
+	require.NoError(t, err)
+
+	var payload models.EventPayload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, event.ID.Hex(), payload.ID)
+	assert.Equal(t, models.EventMilestoneReached, payload.Type)
+	assert.Equal(t, 50.0, payload.Bar.Percentage)
+	assert.Equal(t, "🎯 Yeni Mikrofon hedefin %50 kadarına ulaştı! 1.250 ₺ / 2.500 ₺", payload.Message)
+}
+
+func TestBody_Discord(t *testing.T) {
+	bar, event := testEvent()
+
+	body, err := Body(&models.OutgoingWebhook{Format: models.HookFormatDiscord, Language: "en", Template: "@everyone {bar} {milestone}%"}, bar, event)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"embeds": [{
+			"title": "Yeni Mikrofon",
+			"description": "@everyone Yeni Mikrofon 50%",
+			"color": 5793266,
+			"fields": [
+				{"name": "Total", "value": "₺1,250", "inline": true},
+				{"name": "Goal", "value": "₺2,500", "inline": true},
+				{"name": "Progress", "value": "50%", "inline": true}
+			],
+			"timestamp": "2024-03-01T12:00:00Z"
+		}],
+		"allowed_mentions": {"parse": []}
+	}`, string(body))
+}
+
+func TestBody_Slack(t *testing.T) {
+	bar, event := testEvent()
+	bar.Name = "<Mikrofon & Kamera>"
+
+	body, err := Body(&models.OutgoingWebhook{Format: models.HookFormatSlack}, bar, event)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"text": "🎯 &lt;Mikrofon &amp; Kamera&gt; hedefin %50 kadarına ulaştı! 1.250 ₺ / 2.500 ₺",
+		"blocks": [
+			{"type": "section", "text": {"type": "mrkdwn", "text": "*&lt;Mikrofon &amp; Kamera&gt;*\n🎯 &lt;Mikrofon &amp; Kamera&gt; hedefin %50 kadarına ulaştı! 1.250 ₺ / 2.500 ₺"}},
+			{"type": "context", "elements": [{"type": "mrkdwn", "text": "*Toplam:* 1.250 ₺ · *Hedef:* 2.500 ₺ · *İlerleme:* %50"}]}
+		]
+	}`, string(body))
+}
+
+func TestBody_UnknownFormat(t *testing.T) {
+	bar, event := testEvent()
+
+	_, err := Body(&models.OutgoingWebhook{Format: "teams"}, bar, event)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"discord", "json", "slack"}, Formats())
+}
@@ -0,0 +1,180 @@
+// Package notify sends bar events to user-configured URLs. Each webhook's
+// formatter shapes the body (the JSON payload, a Discord embed or a Slack
+// message) around its localized message template. Deliveries are signed like
+// the generic incoming webhook (t=<unix>,v1=<hex>) so receivers can verify
+// them with the same code.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"donationbars/internal/webhooks"
+)
+
+// Headers of every delivery besides the signature
+const (
+	EventHeader    = "X-Webhook-Event"
+	DeliveryHeader = "X-Webhook-Delivery"
+	userAgent      = "donationbars-webhooks/1.0"
+)
+
+// ErrPrivateAddress is returned when a target resolves to a loopback,
+// private or link-local address and those are not allowed
+var ErrPrivateAddress = errors.New("target resolves to a private network address")
+
+// Message is one delivery attempt
+type Message struct {
+	URL        string
+	Secret     string
+	EventType  string
+	DeliveryID string
+	Body       []byte
+}
+
+// Sender POSTs messages to their targets
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender creates a sender with a per-attempt timeout. Unless allowPrivate
+// is set, connections to private networks are refused, so a webhook cannot
+// be pointed at services only the server can reach.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		// Checked on the resolved address, after DNS, so names cannot sneak past it
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivate(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// A redirect would be sent unsigned to a URL nobody configured
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// Send POSTs a signed message and returns the response status. Any response
+// is returned without error; use Retryable to judge it.
+func (s *Sender) Send(ctx context.Context, msg *Message) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.URL, bytes.NewReader(msg.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(EventHeader, msg.EventType)
+	req.Header.Set(DeliveryHeader, msg.DeliveryID)
+	req.Header.Set(webhooks.SignatureHeader, webhooks.SignGeneric(msg.Secret, s.now(), msg.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// Succeeded reports whether a response status acknowledges the delivery
+func Succeeded(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// Retryable reports whether a failed attempt is worth repeating: network
+// errors, timeouts, rate limiting and server errors are; other client errors
+// mean the receiver rejected the delivery and will again
+func Retryable(status int, err error) bool {
+	if errors.Is(err, ErrPrivateAddress) {
+		return false
+	}
+	if err != nil || status == 0 {
+		return true
+	}
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+// Backoff returns the wait before the next attempt after attempts failed
+// ones: base, then doubled each time, at most max
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	return min(wait, max)
+}
+
+// ValidateURL checks that a target is an absolute http(s) URL
+func ValidateURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("host is required")
+	}
+	if u.User != nil {
+		return fmt.Errorf("credentials are not allowed in the URL")
+	}
+	return nil
+}
+
+// blockedNetworks are the internal ranges the net.IP helpers do not cover:
+// carrier-grade NAT (often the cloud's internal network) and "this network",
+// which Linux routes to the local host
+var blockedNetworks = mustParseCIDRs("100.64.0.0/10", "0.0.0.0/8")
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func isPrivate(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"strconv"
+	"strings"
+
+	"donationbars/internal/models"
+	"donationbars/internal/render"
+)
+
+// Template fields of notifications besides the bar's injection fields
+const (
+	FieldBar       = "{bar}"       // The bar's name
+	FieldMilestone = "{milestone}" // The milestone reached, in percent of the goal
+)
+
+// MaxTemplateLength bounds a custom message template, in characters
+const MaxTemplateLength = 1000
+
+// defaultTemplates are the messages of webhooks without their own template
+var defaultTemplates = map[string]map[string]string{
+	"tr": {
+		models.EventMilestoneReached: "🎯 {bar} hedefin %{milestone} kadarına ulaştı! {total_formatted} / {goal_formatted}",
+		models.EventGoalReached:      "🏁 {bar} hedefine ulaştı! {total_formatted} / {goal_formatted} 🎉",
+	},
+	"en": {
+		models.EventMilestoneReached: "🎯 {bar} reached {milestone}% of its goal! {total_formatted} / {goal_formatted}",
+		models.EventGoalReached:      "🏁 {bar} reached its goal! {total_formatted} / {goal_formatted} 🎉",
+	},
+}
+
+// labels name the amounts shown next to a message
+type labels struct {
+	total, goal, progress string
+}
+
+var localizedLabels = map[string]labels{
+	"tr": {total: "Toplam", goal: "Hedef", progress: "İlerleme"},
+	"en": {total: "Total", goal: "Goal", progress: "Progress"},
+}
+
+// DefaultTemplate returns the localized message of an event type; languages
+// other than English get Turkish, like the bar's default currency
+func DefaultTemplate(language, eventType string) string {
+	return defaultTemplates[localeOf(language)][eventType]
+}
+
+// RenderTemplate fills a message template with the event's values. An empty
+// template uses the localized default. Besides {bar} and {milestone} the
+// bar's injection fields are available, e.g. {total_formatted} or {goal_label};
+// the goal is the one the event is about.
+func RenderTemplate(template string, bar *models.DonationBar, event *models.BarEvent, language string) string {
+	language = localeOf(language)
+	if strings.TrimSpace(template) == "" {
+		template = DefaultTemplate(language, event.Type)
+	}
+
+	template = strings.ReplaceAll(template, FieldMilestone, strconv.Itoa(event.Milestone))
+	state := render.State{
+		Goal:        event.Goal,
+		Total:       event.Total,
+		Description: bar.Description,
+		Currency:    event.Currency,
+		Language:    language,
+		GoalLabel:   event.GoalLabel,
+		GoalIndex:   event.Stage,
+		GoalsTotal:  len(bar.Stages()),
+	}
+
+	// The name goes in after rendering so a name like "Road to {goal}" is
+	// shown as written
+	parts := strings.Split(template, FieldBar)
+	for i, part := range parts {
+		parts[i] = render.Parse(part).ExecuteText(state)
+	}
+	return strings.Join(parts, bar.Name)
+}
+
+// labelsFor returns the amount labels of a language
+func labelsFor(language string) labels {
+	return localizedLabels[localeOf(language)]
+}
+
+// formatPercent writes a percentage the way the language does, "%50" or "50%"
+func formatPercent(value float64, language string) string {
+	number := strconv.FormatFloat(value, 'f', 0, 64)
+	if localeOf(language) == "tr" {
+		return "%" + number
+	}
+	return number + "%"
+}
+
+// localeOf maps a language to one with templates
+func localeOf(language string) string {
+	if language == "en" {
+		return "en"
+	}
+	return "tr"
+}
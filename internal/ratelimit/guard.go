@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+)
+
+// Guard applies the policy of each action to a user's requests
+type Guard struct {
+	limiter  Limiter
+	policies Policies
+}
+
+// NewGuard creates a guard; actions without a policy are not limited
+func NewGuard(limiter Limiter, policies Policies) *Guard {
+	return &Guard{limiter: limiter, policies: policies}
+}
+
+// Policy returns the policy of an action
+func (g *Guard) Policy(action Action) (Policy, bool) {
+	if g == nil {
+		return Policy{}, false
+	}
+	policy, ok := g.policies[action]
+	return policy, ok && policy.Limit > 0
+}
+
+// Allow records a request of the user. It returns the limiter state and an
+// Exceeded error when the request is refused; errors of the limiter itself are
+// returned as they are so callers can choose a fallback. A nil guard allows
+// everything.
+func (g *Guard) Allow(ctx context.Context, action Action, userID string) (*Result, error) {
+	return g.check(ctx, action, userID, 1)
+}
+
+// AllowN records n requests of the user at once, e.g. the provider calls of
+// one AI job; they are refused together unless all of them fit
+func (g *Guard) AllowN(ctx context.Context, action Action, userID string, n int) (*Result, error) {
+	return g.check(ctx, action, userID, max(n, 1))
+}
+
+// Peek reports whether the user's next request would be allowed without recording one
+func (g *Guard) Peek(ctx context.Context, action Action, userID string) (*Result, error) {
+	return g.check(ctx, action, userID, 0)
+}
+
+// check records cost requests, or peeks when cost is zero
+func (g *Guard) check(ctx context.Context, action Action, userID string, cost int) (*Result, error) {
+	policy, ok := g.Policy(action)
+	if !ok {
+		return &Result{Allowed: true}, nil
+	}
+
+	var result *Result
+	var err error
+	if cost == 0 {
+		result, err = g.limiter.Peek(ctx, key(action, userID), policy)
+	} else {
+		result, err = g.limiter.AllowN(ctx, key(action, userID), policy, cost)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !result.Allowed {
+		return result, Exceeded(action, policy, result)
+	}
+	return result, nil
+}
+
+func key(action Action, userID string) string {
+	return fmt.Sprintf("ratelimit:%s:%s", action, userID)
+}
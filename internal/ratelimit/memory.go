@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often full buckets are dropped from memory
+const sweepInterval = time.Minute
+
+// MemoryLimiter is a token bucket per key held in process memory. A bucket
+// holds Limit tokens and refills continuously at Limit per Window, so bursts
+// up to the limit are allowed and the budget comes back gradually. Buckets of
+// daily policies do not refill; each calendar day gets a new one. Counts are
+// per instance and lost on restart.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // When the bucket is back at its limit
+}
+
+// NewMemoryLimiter creates an empty memory limiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Allow takes a token from the key's bucket when one is left
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, policy Policy) (*Result, error) {
+	return l.take(key, policy, 1), nil
+}
+
+// AllowN takes n tokens from the key's bucket when that many are left
+func (l *MemoryLimiter) AllowN(ctx context.Context, key string, policy Policy, n int) (*Result, error) {
+	return l.take(key, policy, float64(n)), nil
+}
+
+// Peek reports the key's bucket without taking a token
+func (l *MemoryLimiter) Peek(ctx context.Context, key string, policy Policy) (*Result, error) {
+	return l.take(key, policy, 0), nil
+}
+
+func (l *MemoryLimiter) take(key string, policy Policy, cost float64) *Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	if _, end, suffix, ok := policy.day(now); ok {
+		return l.takeDaily(key+suffix, policy, cost, now, end)
+	}
+
+	limit := float64(policy.Limit)
+	rate := limit / policy.Window.Seconds() // Tokens per second
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit, updated: now}
+		l.buckets[key] = b
+	}
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(limit, b.tokens+elapsed*rate)
+	b.updated = now
+
+	// A peek asks whether a single request would fit
+	need := math.Max(cost, 1)
+
+	result := &Result{Limit: policy.Limit}
+	if b.tokens >= need {
+		result.Allowed = true
+		b.tokens -= cost
+	} else {
+		result.RetryAfter = seconds((need - b.tokens) / rate)
+	}
+	b.full = now.Add(seconds((limit - b.tokens) / rate))
+
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = b.full
+	return result
+}
+
+// takeDaily takes tokens from a bucket that lasts until the end of the day
+func (l *MemoryLimiter) takeDaily(key string, policy Policy, cost float64, now, end time.Time) *Result {
+	limit := float64(policy.Limit)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit, updated: now, full: end}
+		l.buckets[key] = b
+	}
+
+	need := math.Max(cost, 1)
+	result := &Result{Limit: policy.Limit, Reset: now}
+	if b.tokens >= need {
+		result.Allowed = true
+		b.tokens -= cost
+	} else {
+		result.RetryAfter = end.Sub(now)
+	}
+	if b.tokens < limit {
+		result.Reset = end
+	}
+
+	result.Remaining = int(math.Floor(b.tokens))
+	return result
+}
+
+// sweep drops buckets that have refilled, which hold no information
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if !now.Before(b.full) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,143 @@
+// Package ratelimit limits how often a user may repeat an action.
+//
+// A Limiter counts requests per key: the Redis limiter keeps a sliding
+// window log shared by every instance, the memory limiter a token bucket per
+// process. Daily policies instead count the requests of each calendar day in
+// the configured time zone on both, so they reset at midnight. A Guard maps
+// each Action to its configured Policy and turns a refusal into a
+// RATE_LIMIT_EXCEEDED application error.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+)
+
+// Action names a limited operation; it is part of the limiter key
+type Action string
+
+// Limited actions
+const (
+	ActionBarCreate  Action = "bar_create"  // Saving a new bar, by hand or from AI output
+	ActionAIGenerate Action = "ai_generate" // Every call to the AI provider
+	ActionDonation   Action = "donation"    // Recording a donation
+)
+
+// Policy allows Limit requests per Window. With a Zone the window is the
+// calendar day in that zone instead of the last Window.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+	Zone   *time.Location
+}
+
+// day returns the calendar day now falls on for a policy with a zone, and
+// the key suffix counting it
+func (p Policy) day(now time.Time) (start, end time.Time, suffix string, ok bool) {
+	if p.Zone == nil {
+		return time.Time{}, time.Time{}, "", false
+	}
+	start = StartOfDay(now, p.Zone)
+	return start, start.AddDate(0, 0, 1), ":" + start.Format("2006-01-02"), true
+}
+
+// Policies holds the policy of each limited action
+type Policies map[Action]Policy
+
+// PoliciesFromConfig builds the policies of the configured limits
+func PoliciesFromConfig(cfg config.RateLimitConfig) Policies {
+	zone := cfg.Location()
+	return Policies{
+		ActionBarCreate:  {Limit: cfg.BarsPerDay, Window: 24 * time.Hour, Zone: zone},
+		ActionAIGenerate: {Limit: cfg.AIPerDay, Window: 24 * time.Hour, Zone: zone},
+		ActionDonation:   {Limit: cfg.DonationsPerMinute, Window: time.Minute},
+	}
+}
+
+// Result is the state of a key after a request
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time     // When the key has its full limit again
+	RetryAfter time.Duration // Wait before the next request is allowed, zero when allowed
+}
+
+// Limiter counts requests per key under a policy
+type Limiter interface {
+	// Allow records a request and reports whether it is within the policy;
+	// refused requests are not recorded
+	Allow(ctx context.Context, key string, policy Policy) (*Result, error)
+	// AllowN records n requests at once when all of them fit
+	AllowN(ctx context.Context, key string, policy Policy, n int) (*Result, error)
+	// Peek reports the state of a key without recording a request
+	Peek(ctx context.Context, key string, policy Policy) (*Result, error)
+}
+
+// NewLimiter returns the Redis limiter when Redis is enabled and the memory limiter otherwise
+func NewLimiter(redisClient *config.RedisClient, timeout time.Duration) Limiter {
+	if redisClient != nil && redisClient.IsEnabled() {
+		return NewRedisLimiter(redisClient.Client, timeout)
+	}
+	return NewMemoryLimiter()
+}
+
+// ExceededError carries the limiter state of a refused request so handlers
+// can send the rate limit headers
+type ExceededError struct {
+	Action Action
+	Result *Result
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded", e.Action)
+}
+
+func (e *ExceededError) Unwrap() error {
+	return apperrors.ErrRateLimitExceeded
+}
+
+// actionNames are the Turkish names used in user-facing messages
+var actionNames = map[Action]string{
+	ActionBarCreate:  "bar oluşturma",
+	ActionAIGenerate: "AI üretim",
+	ActionDonation:   "bağış kaydı",
+}
+
+// Exceeded returns the application error of a refused request
+func Exceeded(action Action, policy Policy, result *Result) *apperrors.AppError {
+	name, ok := actionNames[action]
+	if !ok {
+		name = string(action)
+	}
+
+	return &apperrors.AppError{
+		Type:    apperrors.TypeRateLimitExceeded,
+		Message: fmt.Sprintf("%s sınırına ulaşıldı (%d/%s)", name, policy.Limit, windowName(policy.Window)),
+		Details: fmt.Sprintf("retry after %ds", int(result.RetryAfter.Round(time.Second)/time.Second)),
+		Err:     &ExceededError{Action: action, Result: result},
+	}
+}
+
+func windowName(window time.Duration) string {
+	switch window {
+	case 24 * time.Hour:
+		return "gün"
+	case time.Hour:
+		return "saat"
+	case time.Minute:
+		return "dakika"
+	default:
+		return window.String()
+	}
+}
+
+// StartOfDay returns midnight of the day t falls on in loc
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,225 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMemoryLimiter returns a memory limiter on a clock the test moves
+func newTestMemoryLimiter() (*MemoryLimiter, *time.Time) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestMemoryLimiter_AllowsBurstUpToLimit(t *testing.T) {
+	limiter, _ := newTestMemoryLimiter()
+	policy := Policy{Limit: 3, Window: time.Hour}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow(ctx, "k", policy)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "k", policy)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 20*time.Minute, result.RetryAfter, "one token refills every window/limit")
+
+	// Other keys have their own bucket
+	result, err = limiter.Allow(ctx, "other", policy)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryLimiter_Refills(t *testing.T) {
+	limiter, now := newTestMemoryLimiter()
+	policy := Policy{Limit: 2, Window: time.Minute}
+	ctx := context.Background()
+
+	limiter.Allow(ctx, "k", policy)
+	limiter.Allow(ctx, "k", policy)
+	result, _ := limiter.Allow(ctx, "k", policy)
+	require.False(t, result.Allowed)
+	assert.Equal(t, now.Add(time.Minute), result.Reset)
+
+	*now = now.Add(30 * time.Second)
+	result, _ = limiter.Allow(ctx, "k", policy)
+	assert.True(t, result.Allowed)
+	result, _ = limiter.Allow(ctx, "k", policy)
+	assert.False(t, result.Allowed)
+
+	// The bucket never holds more than the limit
+	*now = now.Add(time.Hour)
+	result, _ = limiter.Peek(ctx, "k", policy)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestMemoryLimiter_PeekDoesNotConsume(t *testing.T) {
+	limiter, _ := newTestMemoryLimiter()
+	policy := Policy{Limit: 1, Window: time.Minute}
+	ctx := context.Background()
+
+	for range 3 {
+		result, err := limiter.Peek(ctx, "k", policy)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 1, result.Remaining)
+	}
+
+	limiter.Allow(ctx, "k", policy)
+	result, _ := limiter.Peek(ctx, "k", policy)
+	assert.False(t, result.Allowed)
+}
+
+func TestMemoryLimiter_AllowN(t *testing.T) {
+	limiter, _ := newTestMemoryLimiter()
+	policy := Policy{Limit: 5, Window: 5 * time.Hour}
+	ctx := context.Background()
+
+	result, err := limiter.AllowN(ctx, "k", policy, 4)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+
+	// Requests that do not all fit are refused together and take nothing
+	result, err = limiter.AllowN(ctx, "k", policy, 2)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, time.Hour, result.RetryAfter, "one more token refills every window/limit")
+
+	result, err = limiter.AllowN(ctx, "k", policy, 1)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryLimiter_DailyPolicyResetsAtMidnight(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	require.NoError(t, err)
+	limiter, now := newTestMemoryLimiter()
+	*now = time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC) // 23:00 in Istanbul
+	policy := Policy{Limit: 2, Window: 24 * time.Hour, Zone: istanbul}
+	ctx := context.Background()
+	midnight := time.Date(2024, 3, 1, 21, 0, 0, 0, time.UTC)
+
+	limiter.Allow(ctx, "k", policy)
+	limiter.Allow(ctx, "k", policy)
+	result, _ := limiter.Allow(ctx, "k", policy)
+	require.False(t, result.Allowed)
+	assert.Equal(t, time.Hour, result.RetryAfter)
+	assert.True(t, result.Reset.Equal(midnight))
+
+	// Nothing refills during the day
+	*now = now.Add(59 * time.Minute)
+	result, _ = limiter.Allow(ctx, "k", policy)
+	assert.False(t, result.Allowed)
+
+	// The new day has the full limit
+	*now = midnight
+	result, _ = limiter.Peek(ctx, "k", policy)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestMemoryLimiter_SweepsFullBuckets(t *testing.T) {
+	limiter, now := newTestMemoryLimiter()
+	policy := Policy{Limit: 1, Window: time.Minute}
+	ctx := context.Background()
+
+	limiter.Allow(ctx, "a", policy)
+	limiter.Allow(ctx, "b", policy)
+	assert.Len(t, limiter.buckets, 2)
+
+	*now = now.Add(2 * time.Minute)
+	limiter.Peek(ctx, "c", policy)
+	assert.Len(t, limiter.buckets, 1)
+}
+
+func TestGuard_Allow(t *testing.T) {
+	limiter, _ := newTestMemoryLimiter()
+	guard := NewGuard(limiter, PoliciesFromConfig(config.RateLimitConfig{BarsPerDay: 2, AIPerDay: 1, DonationsPerMinute: 1}))
+	ctx := context.Background()
+
+	_, err := guard.Allow(ctx, ActionBarCreate, "user-1")
+	require.NoError(t, err)
+	result, err := guard.Peek(ctx, ActionBarCreate, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Remaining)
+
+	_, err = guard.Allow(ctx, ActionBarCreate, "user-1")
+	require.NoError(t, err)
+
+	result, err = guard.Allow(ctx, ActionBarCreate, "user-1")
+	require.Error(t, err)
+	assert.False(t, result.Allowed)
+	assert.ErrorIs(t, err, apperrors.ErrRateLimitExceeded)
+
+	var appErr *apperrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.TypeRateLimitExceeded, appErr.Type)
+	assert.Equal(t, "bar oluşturma sınırına ulaşıldı (2/gün)", appErr.Message)
+
+	var exceeded *ExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, ActionBarCreate, exceeded.Action)
+	assert.Positive(t, exceeded.Result.RetryAfter)
+
+	// Actions and users are counted separately
+	_, err = guard.Allow(ctx, ActionAIGenerate, "user-1")
+	assert.NoError(t, err)
+	_, err = guard.Allow(ctx, ActionBarCreate, "user-2")
+	assert.NoError(t, err)
+}
+
+func TestGuard_AllowN(t *testing.T) {
+	limiter, _ := newTestMemoryLimiter()
+	guard := NewGuard(limiter, PoliciesFromConfig(config.RateLimitConfig{BarsPerDay: 1, AIPerDay: 5, DonationsPerMinute: 1}))
+	ctx := context.Background()
+
+	_, err := guard.AllowN(ctx, ActionAIGenerate, "user-1", 4)
+	require.NoError(t, err)
+	_, err = guard.AllowN(ctx, ActionAIGenerate, "user-1", 4)
+	assert.ErrorIs(t, err, apperrors.ErrRateLimitExceeded)
+
+	result, err := guard.Peek(ctx, ActionAIGenerate, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestGuard_UnlimitedActions(t *testing.T) {
+	guard := NewGuard(NewMemoryLimiter(), Policies{ActionDonation: {Limit: 0, Window: time.Minute}})
+
+	for range 10 {
+		result, err := guard.Allow(context.Background(), ActionDonation, "user-1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	var nilGuard *Guard
+	_, err := nilGuard.Allow(context.Background(), ActionBarCreate, "user-1")
+	assert.NoError(t, err)
+}
+
+func TestStartOfDay(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	require.NoError(t, err)
+
+	// 22:30 UTC is already the next day in Istanbul (UTC+3)
+	t1 := time.Date(2024, 3, 1, 22, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), StartOfDay(t1, time.UTC))
+	assert.True(t, StartOfDay(t1, istanbul).Equal(time.Date(2024, 3, 1, 21, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindow keeps the timestamps of the requests of the last window in a
+// sorted set. It drops the expired ones, records cost requests when they all
+// fit (none for a peek, which asks about one) and returns {allowed, count,
+// freed, newest}: freed is when the request that has to leave the window
+// before the refused ones fit was made. Timestamps are in milliseconds.
+//
+// KEYS[1] key, ARGV[1] now, ARGV[2] window, ARGV[3] limit, ARGV[4] member prefix, ARGV[5] cost
+var slidingWindow = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local cost = tonumber(ARGV[5])
+local need = math.max(cost, 1)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count + need <= limit then
+	allowed = 1
+	if cost > 0 then
+		for i = 1, cost do
+			redis.call('ZADD', KEYS[1], now, ARGV[4] .. '-' .. i)
+		end
+		redis.call('PEXPIRE', KEYS[1], window)
+		count = count + cost
+	end
+end
+
+local freed, newest = now, now
+local excess = count + need - limit
+if allowed == 0 and excess <= count then
+	freed = tonumber(redis.call('ZRANGE', KEYS[1], excess - 1, excess - 1, 'WITHSCORES')[2])
+end
+if count > 0 then
+	newest = tonumber(redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')[2])
+end
+return {allowed, count, freed, newest}
+`)
+
+// RedisLimiter is a sliding window log in Redis, shared by every instance.
+// Unlike a fixed window it never allows twice the limit around a window
+// boundary, and unlike resetting the key's expiry on each call it lets old
+// requests fall out of the window. Daily policies keep one log per calendar
+// day, which the day's requests never leave.
+type RedisLimiter struct {
+	client  *redis.Client
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewRedisLimiter creates a limiter on a Redis client
+func NewRedisLimiter(client *redis.Client, timeout time.Duration) *RedisLimiter {
+	return &RedisLimiter{client: client, timeout: timeout, now: time.Now}
+}
+
+// Allow records a request in the key's window when it fits
+func (l *RedisLimiter) Allow(ctx context.Context, key string, policy Policy) (*Result, error) {
+	return l.run(ctx, key, policy, 1)
+}
+
+// AllowN records n requests in the key's window when they all fit
+func (l *RedisLimiter) AllowN(ctx context.Context, key string, policy Policy, n int) (*Result, error) {
+	return l.run(ctx, key, policy, n)
+}
+
+// Peek counts the requests in the key's window
+func (l *RedisLimiter) Peek(ctx context.Context, key string, policy Policy) (*Result, error) {
+	return l.run(ctx, key, policy, 0)
+}
+
+func (l *RedisLimiter) run(ctx context.Context, key string, policy Policy, cost int) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	current := l.now()
+	now := current.UnixMilli()
+	window := policy.Window.Milliseconds()
+	start, end, suffix, daily := policy.day(current)
+	if daily {
+		// Nothing of the day leaves the window, the key expires after it
+		key += suffix
+		window = end.Sub(start).Milliseconds()
+	}
+	member := fmt.Sprintf("%d-%s", now, rand.Text())
+
+	values, err := slidingWindow.Run(ctx, l.client, []string{key}, now, window, policy.Limit, member, cost).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("redis rate limit: %w", err)
+	}
+	allowed, count, freed, newest := values[0] == 1, values[1], values[2], values[3]
+
+	result := &Result{
+		Allowed:   allowed,
+		Limit:     policy.Limit,
+		Remaining: max(policy.Limit-int(count), 0),
+		Reset:     time.UnixMilli(newest + window),
+	}
+	if count == 0 {
+		result.Reset = time.UnixMilli(now)
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration(freed+window-now) * time.Millisecond
+	}
+	if daily {
+		if count > 0 {
+			result.Reset = end
+		}
+		if !allowed {
+			result.RetryAfter = end.Sub(current)
+		}
+	}
+	return result, nil
+}
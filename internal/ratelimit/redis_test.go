@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestRedisLimiter returns a limiter on REDIS_TEST_ADDR and a key nobody else uses
+func newTestRedisLimiter(t *testing.T) (*RedisLimiter, string) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR not set")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	require.NoError(t, client.Ping(context.Background()).Err())
+
+	key := "ratelimit:test:" + primitive.NewObjectID().Hex()
+	t.Cleanup(func() {
+		keys, _ := client.Keys(context.Background(), key+"*").Result()
+		client.Del(context.Background(), append(keys, key)...)
+		client.Close()
+	})
+	return NewRedisLimiter(client, 2*time.Second), key
+}
+
+func TestRedisLimiter_SlidingWindow(t *testing.T) {
+	limiter, key := newTestRedisLimiter(t)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	policy := Policy{Limit: 2, Window: time.Minute}
+	ctx := context.Background()
+
+	result, err := limiter.Peek(ctx, key, policy)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+
+	start := now
+	limiter.Allow(ctx, key, policy)
+	now = now.Add(20 * time.Second)
+	result, err = limiter.Allow(ctx, key, policy)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	now = now.Add(20 * time.Second)
+	result, err = limiter.Allow(ctx, key, policy)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 20*time.Second, result.RetryAfter, "the first request leaves the window a minute after it was made")
+
+	// The refused request was not recorded, so the first slot frees on time
+	now = start.Add(time.Minute + time.Millisecond)
+	result, err = limiter.Allow(ctx, key, policy)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestRedisLimiter_AllowN(t *testing.T) {
+	limiter, key := newTestRedisLimiter(t)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	policy := Policy{Limit: 5, Window: time.Minute}
+	ctx := context.Background()
+
+	result, err := limiter.Allow(ctx, key, policy)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	now = now.Add(10 * time.Second)
+	result, err = limiter.AllowN(ctx, key, policy, 3)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+
+	// Three more only fit once the first two requests left the window
+	now = now.Add(10 * time.Second)
+	result, err = limiter.AllowN(ctx, key, policy, 3)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining, "refused requests are not recorded")
+	assert.Equal(t, 40*time.Second, result.RetryAfter)
+}
+
+func TestRedisLimiter_DailyPolicyResetsAtMidnight(t *testing.T) {
+	limiter, key := newTestRedisLimiter(t)
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	require.NoError(t, err)
+	now := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC) // 23:00 in Istanbul
+	limiter.now = func() time.Time { return now }
+	policy := Policy{Limit: 2, Window: 24 * time.Hour, Zone: istanbul}
+	ctx := context.Background()
+	midnight := time.Date(2024, 3, 1, 21, 0, 0, 0, time.UTC)
+
+	_, err = limiter.AllowN(ctx, key, policy, 2)
+	require.NoError(t, err)
+	result, err := limiter.Allow(ctx, key, policy)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Hour, result.RetryAfter)
+	assert.True(t, result.Reset.Equal(midnight))
+
+	now = midnight
+	result, err = limiter.Allow(ctx, key, policy)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
@@ -0,0 +1,117 @@
+package render
+
+import (
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// numberFormat describes how a language writes amounts
+type numberFormat struct {
+	decimal     string
+	thousands   string
+	symbolFirst bool // "$1,250.50" vs "1.250,50 ₺"
+}
+
+var localeFormats = map[string]numberFormat{
+	"tr": {decimal: ",", thousands: ".", symbolFirst: false},
+	"en": {decimal: ".", thousands: ",", symbolFirst: true},
+}
+
+var currencySymbols = map[string]string{
+	"TRY": "₺",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+}
+
+// SupportedCurrencies are the currencies offered by the web forms
+var SupportedCurrencies = []string{"TRY", "USD", "EUR", "GBP"}
+
+// IsSupportedCurrency reports whether the web forms offer a currency
+func IsSupportedCurrency(currency string) bool {
+	_, ok := currencySymbols[strings.ToUpper(currency)]
+	return ok
+}
+
+// CurrencySymbol returns the display symbol of an ISO 4217 code
+func CurrencySymbol(currency string) string {
+	currency = strings.ToUpper(currency)
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol
+	}
+	return currency
+}
+
+// FormatMoney formats an amount for a language, e.g. "1.250,50 ₺" or "$1,250.50".
+// Whole amounts are written without decimals.
+func FormatMoney(amount float64, currency, language string) string {
+	return formatMoney(amount, currency, language, autoDecimals(amount))
+}
+
+// TemplateFuncs exposes formatting helpers to the SSR templates
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"money": FormatMoney,
+	}
+}
+
+// formatMoney formats an amount with a fixed number of decimals
+func formatMoney(amount float64, currency, language string, decimals int) string {
+	format := localeFormat(language)
+	number := formatGrouped(amount, decimals, format)
+	symbol := CurrencySymbol(currency)
+
+	// The sign leads the whole amount: "-$5", not "$-5"
+	sign := ""
+	if unsigned, ok := strings.CutPrefix(number, "-"); ok {
+		sign, number = "-", unsigned
+	}
+
+	if format.symbolFirst {
+		if len(symbol) == 3 && symbol == strings.ToUpper(currency) {
+			return sign + symbol + " " + number // "CHF 1,250.50"
+		}
+		return sign + symbol + number
+	}
+	return sign + number + " " + symbol
+}
+
+// formatGrouped writes a number with locale decimal and thousands separators
+func formatGrouped(value float64, decimals int, format numberFormat) string {
+	raw := strconv.FormatFloat(math.Abs(value), 'f', decimals, 64)
+	whole, frac, _ := strings.Cut(raw, ".")
+
+	var out strings.Builder
+	if value < 0 && strings.Trim(raw, "0.") != "" {
+		out.WriteByte('-')
+	}
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			out.WriteString(format.thousands)
+		}
+		out.WriteRune(digit)
+	}
+	if frac != "" {
+		out.WriteString(format.decimal)
+		out.WriteString(frac)
+	}
+	return out.String()
+}
+
+// autoDecimals shows cents only when the amount has them
+func autoDecimals(amount float64) int {
+	if math.Abs(amount-math.Round(amount)) < 0.005 {
+		return 0
+	}
+	return 2
+}
+
+// localeFormat returns the number format of a language, English by default
+func localeFormat(language string) numberFormat {
+	if format, ok := localeFormats[language]; ok {
+		return format
+	}
+	return localeFormats["en"]
+}
@@ -0,0 +1,181 @@
+package render
+
+import (
+	"html"
+	"strings"
+
+	"donationbars/internal/models"
+)
+
+// Injection field names
+const (
+	FieldGoal        = "goal"
+	FieldTotal       = "total"
+	FieldPercentage  = "percentage"
+	FieldRemaining   = "remaining"
+	FieldDescription = "description"
+
+	// Locale and currency aware variants
+	FieldCurrency           = "currency"
+	FieldGoalFormatted      = "goal_formatted"
+	FieldTotalFormatted     = "total_formatted"
+	FieldRemainingFormatted = "remaining_formatted"
+
+	// Stretch goal stage, the goal fields above follow the current stage
+	FieldGoalLabel  = "goal_label"
+	FieldGoalIndex  = "goal_index"
+	FieldGoalsTotal = "goals_total"
+)
+
+// maxPlaceholderLength bounds the scan for the closing brace of a placeholder
+const maxPlaceholderLength = 48
+
+// segment is either a literal chunk of HTML or an injection field
+type segment struct {
+	literal  string
+	field    string
+	modifier string
+}
+
+// Template is a bar HTML tokenized into literals and injection fields
+type Template struct {
+	segments []segment
+}
+
+// Render fills the injection fields of the bar's HTML with the given state
+func Render(bar *models.DonationBar, state State) string {
+	return Parse(bar.HTML).Execute(state)
+}
+
+// Parse tokenizes the placeholders of an HTML template once.
+//
+// Placeholders look like {name} or {name:modifier}. Unknown names, invalid
+// modifiers and anything inside HTML comments are kept as literal text.
+func Parse(src string) *Template {
+	t := &Template{}
+	var literal strings.Builder
+
+	flush := func() {
+		if literal.Len() > 0 {
+			t.segments = append(t.segments, segment{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(src); {
+		// HTML comments are copied verbatim
+		if strings.HasPrefix(src[i:], "<!--") {
+			end := strings.Index(src[i+4:], "-->")
+			if end == -1 {
+				literal.WriteString(src[i:])
+				break
+			}
+			literal.WriteString(src[i : i+4+end+3])
+			i += 4 + end + 3
+			continue
+		}
+
+		if src[i] == '{' {
+			if field, modifier, size, ok := scanPlaceholder(src[i:]); ok {
+				flush()
+				t.segments = append(t.segments, segment{field: field, modifier: modifier})
+				i += size
+
+				// Templates often write "{percentage}%%" out of printf habit
+				if field == FieldPercentage && strings.HasPrefix(src[i:], "%%") {
+					i++
+				}
+				continue
+			}
+		}
+
+		literal.WriteByte(src[i])
+		i++
+	}
+	flush()
+
+	return t
+}
+
+// Execute renders the template with HTML escaped values
+func (t *Template) Execute(state State) string {
+	var out strings.Builder
+	for _, seg := range t.segments {
+		if seg.field == "" {
+			out.WriteString(seg.literal)
+			continue
+		}
+		out.WriteString(html.EscapeString(state.format(seg.field, seg.modifier)))
+	}
+	return out.String()
+}
+
+// ExecuteText renders the template with plain values, for text that is not
+// HTML such as chat notifications
+func (t *Template) ExecuteText(state State) string {
+	var out strings.Builder
+	for _, seg := range t.segments {
+		if seg.field == "" {
+			out.WriteString(seg.literal)
+			continue
+		}
+		out.WriteString(state.format(seg.field, seg.modifier))
+	}
+	return out.String()
+}
+
+// Fields returns the distinct injection fields used by the template
+func (t *Template) Fields() []string {
+	seen := make(map[string]bool)
+	var fields []string
+	for _, seg := range t.segments {
+		if seg.field != "" && !seen[seg.field] {
+			seen[seg.field] = true
+			fields = append(fields, seg.field)
+		}
+	}
+	return fields
+}
+
+// MissingFields returns the required injections that the HTML does not use.
+// A formatted variant such as {total_formatted} satisfies {total}.
+func MissingFields(src string) []string {
+	used := make(map[string]bool)
+	for _, field := range Parse(src).Fields() {
+		used[field] = true
+	}
+
+	var missing []string
+	for _, injection := range models.RequiredInjections {
+		name := strings.Trim(injection, "{}")
+		if !used[name] && !used[name+"_formatted"] {
+			missing = append(missing, injection)
+		}
+	}
+	return missing
+}
+
+// HasRequiredFields reports whether every required injection is used
+func HasRequiredFields(src string) bool {
+	return len(MissingFields(src)) == 0
+}
+
+// scanPlaceholder parses a placeholder at the start of s
+func scanPlaceholder(s string) (field, modifier string, size int, ok bool) {
+	limit := min(len(s), maxPlaceholderLength)
+	end := strings.IndexByte(s[1:limit], '}')
+	if end == -1 {
+		return "", "", 0, false
+	}
+
+	body := s[1 : end+1]
+	field, modifier, _ = strings.Cut(body, ":")
+	if !isKnownField(field) {
+		return "", "", 0, false
+	}
+	if strings.Contains(body, ":") && !isValidModifier(modifier) {
+		return "", "", 0, false
+	}
+
+	return field, modifier, end + 2, true
+}
@@ -0,0 +1,181 @@
+package render
+
+import (
+	"testing"
+
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_AllFields(t *testing.T) {
+	bar := &models.DonationBar{
+		HTML:          `<div>{description}: {total}/{goal} - {percentage}% ({remaining} kaldı)</div>`,
+		Description:   "Yeni mikrofon",
+		InitialAmount: 100,
+		DonationTotal: 150,
+		GoalAmount:    1000,
+	}
+
+	result := Render(bar, NewState(bar))
+
+	assert.Equal(t, `<div>Yeni mikrofon: 250/1000 - 25% (750 kaldı)</div>`, result)
+}
+
+func TestRender_StretchGoals(t *testing.T) {
+	bar := &models.DonationBar{
+		HTML:          `{goal_index}/{goals_total} {goal_label}: {total}/{goal} - {percentage}%`,
+		InitialAmount: 600,
+		GoalAmount:    3000,
+		Goals: []models.Goal{
+			{Amount: 500, Label: "Mikrofon"},
+			{Amount: 1500, Label: "Kamera"},
+			{Amount: 3000, Label: "Bilgisayar"},
+		},
+	}
+
+	// The first goal is reached, the bar works towards the second
+	assert.Equal(t, `2/3 Kamera: 600/1500 - 40%`, Render(bar, NewState(bar)))
+
+	bar.InitialAmount = 1500
+	assert.Equal(t, `3/3 Bilgisayar: 1500/3000 - 50%`, Render(bar, NewState(bar)))
+
+	bar.InitialAmount = 4000
+	assert.Equal(t, `3/3 Bilgisayar: 4000/3000 - 100%`, Render(bar, NewState(bar)))
+
+	// A single goal is one unlabeled stage
+	bar.Goals = nil
+	assert.Equal(t, `1/1 : 4000/3000 - 100%`, Render(bar, NewState(bar)))
+	assert.Equal(t, `1/1`, Parse(`{goal_index}/{goals_total}`).Execute(State{}))
+}
+
+func TestRender_FormatModifiers(t *testing.T) {
+	tmpl := Parse(`{total:0.00}|{goal:0}|{percentage:int}|{percentage:0.0}|{remaining:0.000}`)
+	state := State{Goal: 300, Total: 199.999}
+
+	result := tmpl.Execute(state)
+
+	assert.Equal(t, `200.00|300|66|66.7|100.001`, result)
+}
+
+func TestRender_ClampsPercentageAndRemaining(t *testing.T) {
+	tmpl := Parse(`{percentage}|{remaining}`)
+
+	assert.Equal(t, `100|0`, tmpl.Execute(State{Goal: 100, Total: 250}))
+	assert.Equal(t, `0|100`, tmpl.Execute(State{Goal: 100, Total: 0}))
+	assert.Equal(t, `0|0`, tmpl.Execute(State{Goal: 0, Total: 50}))
+}
+
+func TestRender_EscapesValues(t *testing.T) {
+	tmpl := Parse(`<p title="{description}">{description}</p>`)
+
+	result := tmpl.Execute(State{Description: `<script>alert("x")</script>`})
+
+	assert.Equal(t, `<p title="&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;">&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>`, result)
+}
+
+func TestTemplate_ExecuteText_DoesNotEscape(t *testing.T) {
+	tmpl := Parse(`{description} & {total_formatted}`)
+
+	result := tmpl.ExecuteText(State{Description: `"Mikrofon" <3`, Total: 1250, Currency: "TRY", Language: "tr"})
+
+	assert.Equal(t, `"Mikrofon" <3 & 1.250 ₺`, result)
+}
+
+func TestRender_CollapsesDoublePercent(t *testing.T) {
+	tmpl := Parse(`<div style="width: {percentage}%%"></div>`)
+
+	result := tmpl.Execute(State{Goal: 100, Total: 40})
+
+	assert.Equal(t, `<div style="width: 40%"></div>`, result)
+}
+
+func TestRender_KeepsUnknownPlaceholdersAndComments(t *testing.T) {
+	src := `<!-- {total} --><style>.a { color: red; }</style>{unknown} {total:bogus} {total}`
+	tmpl := Parse(src)
+
+	result := tmpl.Execute(State{Goal: 10, Total: 5})
+
+	assert.Equal(t, `<!-- {total} --><style>.a { color: red; }</style>{unknown} {total:bogus} 5`, result)
+	assert.Equal(t, []string{FieldTotal}, tmpl.Fields())
+}
+
+func TestMissingFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected []string
+	}{
+		{
+			name:     "All fields present with modifiers",
+			html:     "<div>{goal:0.00} {total} {percentage:int} {remaining} {description}</div>",
+			expected: nil,
+		},
+		{
+			name:     "Formatted variants satisfy amount fields",
+			html:     "<div>{goal_formatted} {total_formatted} {percentage} {remaining_formatted} {description}</div>",
+			expected: nil,
+		},
+		{
+			name:     "Fields only inside a comment",
+			html:     "<div>{goal} {total}<!-- {percentage} {remaining} {description} --></div>",
+			expected: []string{"{percentage}", "{remaining}", "{description}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MissingFields(tt.html))
+			assert.Equal(t, tt.expected == nil, HasRequiredFields(tt.html))
+		})
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		language string
+		expected string
+	}{
+		{1250.5, "TRY", "tr", "1.250,50 ₺"},
+		{1250.5, "USD", "en", "$1,250.50"},
+		{1000, "EUR", "tr", "1.000 €"},
+		{999, "GBP", "en", "£999"},
+		{1234567.891, "TRY", "en", "₺1,234,567.89"},
+		{50, "CHF", "en", "CHF 50"},
+		{-5, "USD", "en", "-$5"},
+		{-1250.5, "USD", "en", "-$1,250.50"},
+		{-1250.5, "TRY", "tr", "-1.250,50 ₺"},
+		{-50, "CHF", "en", "-CHF 50"},
+		{-50, "CHF", "tr", "-50 CHF"},
+		{-0.001, "USD", "en", "$0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatMoney(tt.amount, tt.currency, tt.language))
+		})
+	}
+}
+
+func TestRender_CurrencyFields(t *testing.T) {
+	bar := &models.DonationBar{
+		HTML:          `{total_formatted} / {goal_formatted:0.00} ({remaining_formatted:int}) {currency}`,
+		Language:      "tr",
+		Currency:      "EUR",
+		InitialAmount: 1250.5,
+		GoalAmount:    5000,
+	}
+
+	result := Render(bar, NewState(bar))
+
+	assert.Equal(t, `1.250,50 € / 5.000,00 € (3.749 €) €`, result)
+}
+
+func TestRender_CurrencyDefaultsToLanguage(t *testing.T) {
+	tmpl := Parse(`{total_formatted}`)
+
+	assert.Equal(t, `$1,500`, tmpl.Execute(State{Total: 1500, Language: "en"}))
+	assert.Equal(t, `1.500 ₺`, tmpl.Execute(State{Total: 1500, Language: "tr"}))
+}
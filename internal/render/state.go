@@ -0,0 +1,153 @@
+package render
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"donationbars/internal/models"
+)
+
+// State holds the live values injected into a bar at render time
+type State struct {
+	Goal        float64 // The current stage's goal
+	Total       float64
+	Description string
+	Currency    string // ISO 4217 code
+	Language    string // "tr" or "en", drives number formatting
+	GoalLabel   string
+	GoalIndex   int // 1-based, 0 renders as 1
+	GoalsTotal  int // 0 renders as 1
+}
+
+// NewState captures the current state of a bar; with stretch goals the goal
+// is the first one not reached yet
+func NewState(bar *models.DonationBar) State {
+	stage := bar.CurrentStage()
+	return State{
+		Goal:        stage.Amount,
+		Total:       bar.CurrentTotal(),
+		Description: bar.Description,
+		Currency:    bar.CurrencyCode(),
+		Language:    bar.Language,
+		GoalLabel:   stage.Label,
+		GoalIndex:   stage.Index,
+		GoalsTotal:  stage.Count,
+	}
+}
+
+// Percentage returns the progress towards the goal, clamped to 0-100
+func (s State) Percentage() float64 {
+	if s.Goal <= 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(100, s.Total/s.Goal*100))
+}
+
+// Remaining returns the amount left to reach the goal, never negative
+func (s State) Remaining() float64 {
+	return math.Max(0, s.Goal-s.Total)
+}
+
+// Format returns the default, unescaped representation of a field
+func (s State) Format(field string) string {
+	return s.format(field, "")
+}
+
+// format renders a field with an optional modifier
+func (s State) format(field, modifier string) string {
+	switch field {
+	case FieldDescription:
+		return s.Description
+	case FieldGoal:
+		return formatNumber(s.Goal, modifier)
+	case FieldTotal:
+		return formatNumber(s.Total, modifier)
+	case FieldPercentage:
+		return formatNumber(s.Percentage(), modifier)
+	case FieldRemaining:
+		return formatNumber(s.Remaining(), modifier)
+	case FieldCurrency:
+		return CurrencySymbol(s.currency())
+	case FieldGoalFormatted:
+		return s.formatMoney(s.Goal, modifier)
+	case FieldTotalFormatted:
+		return s.formatMoney(s.Total, modifier)
+	case FieldRemainingFormatted:
+		return s.formatMoney(s.Remaining(), modifier)
+	case FieldGoalLabel:
+		return s.GoalLabel
+	case FieldGoalIndex:
+		return strconv.Itoa(max(s.GoalIndex, 1))
+	case FieldGoalsTotal:
+		return strconv.Itoa(max(s.GoalsTotal, 1))
+	}
+	return ""
+}
+
+// formatMoney renders an amount in the state's locale and currency
+func (s State) formatMoney(amount float64, modifier string) string {
+	if modifier == "int" {
+		return formatMoney(math.Trunc(amount), s.currency(), s.Language, 0)
+	}
+	if decimals, ok := decimalsOf(modifier); ok {
+		return formatMoney(amount, s.currency(), s.Language, decimals)
+	}
+	return FormatMoney(amount, s.currency(), s.Language)
+}
+
+// currency returns the state's currency, falling back to the language default
+func (s State) currency() string {
+	if s.Currency != "" {
+		return s.Currency
+	}
+	return models.DefaultCurrency(s.Language)
+}
+
+// isKnownField reports whether name is an injection field
+func isKnownField(name string) bool {
+	switch name {
+	case FieldGoal, FieldTotal, FieldPercentage, FieldRemaining, FieldDescription,
+		FieldCurrency, FieldGoalFormatted, FieldTotalFormatted, FieldRemainingFormatted,
+		FieldGoalLabel, FieldGoalIndex, FieldGoalsTotal:
+		return true
+	}
+	return false
+}
+
+// isValidModifier accepts "int" and fixed decimal patterns like "0", "0.00"
+func isValidModifier(modifier string) bool {
+	if modifier == "int" {
+		return true
+	}
+	_, ok := decimalsOf(modifier)
+	return ok
+}
+
+// decimalsOf returns the number of decimals of a pattern like "0.00"
+func decimalsOf(pattern string) (int, bool) {
+	whole, frac, hasFrac := strings.Cut(pattern, ".")
+	if whole != "0" {
+		return 0, false
+	}
+	if !hasFrac {
+		return 0, true
+	}
+	if frac == "" || len(frac) > 4 || strings.Trim(frac, "0") != "" {
+		return 0, false
+	}
+	return len(frac), true
+}
+
+// formatNumber applies a number modifier; the default is a rounded integer
+func formatNumber(value float64, modifier string) string {
+	if modifier == "int" {
+		return strconv.FormatFloat(math.Trunc(value), 'f', 0, 64)
+	}
+
+	decimals, ok := decimalsOf(modifier)
+	if !ok {
+		decimals = 0
+	}
+	return strconv.FormatFloat(value, 'f', decimals, 64)
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.APIKeyRepositoryInterface {
+	repo := &APIKeyRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("api_keys")
+	}
+	return repo
+}
+
+// Insert stores a new API key
+func (r *APIKeyRepository) Insert(ctx context.Context, key *models.APIKey) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(writeCtx, key)
+	return err
+}
+
+// FindByUserID returns all keys of a user, newest first
+func (r *APIKeyRepository) FindByUserID(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	if r.collection == nil {
+		return []*models.APIKey{}, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(readCtx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(readCtx)
+
+	keys := []*models.APIKey{}
+	if err := cursor.All(readCtx, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// FindByHash finds an active key by the hash of its secret
+func (r *APIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	filter := bson.M{
+		"key_hash":   keyHash,
+		"revoked_at": bson.M{"$exists": false},
+	}
+
+	var key models.APIKey
+	err := r.collection.FindOne(readCtx, filter).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("api key")
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// Revoke marks a key of the user as revoked
+func (r *APIKeyRepository) Revoke(ctx context.Context, userID, keyID string) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return apperrors.Missing("api key")
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":        objectID,
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(writeCtx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return apperrors.Missing("api key")
+	}
+
+	return nil
+}
+
+// TouchLastUsed records that a key was just used
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, keyID string) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return apperrors.Missing("api key")
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err = r.collection.UpdateOne(writeCtx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"last_used_at": time.Now()}})
+	return err
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiKeyColumns is the column list every API key query selects, in scanAPIKey order
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at`
+
+type SQLiteAPIKeyRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteAPIKeyRepository creates a new SQLite backed API key repository
+func NewSQLiteAPIKeyRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.APIKeyRepositoryInterface {
+	repo := &SQLiteAPIKeyRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Insert stores a new API key
+func (r *SQLiteAPIKeyRepository) Insert(ctx context.Context, key *models.APIKey) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	scopesJSON, err := json.Marshal(scopes)
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err = r.db.ExecContext(writeCtx, `INSERT INTO api_keys (`+apiKeyColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.ID.Hex(), key.UserID, key.Name, key.Prefix, key.KeyHash, string(scopesJSON),
+		toMillis(key.CreatedAt), toNullMillis(key.LastUsedAt), toNullMillis(key.RevokedAt),
+	)
+	return err
+}
+
+// FindByUserID returns all keys of a user, newest first
+func (r *SQLiteAPIKeyRepository) FindByUserID(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	if r.db == nil {
+		return []*models.APIKey{}, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(readCtx, `SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE user_id = ? ORDER BY created_at DESC, rowid DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// FindByHash finds an active key by the hash of its secret
+func (r *SQLiteAPIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	row := r.db.QueryRowContext(readCtx, `SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE key_hash = ? AND revoked_at IS NULL`, keyHash)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Missing("api key")
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Revoke marks a key of the user as revoked
+func (r *SQLiteAPIKeyRepository) Revoke(ctx context.Context, userID, keyID string) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if _, err := primitive.ObjectIDFromHex(keyID); err != nil {
+		return apperrors.Missing("api key")
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.db.ExecContext(writeCtx, `UPDATE api_keys SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL`, toMillis(time.Now()), keyID, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, apperrors.Missing("api key"))
+}
+
+// TouchLastUsed records that a key was just used
+func (r *SQLiteAPIKeyRepository) TouchLastUsed(ctx context.Context, keyID string) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if _, err := primitive.ObjectIDFromHex(keyID); err != nil {
+		return apperrors.Missing("api key")
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.db.ExecContext(writeCtx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, toMillis(time.Now()), keyID)
+	return err
+}
+
+// scanAPIKey scans the columns of apiKeyColumns into a key
+func scanAPIKey(s sqliteScanner) (*models.APIKey, error) {
+	var (
+		key                   models.APIKey
+		id, scopes            string
+		createdAt             int64
+		lastUsedAt, revokedAt sql.NullInt64
+	)
+
+	err := s.Scan(&id, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &createdAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return nil, err
+	}
+	key.CreatedAt = fromMillis(createdAt)
+	key.LastUsedAt = fromNullMillis(lastUsedAt)
+	key.RevokedAt = fromNullMillis(revokedAt)
+
+	return &key, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type BarEventRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewBarEventRepository creates a MongoDB backed bar event repository
+func NewBarEventRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.BarEventRepositoryInterface {
+	repo := &BarEventRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("bar_events")
+	}
+	return repo
+}
+
+// Insert records an event; the unique (bar_id, campaign, milestone) index
+// lets only the first of concurrent inserts through
+func (r *BarEventRepository) Insert(ctx context.Context, event *models.BarEvent) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(writeCtx, event)
+	if mongo.IsDuplicateKeyError(err) {
+		return apperrors.Wrap(apperrors.ErrConflict, "milestone already reached")
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SQLiteBarEventRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteBarEventRepository creates a SQLite backed bar event repository
+func NewSQLiteBarEventRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.BarEventRepositoryInterface {
+	repo := &SQLiteBarEventRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Insert records an event; the UNIQUE (bar_id, campaign, milestone)
+// constraint lets only the first of concurrent inserts through
+func (r *SQLiteBarEventRepository) Insert(ctx context.Context, event *models.BarEvent) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.db.ExecContext(writeCtx, `INSERT INTO bar_events
+		(id, bar_id, user_id, type, campaign, milestone, total, goal, stage, goal_label, currency, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.ID.Hex(), event.BarID.Hex(), event.UserID, event.Type, event.Campaign, event.Milestone,
+		event.Total, event.Goal, event.Stage, event.GoalLabel, event.Currency, toMillis(event.CreatedAt),
+	)
+	if isUniqueViolation(err) {
+		return apperrors.Wrap(apperrors.ErrConflict, "milestone already reached")
+	}
+	return err
+}
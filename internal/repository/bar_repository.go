@@ -2,17 +2,19 @@ package repository
 
 import (
 	"context"
-	"errors"
-	"strings"
+	"regexp"
 	"time"
 
 	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
 	"donationbars/internal/interfaces"
 	"donationbars/internal/models"
+	"donationbars/internal/render"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type BarRepository struct {
@@ -36,7 +38,7 @@ func NewBarRepository(db *config.Database, timeouts config.TimeoutConfig) interf
 // Insert adds a new bar to the database
 func (r *BarRepository) Insert(ctx context.Context, bar *models.DonationBar) error {
 	if r.collection == nil {
-		return errors.New("database connection not available")
+		return apperrors.ErrDatabaseUnavailable
 	}
 
 	// Use configured timeout for write operations
@@ -47,18 +49,48 @@ func (r *BarRepository) Insert(ctx context.Context, bar *models.DonationBar) err
 	return err
 }
 
-// FindByUserID returns all bars for a user
-func (r *BarRepository) FindByUserID(ctx context.Context, userID string) ([]*models.DonationBar, error) {
+// ListByUserID returns one page of a user's bars
+func (r *BarRepository) ListByUserID(ctx context.Context, userID string, opts *models.BarListOptions) (*models.BarPage, error) {
 	if r.collection == nil {
-		return []*models.DonationBar{}, nil
+		return opts.Page(nil), nil
 	}
 
 	// Use configured timeout for read operations
 	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
 	defer cancel()
 
-	filter := bson.M{"user_id": userID}
-	cursor, err := r.collection.Find(readCtx, filter)
+	key, dir, op := mongoBarSortKey(opts.Sort), 1, "$gt"
+	if opts.Descending() {
+		dir, op = -1, "$lt"
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: mongoBarFilter(userID, &opts.BarFilter)}}}
+	if opts.Sort == models.BarSortProgress {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{key: mongoProgressExpr}}})
+	}
+
+	if opts.After != nil {
+		var value any
+		switch opts.Sort {
+		case models.BarSortName:
+			value = opts.After.Name
+		case models.BarSortProgress:
+			value = opts.After.Progress
+		default:
+			value = opts.After.Time
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{key: bson.M{op: value}},
+			bson.M{key: value, "_id": bson.M{op: opts.After.ID}},
+		}}}})
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: key, Value: dir}, {Key: "_id", Value: dir}}}},
+		bson.D{{Key: "$limit", Value: opts.Limit + 1}},
+	)
+
+	cursor, err := r.collection.Aggregate(readCtx, pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -69,18 +101,66 @@ func (r *BarRepository) FindByUserID(ctx context.Context, userID string) ([]*mod
 		return nil, err
 	}
 
-	return bars, nil
+	return opts.Page(bars), nil
 }
 
-// FindByID returns a specific bar by ID for a user
-func (r *BarRepository) FindByID(ctx context.Context, userID, barID string) (*models.DonationBar, error) {
-	if r.collection == nil {
-		return nil, errors.New("database connection not available")
+// mongoBarFilter selects a user's bars that pass the filter
+func mongoBarFilter(userID string, filter *models.BarFilter) bson.M {
+	query := bson.M{"user_id": userID}
+
+	if filter.Active != nil {
+		query["is_active"] = *filter.Active
+	}
+	if filter.AIGenerated != nil {
+		query["ai_generated"] = *filter.AIGenerated
+	}
+	if filter.Language != "" {
+		query["language"] = filter.Language
 	}
+	if filter.Theme != "" {
+		query["theme"] = filter.Theme
+	}
+	if filter.Search != "" {
+		search := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+		query["$or"] = bson.A{bson.M{"name": search}, bson.M{"description": search}}
+	}
+
+	return query
+}
+
+// mongoBarSortKey returns the field a listing is ordered by; progress is computed into sort_progress
+func mongoBarSortKey(sort string) string {
+	switch sort {
+	case models.BarSortUpdatedAt:
+		return "updated_at"
+	case models.BarSortName:
+		return "name"
+	case models.BarSortProgress:
+		return "sort_progress"
+	default:
+		return "created_at"
+	}
+}
 
+// mongoProgressExpr computes DonationBar.Progress; bars saved before the ledger have no donation_total
+var mongoProgressExpr = bson.M{"$cond": bson.A{
+	bson.M{"$gt": bson.A{"$goal_amount", 0}},
+	bson.M{"$divide": bson.A{
+		bson.M{"$add": bson.A{"$initial_amount", bson.M{"$ifNull": bson.A{"$donation_total", 0}}}},
+		"$goal_amount",
+	}},
+	0,
+}}
+
+// FindByID returns a specific bar by ID for a user
+func (r *BarRepository) FindByID(ctx context.Context, userID, barID string) (*models.DonationBar, error) {
 	objectID, err := primitive.ObjectIDFromHex(barID)
 	if err != nil {
-		return nil, errors.New("invalid bar ID format")
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
 	}
 
 	// Use configured timeout for read operations
@@ -96,7 +176,7 @@ func (r *BarRepository) FindByID(ctx context.Context, userID, barID string) (*mo
 	err = r.collection.FindOne(readCtx, filter).Decode(&bar)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("bar not found")
+			return nil, apperrors.Missing("bar")
 		}
 		return nil, err
 	}
@@ -106,13 +186,13 @@ func (r *BarRepository) FindByID(ctx context.Context, userID, barID string) (*mo
 
 // Update updates basic fields of a bar
 func (r *BarRepository) Update(ctx context.Context, userID, barID string, req *models.UpdateBarRequest) (*models.DonationBar, error) {
-	if r.collection == nil {
-		return nil, errors.New("database connection not available")
-	}
-
 	objectID, err := primitive.ObjectIDFromHex(barID)
 	if err != nil {
-		return nil, errors.New("invalid bar ID format")
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
 	}
 
 	// Build update document
@@ -137,6 +217,12 @@ func (r *BarRepository) Update(ctx context.Context, userID, barID string, req *m
 	if req.GoalAmount != nil {
 		update["$set"].(bson.M)["goal_amount"] = *req.GoalAmount
 	}
+	if req.Currency != nil {
+		update["$set"].(bson.M)["currency"] = *req.Currency
+	}
+	if req.Goals != nil {
+		update["$set"].(bson.M)["goals"] = *req.Goals
+	}
 
 	filter := bson.M{
 		"_id":     objectID,
@@ -149,42 +235,98 @@ func (r *BarRepository) Update(ctx context.Context, userID, barID string, req *m
 	}
 
 	if result.MatchedCount == 0 {
-		return nil, errors.New("bar not found")
+		return nil, apperrors.Missing("bar")
 	}
 
 	// Return updated bar
 	return r.FindByID(ctx, userID, barID)
 }
 
-// UpdateComplete updates all fields of a bar including HTML/CSS
-func (r *BarRepository) UpdateComplete(ctx context.Context, userID, barID string, req *models.CreateBarRequest, isActive bool) error {
+// UpdateComplete updates all fields of a bar including HTML/CSS and counts
+// a revision when the content changed
+func (r *BarRepository) UpdateComplete(ctx context.Context, userID, barID string, req *models.CreateBarRequest, isActive bool) (*models.DonationBar, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
 	if r.collection == nil {
-		return errors.New("database connection not available")
+		return nil, apperrors.ErrDatabaseUnavailable
 	}
 
+	// Validate injections
+	hasValidInjections := r.validateInjections(req.HTML)
+
+	// A pipeline update compares the stored content in the same write that
+	// replaces it; values are wrapped in $literal so a leading "$" is not
+	// read as a field path
+	contentChanged := bson.M{"$or": bson.A{
+		bson.M{"$ne": bson.A{"$html", bson.M{"$literal": req.HTML}}},
+		bson.M{"$ne": bson.A{"$css", bson.M{"$literal": req.CSS}}},
+	}}
+	goals := req.Goals
+	if goals == nil {
+		goals = []models.Goal{}
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"revision": bson.M{"$cond": bson.A{
+			contentChanged,
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$revision", 0}}, 1}},
+			bson.M{"$ifNull": bson.A{"$revision", 0}},
+		}},
+		"name":                 bson.M{"$literal": req.Name},
+		"description":          bson.M{"$literal": req.Description},
+		"html":                 bson.M{"$literal": req.HTML},
+		"css":                  bson.M{"$literal": req.CSS},
+		"language":             bson.M{"$literal": req.Language},
+		"currency":             bson.M{"$literal": req.Currency},
+		"theme":                bson.M{"$literal": req.Theme},
+		"is_active":            isActive,
+		"initial_amount":       req.InitialAmount,
+		"goal_amount":          req.GoalAmount,
+		"goals":                bson.M{"$literal": goals},
+		"updated_at":           time.Now(),
+		"has_valid_injections": hasValidInjections,
+	}}}}
+
+	filter := bson.M{
+		"_id":     objectID,
+		"user_id": userID,
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var bar models.DonationBar
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&bar)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("bar")
+		}
+		return nil, err
+	}
+
+	return &bar, nil
+}
+
+// UpdateContent replaces a bar's HTML/CSS and counts the revision
+func (r *BarRepository) UpdateContent(ctx context.Context, userID, barID, html, css string) (*models.DonationBar, error) {
 	objectID, err := primitive.ObjectIDFromHex(barID)
 	if err != nil {
-		return errors.New("invalid bar ID format")
+		return nil, apperrors.ErrInvalidBarID
 	}
 
-	// Validate injections
-	hasValidInjections := r.validateInjections(req.HTML)
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
 
-	// Build update document
 	update := bson.M{
 		"$set": bson.M{
-			"name":                 req.Name,
-			"description":          req.Description,
-			"html":                 req.HTML,
-			"css":                  req.CSS,
-			"language":             req.Language,
-			"theme":                req.Theme,
-			"is_active":            isActive,
-			"initial_amount":       req.InitialAmount,
-			"goal_amount":          req.GoalAmount,
+			"html":                 html,
+			"css":                  css,
+			"has_valid_injections": r.validateInjections(html),
 			"updated_at":           time.Now(),
-			"has_valid_injections": hasValidInjections,
 		},
+		"$inc": bson.M{"revision": 1},
 	}
 
 	filter := bson.M{
@@ -192,27 +334,30 @@ func (r *BarRepository) UpdateComplete(ctx context.Context, userID, barID string
 		"user_id": userID,
 	}
 
-	result, err := r.collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
-	}
+	// Read the counter back in the same operation so concurrent edits get distinct revisions
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
-	if result.MatchedCount == 0 {
-		return errors.New("bar not found")
+	var bar models.DonationBar
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&bar)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("bar")
+		}
+		return nil, err
 	}
 
-	return nil
+	return &bar, nil
 }
 
 // Delete removes a bar from the database
 func (r *BarRepository) Delete(ctx context.Context, userID, barID string) error {
-	if r.collection == nil {
-		return errors.New("database connection not available")
-	}
-
 	objectID, err := primitive.ObjectIDFromHex(barID)
 	if err != nil {
-		return errors.New("invalid bar ID format")
+		return apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
 	}
 
 	filter := bson.M{
@@ -226,7 +371,7 @@ func (r *BarRepository) Delete(ctx context.Context, userID, barID string) error
 	}
 
 	if result.DeletedCount == 0 {
-		return errors.New("bar not found")
+		return apperrors.Missing("bar")
 	}
 
 	return nil
@@ -235,39 +380,236 @@ func (r *BarRepository) Delete(ctx context.Context, userID, barID string) error
 // CountByUserID returns the total number of bars for a user
 func (r *BarRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
 	if r.collection == nil {
-		return 0, errors.New("database connection not available")
+		return 0, nil
 	}
 
 	filter := bson.M{"user_id": userID}
 	return r.collection.CountDocuments(ctx, filter)
 }
 
-// CountByUserIDToday returns the number of bars created by user today
-func (r *BarRepository) CountByUserIDToday(ctx context.Context, userID string) (int64, error) {
+// CountByFilter returns the number of a user's bars that pass the filter
+func (r *BarRepository) CountByFilter(ctx context.Context, userID string, filter *models.BarFilter) (int64, error) {
+	if r.collection == nil {
+		return 0, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	return r.collection.CountDocuments(readCtx, mongoBarFilter(userID, filter))
+}
+
+// CountCreatedSince returns the number of bars the user created at or after since
+func (r *BarRepository) CountCreatedSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	if r.collection == nil {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"user_id":    userID,
+		"created_at": bson.M{"$gte": since},
+	}
+
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+// IncrementAmount adds delta to the bar's starting amount and returns the
+// updated bar. Unlike Update, $inc applies concurrent increments in turn.
+func (r *BarRepository) IncrementAmount(ctx context.Context, userID, barID string, delta float64) (*models.DonationBar, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":     objectID,
+		"user_id": userID,
+	}
+	update := bson.M{
+		"$inc": bson.M{"initial_amount": delta},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var bar models.DonationBar
+	err = r.collection.FindOneAndUpdate(writeCtx, filter, update, opts).Decode(&bar)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("bar")
+		}
+		return nil, err
+	}
+
+	return &bar, nil
+}
+
+// AddDonationTotal adds a recorded donation to the bar's ledger total and
+// returns the updated bar. $inc keeps concurrent donations from overwriting
+// each other.
+func (r *BarRepository) AddDonationTotal(ctx context.Context, barID string, amount float64) (*models.DonationBar, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	update := bson.M{
+		"$inc": bson.M{"donation_total": amount},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var bar models.DonationBar
+	err = r.collection.FindOneAndUpdate(writeCtx, bson.M{"_id": objectID}, update, opts).Decode(&bar)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("bar")
+		}
+		return nil, err
+	}
+
+	return &bar, nil
+}
+
+// FindByOverlayToken returns the bar owning the given overlay token
+func (r *BarRepository) FindByOverlayToken(ctx context.Context, token string) (*models.DonationBar, error) {
 	if r.collection == nil {
-		return 0, errors.New("database connection not available")
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	if token == "" {
+		return nil, apperrors.Missing("bar")
 	}
 
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var bar models.DonationBar
+	err := r.collection.FindOne(readCtx, bson.M{"overlay_token": token}).Decode(&bar)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("bar")
+		}
+		return nil, err
+	}
+
+	return &bar, nil
+}
+
+// SetOverlayToken replaces the overlay token of a bar
+func (r *BarRepository) SetOverlayToken(ctx context.Context, userID, barID, token string) error {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
 
 	filter := bson.M{
+		"_id":     objectID,
 		"user_id": userID,
-		"created_at": bson.M{
-			"$gte": today,
-			"$lt":  tomorrow,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"overlay_token": token,
+			"updated_at":    time.Now(),
 		},
 	}
 
-	return r.collection.CountDocuments(ctx, filter)
+	result, err := r.collection.UpdateOne(writeCtx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return apperrors.Missing("bar")
+	}
+
+	return nil
 }
 
 // validateInjections checks if all required injection fields are present
 func (r *BarRepository) validateInjections(html string) bool {
-	for _, injection := range models.RequiredInjections {
-		if !strings.Contains(html, injection) {
-			return false
+	return render.HasRequiredFields(html)
+}
+
+// FindByWebhookToken returns the bar owning the given webhook token
+func (r *BarRepository) FindByWebhookToken(ctx context.Context, token string) (*models.DonationBar, error) {
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	if token == "" {
+		return nil, apperrors.Missing("bar")
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var bar models.DonationBar
+	err := r.collection.FindOne(readCtx, bson.M{"webhook_token": token}).Decode(&bar)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("bar")
 		}
+		return nil, err
+	}
+
+	return &bar, nil
+}
+
+// SetWebhook replaces the webhook token and signing secret of a bar
+func (r *BarRepository) SetWebhook(ctx context.Context, userID, barID, token, secret string) error {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
 	}
-	return true
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":     objectID,
+		"user_id": userID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"webhook_token":  token,
+			"webhook_secret": secret,
+			"updated_at":     time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(writeCtx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return apperrors.Missing("bar")
+	}
+
+	return nil
 }
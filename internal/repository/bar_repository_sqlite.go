@@ -0,0 +1,542 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+	"donationbars/internal/render"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// barColumns is the column list every bar query selects, in scanBar order
+const barColumns = `id, user_id, name, description, html, css, language, currency, theme,
+	is_active, created_at, updated_at, initial_amount, goal_amount, donation_total,
+	prompt, ai_generated, has_valid_injections, revision, overlay_token,
+	webhook_token, webhook_secret, goals`
+
+type SQLiteBarRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteBarRepository creates a new SQLite backed bar repository
+func NewSQLiteBarRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.BarRepositoryInterface {
+	repo := &SQLiteBarRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Insert adds a new bar to the database
+func (r *SQLiteBarRepository) Insert(ctx context.Context, bar *models.DonationBar) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if bar.ID.IsZero() {
+		bar.ID = primitive.NewObjectID()
+	}
+
+	goals, err := encodeGoals(bar.Goals)
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err = r.db.ExecContext(writeCtx, `INSERT INTO donation_bars (`+barColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		bar.ID.Hex(), bar.UserID, bar.Name, bar.Description, bar.HTML, bar.CSS,
+		bar.Language, bar.Currency, bar.Theme, bar.IsActive,
+		toMillis(bar.CreatedAt), toMillis(bar.UpdatedAt),
+		bar.InitialAmount, bar.GoalAmount, bar.DonationTotal,
+		bar.Prompt, bar.AIGenerated, bar.HasValidInjections, bar.Revision, bar.OverlayToken,
+		bar.WebhookToken, bar.WebhookSecret, goals,
+	)
+	return err
+}
+
+// ListByUserID returns one page of a user's bars
+func (r *SQLiteBarRepository) ListByUserID(ctx context.Context, userID string, opts *models.BarListOptions) (*models.BarPage, error) {
+	if r.db == nil {
+		return opts.Page(nil), nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	where, args := sqliteBarFilter(userID, &opts.BarFilter)
+
+	key, dir, op := sqliteBarSortKey(opts.Sort), "ASC", ">"
+	if opts.Descending() {
+		dir, op = "DESC", "<"
+	}
+
+	if opts.After != nil {
+		var value any
+		switch opts.Sort {
+		case models.BarSortName:
+			value = opts.After.Name
+		case models.BarSortProgress:
+			value = opts.After.Progress
+		default:
+			value = toMillis(opts.After.Time)
+		}
+		where += ` AND (` + key + ` ` + op + ` ? OR (` + key + ` = ? AND id ` + op + ` ?))`
+		args = append(args, value, value, opts.After.ID.Hex())
+	}
+
+	args = append(args, opts.Limit+1)
+	rows, err := r.db.QueryContext(readCtx, `SELECT `+barColumns+` FROM donation_bars
+		WHERE `+where+` ORDER BY `+key+` `+dir+`, id `+dir+` LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bars []*models.DonationBar
+	for rows.Next() {
+		bar, err := scanBar(rows)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, bar)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return opts.Page(bars), nil
+}
+
+// sqliteBarFilter returns the WHERE clause and arguments selecting a user's bars that pass the filter
+func sqliteBarFilter(userID string, filter *models.BarFilter) (string, []any) {
+	where := []string{"user_id = ?"}
+	args := []any{userID}
+
+	if filter.Active != nil {
+		where = append(where, "is_active = ?")
+		args = append(args, *filter.Active)
+	}
+	if filter.AIGenerated != nil {
+		where = append(where, "ai_generated = ?")
+		args = append(args, *filter.AIGenerated)
+	}
+	if filter.Language != "" {
+		where = append(where, "language = ?")
+		args = append(args, filter.Language)
+	}
+	if filter.Theme != "" {
+		where = append(where, "theme = ?")
+		args = append(args, filter.Theme)
+	}
+	if filter.Search != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Search) + "%"
+		where = append(where, `(name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+
+	return strings.Join(where, " AND "), args
+}
+
+// likeEscaper escapes the LIKE wildcards of a search text
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sqliteBarSortKey returns the SQL expression a listing is ordered by
+func sqliteBarSortKey(sort string) string {
+	switch sort {
+	case models.BarSortUpdatedAt:
+		return "updated_at"
+	case models.BarSortName:
+		return "name"
+	case models.BarSortProgress:
+		return "(CASE WHEN goal_amount > 0 THEN (initial_amount + donation_total) / goal_amount ELSE 0 END)"
+	default:
+		return "created_at"
+	}
+}
+
+// FindByID returns a specific bar by ID for a user
+func (r *SQLiteBarRepository) FindByID(ctx context.Context, userID, barID string) (*models.DonationBar, error) {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	row := r.db.QueryRowContext(readCtx, `SELECT `+barColumns+` FROM donation_bars
+		WHERE id = ? AND user_id = ?`, barID, userID)
+	return scanBarRow(row)
+}
+
+// Update updates basic fields of a bar
+func (r *SQLiteBarRepository) Update(ctx context.Context, userID, barID string, req *models.UpdateBarRequest) (*models.DonationBar, error) {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	// Build the SET clause from the fields present in the request
+	set := []string{"updated_at = ?"}
+	args := []any{toMillis(time.Now())}
+
+	if req.Name != nil {
+		set = append(set, "name = ?")
+		args = append(args, *req.Name)
+	}
+	if req.Description != nil {
+		set = append(set, "description = ?")
+		args = append(args, *req.Description)
+	}
+	if req.IsActive != nil {
+		set = append(set, "is_active = ?")
+		args = append(args, *req.IsActive)
+	}
+	if req.InitialAmount != nil {
+		set = append(set, "initial_amount = ?")
+		args = append(args, *req.InitialAmount)
+	}
+	if req.GoalAmount != nil {
+		set = append(set, "goal_amount = ?")
+		args = append(args, *req.GoalAmount)
+	}
+	if req.Currency != nil {
+		set = append(set, "currency = ?")
+		args = append(args, *req.Currency)
+	}
+	if req.Goals != nil {
+		goals, err := encodeGoals(*req.Goals)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, "goals = ?")
+		args = append(args, goals)
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	args = append(args, barID, userID)
+	row := r.db.QueryRowContext(writeCtx, `UPDATE donation_bars SET `+strings.Join(set, ", ")+`
+		WHERE id = ? AND user_id = ? RETURNING `+barColumns, args...)
+	return scanBarRow(row)
+}
+
+// UpdateComplete updates all fields of a bar including HTML/CSS and counts
+// a revision when the content changed
+func (r *SQLiteBarRepository) UpdateComplete(ctx context.Context, userID, barID string, req *models.CreateBarRequest, isActive bool) (*models.DonationBar, error) {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	goals, err := encodeGoals(req.Goals)
+	if err != nil {
+		return nil, err
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	// The revision compares against the stored content in the same statement,
+	// so the fields and the counter cannot be written apart
+	row := r.db.QueryRowContext(writeCtx, `UPDATE donation_bars SET
+		revision = revision + CASE WHEN html = ? AND css = ? THEN 0 ELSE 1 END,
+		name = ?, description = ?, html = ?, css = ?, language = ?, currency = ?, theme = ?,
+		is_active = ?, initial_amount = ?, goal_amount = ?, goals = ?, updated_at = ?, has_valid_injections = ?
+		WHERE id = ? AND user_id = ? RETURNING `+barColumns,
+		req.HTML, req.CSS,
+		req.Name, req.Description, req.HTML, req.CSS, req.Language, req.Currency, req.Theme,
+		isActive, req.InitialAmount, req.GoalAmount, goals, toMillis(time.Now()), render.HasRequiredFields(req.HTML),
+		barID, userID,
+	)
+	return scanBarRow(row)
+}
+
+// UpdateContent replaces a bar's HTML/CSS and counts the revision
+func (r *SQLiteBarRepository) UpdateContent(ctx context.Context, userID, barID, html, css string) (*models.DonationBar, error) {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	// RETURNING reads the counter back in the same statement so concurrent edits get distinct revisions
+	row := r.db.QueryRowContext(writeCtx, `UPDATE donation_bars SET
+		html = ?, css = ?, has_valid_injections = ?, updated_at = ?, revision = revision + 1
+		WHERE id = ? AND user_id = ? RETURNING `+barColumns,
+		html, css, render.HasRequiredFields(html), toMillis(time.Now()), barID, userID,
+	)
+	return scanBarRow(row)
+}
+
+// Delete removes a bar from the database
+func (r *SQLiteBarRepository) Delete(ctx context.Context, userID, barID string) error {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.db.ExecContext(writeCtx, `DELETE FROM donation_bars WHERE id = ? AND user_id = ?`, barID, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, apperrors.Missing("bar"))
+}
+
+// CountByUserID returns the total number of bars for a user
+func (r *SQLiteBarRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	if r.db == nil {
+		return 0, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var count int64
+	err := r.db.QueryRowContext(readCtx, `SELECT COUNT(*) FROM donation_bars WHERE user_id = ?`, userID).Scan(&count)
+	return count, err
+}
+
+// CountByFilter returns the number of a user's bars that pass the filter
+func (r *SQLiteBarRepository) CountByFilter(ctx context.Context, userID string, filter *models.BarFilter) (int64, error) {
+	if r.db == nil {
+		return 0, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	where, args := sqliteBarFilter(userID, filter)
+
+	var count int64
+	err := r.db.QueryRowContext(readCtx, `SELECT COUNT(*) FROM donation_bars WHERE `+where, args...).Scan(&count)
+	return count, err
+}
+
+// CountCreatedSince returns the number of bars the user created at or after since
+func (r *SQLiteBarRepository) CountCreatedSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var count int64
+	err := r.db.QueryRowContext(readCtx, `SELECT COUNT(*) FROM donation_bars
+		WHERE user_id = ? AND created_at >= ?`,
+		userID, toMillis(since),
+	).Scan(&count)
+	return count, err
+}
+
+// IncrementAmount adds delta to the bar's starting amount and returns the
+// updated bar in a single statement
+func (r *SQLiteBarRepository) IncrementAmount(ctx context.Context, userID, barID string, delta float64) (*models.DonationBar, error) {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	row := r.db.QueryRowContext(writeCtx, `UPDATE donation_bars SET
+		initial_amount = initial_amount + ?, updated_at = ?
+		WHERE id = ? AND user_id = ? RETURNING `+barColumns,
+		delta, toMillis(time.Now()), barID, userID,
+	)
+	return scanBarRow(row)
+}
+
+// AddDonationTotal adds a recorded donation to the bar's ledger total and
+// returns the updated bar in a single statement
+func (r *SQLiteBarRepository) AddDonationTotal(ctx context.Context, barID string, amount float64) (*models.DonationBar, error) {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	row := r.db.QueryRowContext(writeCtx, `UPDATE donation_bars SET
+		donation_total = donation_total + ?, updated_at = ?
+		WHERE id = ? RETURNING `+barColumns,
+		amount, toMillis(time.Now()), barID,
+	)
+	return scanBarRow(row)
+}
+
+// FindByOverlayToken returns the bar owning the given overlay token
+func (r *SQLiteBarRepository) FindByOverlayToken(ctx context.Context, token string) (*models.DonationBar, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	if token == "" {
+		return nil, apperrors.Missing("bar")
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	row := r.db.QueryRowContext(readCtx, `SELECT `+barColumns+` FROM donation_bars WHERE overlay_token = ?`, token)
+	return scanBarRow(row)
+}
+
+// SetOverlayToken replaces the overlay token of a bar
+func (r *SQLiteBarRepository) SetOverlayToken(ctx context.Context, userID, barID, token string) error {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.db.ExecContext(writeCtx, `UPDATE donation_bars SET overlay_token = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?`, token, toMillis(time.Now()), barID, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, apperrors.Missing("bar"))
+}
+
+// FindByWebhookToken returns the bar owning the given webhook token
+func (r *SQLiteBarRepository) FindByWebhookToken(ctx context.Context, token string) (*models.DonationBar, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	if token == "" {
+		return nil, apperrors.Missing("bar")
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	row := r.db.QueryRowContext(readCtx, `SELECT `+barColumns+` FROM donation_bars WHERE webhook_token = ?`, token)
+	return scanBarRow(row)
+}
+
+// SetWebhook replaces the webhook token and signing secret of a bar
+func (r *SQLiteBarRepository) SetWebhook(ctx context.Context, userID, barID, token, secret string) error {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.db.ExecContext(writeCtx, `UPDATE donation_bars SET webhook_token = ?, webhook_secret = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?`, token, secret, toMillis(time.Now()), barID, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, apperrors.Missing("bar"))
+}
+
+// scanBarRow scans a single bar, mapping a missing row to "bar not found"
+func scanBarRow(row *sql.Row) (*models.DonationBar, error) {
+	bar, err := scanBar(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Missing("bar")
+		}
+		return nil, err
+	}
+	return bar, nil
+}
+
+// scanBar scans the columns of barColumns into a bar
+func scanBar(s sqliteScanner) (*models.DonationBar, error) {
+	var (
+		bar                  models.DonationBar
+		id, goals            string
+		createdAt, updatedAt int64
+	)
+
+	err := s.Scan(
+		&id, &bar.UserID, &bar.Name, &bar.Description, &bar.HTML, &bar.CSS,
+		&bar.Language, &bar.Currency, &bar.Theme, &bar.IsActive,
+		&createdAt, &updatedAt, &bar.InitialAmount, &bar.GoalAmount, &bar.DonationTotal,
+		&bar.Prompt, &bar.AIGenerated, &bar.HasValidInjections, &bar.Revision, &bar.OverlayToken,
+		&bar.WebhookToken, &bar.WebhookSecret, &goals,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if bar.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	bar.CreatedAt = fromMillis(createdAt)
+	bar.UpdatedAt = fromMillis(updatedAt)
+	if goals != "[]" {
+		if err := json.Unmarshal([]byte(goals), &bar.Goals); err != nil {
+			return nil, err
+		}
+	}
+
+	return &bar, nil
+}
+
+// encodeGoals stores stretch goals as a JSON array, "[]" without any
+func encodeGoals(goals []models.Goal) (string, error) {
+	if len(goals) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(goals)
+	return string(data), err
+}
@@ -75,16 +75,19 @@ func TestBarRepository_Insert_WithNilDB(t *testing.T) {
 	}
 }
 
-func TestBarRepository_FindByUserID_WithNilDB(t *testing.T) {
+func TestBarRepository_ListByUserID_WithNilDB(t *testing.T) {
 	timeouts := createTestTimeoutConfig()
 	repo := NewBarRepository(nil, timeouts)
 
-	bars, err := repo.FindByUserID(context.Background(), "test-user")
+	opts := &models.BarListOptions{}
+	opts.Normalize()
+	page, err := repo.ListByUserID(context.Background(), "test-user", opts)
 
 	if err != nil {
 		t.Errorf("Expected no error with nil database, got %v", err)
 	}
 
+	bars := page.Bars
 	if bars == nil {
 		t.Error("Expected empty slice, got nil")
 	}
@@ -253,11 +256,11 @@ func TestBarRepository_CountByUserID_WithNilDB(t *testing.T) {
 	}
 }
 
-func TestBarRepository_CountByUserIDToday_WithNilDB(t *testing.T) {
+func TestBarRepository_CountCreatedSince_WithNilDB(t *testing.T) {
 	timeouts := createTestTimeoutConfig()
 	repo := NewBarRepository(nil, timeouts)
 
-	count, err := repo.CountByUserIDToday(context.Background(), "test-user")
+	count, err := repo.CountCreatedSince(context.Background(), "test-user", time.Now())
 
 	if err != nil {
 		t.Errorf("Expected no error with nil database, got %v", err)
@@ -0,0 +1,982 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/migrations"
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The contract tests run the same scenarios against every storage driver so
+// the backends cannot drift apart. Memory and SQLite always run; MongoDB runs
+// when MONGO_TEST_URI points at a server (a throwaway database is created).
+
+func contractBackends(t *testing.T) map[string]func(t *testing.T) *Stores {
+	backends := map[string]func(t *testing.T) *Stores{
+		config.StorageDriverMemory: openMemoryTestStores,
+		config.StorageDriverSQLite: openSQLiteTestStores,
+	}
+	if os.Getenv("MONGO_TEST_URI") != "" {
+		backends[config.StorageDriverMongo] = openMongoTestStores
+	}
+	return backends
+}
+
+func openMemoryTestStores(t *testing.T) *Stores {
+	return NewMemoryStores(nil, createTestTimeoutConfig())
+}
+
+func openSQLiteTestStores(t *testing.T) *Stores {
+	timeouts := createTestTimeoutConfig()
+
+	db, err := config.InitSQLite(filepath.Join(t.TempDir(), "test.db"), timeouts)
+	require.NoError(t, err)
+	require.NoError(t, MigrateSQLite(context.Background(), db))
+
+	stores := NewSQLiteStores(db, nil, timeouts)
+	t.Cleanup(func() { stores.Close() })
+	return stores
+}
+
+func openMongoTestStores(t *testing.T) *Stores {
+	timeouts := createTestTimeoutConfig()
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(os.Getenv("MONGO_TEST_URI")))
+	require.NoError(t, err)
+	require.NoError(t, client.Ping(ctx, nil))
+
+	name := fmt.Sprintf("donationbars_test_%s", primitive.NewObjectID().Hex())
+	db := &config.Database{Client: client, DB: client.Database(name)}
+
+	_, err = migrations.New(db).Up(ctx)
+	require.NoError(t, err)
+
+	stores := NewMongoStores(db, nil, timeouts)
+	t.Cleanup(func() {
+		db.DB.Drop(context.Background())
+		stores.Close()
+	})
+	return stores
+}
+
+func TestStoresContract(t *testing.T) {
+	for name, open := range contractBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Run("Bars", func(t *testing.T) { testBarContract(t, open(t)) })
+			t.Run("BarIncrements", func(t *testing.T) { testBarIncrementContract(t, open(t)) })
+			t.Run("BarGoals", func(t *testing.T) { testBarGoalsContract(t, open(t)) })
+			t.Run("BarListing", func(t *testing.T) { testBarListingContract(t, open(t)) })
+			t.Run("Donations", func(t *testing.T) { testDonationContract(t, open(t)) })
+			t.Run("Revisions", func(t *testing.T) { testRevisionContract(t, open(t)) })
+			t.Run("Users", func(t *testing.T) { testUserContract(t, open(t)) })
+			t.Run("Usage", func(t *testing.T) { testUsageContract(t, open(t)) })
+			t.Run("APIKeys", func(t *testing.T) { testAPIKeyContract(t, open(t)) })
+			t.Run("Sessions", func(t *testing.T) { testSessionContract(t, open(t)) })
+			t.Run("Jobs", func(t *testing.T) { testJobContract(t, open(t)) })
+			t.Run("BarEvents", func(t *testing.T) { testBarEventContract(t, open(t)) })
+			t.Run("OutgoingWebhooks", func(t *testing.T) { testOutgoingWebhookContract(t, open(t)) })
+			t.Run("WebhookDeliveries", func(t *testing.T) { testWebhookDeliveryContract(t, open(t)) })
+		})
+	}
+}
+
+// contractNow returns the current time at the millisecond precision both backends keep
+func contractNow() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func newContractBar(userID string) *models.DonationBar {
+	now := contractNow()
+	return &models.DonationBar{
+		ID:            primitive.NewObjectID(),
+		UserID:        userID,
+		Name:          "Yayın Hedefi",
+		Description:   "Yeni mikrofon",
+		HTML:          "<div>{goal} {total} {percentage} {remaining} {description}</div>",
+		CSS:           ".bar {\n  color: red;\n}",
+		Language:      "tr",
+		Currency:      "TRY",
+		Theme:         "modern",
+		IsActive:      true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		InitialAmount: 100,
+		GoalAmount:    1000,
+		Prompt:        "mor neon bar",
+		AIGenerated:   true,
+	}
+}
+
+func testBarContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Bars
+
+	bar := newContractBar("user-1")
+	require.NoError(t, repo.Insert(ctx, bar))
+	require.NoError(t, repo.Insert(ctx, newContractBar("user-2")))
+
+	found, err := repo.FindByID(ctx, "user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, bar.ID, found.ID)
+	assert.Equal(t, bar.Name, found.Name)
+	assert.Equal(t, bar.HTML, found.HTML)
+	assert.Equal(t, bar.CSS, found.CSS)
+	assert.Equal(t, bar.Currency, found.Currency)
+	assert.Equal(t, bar.InitialAmount, found.InitialAmount)
+	assert.Equal(t, bar.GoalAmount, found.GoalAmount)
+	assert.True(t, found.IsActive)
+	assert.True(t, found.AIGenerated)
+	assert.True(t, bar.CreatedAt.Equal(found.CreatedAt))
+
+	// Bars are scoped to their owner
+	_, err = repo.FindByID(ctx, "user-2", bar.ID.Hex())
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	assert.EqualError(t, err, "bar not found")
+	_, err = repo.FindByID(ctx, "user-1", primitive.NewObjectID().Hex())
+	assert.EqualError(t, err, "bar not found")
+	_, err = repo.FindByID(ctx, "user-1", "not-an-id")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidBarID)
+
+	page, err := repo.ListByUserID(ctx, "user-1", listOptions(models.BarListOptions{}))
+	require.NoError(t, err)
+	assert.Len(t, page.Bars, 1)
+
+	count, err := repo.CountByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	since, err := repo.CountCreatedSince(ctx, "user-1", bar.CreatedAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), since)
+	since, err = repo.CountCreatedSince(ctx, "user-1", bar.CreatedAt.Add(time.Millisecond))
+	require.NoError(t, err)
+	assert.Zero(t, since)
+
+	// Partial update only touches the given fields
+	name := "Yeni İsim"
+	inactive := false
+	updated, err := repo.Update(ctx, "user-1", bar.ID.Hex(), &models.UpdateBarRequest{Name: &name, IsActive: &inactive})
+	require.NoError(t, err)
+	assert.Equal(t, name, updated.Name)
+	assert.False(t, updated.IsActive)
+	assert.Equal(t, bar.Description, updated.Description)
+
+	_, err = repo.Update(ctx, "user-2", bar.ID.Hex(), &models.UpdateBarRequest{Name: &name})
+	assert.EqualError(t, err, "bar not found")
+
+	// A complete update counts a revision in the same write, only when the content changed
+	complete := &models.CreateBarRequest{
+		Name:       "Tam Güncelleme",
+		HTML:       "<div>{total}</div>",
+		CSS:        ".bar {\n  color: blue;\n}",
+		Language:   "en",
+		Currency:   "USD",
+		GoalAmount: 500,
+	}
+	completed, err := repo.UpdateComplete(ctx, "user-1", bar.ID.Hex(), complete, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, completed.Revision)
+	assert.Equal(t, "Tam Güncelleme", completed.Name)
+
+	found, err = repo.FindByID(ctx, "user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, "Tam Güncelleme", found.Name)
+	assert.Equal(t, "en", found.Language)
+	assert.Equal(t, 500.0, found.GoalAmount)
+	assert.False(t, found.HasValidInjections)
+	assert.Equal(t, 1, found.Revision)
+
+	complete.Name = "Sadece İsim"
+	completed, err = repo.UpdateComplete(ctx, "user-1", bar.ID.Hex(), complete, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, completed.Revision, "same content is not a new revision")
+	assert.Equal(t, "Sadece İsim", completed.Name)
+
+	_, err = repo.UpdateComplete(ctx, "user-2", bar.ID.Hex(), complete, true)
+	assert.EqualError(t, err, "bar not found")
+
+	// Content updates bump the revision counter and return the new state
+	first, err := repo.UpdateContent(ctx, "user-1", bar.ID.Hex(), bar.HTML, bar.CSS)
+	require.NoError(t, err)
+	assert.Equal(t, 2, first.Revision)
+	assert.True(t, first.HasValidInjections)
+	second, err := repo.UpdateContent(ctx, "user-1", bar.ID.Hex(), bar.HTML, bar.CSS)
+	require.NoError(t, err)
+	assert.Equal(t, 3, second.Revision)
+
+	_, err = repo.UpdateContent(ctx, "user-2", bar.ID.Hex(), bar.HTML, bar.CSS)
+	assert.EqualError(t, err, "bar not found")
+
+	// Donations add to the total and return the new state, without the owner
+	updated, err = repo.AddDonationTotal(ctx, bar.ID.Hex(), 40)
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, updated.DonationTotal)
+	updated, err = repo.AddDonationTotal(ctx, bar.ID.Hex(), 2.5)
+	require.NoError(t, err)
+	assert.Equal(t, 42.5, updated.DonationTotal)
+	found, err = repo.FindByID(ctx, "user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 42.5, found.DonationTotal)
+	_, err = repo.AddDonationTotal(ctx, primitive.NewObjectID().Hex(), 1)
+	assert.EqualError(t, err, "bar not found")
+
+	// Increments add to the starting amount of the owner's bar only
+	updated, err = repo.IncrementAmount(ctx, "user-1", bar.ID.Hex(), 25)
+	require.NoError(t, err)
+	assert.Equal(t, found.InitialAmount+25, updated.InitialAmount)
+	assert.Equal(t, 42.5, updated.DonationTotal)
+	_, err = repo.IncrementAmount(ctx, "user-2", bar.ID.Hex(), 25)
+	assert.EqualError(t, err, "bar not found")
+
+	// Overlay tokens resolve without the owner
+	require.NoError(t, repo.SetOverlayToken(ctx, "user-1", bar.ID.Hex(), "tok-123"))
+	byToken, err := repo.FindByOverlayToken(ctx, "tok-123")
+	require.NoError(t, err)
+	assert.Equal(t, bar.ID, byToken.ID)
+	_, err = repo.FindByOverlayToken(ctx, "")
+	assert.EqualError(t, err, "bar not found")
+	_, err = repo.FindByOverlayToken(ctx, "tok-unknown")
+	assert.EqualError(t, err, "bar not found")
+
+	// Webhook tokens resolve without the owner and carry the signing secret
+	assert.EqualError(t, repo.SetWebhook(ctx, "user-2", bar.ID.Hex(), "wh-123", "secret"), "bar not found")
+	require.NoError(t, repo.SetWebhook(ctx, "user-1", bar.ID.Hex(), "wh-123", "secret"))
+	byToken, err = repo.FindByWebhookToken(ctx, "wh-123")
+	require.NoError(t, err)
+	assert.Equal(t, bar.ID, byToken.ID)
+	assert.Equal(t, "secret", byToken.WebhookSecret)
+	_, err = repo.FindByWebhookToken(ctx, "")
+	assert.EqualError(t, err, "bar not found")
+
+	assert.EqualError(t, repo.Delete(ctx, "user-2", bar.ID.Hex()), "bar not found")
+	require.NoError(t, repo.Delete(ctx, "user-1", bar.ID.Hex()))
+	assert.EqualError(t, repo.Delete(ctx, "user-1", bar.ID.Hex()), "bar not found")
+}
+
+func testBarGoalsContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Bars
+
+	goals := []models.Goal{{Amount: 500, Label: "Mikrofon"}, {Amount: 1000, Label: "Kamera"}}
+	bar := newContractBar("user-1")
+	bar.Goals = goals
+	require.NoError(t, repo.Insert(ctx, bar))
+	plain := newContractBar("user-1")
+	require.NoError(t, repo.Insert(ctx, plain))
+
+	found, err := repo.FindByID(ctx, "user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, goals, found.Goals)
+	found, err = repo.FindByID(ctx, "user-1", plain.ID.Hex())
+	require.NoError(t, err)
+	assert.Empty(t, found.Goals)
+
+	// Updates without goals keep them, amounts change around them
+	updated, err := repo.IncrementAmount(ctx, "user-1", bar.ID.Hex(), 450)
+	require.NoError(t, err)
+	assert.Equal(t, goals, updated.Goals)
+	assert.Equal(t, "Kamera", updated.CurrentStage().Label)
+
+	replaced := []models.Goal{{Amount: 2000, Label: "Bilgisayar"}}
+	updated, err = repo.Update(ctx, "user-1", bar.ID.Hex(), &models.UpdateBarRequest{Goals: &replaced})
+	require.NoError(t, err)
+	assert.Equal(t, replaced, updated.Goals)
+
+	updated, err = repo.Update(ctx, "user-1", bar.ID.Hex(), &models.UpdateBarRequest{Goals: &[]models.Goal{}})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Goals)
+
+	complete := &models.CreateBarRequest{
+		Name:       "Aşamalı",
+		HTML:       bar.HTML,
+		CSS:        bar.CSS,
+		Language:   "tr",
+		GoalAmount: 1000,
+		Goals:      goals,
+	}
+	_, err = repo.UpdateComplete(ctx, "user-1", bar.ID.Hex(), complete, true)
+	require.NoError(t, err)
+	found, err = repo.FindByID(ctx, "user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, goals, found.Goals)
+
+	complete.Goals = nil
+	_, err = repo.UpdateComplete(ctx, "user-1", bar.ID.Hex(), complete, true)
+	require.NoError(t, err)
+	found, err = repo.FindByID(ctx, "user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Empty(t, found.Goals)
+}
+
+// testBarIncrementContract fires concurrent increments at one bar; a
+// read-modify-write would lose some of them
+func testBarIncrementContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Bars
+
+	bar := newContractBar("user-1")
+	require.NoError(t, repo.Insert(ctx, bar))
+
+	const workers = 300
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.IncrementAmount(ctx, "user-1", bar.ID.Hex(), float64(i%5+1))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Each of the five deltas 1..5 is used workers/5 times
+	found, err := repo.FindByID(ctx, "user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, bar.InitialAmount+float64(workers/5*15), found.InitialAmount)
+}
+
+// listOptions normalizes listing options the way the bar service does
+func listOptions(opts models.BarListOptions) *models.BarListOptions {
+	if err := opts.Normalize(); err != nil {
+		panic(err)
+	}
+	return &opts
+}
+
+func testBarListingContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Bars
+	start := contractNow().Add(-time.Hour)
+
+	newBar := func(name, description string, offset time.Duration, active, ai bool, language, theme string, initial, goal float64) {
+		bar := newContractBar("user-1")
+		bar.Name, bar.Description = name, description
+		bar.CreatedAt, bar.UpdatedAt = start.Add(offset), start.Add(offset)
+		bar.IsActive, bar.AIGenerated = active, ai
+		bar.Language, bar.Theme = language, theme
+		bar.InitialAmount, bar.GoalAmount = initial, goal
+		require.NoError(t, repo.Insert(ctx, bar))
+	}
+	newBar("Alpha", "Yayın hedefi", 0, true, false, "tr", "neon", 10, 100)               // progress 0.1
+	newBar("bravo", "Mikrofon", time.Second, false, true, "en", "", 50, 100)             // progress 0.5
+	newBar("Charlie", "Kamera", 2*time.Second, true, true, "tr", "neon", 100, 200)       // progress 0.5
+	newBar("delta 50%", "Hedef %50_test", 3*time.Second, true, false, "en", "", 90, 100) // progress 0.9
+	require.NoError(t, repo.Insert(ctx, newContractBar("user-2")))
+
+	// Alpha was edited last
+	alpha, err := repo.ListByUserID(ctx, "user-1", listOptions(models.BarListOptions{BarFilter: models.BarFilter{Search: "alpha"}}))
+	require.NoError(t, err)
+	require.Len(t, alpha.Bars, 1)
+	_, err = repo.Update(ctx, "user-1", alpha.Bars[0].ID.Hex(), &models.UpdateBarRequest{})
+	require.NoError(t, err)
+
+	// listAll follows the cursors to the end and returns the names in order
+	listAll := func(opts models.BarListOptions) []string {
+		t.Helper()
+		var names []string
+		for page := 0; ; page++ {
+			require.Less(t, page, 10, "cursor did not advance")
+			result, err := repo.ListByUserID(ctx, "user-1", listOptions(opts))
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(result.Bars), listOptions(opts).Limit)
+			for _, bar := range result.Bars {
+				names = append(names, bar.Name)
+			}
+			if result.NextCursor == "" {
+				return names
+			}
+			opts.Cursor = result.NextCursor
+		}
+	}
+
+	all := []string{"Alpha", "bravo", "Charlie", "delta 50%"}
+	assert.Equal(t, all, listAll(models.BarListOptions{}))
+	assert.Equal(t, all, listAll(models.BarListOptions{Limit: 1}))
+	assert.Equal(t, []string{"delta 50%", "Charlie", "bravo", "Alpha"}, listAll(models.BarListOptions{Order: models.SortDesc, Limit: 3}))
+	assert.Equal(t, []string{"bravo", "Charlie", "delta 50%", "Alpha"}, listAll(models.BarListOptions{Sort: models.BarSortUpdatedAt, Limit: 2}))
+	assert.Equal(t, []string{"Alpha", "Charlie", "bravo", "delta 50%"}, listAll(models.BarListOptions{Sort: models.BarSortName, Limit: 2}))
+
+	// bravo and Charlie are tied on progress; the ID breaks the tie across the page boundary
+	assert.Equal(t, []string{"delta 50%", "Charlie", "bravo", "Alpha"}, listAll(models.BarListOptions{Sort: models.BarSortProgress, Order: models.SortDesc, Limit: 2}))
+	assert.Equal(t, []string{"Alpha", "bravo", "Charlie", "delta 50%"}, listAll(models.BarListOptions{Sort: models.BarSortProgress, Limit: 2}))
+
+	active, aiGenerated := true, true
+	filters := []struct {
+		name   string
+		filter models.BarFilter
+		want   []string
+	}{
+		{"active", models.BarFilter{Active: &active}, []string{"Alpha", "Charlie", "delta 50%"}},
+		{"ai generated", models.BarFilter{AIGenerated: &aiGenerated}, []string{"bravo", "Charlie"}},
+		{"active and ai generated", models.BarFilter{Active: &active, AIGenerated: &aiGenerated}, []string{"Charlie"}},
+		{"language", models.BarFilter{Language: "en"}, []string{"bravo", "delta 50%"}},
+		{"theme", models.BarFilter{Theme: "neon"}, []string{"Alpha", "Charlie"}},
+		{"search is case-insensitive", models.BarFilter{Search: "CHAR"}, []string{"Charlie"}},
+		{"search covers the description", models.BarFilter{Search: "hedef"}, []string{"Alpha", "delta 50%"}},
+		{"search treats wildcards literally", models.BarFilter{Search: "%50_"}, []string{"delta 50%"}},
+		{"no match", models.BarFilter{Search: "nothing"}, nil},
+	}
+	for _, tt := range filters {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, listAll(models.BarListOptions{BarFilter: tt.filter, Limit: 1}))
+
+			count, err := repo.CountByFilter(ctx, "user-1", &tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+}
+
+func testDonationContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Donations
+	barID := primitive.NewObjectID()
+
+	total, err := repo.SumByBarID(ctx, barID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, total)
+
+	base := contractNow()
+	for i, amount := range []float64{10, 25.5, 4.5} {
+		require.NoError(t, repo.Insert(ctx, &models.Donation{
+			ID:        primitive.NewObjectID(),
+			BarID:     barID,
+			UserID:    "user-1",
+			DonorName: fmt.Sprintf("donor-%d", i),
+			Amount:    amount,
+			Currency:  "TRY",
+			Source:    models.DefaultDonationSource,
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	donations, err := repo.FindByBarID(ctx, barID.Hex())
+	require.NoError(t, err)
+	require.Len(t, donations, 3)
+	assert.Equal(t, "donor-2", donations[0].DonorName, "newest first")
+	assert.Equal(t, barID, donations[0].BarID)
+
+	total, err = repo.SumByBarID(ctx, barID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, total)
+
+	_, err = repo.FindByBarID(ctx, "not-an-id")
+	assert.EqualError(t, err, "invalid bar ID format")
+
+	// A webhook event is recorded once per bar and source
+	webhook := func(bar primitive.ObjectID, source, externalID string) *models.Donation {
+		return &models.Donation{
+			ID:         primitive.NewObjectID(),
+			BarID:      bar,
+			UserID:     "user-1",
+			Amount:     1,
+			Currency:   "TRY",
+			Source:     source,
+			ExternalID: externalID,
+			CreatedAt:  base,
+		}
+	}
+	require.NoError(t, repo.Insert(ctx, webhook(barID, "streamlabs", "evt-1")))
+	assert.ErrorIs(t, repo.Insert(ctx, webhook(barID, "streamlabs", "evt-1")), apperrors.ErrConflict)
+	require.NoError(t, repo.Insert(ctx, webhook(barID, "generic", "evt-1")))
+	require.NoError(t, repo.Insert(ctx, webhook(primitive.NewObjectID(), "streamlabs", "evt-1")))
+
+	donations, err = repo.FindByBarID(ctx, barID.Hex())
+	require.NoError(t, err)
+	require.Len(t, donations, 5)
+	var external []string
+	for _, donation := range donations {
+		external = append(external, donation.ExternalID)
+	}
+	assert.ElementsMatch(t, []string{"", "", "", "evt-1", "evt-1"}, external)
+
+	// A rolled back event frees its external ID for the provider's redelivery
+	rolledBack := webhook(barID, "streamlabs", "evt-2")
+	require.NoError(t, repo.Insert(ctx, rolledBack))
+	require.NoError(t, repo.Delete(ctx, rolledBack.ID.Hex()))
+	assert.EqualError(t, repo.Delete(ctx, rolledBack.ID.Hex()), "donation not found")
+	require.NoError(t, repo.Insert(ctx, webhook(barID, "streamlabs", "evt-2")))
+}
+
+func testRevisionContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Revisions
+	barID := primitive.NewObjectID()
+
+	restoredFrom := 1
+	for i, source := range []string{models.RevisionSourceManual, models.RevisionSourceAIRefine, models.RevisionSourceRestore} {
+		revision := &models.BarRevision{
+			ID:        primitive.NewObjectID(),
+			BarID:     barID,
+			UserID:    "user-1",
+			Revision:  i + 1,
+			AuthorID:  "user-1",
+			Source:    source,
+			HTML:      fmt.Sprintf("<div>%d</div>", i),
+			CSS:       ".bar {\n  color: red;\n}",
+			Summary:   models.RevisionSummary{HTMLAdded: i, HTMLRemoved: 1},
+			CreatedAt: contractNow(),
+		}
+		if source == models.RevisionSourceRestore {
+			revision.RestoredFrom = &restoredFrom
+		}
+		require.NoError(t, repo.Insert(ctx, revision))
+	}
+
+	revisions, err := repo.FindByBarID(ctx, barID.Hex())
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, 3, revisions[0].Revision, "newest first")
+	require.NotNil(t, revisions[0].RestoredFrom)
+	assert.Equal(t, 1, *revisions[0].RestoredFrom)
+	assert.Nil(t, revisions[1].RestoredFrom)
+
+	revision, err := repo.FindByNumber(ctx, barID.Hex(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, models.RevisionSourceAIRefine, revision.Source)
+	assert.Equal(t, models.RevisionSummary{HTMLAdded: 1, HTMLRemoved: 1}, revision.Summary)
+
+	_, err = repo.FindByNumber(ctx, barID.Hex(), 9)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	assert.EqualError(t, err, "revision not found")
+
+	count, err := repo.CountByBarID(ctx, barID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func testUserContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Users
+
+	user := &models.User{
+		ID:           primitive.NewObjectID(),
+		Email:        "streamer@example.com",
+		DisplayName:  "Streamer",
+		PasswordHash: "hash",
+		CreatedAt:    contractNow(),
+	}
+	require.NoError(t, repo.Insert(ctx, user))
+
+	found, err := repo.FindByEmail(ctx, "Streamer@Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.Equal(t, "hash", found.PasswordHash)
+
+	found, err = repo.FindByID(ctx, user.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, found.Email)
+	assert.Empty(t, found.Plan)
+
+	_, err = repo.FindByEmail(ctx, "nobody@example.com")
+	assert.EqualError(t, err, "user not found")
+	_, err = repo.FindByID(ctx, "not-an-id")
+	assert.EqualError(t, err, "user not found")
+
+	require.NoError(t, repo.SetPlan(ctx, user.ID.Hex(), models.PlanPro))
+	found, err = repo.FindByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, models.PlanPro, found.Plan)
+
+	err = repo.SetPlan(ctx, primitive.NewObjectID().Hex(), models.PlanPro)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func testUsageContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Usage
+
+	usage, err := repo.Find(ctx, "user-1", "2024-03")
+	require.NoError(t, err)
+	assert.Zero(t, usage.Generations)
+	assert.Zero(t, usage.Tokens)
+
+	require.NoError(t, repo.Add(ctx, "user-1", "2024-03", 1, 0))
+	require.NoError(t, repo.Add(ctx, "user-1", "2024-03", 0, 1500))
+	require.NoError(t, repo.Add(ctx, "user-1", "2024-03", 1, 200))
+	require.NoError(t, repo.Add(ctx, "user-1", "2024-03-05", 1, 200))
+	require.NoError(t, repo.Add(ctx, "user-2", "2024-03", 7, 7))
+
+	usage, err = repo.Find(ctx, "user-1", "2024-03")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03", usage.Period)
+	assert.Equal(t, int64(2), usage.Generations)
+	assert.Equal(t, int64(1700), usage.Tokens)
+
+	usage, err = repo.Find(ctx, "user-1", "2024-03-05")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), usage.Generations)
+}
+
+func testAPIKeyContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.APIKeys
+
+	base := contractNow()
+	older := &models.APIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    "user-1",
+		Name:      "OBS",
+		Prefix:    "dbk_aaaa",
+		KeyHash:   "hash-1",
+		Scopes:    []string{models.ScopeBarsRead},
+		CreatedAt: base,
+	}
+	newer := &models.APIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    "user-1",
+		Name:      "Bot",
+		Prefix:    "dbk_bbbb",
+		KeyHash:   "hash-2",
+		Scopes:    []string{models.ScopeBarsRead, models.ScopeDonationsWrite},
+		CreatedAt: base.Add(time.Second),
+	}
+	require.NoError(t, repo.Insert(ctx, older))
+	require.NoError(t, repo.Insert(ctx, newer))
+
+	keys, err := repo.FindByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, newer.ID, keys[0].ID, "newest first")
+	assert.Equal(t, newer.Scopes, keys[0].Scopes)
+
+	found, err := repo.FindByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, older.ID, found.ID)
+	assert.Nil(t, found.LastUsedAt)
+
+	require.NoError(t, repo.TouchLastUsed(ctx, older.ID.Hex()))
+	found, err = repo.FindByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.NotNil(t, found.LastUsedAt)
+
+	// Only the owner can revoke, and only once
+	assert.EqualError(t, repo.Revoke(ctx, "user-2", older.ID.Hex()), "api key not found")
+	require.NoError(t, repo.Revoke(ctx, "user-1", older.ID.Hex()))
+	assert.EqualError(t, repo.Revoke(ctx, "user-1", older.ID.Hex()), "api key not found")
+	assert.EqualError(t, repo.Revoke(ctx, "user-1", "not-an-id"), "api key not found")
+
+	_, err = repo.FindByHash(ctx, "hash-1")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	keys, err = repo.FindByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, keys, 2, "revoked keys stay listed")
+	assert.NotNil(t, keys[1].RevokedAt)
+}
+
+func testSessionContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Sessions
+	now := contractNow()
+
+	require.NoError(t, repo.Create(ctx, &models.Session{ID: "live", UserID: "user-1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, repo.Create(ctx, &models.Session{ID: "expired", UserID: "user-1", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}))
+
+	session, err := repo.Find(ctx, "live")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", session.UserID)
+	assert.True(t, now.Add(time.Hour).Equal(session.ExpiresAt))
+
+	_, err = repo.Find(ctx, "expired")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	require.NoError(t, repo.Delete(ctx, "live"))
+	_, err = repo.Find(ctx, "live")
+	assert.EqualError(t, err, "session not found")
+}
+
+func testJobContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Jobs
+	now := contractNow()
+
+	job := &models.GenerationJob{
+		ID:     primitive.NewObjectID(),
+		UserID: "user-1",
+		Kind:   models.JobKindGenerate,
+		Status: models.JobStatusQueued,
+		Request: models.GenerateBarRequest{
+			Prompt:     "mor neon bar, sade",
+			Language:   "tr",
+			GoalAmount: 1000,
+			Variations: 2,
+		},
+		SaveBar:   true,
+		CreatedAt: now,
+		ExpiresAt: now.Add(24 * time.Hour),
+	}
+	require.NoError(t, repo.Create(ctx, job))
+
+	found, err := repo.Find(ctx, job.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, job.Request, found.Request)
+	assert.Nil(t, found.StartedAt)
+
+	_, err = repo.Find(ctx, "not-an-id")
+	assert.EqualError(t, err, "job not found")
+	_, err = repo.Find(ctx, primitive.NewObjectID().Hex())
+	assert.EqualError(t, err, "job not found")
+
+	unfinished, err := repo.FindUnfinished(ctx)
+	require.NoError(t, err)
+	require.Len(t, unfinished, 1)
+
+	// A claimed job cannot be claimed again until it goes stale
+	claimed, err := repo.Claim(ctx, job.ID.Hex(), now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusRunning, claimed.Status)
+	assert.Equal(t, 1, claimed.Attempts)
+	assert.NotNil(t, claimed.StartedAt)
+
+	_, err = repo.Claim(ctx, job.ID.Hex(), now.Add(-time.Minute))
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+
+	reclaimed, err := repo.Claim(ctx, job.ID.Hex(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, reclaimed.Attempts)
+
+	finishedAt := contractNow()
+	first := &models.AIGenerateResponse{HTML: "<div>1</div>", CSS: ".a {\n  color: red;\n}", Metadata: models.AIGenerateMetadata{Language: "tr"}}
+	second := &models.AIGenerateResponse{HTML: "<div>2</div>", CSS: ".b {\n  color: blue;\n}", Metadata: models.AIGenerateMetadata{Language: "tr"}}
+	reclaimed.Status = models.JobStatusSucceeded
+	reclaimed.Result = first
+	reclaimed.Results = []*models.AIGenerateResponse{first, second}
+	reclaimed.BarID = primitive.NewObjectID().Hex()
+	reclaimed.FinishedAt = &finishedAt
+	require.NoError(t, repo.Finish(ctx, reclaimed))
+
+	found, err = repo.Find(ctx, job.ID.Hex())
+	require.NoError(t, err)
+	assert.True(t, found.IsFinished())
+	assert.Equal(t, first, found.Result)
+	assert.Equal(t, []*models.AIGenerateResponse{first, second}, found.Results)
+	assert.Equal(t, reclaimed.BarID, found.BarID)
+	require.NotNil(t, found.FinishedAt)
+	assert.True(t, finishedAt.Equal(*found.FinishedAt))
+
+	unfinished, err = repo.FindUnfinished(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, unfinished)
+
+	_, err = repo.Claim(ctx, job.ID.Hex(), time.Now().Add(time.Minute))
+	assert.EqualError(t, err, "job not claimable")
+}
+
+func testBarEventContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Events
+
+	bar := newContractBar("user-1")
+	bar.InitialAmount = 500
+
+	event := models.NewBarEvent(bar, bar.CurrentStage(), 50)
+	event.CreatedAt = contractNow()
+	require.NoError(t, repo.Insert(ctx, event))
+	assert.Equal(t, models.EventMilestoneReached, event.Type)
+
+	// A milestone is recorded once per campaign
+	err := repo.Insert(ctx, models.NewBarEvent(bar, bar.CurrentStage(), 50))
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+
+	require.NoError(t, repo.Insert(ctx, models.NewBarEvent(bar, bar.CurrentStage(), 100)))
+
+	// A new goal is a new campaign
+	bar.GoalAmount = 2000
+	require.NoError(t, repo.Insert(ctx, models.NewBarEvent(bar, bar.CurrentStage(), 50)))
+}
+
+func testOutgoingWebhookContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Hooks
+	barID := primitive.NewObjectID()
+
+	hook := &models.OutgoingWebhook{
+		ID:         primitive.NewObjectID(),
+		BarID:      barID,
+		UserID:     "user-1",
+		URL:        "https://example.com/hook",
+		Secret:     "whsec_test",
+		Milestones: []int{25, 50},
+		Format:     models.HookFormatDiscord,
+		Language:   "en",
+		Template:   "{bar} hit {milestone}%!",
+		CreatedAt:  contractNow(),
+	}
+	require.NoError(t, repo.Insert(ctx, hook))
+
+	goalOnly := &models.OutgoingWebhook{
+		ID:         primitive.NewObjectID(),
+		BarID:      barID,
+		UserID:     "user-1",
+		URL:        "https://example.com/goal",
+		Secret:     "whsec_goal",
+		Milestones: []int{},
+		CreatedAt:  contractNow().Add(time.Second),
+	}
+	require.NoError(t, repo.Insert(ctx, goalOnly))
+
+	found, err := repo.Find(ctx, hook.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, hook.URL, found.URL)
+	assert.Equal(t, hook.Secret, found.Secret)
+	assert.Equal(t, []int{25, 50}, found.Milestones)
+	assert.Equal(t, models.HookFormatDiscord, found.BodyFormat())
+	assert.Equal(t, "en", found.Language)
+	assert.Equal(t, hook.Template, found.Template)
+	assert.True(t, hook.CreatedAt.Equal(found.CreatedAt))
+
+	_, err = repo.Find(ctx, primitive.NewObjectID().Hex())
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	hooks, err := repo.FindByBarID(ctx, barID.Hex())
+	require.NoError(t, err)
+	require.Len(t, hooks, 2)
+	assert.Equal(t, hook.ID, hooks[0].ID)
+	assert.Empty(t, hooks[1].Milestones)
+	assert.Equal(t, models.HookFormatJSON, hooks[1].BodyFormat())
+
+	// Only the owner of the bar can delete
+	err = repo.Delete(ctx, "user-2", barID.Hex(), hook.ID.Hex())
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	err = repo.Delete(ctx, "user-1", primitive.NewObjectID().Hex(), hook.ID.Hex())
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	require.NoError(t, repo.Delete(ctx, "user-1", barID.Hex(), hook.ID.Hex()))
+	hooks, err = repo.FindByBarID(ctx, barID.Hex())
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Equal(t, goalOnly.ID, hooks[0].ID)
+}
+
+func testWebhookDeliveryContract(t *testing.T, stores *Stores) {
+	ctx := context.Background()
+	repo := stores.Deliveries
+	barID := primitive.NewObjectID()
+	now := contractNow()
+
+	newDelivery := func(createdAt time.Time) *models.WebhookDelivery {
+		return &models.WebhookDelivery{
+			ID:            primitive.NewObjectID(),
+			HookID:        primitive.NewObjectID(),
+			BarID:         barID,
+			UserID:        "user-1",
+			EventID:       primitive.NewObjectID(),
+			EventType:     models.EventGoalReached,
+			Milestone:     models.GoalMilestone,
+			URL:           "https://example.com/hook",
+			Payload:       `{"type":"goal_reached"}`,
+			Status:        models.DeliveryStatusPending,
+			Attempts:      []models.DeliveryAttempt{},
+			NextAttemptAt: createdAt,
+			CreatedAt:     createdAt,
+			UpdatedAt:     createdAt,
+		}
+	}
+
+	first := newDelivery(now.Add(-time.Minute))
+	second := newDelivery(now)
+	later := newDelivery(now)
+	later.NextAttemptAt = now.Add(time.Hour)
+	for _, delivery := range []*models.WebhookDelivery{first, second, later} {
+		require.NoError(t, repo.Insert(ctx, delivery))
+	}
+
+	due, err := repo.FindDue(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, first.ID, due[0].ID)
+	assert.Equal(t, second.ID, due[1].ID)
+
+	due, err = repo.FindDue(ctx, now, 1)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	// A claimed delivery is leased and cannot be claimed again until the lease ends
+	lease := now.Add(time.Minute)
+	claimed, err := repo.Claim(ctx, first.ID.Hex(), now, lease)
+	require.NoError(t, err)
+	assert.Equal(t, first.Payload, claimed.Payload)
+	assert.True(t, lease.Equal(claimed.NextAttemptAt))
+
+	_, err = repo.Claim(ctx, first.ID.Hex(), now, lease)
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+	_, err = repo.Claim(ctx, later.ID.Hex(), now, lease)
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+
+	_, err = repo.Claim(ctx, first.ID.Hex(), lease, lease.Add(time.Minute))
+	require.NoError(t, err)
+
+	claimed.Status = models.DeliveryStatusSucceeded
+	claimed.Attempts = append(claimed.Attempts, models.DeliveryAttempt{At: now, StatusCode: 200, DurationMS: 12})
+	claimed.UpdatedAt = now
+	require.NoError(t, repo.Save(ctx, claimed))
+
+	_, err = repo.Claim(ctx, first.ID.Hex(), now.Add(time.Hour), now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+
+	log, err := repo.FindByBarID(ctx, barID.Hex(), 10)
+	require.NoError(t, err)
+	require.Len(t, log, 3)
+	assert.Equal(t, first.ID, log[2].ID)
+	assert.Equal(t, models.DeliveryStatusSucceeded, log[2].Status)
+	require.Len(t, log[2].Attempts, 1)
+	assert.Equal(t, 200, log[2].Attempts[0].StatusCode)
+	assert.True(t, now.Equal(log[2].Attempts[0].At))
+
+	log, err = repo.FindByBarID(ctx, primitive.NewObjectID().Hex(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, log)
+}
+
+func TestMigrateSQLite_IsIdempotent(t *testing.T) {
+	timeouts := createTestTimeoutConfig()
+	db, err := config.InitSQLite(filepath.Join(t.TempDir(), "test.db"), timeouts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	require.NoError(t, MigrateSQLite(ctx, db))
+	require.NoError(t, MigrateSQLite(ctx, db))
+
+	var version int
+	require.NoError(t, db.DB.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version))
+	assert.Equal(t, len(sqliteMigrations), version)
+}
+
+func TestUserRepository_Insert_DuplicateEmail(t *testing.T) {
+	for name, open := range contractBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			stores := open(t)
+			ctx := context.Background()
+
+			user := &models.User{Email: "dup@example.com", DisplayName: "Dup", PasswordHash: "hash", CreatedAt: contractNow()}
+			require.NoError(t, stores.Users.Insert(ctx, user))
+
+			again := &models.User{Email: "dup@example.com", DisplayName: "Dup", PasswordHash: "hash", CreatedAt: contractNow()}
+			err := stores.Users.Insert(ctx, again)
+			assert.ErrorIs(t, err, apperrors.ErrConflict)
+			assert.EqualError(t, err, "email already registered")
+		})
+	}
+}
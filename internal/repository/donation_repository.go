@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DonationRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewDonationRepository creates a new donation ledger repository
+func NewDonationRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.DonationRepositoryInterface {
+	repo := &DonationRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("donations")
+	}
+	return repo
+}
+
+// Insert appends a donation to the ledger
+func (r *DonationRepository) Insert(ctx context.Context, donation *models.Donation) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(writeCtx, donation)
+	if mongo.IsDuplicateKeyError(err) {
+		return apperrors.Wrap(apperrors.ErrConflict, "donation already recorded")
+	}
+	return err
+}
+
+// Delete removes a donation from the ledger
+func (r *DonationRepository) Delete(ctx context.Context, donationID string) error {
+	objectID, err := primitive.ObjectIDFromHex(donationID)
+	if err != nil {
+		return apperrors.InvalidInput("donation ID", donationID)
+	}
+
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(writeCtx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return apperrors.Missing("donation")
+	}
+	return nil
+}
+
+// FindByBarID returns all donations of a bar, newest first
+func (r *DonationRepository) FindByBarID(ctx context.Context, barID string) ([]*models.Donation, error) {
+	if r.collection == nil {
+		return []*models.Donation{}, nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(readCtx, bson.M{"bar_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	donations := []*models.Donation{}
+	if err = cursor.All(readCtx, &donations); err != nil {
+		return nil, err
+	}
+
+	return donations, nil
+}
+
+// SumByBarID returns the sum of all donation amounts of a bar
+func (r *DonationRepository) SumByBarID(ctx context.Context, barID string) (float64, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return 0, apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return 0, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"bar_id": objectID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(readCtx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Total float64 `bson:"total"`
+	}
+	if err = cursor.All(readCtx, &result); err != nil {
+		return 0, err
+	}
+
+	if len(result) == 0 {
+		return 0, nil
+	}
+
+	return result[0].Total, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SQLiteDonationRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteDonationRepository creates a new SQLite backed donation ledger repository
+func NewSQLiteDonationRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.DonationRepositoryInterface {
+	repo := &SQLiteDonationRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Insert appends a donation to the ledger
+func (r *SQLiteDonationRepository) Insert(ctx context.Context, donation *models.Donation) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if donation.ID.IsZero() {
+		donation.ID = primitive.NewObjectID()
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.db.ExecContext(writeCtx, `INSERT INTO donations
+		(id, bar_id, user_id, donor_name, amount, currency, message, source, created_at, external_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		donation.ID.Hex(), donation.BarID.Hex(), donation.UserID, donation.DonorName, donation.Amount,
+		donation.Currency, donation.Message, donation.Source, toMillis(donation.CreatedAt), donation.ExternalID,
+	)
+	if isUniqueViolation(err) {
+		return apperrors.Wrap(apperrors.ErrConflict, "donation already recorded")
+	}
+	return err
+}
+
+// Delete removes a donation from the ledger
+func (r *SQLiteDonationRepository) Delete(ctx context.Context, donationID string) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.db.ExecContext(writeCtx, `DELETE FROM donations WHERE id = ?`, donationID)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, apperrors.Missing("donation"))
+}
+
+// FindByBarID returns all donations of a bar, newest first
+func (r *SQLiteDonationRepository) FindByBarID(ctx context.Context, barID string) ([]*models.Donation, error) {
+	if r.db == nil {
+		return []*models.Donation{}, nil
+	}
+
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(readCtx, `SELECT id, bar_id, user_id, donor_name, amount, currency, message, source, created_at, external_id
+		FROM donations WHERE bar_id = ? ORDER BY created_at DESC, rowid DESC`, barID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	donations := []*models.Donation{}
+	for rows.Next() {
+		var (
+			donation  models.Donation
+			id, bar   string
+			createdAt int64
+		)
+		err := rows.Scan(&id, &bar, &donation.UserID, &donation.DonorName, &donation.Amount,
+			&donation.Currency, &donation.Message, &donation.Source, &createdAt, &donation.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		if donation.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+			return nil, err
+		}
+		if donation.BarID, err = primitive.ObjectIDFromHex(bar); err != nil {
+			return nil, err
+		}
+		donation.CreatedAt = fromMillis(createdAt)
+		donations = append(donations, &donation)
+	}
+
+	return donations, rows.Err()
+}
+
+// SumByBarID returns the sum of all donation amounts of a bar
+func (r *SQLiteDonationRepository) SumByBarID(ctx context.Context, barID string) (float64, error) {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return 0, apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return 0, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var total float64
+	err := r.db.QueryRowContext(readCtx, `SELECT COALESCE(SUM(amount), 0) FROM donations WHERE bar_id = ?`, barID).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Redis keys of the job store
+const (
+	jobKeyPrefix      = "job:"
+	unfinishedJobsKey = "jobs:unfinished"
+)
+
+// NewJobStore returns a Redis job store when Redis is available, MongoDB otherwise
+func NewJobStore(db *config.Database, redisClient *config.RedisClient, timeouts config.TimeoutConfig) interfaces.JobStoreInterface {
+	if redisClient != nil && redisClient.IsEnabled() {
+		slog.Info("Job store initialized", "backend", "redis")
+		return &RedisJobStore{client: redisClient.Client, timeout: timeouts.RedisOperation}
+	}
+
+	slog.Info("Job store initialized", "backend", "mongodb")
+	return NewJobRepository(db, timeouts)
+}
+
+type JobRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewJobRepository creates a MongoDB backed job store
+func NewJobRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.JobStoreInterface {
+	repo := &JobRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("ai_jobs")
+	}
+	return repo
+}
+
+// Create stores a new job
+func (r *JobRepository) Create(ctx context.Context, job *models.GenerationJob) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(writeCtx, job)
+	return err
+}
+
+// Find returns a job by ID
+func (r *JobRepository) Find(ctx context.Context, jobID string) (*models.GenerationJob, error) {
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, apperrors.Missing("job")
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var job models.GenerationJob
+	err = r.collection.FindOne(readCtx, bson.M{"_id": objectID}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("job")
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Claim atomically moves a queued job, or a running job started before
+// staleBefore, to running and counts the attempt
+func (r *JobRepository) Claim(ctx context.Context, jobID string, staleBefore time.Time) (*models.GenerationJob, error) {
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, apperrors.Missing("job")
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	filter := bson.M{
+		"_id": objectID,
+		"$or": bson.A{
+			bson.M{"status": models.JobStatusQueued},
+			bson.M{"status": models.JobStatusRunning, "started_at": bson.M{"$lt": staleBefore}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": models.JobStatusRunning, "started_at": time.Now()},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.GenerationJob
+	err = r.collection.FindOneAndUpdate(writeCtx, filter, update, opts).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Wrap(apperrors.ErrConflict, "job not claimable")
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Finish stores the outcome of a job
+func (r *JobRepository) Finish(ctx context.Context, job *models.GenerationJob) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":      job.Status,
+			"result":      job.Result,
+			"results":     job.Results,
+			"bar_id":      job.BarID,
+			"error":       job.Error,
+			"finished_at": job.FinishedAt,
+			"expires_at":  job.ExpiresAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(writeCtx, bson.M{"_id": job.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return apperrors.Missing("job")
+	}
+
+	return nil
+}
+
+// FindUnfinished returns queued and running jobs, oldest first
+func (r *JobRepository) FindUnfinished(ctx context.Context) ([]*models.GenerationJob, error) {
+	if r.collection == nil {
+		return []*models.GenerationJob{}, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	filter := bson.M{"status": bson.M{"$in": bson.A{models.JobStatusQueued, models.JobStatusRunning}}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(readCtx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(readCtx)
+
+	jobs := []*models.GenerationJob{}
+	if err := cursor.All(readCtx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// RedisJobStore keeps jobs as JSON in Redis with a set of unfinished job IDs
+type RedisJobStore struct {
+	client  *redis.Client
+	timeout time.Duration
+}
+
+// Create stores a new job and marks it unfinished
+func (s *RedisJobStore) Create(ctx context.Context, job *models.GenerationJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err = s.client.TxPipelined(opCtx, func(pipe redis.Pipeliner) error {
+		pipe.Set(opCtx, jobKeyPrefix+job.ID.Hex(), data, time.Until(job.ExpiresAt))
+		pipe.SAdd(opCtx, unfinishedJobsKey, job.ID.Hex())
+		return nil
+	})
+	return err
+}
+
+// Find returns a job by ID
+func (s *RedisJobStore) Find(ctx context.Context, jobID string) (*models.GenerationJob, error) {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.get(opCtx, s.client, jobID)
+}
+
+// Claim atomically moves a queued job, or a running job started before
+// staleBefore, to running and counts the attempt
+func (s *RedisJobStore) Claim(ctx context.Context, jobID string, staleBefore time.Time) (*models.GenerationJob, error) {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	key := jobKeyPrefix + jobID
+	var claimed *models.GenerationJob
+
+	err := s.client.Watch(opCtx, func(tx *redis.Tx) error {
+		job, err := s.get(opCtx, tx, jobID)
+		if err != nil {
+			return err
+		}
+
+		stale := job.Status == models.JobStatusRunning && job.StartedAt != nil && job.StartedAt.Before(staleBefore)
+		if job.Status != models.JobStatusQueued && !stale {
+			return apperrors.Wrap(apperrors.ErrConflict, "job not claimable")
+		}
+
+		now := time.Now()
+		job.Status = models.JobStatusRunning
+		job.StartedAt = &now
+		job.Attempts++
+
+		data, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(opCtx, func(pipe redis.Pipeliner) error {
+			pipe.Set(opCtx, key, data, redis.KeepTTL)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		claimed = job
+		return nil
+	}, key)
+	if err != nil {
+		if err == redis.TxFailedErr {
+			return nil, apperrors.Wrap(apperrors.ErrConflict, "job not claimable")
+		}
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// Finish stores the outcome of a job and removes it from the unfinished set
+func (s *RedisJobStore) Finish(ctx context.Context, job *models.GenerationJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err = s.client.TxPipelined(opCtx, func(pipe redis.Pipeliner) error {
+		pipe.Set(opCtx, jobKeyPrefix+job.ID.Hex(), data, time.Until(job.ExpiresAt))
+		pipe.SRem(opCtx, unfinishedJobsKey, job.ID.Hex())
+		return nil
+	})
+	return err
+}
+
+// FindUnfinished returns queued and running jobs, dropping IDs whose job expired
+func (s *RedisJobStore) FindUnfinished(ctx context.Context) ([]*models.GenerationJob, error) {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	ids, err := s.client.SMembers(opCtx, unfinishedJobsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []*models.GenerationJob{}
+	for _, id := range ids {
+		job, err := s.get(opCtx, s.client, id)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				s.client.SRem(opCtx, unfinishedJobsKey, id)
+				continue
+			}
+			return nil, err
+		}
+		if !job.IsFinished() {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// get loads a job with either the client or a watching transaction
+func (s *RedisJobStore) get(ctx context.Context, cmd redis.Cmdable, jobID string) (*models.GenerationJob, error) {
+	data, err := cmd.Get(ctx, jobKeyPrefix+jobID).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, apperrors.Missing("job")
+		}
+		return nil, err
+	}
+
+	var job models.GenerationJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
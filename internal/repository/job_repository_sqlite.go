@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// jobColumns is the column list every job query selects, in scanJob order
+const jobColumns = `id, user_id, kind, status, request, save_bar, attempts, instruction,
+	result, results, bar_id, error, created_at, started_at, finished_at, expires_at`
+
+type SQLiteJobRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteJobRepository creates a SQLite backed job store
+func NewSQLiteJobRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.JobStoreInterface {
+	repo := &SQLiteJobRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Create stores a new job and purges expired ones, SQLite has no TTL index
+func (r *SQLiteJobRepository) Create(ctx context.Context, job *models.GenerationJob) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+
+	request, err := json.Marshal(job.Request)
+	if err != nil {
+		return err
+	}
+	result, err := toNullJSON(job.Result, job.Result == nil)
+	if err != nil {
+		return err
+	}
+	results, err := toNullJSON(job.Results, job.Results == nil)
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(writeCtx, `DELETE FROM ai_jobs WHERE expires_at <= ?`, toMillis(time.Now())); err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(writeCtx, `INSERT INTO ai_jobs (`+jobColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.ID.Hex(), job.UserID, job.Kind, job.Status, string(request), job.SaveBar, job.Attempts, job.Instruction,
+		result, results, job.BarID, job.Error,
+		toMillis(job.CreatedAt), toNullMillis(job.StartedAt), toNullMillis(job.FinishedAt), toMillis(job.ExpiresAt),
+	)
+	return err
+}
+
+// Find returns a job by ID
+func (r *SQLiteJobRepository) Find(ctx context.Context, jobID string) (*models.GenerationJob, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	if _, err := primitive.ObjectIDFromHex(jobID); err != nil {
+		return nil, apperrors.Missing("job")
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	row := r.db.QueryRowContext(readCtx, `SELECT `+jobColumns+` FROM ai_jobs WHERE id = ?`, jobID)
+	job, err := scanJob(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Missing("job")
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Claim atomically moves a queued job, or a running job started before
+// staleBefore, to running and counts the attempt
+func (r *SQLiteJobRepository) Claim(ctx context.Context, jobID string, staleBefore time.Time) (*models.GenerationJob, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	if _, err := primitive.ObjectIDFromHex(jobID); err != nil {
+		return nil, apperrors.Missing("job")
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	row := r.db.QueryRowContext(writeCtx, `UPDATE ai_jobs SET status = ?, started_at = ?, attempts = attempts + 1
+		WHERE id = ? AND (status = ? OR (status = ? AND started_at < ?))
+		RETURNING `+jobColumns,
+		models.JobStatusRunning, toMillis(time.Now()),
+		jobID, models.JobStatusQueued, models.JobStatusRunning, toMillis(staleBefore),
+	)
+	job, err := scanJob(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Wrap(apperrors.ErrConflict, "job not claimable")
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Finish stores the outcome of a job
+func (r *SQLiteJobRepository) Finish(ctx context.Context, job *models.GenerationJob) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	result, err := toNullJSON(job.Result, job.Result == nil)
+	if err != nil {
+		return err
+	}
+	results, err := toNullJSON(job.Results, job.Results == nil)
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	res, err := r.db.ExecContext(writeCtx, `UPDATE ai_jobs SET
+		status = ?, result = ?, results = ?, bar_id = ?, error = ?, finished_at = ?, expires_at = ?
+		WHERE id = ?`,
+		job.Status, result, results, job.BarID, job.Error, toNullMillis(job.FinishedAt), toMillis(job.ExpiresAt),
+		job.ID.Hex(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(res, apperrors.Missing("job"))
+}
+
+// FindUnfinished returns queued and running jobs, oldest first
+func (r *SQLiteJobRepository) FindUnfinished(ctx context.Context) ([]*models.GenerationJob, error) {
+	if r.db == nil {
+		return []*models.GenerationJob{}, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(readCtx, `SELECT `+jobColumns+` FROM ai_jobs
+		WHERE status IN (?, ?) ORDER BY created_at, rowid`, models.JobStatusQueued, models.JobStatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*models.GenerationJob{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// scanJob scans the columns of jobColumns into a job
+func scanJob(s sqliteScanner) (*models.GenerationJob, error) {
+	var (
+		job                   models.GenerationJob
+		id, request           string
+		result, results       sql.NullString
+		createdAt, expiresAt  int64
+		startedAt, finishedAt sql.NullInt64
+	)
+
+	err := s.Scan(
+		&id, &job.UserID, &job.Kind, &job.Status, &request, &job.SaveBar, &job.Attempts, &job.Instruction,
+		&result, &results, &job.BarID, &job.Error, &createdAt, &startedAt, &finishedAt, &expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(request), &job.Request); err != nil {
+		return nil, err
+	}
+	if result.Valid {
+		if err := json.Unmarshal([]byte(result.String), &job.Result); err != nil {
+			return nil, err
+		}
+	}
+	if results.Valid {
+		if err := json.Unmarshal([]byte(results.String), &job.Results); err != nil {
+			return nil, err
+		}
+	}
+	job.CreatedAt = fromMillis(createdAt)
+	job.StartedAt = fromNullMillis(startedAt)
+	job.FinishedAt = fromNullMillis(finishedAt)
+	job.ExpiresAt = fromMillis(expiresAt)
+
+	return &job, nil
+}
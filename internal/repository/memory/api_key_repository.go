@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKeyRepository struct {
+	mu   sync.RWMutex
+	keys []*models.APIKey // Insertion order
+}
+
+// NewAPIKeyRepository creates an empty in-memory API key repository
+func NewAPIKeyRepository() interfaces.APIKeyRepositoryInterface {
+	return &APIKeyRepository{}
+}
+
+// Insert stores a new API key
+func (r *APIKeyRepository) Insert(ctx context.Context, key *models.APIKey) error {
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = append(r.keys, cloneAPIKey(key))
+	return nil
+}
+
+// FindByUserID returns all keys of a user, newest first
+func (r *APIKeyRepository) FindByUserID(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := []*models.APIKey{}
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		if r.keys[i].UserID == userID {
+			keys = append(keys, cloneAPIKey(r.keys[i]))
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// FindByHash finds an active key by the hash of its secret
+func (r *APIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash && key.RevokedAt == nil {
+			return cloneAPIKey(key), nil
+		}
+	}
+	return nil, apperrors.Missing("api key")
+}
+
+// Revoke marks a key of the user as revoked
+func (r *APIKeyRepository) Revoke(ctx context.Context, userID, keyID string) error {
+	objectID, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return apperrors.Missing("api key")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.ID == objectID && key.UserID == userID && key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+			return nil
+		}
+	}
+	return apperrors.Missing("api key")
+}
+
+// TouchLastUsed records that a key was just used
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, keyID string) error {
+	objectID, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return apperrors.Missing("api key")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.ID == objectID {
+			now := time.Now()
+			key.LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+// cloneAPIKey copies a key so callers never share the stored value
+func cloneAPIKey(key *models.APIKey) *models.APIKey {
+	clone := *key
+	clone.Scopes = slices.Clone(key.Scopes)
+	clone.LastUsedAt = cloneTime(key.LastUsedAt)
+	clone.RevokedAt = cloneTime(key.RevokedAt)
+	return &clone
+}
+
+// cloneTime copies an optional timestamp
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	return &clone
+}
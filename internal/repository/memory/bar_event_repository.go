@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// eventKey identifies the milestone of a campaign a bar can reach once
+type eventKey struct {
+	barID     primitive.ObjectID
+	campaign  string
+	milestone int
+}
+
+type BarEventRepository struct {
+	mu     sync.Mutex
+	events map[eventKey]*models.BarEvent
+}
+
+// NewBarEventRepository creates an empty in-memory bar event repository
+func NewBarEventRepository() interfaces.BarEventRepositoryInterface {
+	return &BarEventRepository{
+		events: make(map[eventKey]*models.BarEvent),
+	}
+}
+
+// Insert records an event unless its campaign's milestone is already recorded
+func (r *BarEventRepository) Insert(ctx context.Context, event *models.BarEvent) error {
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+
+	key := eventKey{barID: event.BarID, campaign: event.Campaign, milestone: event.Milestone}
+	clone := *event
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.events[key]; ok {
+		return apperrors.Wrap(apperrors.ErrConflict, "milestone already reached")
+	}
+	r.events[key] = &clone
+	return nil
+}
@@ -0,0 +1,346 @@
+// Package memory keeps repositories in process memory. Nothing is persisted;
+// it lets the server and tests run without any external service.
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+	"donationbars/internal/render"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BarRepository struct {
+	mu   sync.RWMutex
+	bars map[primitive.ObjectID]*models.DonationBar
+}
+
+// NewBarRepository creates an empty in-memory bar repository
+func NewBarRepository() interfaces.BarRepositoryInterface {
+	return &BarRepository{
+		bars: make(map[primitive.ObjectID]*models.DonationBar),
+	}
+}
+
+// Insert adds a new bar
+func (r *BarRepository) Insert(ctx context.Context, bar *models.DonationBar) error {
+	if bar.ID.IsZero() {
+		bar.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.bars[bar.ID]; exists {
+		return apperrors.Wrap(apperrors.ErrConflict, "duplicate bar ID")
+	}
+
+	r.bars[bar.ID] = cloneBar(bar)
+	return nil
+}
+
+// ListByUserID returns one page of a user's bars
+func (r *BarRepository) ListByUserID(ctx context.Context, userID string, opts *models.BarListOptions) (*models.BarPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var bars []*models.DonationBar
+	for _, bar := range r.bars {
+		if bar.UserID == userID && opts.Matches(bar) && opts.IsAfterCursor(bar) {
+			bars = append(bars, bar)
+		}
+	}
+
+	slices.SortFunc(bars, opts.Compare)
+	if len(bars) > opts.Limit+1 {
+		bars = bars[:opts.Limit+1]
+	}
+	for i, bar := range bars {
+		bars[i] = cloneBar(bar)
+	}
+
+	return opts.Page(bars), nil
+}
+
+// FindByID returns a specific bar by ID for a user
+func (r *BarRepository) FindByID(ctx context.Context, userID, barID string) (*models.DonationBar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bar, err := r.owned(userID, barID)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneBar(bar), nil
+}
+
+// Update updates basic fields of a bar
+func (r *BarRepository) Update(ctx context.Context, userID, barID string, req *models.UpdateBarRequest) (*models.DonationBar, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bar, err := r.owned(userID, barID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		bar.Name = *req.Name
+	}
+	if req.Description != nil {
+		bar.Description = *req.Description
+	}
+	if req.IsActive != nil {
+		bar.IsActive = *req.IsActive
+	}
+	if req.InitialAmount != nil {
+		bar.InitialAmount = *req.InitialAmount
+	}
+	if req.GoalAmount != nil {
+		bar.GoalAmount = *req.GoalAmount
+	}
+	if req.Goals != nil {
+		bar.Goals = slices.Clone(*req.Goals)
+	}
+	if req.Currency != nil {
+		bar.Currency = *req.Currency
+	}
+	bar.UpdatedAt = time.Now()
+
+	return cloneBar(bar), nil
+}
+
+// UpdateComplete updates all fields of a bar including HTML/CSS and counts
+// a revision when the content changed
+func (r *BarRepository) UpdateComplete(ctx context.Context, userID, barID string, req *models.CreateBarRequest, isActive bool) (*models.DonationBar, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bar, err := r.owned(userID, barID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bar.HTML != req.HTML || bar.CSS != req.CSS {
+		bar.Revision++
+	}
+	bar.Name = req.Name
+	bar.Description = req.Description
+	bar.HTML = req.HTML
+	bar.CSS = req.CSS
+	bar.Language = req.Language
+	bar.Currency = req.Currency
+	bar.Theme = req.Theme
+	bar.IsActive = isActive
+	bar.InitialAmount = req.InitialAmount
+	bar.GoalAmount = req.GoalAmount
+	bar.Goals = slices.Clone(req.Goals)
+	bar.UpdatedAt = time.Now()
+	bar.HasValidInjections = render.HasRequiredFields(req.HTML)
+
+	return cloneBar(bar), nil
+}
+
+// UpdateContent replaces a bar's HTML/CSS and counts the revision
+func (r *BarRepository) UpdateContent(ctx context.Context, userID, barID, html, css string) (*models.DonationBar, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bar, err := r.owned(userID, barID)
+	if err != nil {
+		return nil, err
+	}
+
+	bar.HTML = html
+	bar.CSS = css
+	bar.HasValidInjections = render.HasRequiredFields(html)
+	bar.UpdatedAt = time.Now()
+	bar.Revision++
+
+	return cloneBar(bar), nil
+}
+
+// Delete removes a bar
+func (r *BarRepository) Delete(ctx context.Context, userID, barID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bar, err := r.owned(userID, barID)
+	if err != nil {
+		return err
+	}
+
+	delete(r.bars, bar.ID)
+	return nil
+}
+
+// CountByUserID returns the total number of bars for a user
+func (r *BarRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, bar := range r.bars {
+		if bar.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountByFilter returns the number of a user's bars that pass the filter
+func (r *BarRepository) CountByFilter(ctx context.Context, userID string, filter *models.BarFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, bar := range r.bars {
+		if bar.UserID == userID && filter.Matches(bar) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountCreatedSince returns the number of bars the user created at or after since
+func (r *BarRepository) CountCreatedSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, bar := range r.bars {
+		if bar.UserID == userID && !bar.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// IncrementAmount adds delta to the bar's starting amount
+func (r *BarRepository) IncrementAmount(ctx context.Context, userID, barID string, delta float64) (*models.DonationBar, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bar, err := r.owned(userID, barID)
+	if err != nil {
+		return nil, err
+	}
+
+	bar.InitialAmount += delta
+	bar.UpdatedAt = time.Now()
+	return cloneBar(bar), nil
+}
+
+// AddDonationTotal adds a recorded donation to the bar's ledger total
+func (r *BarRepository) AddDonationTotal(ctx context.Context, barID string, amount float64) (*models.DonationBar, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bar, ok := r.bars[objectID]
+	if !ok {
+		return nil, apperrors.Missing("bar")
+	}
+
+	bar.DonationTotal += amount
+	bar.UpdatedAt = time.Now()
+	return cloneBar(bar), nil
+}
+
+// FindByOverlayToken returns the bar owning the given overlay token
+func (r *BarRepository) FindByOverlayToken(ctx context.Context, token string) (*models.DonationBar, error) {
+	if token == "" {
+		return nil, apperrors.Missing("bar")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, bar := range r.bars {
+		if bar.OverlayToken == token {
+			return cloneBar(bar), nil
+		}
+	}
+	return nil, apperrors.Missing("bar")
+}
+
+// SetOverlayToken replaces the overlay token of a bar
+func (r *BarRepository) SetOverlayToken(ctx context.Context, userID, barID, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bar, err := r.owned(userID, barID)
+	if err != nil {
+		return err
+	}
+
+	bar.OverlayToken = token
+	bar.UpdatedAt = time.Now()
+	return nil
+}
+
+// FindByWebhookToken returns the bar owning the given webhook token
+func (r *BarRepository) FindByWebhookToken(ctx context.Context, token string) (*models.DonationBar, error) {
+	if token == "" {
+		return nil, apperrors.Missing("bar")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, bar := range r.bars {
+		if bar.WebhookToken == token {
+			return cloneBar(bar), nil
+		}
+	}
+	return nil, apperrors.Missing("bar")
+}
+
+// SetWebhook replaces the webhook token and signing secret of a bar
+func (r *BarRepository) SetWebhook(ctx context.Context, userID, barID, token, secret string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bar, err := r.owned(userID, barID)
+	if err != nil {
+		return err
+	}
+
+	bar.WebhookToken = token
+	bar.WebhookSecret = secret
+	bar.UpdatedAt = time.Now()
+	return nil
+}
+
+// owned returns the stored bar if it belongs to the user; callers hold the lock
+func (r *BarRepository) owned(userID, barID string) (*models.DonationBar, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	bar, ok := r.bars[objectID]
+	if !ok || bar.UserID != userID {
+		return nil, apperrors.Missing("bar")
+	}
+	return bar, nil
+}
+
+// cloneBar copies a bar so callers never share the stored value
+func cloneBar(bar *models.DonationBar) *models.DonationBar {
+	clone := *bar
+	clone.Goals = slices.Clone(bar.Goals)
+	clone.SanitizeReport = nil
+	return &clone
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestBar(userID string) *models.DonationBar {
+	now := time.Now()
+	return &models.DonationBar{
+		ID:         primitive.NewObjectID(),
+		UserID:     userID,
+		Name:       "Test Bar",
+		HTML:       "<div>{goal} {total} {percentage} {remaining} {description}</div>",
+		CSS:        ".bar {\n  color: red;\n}",
+		Language:   "tr",
+		IsActive:   true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		GoalAmount: 1000,
+	}
+}
+
+func TestBarRepository_ConcurrentUpdateContent_DistinctRevisions(t *testing.T) {
+	repo := NewBarRepository()
+	ctx := context.Background()
+
+	bar := newTestBar("user-1")
+	require.NoError(t, repo.Insert(ctx, bar))
+
+	const writers = 50
+	revisions := make([]int, writers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			updated, err := repo.UpdateContent(ctx, "user-1", bar.ID.Hex(), bar.HTML, bar.CSS)
+			if assert.NoError(t, err) {
+				revisions[i] = updated.Revision
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Ints(revisions)
+	for i, revision := range revisions {
+		assert.Equal(t, i+1, revision)
+	}
+}
+
+func TestBarRepository_ReturnsCopies(t *testing.T) {
+	repo := NewBarRepository()
+	ctx := context.Background()
+
+	bar := newTestBar("user-1")
+	require.NoError(t, repo.Insert(ctx, bar))
+
+	// Changing the inserted or returned value must not change the stored bar
+	bar.Name = "changed after insert"
+	found, err := repo.FindByID(ctx, "user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, "Test Bar", found.Name)
+
+	found.Name = "changed after find"
+	again, err := repo.FindByID(ctx, "user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, "Test Bar", again.Name)
+}
+
+func TestBarRepository_ListByUserID_OldestFirst(t *testing.T) {
+	repo := NewBarRepository()
+	ctx := context.Background()
+
+	newer := newTestBar("user-1")
+	older := newTestBar("user-1")
+	older.CreatedAt = newer.CreatedAt.Add(-time.Hour)
+	require.NoError(t, repo.Insert(ctx, newer))
+	require.NoError(t, repo.Insert(ctx, older))
+	require.NoError(t, repo.Insert(ctx, newTestBar("user-2")))
+
+	opts := &models.BarListOptions{}
+	require.NoError(t, opts.Normalize())
+	page, err := repo.ListByUserID(ctx, "user-1", opts)
+	require.NoError(t, err)
+	bars := page.Bars
+	require.Len(t, bars, 2)
+	assert.Equal(t, older.ID, bars[0].ID)
+	assert.Equal(t, newer.ID, bars[1].ID)
+}
+
+func TestBarRepository_InvalidID(t *testing.T) {
+	repo := NewBarRepository()
+	ctx := context.Background()
+
+	_, err := repo.FindByID(ctx, "user-1", "invalid-id")
+	assert.EqualError(t, err, "invalid bar ID format")
+
+	err = repo.Delete(ctx, "user-1", "invalid-id")
+	assert.EqualError(t, err, "invalid bar ID format")
+
+	_, err = repo.UpdateContent(ctx, "user-1", "invalid-id", "", "")
+	assert.EqualError(t, err, "invalid bar ID format")
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DonationRepository struct {
+	mu        sync.RWMutex
+	donations []*models.Donation // Insertion order
+}
+
+// NewDonationRepository creates an empty in-memory donation ledger
+func NewDonationRepository() interfaces.DonationRepositoryInterface {
+	return &DonationRepository{}
+}
+
+// Insert appends a donation to the ledger
+func (r *DonationRepository) Insert(ctx context.Context, donation *models.Donation) error {
+	if donation.ID.IsZero() {
+		donation.ID = primitive.NewObjectID()
+	}
+
+	clone := *donation
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if clone.ExternalID != "" {
+		for _, recorded := range r.donations {
+			if recorded.BarID == clone.BarID && recorded.Source == clone.Source && recorded.ExternalID == clone.ExternalID {
+				return apperrors.Wrap(apperrors.ErrConflict, "donation already recorded")
+			}
+		}
+	}
+	r.donations = append(r.donations, &clone)
+	return nil
+}
+
+// Delete removes a donation from the ledger
+func (r *DonationRepository) Delete(ctx context.Context, donationID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.donations, func(donation *models.Donation) bool {
+		return donation.ID.Hex() == donationID
+	})
+	if i < 0 {
+		return apperrors.Missing("donation")
+	}
+	r.donations = slices.Delete(r.donations, i, i+1)
+	return nil
+}
+
+// FindByBarID returns all donations of a bar, newest first
+func (r *DonationRepository) FindByBarID(ctx context.Context, barID string) ([]*models.Donation, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	donations := []*models.Donation{}
+	for i := len(r.donations) - 1; i >= 0; i-- {
+		if r.donations[i].BarID == objectID {
+			clone := *r.donations[i]
+			donations = append(donations, &clone)
+		}
+	}
+
+	// Walking the ledger backwards keeps equal timestamps newest first
+	sort.SliceStable(donations, func(i, j int) bool {
+		return donations[i].CreatedAt.After(donations[j].CreatedAt)
+	})
+	return donations, nil
+}
+
+// SumByBarID returns the sum of all donation amounts of a bar
+func (r *DonationRepository) SumByBarID(ctx context.Context, barID string) (float64, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return 0, apperrors.ErrInvalidBarID
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var total float64
+	for _, donation := range r.donations {
+		if donation.BarID == objectID {
+			total += donation.Amount
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobStore struct {
+	mu   sync.Mutex
+	jobs map[primitive.ObjectID]*models.GenerationJob
+}
+
+// NewJobStore creates an empty in-memory job store
+func NewJobStore() interfaces.JobStoreInterface {
+	return &JobStore{
+		jobs: make(map[primitive.ObjectID]*models.GenerationJob),
+	}
+}
+
+// Create stores a new job and drops expired ones
+func (s *JobStore) Create(ctx context.Context, job *models.GenerationJob) error {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, existing := range s.jobs {
+		if !existing.ExpiresAt.After(now) {
+			delete(s.jobs, id)
+		}
+	}
+
+	s.jobs[job.ID] = cloneJob(job)
+	return nil
+}
+
+// Find returns a job by ID
+func (s *JobStore) Find(ctx context.Context, jobID string) (*models.GenerationJob, error) {
+	objectID, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, apperrors.Missing("job")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[objectID]
+	if !ok {
+		return nil, apperrors.Missing("job")
+	}
+
+	return cloneJob(job), nil
+}
+
+// Claim atomically moves a queued job, or a running job started before
+// staleBefore, to running and counts the attempt
+func (s *JobStore) Claim(ctx context.Context, jobID string, staleBefore time.Time) (*models.GenerationJob, error) {
+	objectID, err := primitive.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, apperrors.Missing("job")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[objectID]
+	if !ok {
+		return nil, apperrors.Wrap(apperrors.ErrConflict, "job not claimable")
+	}
+
+	stale := job.Status == models.JobStatusRunning && job.StartedAt != nil && job.StartedAt.Before(staleBefore)
+	if job.Status != models.JobStatusQueued && !stale {
+		return nil, apperrors.Wrap(apperrors.ErrConflict, "job not claimable")
+	}
+
+	now := time.Now()
+	job.Status = models.JobStatusRunning
+	job.StartedAt = &now
+	job.Attempts++
+
+	return cloneJob(job), nil
+}
+
+// Finish stores the outcome of a job
+func (s *JobStore) Finish(ctx context.Context, job *models.GenerationJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.jobs[job.ID]
+	if !ok {
+		return apperrors.Missing("job")
+	}
+
+	stored.Status = job.Status
+	stored.Result = job.Result
+	stored.Results = slices.Clone(job.Results)
+	stored.BarID = job.BarID
+	stored.Error = job.Error
+	stored.FinishedAt = cloneTime(job.FinishedAt)
+	stored.ExpiresAt = job.ExpiresAt
+
+	return nil
+}
+
+// FindUnfinished returns queued and running jobs, oldest first
+func (s *JobStore) FindUnfinished(ctx context.Context) ([]*models.GenerationJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := []*models.GenerationJob{}
+	for _, job := range s.jobs {
+		if !job.IsFinished() {
+			jobs = append(jobs, cloneJob(job))
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs, nil
+}
+
+// cloneJob copies a job so callers never share the stored value; results
+// are treated as immutable once stored
+func cloneJob(job *models.GenerationJob) *models.GenerationJob {
+	clone := *job
+	clone.Results = slices.Clone(job.Results)
+	clone.StartedAt = cloneTime(job.StartedAt)
+	clone.FinishedAt = cloneTime(job.FinishedAt)
+	return &clone
+}
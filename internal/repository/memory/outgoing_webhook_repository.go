@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutgoingWebhookRepository struct {
+	mu    sync.RWMutex
+	hooks []*models.OutgoingWebhook // Insertion order
+}
+
+// NewOutgoingWebhookRepository creates an empty in-memory outgoing webhook repository
+func NewOutgoingWebhookRepository() interfaces.OutgoingWebhookRepositoryInterface {
+	return &OutgoingWebhookRepository{}
+}
+
+// Insert stores a new outgoing webhook
+func (r *OutgoingWebhookRepository) Insert(ctx context.Context, hook *models.OutgoingWebhook) error {
+	if hook.ID.IsZero() {
+		hook.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hooks = append(r.hooks, cloneOutgoingWebhook(hook))
+	return nil
+}
+
+// Find returns an outgoing webhook by ID
+func (r *OutgoingWebhookRepository) Find(ctx context.Context, hookID string) (*models.OutgoingWebhook, error) {
+	objectID, err := primitive.ObjectIDFromHex(hookID)
+	if err != nil {
+		return nil, apperrors.Missing("webhook")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, hook := range r.hooks {
+		if hook.ID == objectID {
+			return cloneOutgoingWebhook(hook), nil
+		}
+	}
+	return nil, apperrors.Missing("webhook")
+}
+
+// FindByBarID returns the outgoing webhooks of a bar, oldest first
+func (r *OutgoingWebhookRepository) FindByBarID(ctx context.Context, barID string) ([]*models.OutgoingWebhook, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hooks := []*models.OutgoingWebhook{}
+	for _, hook := range r.hooks {
+		if hook.BarID == objectID {
+			hooks = append(hooks, cloneOutgoingWebhook(hook))
+		}
+	}
+	return hooks, nil
+}
+
+// Delete removes an outgoing webhook of the user's bar
+func (r *OutgoingWebhookRepository) Delete(ctx context.Context, userID, barID, hookID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.hooks, func(hook *models.OutgoingWebhook) bool {
+		return hook.ID.Hex() == hookID && hook.BarID.Hex() == barID && hook.UserID == userID
+	})
+	if i < 0 {
+		return apperrors.Missing("webhook")
+	}
+	r.hooks = slices.Delete(r.hooks, i, i+1)
+	return nil
+}
+
+// cloneOutgoingWebhook copies a webhook so callers never share the stored value
+func cloneOutgoingWebhook(hook *models.OutgoingWebhook) *models.OutgoingWebhook {
+	clone := *hook
+	clone.Milestones = slices.Clone(hook.Milestones)
+	return &clone
+}
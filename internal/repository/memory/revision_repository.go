@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RevisionRepository struct {
+	mu        sync.RWMutex
+	revisions map[primitive.ObjectID][]*models.BarRevision // By bar ID
+}
+
+// NewRevisionRepository creates an empty in-memory revision history
+func NewRevisionRepository() interfaces.RevisionRepositoryInterface {
+	return &RevisionRepository{
+		revisions: make(map[primitive.ObjectID][]*models.BarRevision),
+	}
+}
+
+// Insert appends a revision; revisions are never updated
+func (r *RevisionRepository) Insert(ctx context.Context, revision *models.BarRevision) error {
+	if revision.ID.IsZero() {
+		revision.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.revisions[revision.BarID] {
+		if existing.Revision == revision.Revision {
+			return apperrors.Wrap(apperrors.ErrConflict, "duplicate revision number")
+		}
+	}
+
+	r.revisions[revision.BarID] = append(r.revisions[revision.BarID], cloneRevision(revision))
+	return nil
+}
+
+// FindByBarID returns all revisions of a bar, newest first
+func (r *RevisionRepository) FindByBarID(ctx context.Context, barID string) ([]*models.BarRevision, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revisions := []*models.BarRevision{}
+	for _, revision := range r.revisions[objectID] {
+		revisions = append(revisions, cloneRevision(revision))
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision > revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// FindByNumber returns one revision of a bar
+func (r *RevisionRepository) FindByNumber(ctx context.Context, barID string, number int) (*models.BarRevision, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, revision := range r.revisions[objectID] {
+		if revision.Revision == number {
+			return cloneRevision(revision), nil
+		}
+	}
+	return nil, apperrors.Missing("revision")
+}
+
+// CountByBarID returns how many revisions of a bar are stored
+func (r *RevisionRepository) CountByBarID(ctx context.Context, barID string) (int64, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return 0, apperrors.ErrInvalidBarID
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int64(len(r.revisions[objectID])), nil
+}
+
+// cloneRevision copies a revision so callers never share the stored value
+func cloneRevision(revision *models.BarRevision) *models.BarRevision {
+	clone := *revision
+	if revision.RestoredFrom != nil {
+		restoredFrom := *revision.RestoredFrom
+		clone.RestoredFrom = &restoredFrom
+	}
+	return &clone
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+)
+
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]models.Session
+}
+
+// NewSessionStore creates an empty in-memory session store
+func NewSessionStore() interfaces.SessionStoreInterface {
+	return &SessionStore{
+		sessions: make(map[string]models.Session),
+	}
+}
+
+// Create stores a new session and drops expired ones
+func (s *SessionStore) Create(ctx context.Context, session *models.Session) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, existing := range s.sessions {
+		if !existing.ExpiresAt.After(now) {
+			delete(s.sessions, id)
+		}
+	}
+
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+// Find returns a session that has not expired yet
+func (s *SessionStore) Find(ctx context.Context, sessionID string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok || !session.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.Missing("session")
+	}
+
+	return &session, nil
+}
+
+// Delete removes a session
+func (s *SessionStore) Delete(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, sessionID)
+	return nil
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+)
+
+type UsageRepository struct {
+	mu     sync.Mutex
+	usages map[[2]string]*models.UsagePeriod // Keyed by user ID and period
+}
+
+// NewUsageRepository creates an empty in-memory AI usage counter repository
+func NewUsageRepository() interfaces.UsageRepositoryInterface {
+	return &UsageRepository{
+		usages: make(map[[2]string]*models.UsagePeriod),
+	}
+}
+
+// Add increments the counters of a period, creating it on first use
+func (r *UsageRepository) Add(ctx context.Context, userID, period string, generations, tokens int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{userID, period}
+	usage, ok := r.usages[key]
+	if !ok {
+		usage = &models.UsagePeriod{UserID: userID, Period: period}
+		r.usages[key] = usage
+	}
+	usage.Generations += generations
+	usage.Tokens += tokens
+	return nil
+}
+
+// Find returns the counters of a period
+func (r *UsageRepository) Find(ctx context.Context, userID, period string) (*models.UsagePeriod, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if usage, ok := r.usages[[2]string{userID, period}]; ok {
+		clone := *usage
+		return &clone, nil
+	}
+	return &models.UsagePeriod{UserID: userID, Period: period}, nil
+}
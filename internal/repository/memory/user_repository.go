@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserRepository struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]*models.User
+}
+
+// NewUserRepository creates an empty in-memory user repository
+func NewUserRepository() interfaces.UserRepositoryInterface {
+	return &UserRepository{
+		users: make(map[primitive.ObjectID]*models.User),
+	}
+}
+
+// Insert creates a user account; e-mail addresses are unique
+func (r *UserRepository) Insert(ctx context.Context, user *models.User) error {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return apperrors.Wrap(apperrors.ErrConflict, "email already registered")
+		}
+	}
+
+	clone := *user
+	r.users[user.ID] = &clone
+	return nil
+}
+
+// FindByEmail finds a user by e-mail address, case-insensitively
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	email = strings.ToLower(email)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			clone := *user
+			return &clone, nil
+		}
+	}
+	return nil, apperrors.Missing("user")
+}
+
+// FindByID finds a user by ID
+func (r *UserRepository) FindByID(ctx context.Context, userID string) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperrors.Missing("user")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[objectID]
+	if !ok {
+		return nil, apperrors.Missing("user")
+	}
+
+	clone := *user
+	return &clone, nil
+}
+
+// SetPlan assigns a plan to a user
+func (r *UserRepository) SetPlan(ctx context.Context, userID, plan string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return apperrors.Missing("user")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[objectID]
+	if !ok {
+		return apperrors.Missing("user")
+	}
+	user.Plan = plan
+	return nil
+}
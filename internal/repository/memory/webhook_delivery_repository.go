@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebhookDeliveryRepository struct {
+	mu         sync.Mutex
+	deliveries []*models.WebhookDelivery // Insertion order
+}
+
+// NewWebhookDeliveryRepository creates an empty in-memory webhook delivery repository
+func NewWebhookDeliveryRepository() interfaces.WebhookDeliveryRepositoryInterface {
+	return &WebhookDeliveryRepository{}
+}
+
+// Insert stores a new delivery
+func (r *WebhookDeliveryRepository) Insert(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries = append(r.deliveries, cloneDelivery(delivery))
+	return nil
+}
+
+// Claim takes a pending delivery that is due at now, pushing its next
+// attempt to leaseUntil so no other worker sends it meanwhile
+func (r *WebhookDeliveryRepository) Claim(ctx context.Context, deliveryID string, now, leaseUntil time.Time) (*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delivery, err := r.find(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.Status != models.DeliveryStatusPending || delivery.NextAttemptAt.After(now) {
+		return nil, apperrors.Wrap(apperrors.ErrConflict, "delivery not claimable")
+	}
+	delivery.NextAttemptAt = leaseUntil
+	return cloneDelivery(delivery), nil
+}
+
+// Save stores the outcome of a delivery attempt
+func (r *WebhookDeliveryRepository) Save(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.find(delivery.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	stored.Status = delivery.Status
+	stored.Attempts = slices.Clone(delivery.Attempts)
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.UpdatedAt = delivery.UpdatedAt
+	return nil
+}
+
+// FindDue returns pending deliveries whose next attempt is due, oldest first
+func (r *WebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := []*models.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.Status == models.DeliveryStatusPending && !delivery.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, cloneDelivery(delivery))
+		}
+	}
+
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// FindByBarID returns the latest deliveries of a bar, newest first
+func (r *WebhookDeliveryRepository) FindByBarID(ctx context.Context, barID string, limit int) ([]*models.WebhookDelivery, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := []*models.WebhookDelivery{}
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		if r.deliveries[i].BarID == objectID {
+			deliveries = append(deliveries, cloneDelivery(r.deliveries[i]))
+		}
+	}
+
+	// Walking backwards keeps equal timestamps newest first
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// find returns the stored delivery; callers hold the lock
+func (r *WebhookDeliveryRepository) find(deliveryID string) (*models.WebhookDelivery, error) {
+	objectID, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		return nil, apperrors.Missing("delivery")
+	}
+
+	i := slices.IndexFunc(r.deliveries, func(d *models.WebhookDelivery) bool { return d.ID == objectID })
+	if i < 0 {
+		return nil, apperrors.Missing("delivery")
+	}
+	return r.deliveries[i], nil
+}
+
+// cloneDelivery copies a delivery so callers never share the stored value
+func cloneDelivery(delivery *models.WebhookDelivery) *models.WebhookDelivery {
+	clone := *delivery
+	clone.Attempts = slices.Clone(delivery.Attempts)
+	return &clone
+}
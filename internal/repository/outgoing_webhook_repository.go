@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OutgoingWebhookRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewOutgoingWebhookRepository creates a MongoDB backed outgoing webhook repository
+func NewOutgoingWebhookRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.OutgoingWebhookRepositoryInterface {
+	repo := &OutgoingWebhookRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("outgoing_webhooks")
+	}
+	return repo
+}
+
+// Insert stores a new outgoing webhook
+func (r *OutgoingWebhookRepository) Insert(ctx context.Context, hook *models.OutgoingWebhook) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if hook.ID.IsZero() {
+		hook.ID = primitive.NewObjectID()
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(writeCtx, hook)
+	return err
+}
+
+// Find returns an outgoing webhook by ID
+func (r *OutgoingWebhookRepository) Find(ctx context.Context, hookID string) (*models.OutgoingWebhook, error) {
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(hookID)
+	if err != nil {
+		return nil, apperrors.Missing("webhook")
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var hook models.OutgoingWebhook
+	err = r.collection.FindOne(readCtx, bson.M{"_id": objectID}).Decode(&hook)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("webhook")
+		}
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+// FindByBarID returns the outgoing webhooks of a bar, oldest first
+func (r *OutgoingWebhookRepository) FindByBarID(ctx context.Context, barID string) ([]*models.OutgoingWebhook, error) {
+	if r.collection == nil {
+		return []*models.OutgoingWebhook{}, nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(readCtx, bson.M{"bar_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	hooks := []*models.OutgoingWebhook{}
+	if err = cursor.All(readCtx, &hooks); err != nil {
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+// Delete removes an outgoing webhook of the user's bar
+func (r *OutgoingWebhookRepository) Delete(ctx context.Context, userID, barID, hookID string) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(hookID)
+	if err != nil {
+		return apperrors.Missing("webhook")
+	}
+	barObjectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return apperrors.ErrInvalidBarID
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(writeCtx, bson.M{"_id": objectID, "bar_id": barObjectID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return apperrors.Missing("webhook")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// outgoingWebhookColumns is the column list every outgoing webhook query selects, in scanOutgoingWebhook order
+const outgoingWebhookColumns = `id, bar_id, user_id, url, secret, milestones, format, language, template, created_at`
+
+type SQLiteOutgoingWebhookRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteOutgoingWebhookRepository creates a SQLite backed outgoing webhook repository
+func NewSQLiteOutgoingWebhookRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.OutgoingWebhookRepositoryInterface {
+	repo := &SQLiteOutgoingWebhookRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Insert stores a new outgoing webhook
+func (r *SQLiteOutgoingWebhookRepository) Insert(ctx context.Context, hook *models.OutgoingWebhook) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if hook.ID.IsZero() {
+		hook.ID = primitive.NewObjectID()
+	}
+
+	milestones, err := json.Marshal(hook.Milestones)
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err = r.db.ExecContext(writeCtx, `INSERT INTO outgoing_webhooks (`+outgoingWebhookColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		hook.ID.Hex(), hook.BarID.Hex(), hook.UserID, hook.URL, hook.Secret, string(milestones),
+		hook.Format, hook.Language, hook.Template, toMillis(hook.CreatedAt),
+	)
+	return err
+}
+
+// Find returns an outgoing webhook by ID
+func (r *SQLiteOutgoingWebhookRepository) Find(ctx context.Context, hookID string) (*models.OutgoingWebhook, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	if _, err := primitive.ObjectIDFromHex(hookID); err != nil {
+		return nil, apperrors.Missing("webhook")
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	row := r.db.QueryRowContext(readCtx, `SELECT `+outgoingWebhookColumns+` FROM outgoing_webhooks WHERE id = ?`, hookID)
+	hook, err := scanOutgoingWebhook(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Missing("webhook")
+		}
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+// FindByBarID returns the outgoing webhooks of a bar, oldest first
+func (r *SQLiteOutgoingWebhookRepository) FindByBarID(ctx context.Context, barID string) ([]*models.OutgoingWebhook, error) {
+	if r.db == nil {
+		return []*models.OutgoingWebhook{}, nil
+	}
+
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(readCtx, `SELECT `+outgoingWebhookColumns+` FROM outgoing_webhooks
+		WHERE bar_id = ? ORDER BY created_at, rowid`, barID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []*models.OutgoingWebhook{}
+	for rows.Next() {
+		hook, err := scanOutgoingWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, rows.Err()
+}
+
+// Delete removes an outgoing webhook of the user's bar
+func (r *SQLiteOutgoingWebhookRepository) Delete(ctx context.Context, userID, barID, hookID string) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.db.ExecContext(writeCtx, `DELETE FROM outgoing_webhooks WHERE id = ? AND bar_id = ? AND user_id = ?`,
+		hookID, barID, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, apperrors.Missing("webhook"))
+}
+
+// scanOutgoingWebhook scans the columns of outgoingWebhookColumns into an outgoing webhook
+func scanOutgoingWebhook(s sqliteScanner) (*models.OutgoingWebhook, error) {
+	var (
+		hook                  models.OutgoingWebhook
+		id, barID, milestones string
+		createdAt             int64
+	)
+
+	err := s.Scan(&id, &barID, &hook.UserID, &hook.URL, &hook.Secret, &milestones,
+		&hook.Format, &hook.Language, &hook.Template, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if hook.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if hook.BarID, err = primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(milestones), &hook.Milestones); err != nil {
+		return nil, err
+	}
+	hook.CreatedAt = fromMillis(createdAt)
+
+	return &hook, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RevisionRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewRevisionRepository creates a new bar revision history repository
+func NewRevisionRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.RevisionRepositoryInterface {
+	repo := &RevisionRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("bar_revisions")
+	}
+	return repo
+}
+
+// Insert appends a revision; revisions are never updated
+func (r *RevisionRepository) Insert(ctx context.Context, revision *models.BarRevision) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(writeCtx, revision)
+	return err
+}
+
+// FindByBarID returns all revisions of a bar, newest first
+func (r *RevisionRepository) FindByBarID(ctx context.Context, barID string) ([]*models.BarRevision, error) {
+	if r.collection == nil {
+		return []*models.BarRevision{}, nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "revision", Value: -1}, {Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(readCtx, bson.M{"bar_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []*models.BarRevision{}
+	if err = cursor.All(readCtx, &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// FindByNumber returns one revision of a bar
+func (r *RevisionRepository) FindByNumber(ctx context.Context, barID string, number int) (*models.BarRevision, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var revision models.BarRevision
+	err = r.collection.FindOne(readCtx, bson.M{"bar_id": objectID, "revision": number}).Decode(&revision)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("revision")
+		}
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// CountByBarID returns how many revisions of a bar are stored
+func (r *RevisionRepository) CountByBarID(ctx context.Context, barID string) (int64, error) {
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return 0, apperrors.ErrInvalidBarID
+	}
+
+	if r.collection == nil {
+		return 0, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	return r.collection.CountDocuments(readCtx, bson.M{"bar_id": objectID})
+}
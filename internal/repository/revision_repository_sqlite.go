@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// revisionColumns is the column list every revision query selects, in scanRevision order
+const revisionColumns = `id, bar_id, user_id, revision, author_id, source, restored_from, html, css,
+	html_added, html_removed, css_added, css_removed, created_at`
+
+type SQLiteRevisionRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteRevisionRepository creates a new SQLite backed bar revision history repository
+func NewSQLiteRevisionRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.RevisionRepositoryInterface {
+	repo := &SQLiteRevisionRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Insert appends a revision; revisions are never updated
+func (r *SQLiteRevisionRepository) Insert(ctx context.Context, revision *models.BarRevision) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if revision.ID.IsZero() {
+		revision.ID = primitive.NewObjectID()
+	}
+
+	var restoredFrom sql.NullInt64
+	if revision.RestoredFrom != nil {
+		restoredFrom = sql.NullInt64{Int64: int64(*revision.RestoredFrom), Valid: true}
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.db.ExecContext(writeCtx, `INSERT INTO bar_revisions (`+revisionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		revision.ID.Hex(), revision.BarID.Hex(), revision.UserID, revision.Revision, revision.AuthorID,
+		revision.Source, restoredFrom, revision.HTML, revision.CSS,
+		revision.Summary.HTMLAdded, revision.Summary.HTMLRemoved, revision.Summary.CSSAdded, revision.Summary.CSSRemoved,
+		toMillis(revision.CreatedAt),
+	)
+	return err
+}
+
+// FindByBarID returns all revisions of a bar, newest first
+func (r *SQLiteRevisionRepository) FindByBarID(ctx context.Context, barID string) ([]*models.BarRevision, error) {
+	if r.db == nil {
+		return []*models.BarRevision{}, nil
+	}
+
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(readCtx, `SELECT `+revisionColumns+` FROM bar_revisions
+		WHERE bar_id = ? ORDER BY revision DESC, created_at DESC`, barID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.BarRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// FindByNumber returns one revision of a bar
+func (r *SQLiteRevisionRepository) FindByNumber(ctx context.Context, barID string, number int) (*models.BarRevision, error) {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	row := r.db.QueryRowContext(readCtx, `SELECT `+revisionColumns+` FROM bar_revisions
+		WHERE bar_id = ? AND revision = ?`, barID, number)
+	revision, err := scanRevision(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Missing("revision")
+		}
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// CountByBarID returns how many revisions of a bar are stored
+func (r *SQLiteRevisionRepository) CountByBarID(ctx context.Context, barID string) (int64, error) {
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return 0, apperrors.ErrInvalidBarID
+	}
+
+	if r.db == nil {
+		return 0, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var count int64
+	err := r.db.QueryRowContext(readCtx, `SELECT COUNT(*) FROM bar_revisions WHERE bar_id = ?`, barID).Scan(&count)
+	return count, err
+}
+
+// scanRevision scans the columns of revisionColumns into a revision
+func scanRevision(s sqliteScanner) (*models.BarRevision, error) {
+	var (
+		revision     models.BarRevision
+		id, barID    string
+		restoredFrom sql.NullInt64
+		createdAt    int64
+	)
+
+	err := s.Scan(
+		&id, &barID, &revision.UserID, &revision.Revision, &revision.AuthorID, &revision.Source,
+		&restoredFrom, &revision.HTML, &revision.CSS,
+		&revision.Summary.HTMLAdded, &revision.Summary.HTMLRemoved, &revision.Summary.CSSAdded, &revision.Summary.CSSRemoved,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if revision.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if revision.BarID, err = primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, err
+	}
+	if restoredFrom.Valid {
+		number := int(restoredFrom.Int64)
+		revision.RestoredFrom = &number
+	}
+	revision.CreatedAt = fromMillis(createdAt)
+
+	return &revision, nil
+}
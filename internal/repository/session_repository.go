@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// sessionKeyPrefix namespaces session keys in Redis
+const sessionKeyPrefix = "session:"
+
+// NewSessionStore returns a Redis session store when Redis is available, MongoDB otherwise
+func NewSessionStore(db *config.Database, redisClient *config.RedisClient, timeouts config.TimeoutConfig) interfaces.SessionStoreInterface {
+	if redisClient != nil && redisClient.IsEnabled() {
+		slog.Info("Session store initialized", "backend", "redis")
+		return &RedisSessionStore{client: redisClient.Client, timeout: timeouts.RedisOperation}
+	}
+
+	slog.Info("Session store initialized", "backend", "mongodb")
+	return NewSessionRepository(db, timeouts)
+}
+
+type SessionRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewSessionRepository creates a MongoDB backed session store
+func NewSessionRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.SessionStoreInterface {
+	repo := &SessionRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("sessions")
+	}
+	return repo
+}
+
+// Create stores a new session
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(writeCtx, session)
+	return err
+}
+
+// Find returns a session that has not expired yet
+func (r *SessionRepository) Find(ctx context.Context, sessionID string) (*models.Session, error) {
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":        sessionID,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+
+	var session models.Session
+	err := r.collection.FindOne(readCtx, filter).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("session")
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// Delete removes a session
+func (r *SessionRepository) Delete(ctx context.Context, sessionID string) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(writeCtx, bson.M{"_id": sessionID})
+	return err
+}
+
+// RedisSessionStore keeps sessions in Redis and lets key expiry end them
+type RedisSessionStore struct {
+	client  *redis.Client
+	timeout time.Duration
+}
+
+// Create stores a new session with a TTL matching its expiry
+func (s *RedisSessionStore) Create(ctx context.Context, session *models.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return apperrors.Wrap(apperrors.ErrInvalidInput, "session already expired")
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.client.Set(opCtx, sessionKeyPrefix+session.ID, data, ttl).Err()
+}
+
+// Find returns a session that has not expired yet
+func (s *RedisSessionStore) Find(ctx context.Context, sessionID string) (*models.Session, error) {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	data, err := s.client.Get(opCtx, sessionKeyPrefix+sessionID).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, apperrors.Missing("session")
+		}
+		return nil, err
+	}
+
+	var session models.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	session.ID = sessionID
+
+	return &session, nil
+}
+
+// Delete removes a session
+func (s *RedisSessionStore) Delete(ctx context.Context, sessionID string) error {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.client.Del(opCtx, sessionKeyPrefix+sessionID).Err()
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+)
+
+type SQLiteSessionRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteSessionRepository creates a SQLite backed session store
+func NewSQLiteSessionRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.SessionStoreInterface {
+	repo := &SQLiteSessionRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Create stores a new session and purges expired ones, SQLite has no TTL index
+func (r *SQLiteSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(writeCtx, `DELETE FROM sessions WHERE expires_at <= ?`, toMillis(time.Now())); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(writeCtx, `INSERT INTO sessions (id, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		session.ID, session.UserID, toMillis(session.CreatedAt), toMillis(session.ExpiresAt))
+	return err
+}
+
+// Find returns a session that has not expired yet
+func (r *SQLiteSessionRepository) Find(ctx context.Context, sessionID string) (*models.Session, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var (
+		session              models.Session
+		createdAt, expiresAt int64
+	)
+	err := r.db.QueryRowContext(readCtx, `SELECT id, user_id, created_at, expires_at FROM sessions
+		WHERE id = ? AND expires_at > ?`, sessionID, toMillis(time.Now()),
+	).Scan(&session.ID, &session.UserID, &createdAt, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Missing("session")
+		}
+		return nil, err
+	}
+
+	session.CreatedAt = fromMillis(createdAt)
+	session.ExpiresAt = fromMillis(expiresAt)
+
+	return &session, nil
+}
+
+// Delete removes a session
+func (r *SQLiteSessionRepository) Delete(ctx context.Context, sessionID string) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.db.ExecContext(writeCtx, `DELETE FROM sessions WHERE id = ?`, sessionID)
+	return err
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteMigrations build the SQLite schema. They run in order and each one
+// runs once; never edit a released migration, append a new one instead.
+var sqliteMigrations = []string{
+	// 1: initial schema
+	`CREATE TABLE donation_bars (
+		id                   TEXT PRIMARY KEY,
+		user_id              TEXT NOT NULL,
+		name                 TEXT NOT NULL,
+		description          TEXT NOT NULL DEFAULT '',
+		html                 TEXT NOT NULL,
+		css                  TEXT NOT NULL,
+		language             TEXT NOT NULL DEFAULT '',
+		currency             TEXT NOT NULL DEFAULT '',
+		theme                TEXT NOT NULL DEFAULT '',
+		is_active            INTEGER NOT NULL DEFAULT 1,
+		created_at           INTEGER NOT NULL,
+		updated_at           INTEGER NOT NULL,
+		initial_amount       REAL NOT NULL DEFAULT 0,
+		goal_amount          REAL NOT NULL DEFAULT 0,
+		donation_total       REAL NOT NULL DEFAULT 0,
+		prompt               TEXT NOT NULL DEFAULT '',
+		ai_generated         INTEGER NOT NULL DEFAULT 0,
+		has_valid_injections INTEGER NOT NULL DEFAULT 0,
+		revision             INTEGER NOT NULL DEFAULT 0,
+		overlay_token        TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX idx_donation_bars_user ON donation_bars (user_id, created_at);
+	CREATE UNIQUE INDEX idx_donation_bars_overlay_token ON donation_bars (overlay_token) WHERE overlay_token <> '';
+
+	CREATE TABLE donations (
+		id         TEXT PRIMARY KEY,
+		bar_id     TEXT NOT NULL,
+		user_id    TEXT NOT NULL,
+		donor_name TEXT NOT NULL DEFAULT '',
+		amount     REAL NOT NULL,
+		currency   TEXT NOT NULL DEFAULT '',
+		message    TEXT NOT NULL DEFAULT '',
+		source     TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX idx_donations_bar ON donations (bar_id, created_at);
+
+	CREATE TABLE bar_revisions (
+		id            TEXT PRIMARY KEY,
+		bar_id        TEXT NOT NULL,
+		user_id       TEXT NOT NULL,
+		revision      INTEGER NOT NULL,
+		author_id     TEXT NOT NULL DEFAULT '',
+		source        TEXT NOT NULL,
+		restored_from INTEGER,
+		html          TEXT NOT NULL,
+		css           TEXT NOT NULL,
+		html_added    INTEGER NOT NULL DEFAULT 0,
+		html_removed  INTEGER NOT NULL DEFAULT 0,
+		css_added     INTEGER NOT NULL DEFAULT 0,
+		css_removed   INTEGER NOT NULL DEFAULT 0,
+		created_at    INTEGER NOT NULL
+	);
+	CREATE UNIQUE INDEX idx_bar_revisions_number ON bar_revisions (bar_id, revision);
+
+	CREATE TABLE users (
+		id            TEXT PRIMARY KEY,
+		email         TEXT NOT NULL UNIQUE,
+		display_name  TEXT NOT NULL,
+		password_hash TEXT NOT NULL,
+		created_at    INTEGER NOT NULL
+	);
+
+	CREATE TABLE sessions (
+		id         TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL
+	);
+	CREATE INDEX idx_sessions_expires ON sessions (expires_at);
+
+	CREATE TABLE api_keys (
+		id           TEXT PRIMARY KEY,
+		user_id      TEXT NOT NULL,
+		name         TEXT NOT NULL,
+		prefix       TEXT NOT NULL,
+		key_hash     TEXT NOT NULL UNIQUE,
+		scopes       TEXT NOT NULL,
+		created_at   INTEGER NOT NULL,
+		last_used_at INTEGER,
+		revoked_at   INTEGER
+	);
+	CREATE INDEX idx_api_keys_user ON api_keys (user_id, created_at);
+
+	CREATE TABLE ai_jobs (
+		id          TEXT PRIMARY KEY,
+		user_id     TEXT NOT NULL,
+		kind        TEXT NOT NULL DEFAULT '',
+		status      TEXT NOT NULL,
+		request     TEXT NOT NULL,
+		save_bar    INTEGER NOT NULL DEFAULT 0,
+		attempts    INTEGER NOT NULL DEFAULT 0,
+		instruction TEXT NOT NULL DEFAULT '',
+		result      TEXT,
+		results     TEXT,
+		bar_id      TEXT NOT NULL DEFAULT '',
+		error       TEXT NOT NULL DEFAULT '',
+		created_at  INTEGER NOT NULL,
+		started_at  INTEGER,
+		finished_at INTEGER,
+		expires_at  INTEGER NOT NULL
+	);
+	CREATE INDEX idx_ai_jobs_status ON ai_jobs (status, created_at);
+	CREATE INDEX idx_ai_jobs_expires ON ai_jobs (expires_at);`,
+
+	// 2: plans and AI usage
+	`ALTER TABLE users ADD COLUMN plan TEXT NOT NULL DEFAULT '';
+
+	CREATE TABLE ai_usage (
+		user_id     TEXT NOT NULL,
+		period      TEXT NOT NULL,
+		generations INTEGER NOT NULL DEFAULT 0,
+		tokens      INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, period)
+	);`,
+
+	// 3: donation webhooks
+	`ALTER TABLE donation_bars ADD COLUMN webhook_token TEXT NOT NULL DEFAULT '';
+	ALTER TABLE donation_bars ADD COLUMN webhook_secret TEXT NOT NULL DEFAULT '';
+	CREATE UNIQUE INDEX idx_donation_bars_webhook_token ON donation_bars (webhook_token) WHERE webhook_token <> '';
+
+	ALTER TABLE donations ADD COLUMN external_id TEXT NOT NULL DEFAULT '';
+	CREATE UNIQUE INDEX idx_donations_external_id ON donations (bar_id, source, external_id) WHERE external_id <> '';`,
+
+	// 4: milestone events and outgoing webhooks
+	`CREATE TABLE bar_events (
+		id         TEXT PRIMARY KEY,
+		bar_id     TEXT NOT NULL,
+		user_id    TEXT NOT NULL,
+		type       TEXT NOT NULL,
+		campaign   TEXT NOT NULL,
+		milestone  INTEGER NOT NULL,
+		total      REAL NOT NULL,
+		goal       REAL NOT NULL,
+		currency   TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL,
+		UNIQUE (bar_id, campaign, milestone)
+	);
+
+	CREATE TABLE outgoing_webhooks (
+		id         TEXT PRIMARY KEY,
+		bar_id     TEXT NOT NULL,
+		user_id    TEXT NOT NULL,
+		url        TEXT NOT NULL,
+		secret     TEXT NOT NULL,
+		milestones TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX idx_outgoing_webhooks_bar ON outgoing_webhooks (bar_id, created_at);
+
+	CREATE TABLE webhook_deliveries (
+		id              TEXT PRIMARY KEY,
+		hook_id         TEXT NOT NULL,
+		bar_id          TEXT NOT NULL,
+		user_id         TEXT NOT NULL,
+		event_id        TEXT NOT NULL,
+		event_type      TEXT NOT NULL,
+		milestone       INTEGER NOT NULL,
+		url             TEXT NOT NULL,
+		payload         TEXT NOT NULL,
+		status          TEXT NOT NULL,
+		attempts        TEXT NOT NULL,
+		next_attempt_at INTEGER NOT NULL,
+		created_at      INTEGER NOT NULL,
+		updated_at      INTEGER NOT NULL
+	);
+	CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+	CREATE INDEX idx_webhook_deliveries_bar ON webhook_deliveries (bar_id, created_at);`,
+
+	// 5: outgoing webhook formats and message templates
+	`ALTER TABLE outgoing_webhooks ADD COLUMN format TEXT NOT NULL DEFAULT '';
+	ALTER TABLE outgoing_webhooks ADD COLUMN language TEXT NOT NULL DEFAULT '';
+	ALTER TABLE outgoing_webhooks ADD COLUMN template TEXT NOT NULL DEFAULT '';`,
+
+	// 6: stretch goals, a JSON array, and the goal a bar event is about
+	`ALTER TABLE donation_bars ADD COLUMN goals TEXT NOT NULL DEFAULT '[]';
+	ALTER TABLE bar_events ADD COLUMN stage INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE bar_events ADD COLUMN goal_label TEXT NOT NULL DEFAULT '';`,
+}
+
+// MigrateSQLite brings the SQLite schema up to date
+func MigrateSQLite(ctx context.Context, db *config.SQLiteDatabase) error {
+	if db == nil || db.DB == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	_, err := db.DB.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	if current > len(sqliteMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this binary (%d)", current, len(sqliteMigrations))
+	}
+
+	for version := current + 1; version <= len(sqliteMigrations); version++ {
+		if err := applySQLiteMigration(ctx, db.DB, version, sqliteMigrations[version-1]); err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+		slog.Info("SQLite migration applied", "version", version)
+	}
+
+	return nil
+}
+
+// applySQLiteMigration runs one migration and records it in the same transaction
+func applySQLiteMigration(ctx context.Context, db *sql.DB, version int, migration string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, toMillis(time.Now())); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// sqliteScanner is satisfied by *sql.Row and *sql.Rows
+type sqliteScanner interface {
+	Scan(dest ...any) error
+}
+
+// Times are stored as Unix milliseconds, the precision MongoDB keeps
+
+func toMillis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+func toNullMillis(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixMilli(), Valid: true}
+}
+
+func fromNullMillis(ms sql.NullInt64) *time.Time {
+	if !ms.Valid {
+		return nil
+	}
+	t := fromMillis(ms.Int64)
+	return &t
+}
+
+// toNullJSON stores a value as JSON text, nil pointers and slices as NULL
+func toNullJSON(v any, isNil bool) (sql.NullString, error) {
+	if isNil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// isUniqueViolation reports whether err comes from a UNIQUE constraint
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// requireAffected returns notFound when a write matched no row
+func requireAffected(result sql.Result, notFound error) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
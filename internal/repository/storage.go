@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/migrations"
+	"donationbars/internal/repository/memory"
+)
+
+// Stores bundles the repositories of one storage driver
+type Stores struct {
+	Driver    string
+	Bars      interfaces.BarRepositoryInterface
+	Donations interfaces.DonationRepositoryInterface
+	Revisions interfaces.RevisionRepositoryInterface
+	Users     interfaces.UserRepositoryInterface
+	Usage     interfaces.UsageRepositoryInterface
+	APIKeys   interfaces.APIKeyRepositoryInterface
+	Sessions  interfaces.SessionStoreInterface
+	Jobs      interfaces.JobStoreInterface
+
+	Events     interfaces.BarEventRepositoryInterface
+	Hooks      interfaces.OutgoingWebhookRepositoryInterface
+	Deliveries interfaces.WebhookDeliveryRepositoryInterface
+
+	ping  func(ctx context.Context) error
+	close func() error
+}
+
+// OpenStores connects the storage driver selected in the config and builds its repositories
+func OpenStores(cfg *config.Config, redisClient *config.RedisClient) (*Stores, error) {
+	switch cfg.Storage.Driver {
+	case config.StorageDriverMongo:
+		db, err := config.InitDB(cfg.MongoURI, cfg.Timeouts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+		}
+
+		if cfg.Storage.AutoMigrate {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Migration)
+			defer cancel()
+
+			if _, err := migrations.New(db).Up(ctx); err != nil {
+				db.Disconnect()
+				return nil, fmt.Errorf("failed to migrate MongoDB database: %w", err)
+			}
+		}
+		return NewMongoStores(db, redisClient, cfg.Timeouts), nil
+
+	case config.StorageDriverSQLite:
+		db, err := config.InitSQLite(cfg.Storage.SQLitePath, cfg.Timeouts)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Storage.AutoMigrate {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Migration)
+			defer cancel()
+
+			if err := MigrateSQLite(ctx, db); err != nil {
+				db.Close()
+				return nil, fmt.Errorf("failed to migrate SQLite database: %w", err)
+			}
+		}
+		return NewSQLiteStores(db, redisClient, cfg.Timeouts), nil
+
+	case config.StorageDriverMemory:
+		slog.Warn("Using in-memory storage, all data is lost on restart")
+		return NewMemoryStores(redisClient, cfg.Timeouts), nil
+	}
+
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+}
+
+// NewMongoStores builds the MongoDB repositories; sessions and jobs use Redis when it is enabled
+func NewMongoStores(db *config.Database, redisClient *config.RedisClient, timeouts config.TimeoutConfig) *Stores {
+	stores := &Stores{
+		Driver:    config.StorageDriverMongo,
+		Bars:      NewBarRepository(db, timeouts),
+		Donations: NewDonationRepository(db, timeouts),
+		Revisions: NewRevisionRepository(db, timeouts),
+		Users:     NewUserRepository(db, timeouts),
+		Usage:     NewUsageRepository(db, timeouts),
+		APIKeys:   NewAPIKeyRepository(db, timeouts),
+		Sessions:  NewSessionStore(db, redisClient, timeouts),
+		Jobs:      NewJobStore(db, redisClient, timeouts),
+
+		Events:     NewBarEventRepository(db, timeouts),
+		Hooks:      NewOutgoingWebhookRepository(db, timeouts),
+		Deliveries: NewWebhookDeliveryRepository(db, timeouts),
+	}
+
+	if db != nil {
+		stores.ping = func(ctx context.Context) error {
+			return db.Client.Ping(ctx, nil)
+		}
+		stores.close = db.Disconnect
+	}
+
+	return stores
+}
+
+// NewSQLiteStores builds the SQLite repositories; sessions and jobs use Redis when it is enabled
+func NewSQLiteStores(db *config.SQLiteDatabase, redisClient *config.RedisClient, timeouts config.TimeoutConfig) *Stores {
+	stores := &Stores{
+		Driver:    config.StorageDriverSQLite,
+		Bars:      NewSQLiteBarRepository(db, timeouts),
+		Donations: NewSQLiteDonationRepository(db, timeouts),
+		Revisions: NewSQLiteRevisionRepository(db, timeouts),
+		Users:     NewSQLiteUserRepository(db, timeouts),
+		Usage:     NewSQLiteUsageRepository(db, timeouts),
+		APIKeys:   NewSQLiteAPIKeyRepository(db, timeouts),
+
+		Events:     NewSQLiteBarEventRepository(db, timeouts),
+		Hooks:      NewSQLiteOutgoingWebhookRepository(db, timeouts),
+		Deliveries: NewSQLiteWebhookDeliveryRepository(db, timeouts),
+	}
+
+	if redisClient != nil && redisClient.IsEnabled() {
+		stores.Sessions = NewSessionStore(nil, redisClient, timeouts)
+		stores.Jobs = NewJobStore(nil, redisClient, timeouts)
+	} else {
+		slog.Info("Session store initialized", "backend", "sqlite")
+		slog.Info("Job store initialized", "backend", "sqlite")
+		stores.Sessions = NewSQLiteSessionRepository(db, timeouts)
+		stores.Jobs = NewSQLiteJobRepository(db, timeouts)
+	}
+
+	if db != nil {
+		stores.ping = db.DB.PingContext
+		stores.close = db.Close
+	}
+
+	return stores
+}
+
+// NewMemoryStores builds the in-memory repositories; sessions and jobs use Redis when it is enabled
+func NewMemoryStores(redisClient *config.RedisClient, timeouts config.TimeoutConfig) *Stores {
+	stores := &Stores{
+		Driver:    config.StorageDriverMemory,
+		Bars:      memory.NewBarRepository(),
+		Donations: memory.NewDonationRepository(),
+		Revisions: memory.NewRevisionRepository(),
+		Users:     memory.NewUserRepository(),
+		Usage:     memory.NewUsageRepository(),
+		APIKeys:   memory.NewAPIKeyRepository(),
+
+		Events:     memory.NewBarEventRepository(),
+		Hooks:      memory.NewOutgoingWebhookRepository(),
+		Deliveries: memory.NewWebhookDeliveryRepository(),
+
+		ping: func(ctx context.Context) error { return nil },
+	}
+
+	if redisClient != nil && redisClient.IsEnabled() {
+		stores.Sessions = NewSessionStore(nil, redisClient, timeouts)
+		stores.Jobs = NewJobStore(nil, redisClient, timeouts)
+	} else {
+		slog.Info("Session store initialized", "backend", "memory")
+		slog.Info("Job store initialized", "backend", "memory")
+		stores.Sessions = memory.NewSessionStore()
+		stores.Jobs = memory.NewJobStore()
+	}
+
+	return stores
+}
+
+// Ping checks that the database is reachable
+func (s *Stores) Ping(ctx context.Context) error {
+	if s.ping == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+	return s.ping(ctx)
+}
+
+// Close releases the database connection
+func (s *Stores) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	return s.close()
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type UsageRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewUsageRepository creates a new AI usage counter repository
+func NewUsageRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.UsageRepositoryInterface {
+	repo := &UsageRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("ai_usage")
+	}
+	return repo
+}
+
+// Add increments the counters of a period, creating it on first use
+func (r *UsageRepository) Add(ctx context.Context, userID, period string, generations, tokens int64) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	// The unique (user_id, period) index lets the server retry racing upserts
+	_, err := r.collection.UpdateOne(writeCtx,
+		bson.M{"user_id": userID, "period": period},
+		bson.M{"$inc": bson.M{"generations": generations, "tokens": tokens}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Find returns the counters of a period
+func (r *UsageRepository) Find(ctx context.Context, userID, period string) (*models.UsagePeriod, error) {
+	usage := &models.UsagePeriod{UserID: userID, Period: period}
+	if r.collection == nil {
+		return usage, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	err := r.collection.FindOne(readCtx, bson.M{"user_id": userID, "period": period}).Decode(usage)
+	if err == mongo.ErrNoDocuments {
+		return usage, nil
+	}
+	return usage, err
+}
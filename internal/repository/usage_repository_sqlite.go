@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+)
+
+type SQLiteUsageRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteUsageRepository creates a new SQLite backed AI usage counter repository
+func NewSQLiteUsageRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.UsageRepositoryInterface {
+	repo := &SQLiteUsageRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Add increments the counters of a period, creating it on first use
+func (r *SQLiteUsageRepository) Add(ctx context.Context, userID, period string, generations, tokens int64) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.db.ExecContext(writeCtx, `INSERT INTO ai_usage (user_id, period, generations, tokens)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, period) DO UPDATE SET
+			generations = generations + excluded.generations,
+			tokens = tokens + excluded.tokens`,
+		userID, period, generations, tokens,
+	)
+	return err
+}
+
+// Find returns the counters of a period
+func (r *SQLiteUsageRepository) Find(ctx context.Context, userID, period string) (*models.UsagePeriod, error) {
+	usage := &models.UsagePeriod{UserID: userID, Period: period}
+	if r.db == nil {
+		return usage, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	err := r.db.QueryRowContext(readCtx, `SELECT generations, tokens FROM ai_usage WHERE user_id = ? AND period = ?`,
+		userID, period,
+	).Scan(&usage.Generations, &usage.Tokens)
+	if errors.Is(err, sql.ErrNoRows) {
+		return usage, nil
+	}
+	return usage, err
+}
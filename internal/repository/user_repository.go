@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UserRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewUserRepository creates a new user account repository
+func NewUserRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.UserRepositoryInterface {
+	repo := &UserRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("users")
+	}
+	return repo
+}
+
+// Insert creates a user account
+func (r *UserRepository) Insert(ctx context.Context, user *models.User) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(writeCtx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return apperrors.Wrap(apperrors.ErrConflict, "email already registered")
+	}
+	return err
+}
+
+// FindByEmail finds a user by e-mail address, case-insensitively
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var user models.User
+	err := r.collection.FindOne(readCtx, bson.M{"email": strings.ToLower(email)}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("user")
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// FindByID finds a user by ID
+func (r *UserRepository) FindByID(ctx context.Context, userID string) (*models.User, error) {
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, apperrors.Missing("user")
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	var user models.User
+	err = r.collection.FindOne(readCtx, bson.M{"_id": objectID}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Missing("user")
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// SetPlan assigns a plan to a user
+func (r *UserRepository) SetPlan(ctx context.Context, userID, plan string) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return apperrors.Missing("user")
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(writeCtx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"plan": plan}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return apperrors.Missing("user")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SQLiteUserRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteUserRepository creates a new SQLite backed user repository
+func NewSQLiteUserRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.UserRepositoryInterface {
+	repo := &SQLiteUserRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Insert creates a user account
+func (r *SQLiteUserRepository) Insert(ctx context.Context, user *models.User) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.db.ExecContext(writeCtx, `INSERT INTO users (id, email, display_name, password_hash, plan, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		user.ID.Hex(), user.Email, user.DisplayName, user.PasswordHash, user.Plan, toMillis(user.CreatedAt),
+	)
+	if isUniqueViolation(err) {
+		return apperrors.Wrap(apperrors.ErrConflict, "email already registered")
+	}
+	return err
+}
+
+// FindByEmail finds a user by e-mail address, case-insensitively
+func (r *SQLiteUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	row := r.db.QueryRowContext(readCtx, `SELECT id, email, display_name, password_hash, plan, created_at
+		FROM users WHERE email = ?`, strings.ToLower(email))
+	return scanUserRow(row)
+}
+
+// FindByID finds a user by ID
+func (r *SQLiteUserRepository) FindByID(ctx context.Context, userID string) (*models.User, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, apperrors.Missing("user")
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	row := r.db.QueryRowContext(readCtx, `SELECT id, email, display_name, password_hash, plan, created_at
+		FROM users WHERE id = ?`, userID)
+	return scanUserRow(row)
+}
+
+// SetPlan assigns a plan to a user
+func (r *SQLiteUserRepository) SetPlan(ctx context.Context, userID, plan string) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.db.ExecContext(writeCtx, `UPDATE users SET plan = ? WHERE id = ?`, plan, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return apperrors.Missing("user")
+	}
+	return nil
+}
+
+// scanUserRow scans a single user, mapping a missing row to "user not found"
+func scanUserRow(row *sql.Row) (*models.User, error) {
+	var (
+		user      models.User
+		id        string
+		createdAt int64
+	)
+
+	err := row.Scan(&id, &user.Email, &user.DisplayName, &user.PasswordHash, &user.Plan, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Missing("user")
+		}
+		return nil, err
+	}
+
+	if user.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	user.CreatedAt = fromMillis(createdAt)
+
+	return &user, nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookDeliveryRepository struct {
+	db         *config.Database
+	collection *mongo.Collection
+	timeouts   config.TimeoutConfig
+}
+
+// NewWebhookDeliveryRepository creates a MongoDB backed webhook delivery repository
+func NewWebhookDeliveryRepository(db *config.Database, timeouts config.TimeoutConfig) interfaces.WebhookDeliveryRepositoryInterface {
+	repo := &WebhookDeliveryRepository{
+		db:       db,
+		timeouts: timeouts,
+	}
+	if db != nil && db.DB != nil {
+		repo.collection = db.DB.Collection("webhook_deliveries")
+	}
+	return repo
+}
+
+// Insert stores a new delivery
+func (r *WebhookDeliveryRepository) Insert(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(writeCtx, delivery)
+	return err
+}
+
+// Claim atomically takes a pending delivery that is due at now, pushing its
+// next attempt to leaseUntil so no other worker sends it meanwhile
+func (r *WebhookDeliveryRepository) Claim(ctx context.Context, deliveryID string, now, leaseUntil time.Time) (*models.WebhookDelivery, error) {
+	if r.collection == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		return nil, apperrors.Missing("delivery")
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":             objectID,
+		"status":          models.DeliveryStatusPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": leaseUntil}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var delivery models.WebhookDelivery
+	err = r.collection.FindOneAndUpdate(writeCtx, filter, update, opts).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, apperrors.Wrap(apperrors.ErrConflict, "delivery not claimable")
+		}
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// Save stores the outcome of a delivery attempt
+func (r *WebhookDeliveryRepository) Save(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if r.collection == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"updated_at":      delivery.UpdatedAt,
+	}}
+	result, err := r.collection.UpdateOne(writeCtx, bson.M{"_id": delivery.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return apperrors.Missing("delivery")
+	}
+	return nil
+}
+
+// FindDue returns pending deliveries whose next attempt is due, oldest first
+func (r *WebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	if r.collection == nil {
+		return []*models.WebhookDelivery{}, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	filter := bson.M{
+		"status":          models.DeliveryStatusPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(readCtx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []*models.WebhookDelivery{}
+	if err = cursor.All(readCtx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// FindByBarID returns the latest deliveries of a bar, newest first
+func (r *WebhookDeliveryRepository) FindByBarID(ctx context.Context, barID string, limit int) ([]*models.WebhookDelivery, error) {
+	if r.collection == nil {
+		return []*models.WebhookDelivery{}, nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(barID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(readCtx, bson.M{"bar_id": objectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []*models.WebhookDelivery{}
+	if err = cursor.All(readCtx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
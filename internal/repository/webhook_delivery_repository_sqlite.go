@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// deliveryColumns is the column list every delivery query selects, in scanDelivery order
+const deliveryColumns = `id, hook_id, bar_id, user_id, event_id, event_type, milestone, url,
+	payload, status, attempts, next_attempt_at, created_at, updated_at`
+
+type SQLiteWebhookDeliveryRepository struct {
+	db       *sql.DB
+	timeouts config.TimeoutConfig
+}
+
+// NewSQLiteWebhookDeliveryRepository creates a SQLite backed webhook delivery repository
+func NewSQLiteWebhookDeliveryRepository(db *config.SQLiteDatabase, timeouts config.TimeoutConfig) interfaces.WebhookDeliveryRepositoryInterface {
+	repo := &SQLiteWebhookDeliveryRepository{timeouts: timeouts}
+	if db != nil {
+		repo.db = db.DB
+	}
+	return repo
+}
+
+// Insert stores a new delivery
+func (r *SQLiteWebhookDeliveryRepository) Insert(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+
+	attempts, err := marshalAttempts(delivery.Attempts)
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	_, err = r.db.ExecContext(writeCtx, `INSERT INTO webhook_deliveries (`+deliveryColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		delivery.ID.Hex(), delivery.HookID.Hex(), delivery.BarID.Hex(), delivery.UserID, delivery.EventID.Hex(),
+		delivery.EventType, delivery.Milestone, delivery.URL, delivery.Payload, delivery.Status, attempts,
+		toMillis(delivery.NextAttemptAt), toMillis(delivery.CreatedAt), toMillis(delivery.UpdatedAt),
+	)
+	return err
+}
+
+// Claim atomically takes a pending delivery that is due at now, pushing its
+// next attempt to leaseUntil so no other worker sends it meanwhile
+func (r *SQLiteWebhookDeliveryRepository) Claim(ctx context.Context, deliveryID string, now, leaseUntil time.Time) (*models.WebhookDelivery, error) {
+	if r.db == nil {
+		return nil, apperrors.ErrDatabaseUnavailable
+	}
+
+	if _, err := primitive.ObjectIDFromHex(deliveryID); err != nil {
+		return nil, apperrors.Missing("delivery")
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	row := r.db.QueryRowContext(writeCtx, `UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id = ? AND status = ? AND next_attempt_at <= ?
+		RETURNING `+deliveryColumns,
+		toMillis(leaseUntil), deliveryID, models.DeliveryStatusPending, toMillis(now),
+	)
+	delivery, err := scanDelivery(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.Wrap(apperrors.ErrConflict, "delivery not claimable")
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// Save stores the outcome of a delivery attempt
+func (r *SQLiteWebhookDeliveryRepository) Save(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if r.db == nil {
+		return apperrors.ErrDatabaseUnavailable
+	}
+
+	attempts, err := marshalAttempts(delivery.Attempts)
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseWrite)
+	defer cancel()
+
+	result, err := r.db.ExecContext(writeCtx, `UPDATE webhook_deliveries SET
+		status = ?, attempts = ?, next_attempt_at = ?, updated_at = ?
+		WHERE id = ?`,
+		delivery.Status, attempts, toMillis(delivery.NextAttemptAt), toMillis(delivery.UpdatedAt), delivery.ID.Hex(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, apperrors.Missing("delivery"))
+}
+
+// FindDue returns pending deliveries whose next attempt is due, oldest first
+func (r *SQLiteWebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	if r.db == nil {
+		return []*models.WebhookDelivery{}, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(readCtx, `SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, rowid LIMIT ?`,
+		models.DeliveryStatusPending, toMillis(now), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// FindByBarID returns the latest deliveries of a bar, newest first
+func (r *SQLiteWebhookDeliveryRepository) FindByBarID(ctx context.Context, barID string, limit int) ([]*models.WebhookDelivery, error) {
+	if r.db == nil {
+		return []*models.WebhookDelivery{}, nil
+	}
+
+	if _, err := primitive.ObjectIDFromHex(barID); err != nil {
+		return nil, apperrors.ErrInvalidBarID
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, r.timeouts.DatabaseRead)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(readCtx, `SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE bar_id = ? ORDER BY created_at DESC, rowid DESC LIMIT ?`, barID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// marshalAttempts stores the attempt log as JSON text, an empty log as []
+func marshalAttempts(attempts []models.DeliveryAttempt) (string, error) {
+	if attempts == nil {
+		attempts = []models.DeliveryAttempt{}
+	}
+	data, err := json.Marshal(attempts)
+	return string(data), err
+}
+
+// scanDeliveries scans and closes a delivery result set
+func scanDeliveries(rows *sql.Rows) ([]*models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// scanDelivery scans the columns of deliveryColumns into a delivery
+func scanDelivery(s sqliteScanner) (*models.WebhookDelivery, error) {
+	var (
+		delivery                          models.WebhookDelivery
+		id, hookID, barID, eventID        string
+		attempts                          string
+		nextAttemptAt, createdAt, updated int64
+	)
+
+	err := s.Scan(&id, &hookID, &barID, &delivery.UserID, &eventID, &delivery.EventType, &delivery.Milestone,
+		&delivery.URL, &delivery.Payload, &delivery.Status, &attempts, &nextAttemptAt, &createdAt, &updated)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		hex string
+		id  *primitive.ObjectID
+	}{{id, &delivery.ID}, {hookID, &delivery.HookID}, {barID, &delivery.BarID}, {eventID, &delivery.EventID}} {
+		if *field.id, err = primitive.ObjectIDFromHex(field.hex); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal([]byte(attempts), &delivery.Attempts); err != nil {
+		return nil, err
+	}
+	delivery.NextAttemptAt = fromMillis(nextAttemptAt)
+	delivery.CreatedAt = fromMillis(createdAt)
+	delivery.UpdatedAt = fromMillis(updated)
+
+	return &delivery, nil
+}
@@ -0,0 +1,385 @@
+package sanitize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// customPropertyName is the only form of custom property ("--name") kept;
+// the name is written back as is
+var customPropertyName = regexp.MustCompile(`^--[A-Za-z0-9_-]+$`)
+
+// allowedProperties are the CSS properties a bar may use. Vendor prefixes are
+// stripped before the lookup.
+var allowedProperties = map[string]bool{
+	"align-content": true, "align-items": true, "align-self": true,
+	"animation": true, "animation-delay": true, "animation-direction": true,
+	"animation-duration": true, "animation-fill-mode": true, "animation-iteration-count": true,
+	"animation-name": true, "animation-play-state": true, "animation-timing-function": true,
+	"background": true, "background-clip": true, "background-color": true,
+	"background-image": true, "background-origin": true, "background-position": true,
+	"background-repeat": true, "background-size": true,
+	"border": true, "border-bottom": true, "border-collapse": true, "border-color": true,
+	"border-left": true, "border-radius": true, "border-right": true, "border-spacing": true,
+	"border-style": true, "border-top": true, "border-width": true,
+	"border-top-left-radius": true, "border-top-right-radius": true,
+	"border-bottom-left-radius": true, "border-bottom-right-radius": true,
+	"bottom": true, "box-shadow": true, "box-sizing": true,
+	"clip-path": true, "color": true, "column-gap": true, "content": true, "cursor": true,
+	"direction": true, "display": true,
+	"flex": true, "flex-basis": true, "flex-direction": true, "flex-flow": true,
+	"flex-grow": true, "flex-shrink": true, "flex-wrap": true, "float": true,
+	"font": true, "font-family": true, "font-size": true, "font-style": true,
+	"font-variant": true, "font-weight": true,
+	"gap": true, "grid": true, "grid-area": true, "grid-column": true, "grid-gap": true,
+	"grid-row": true, "grid-template": true, "grid-template-areas": true,
+	"grid-template-columns": true, "grid-template-rows": true,
+	"height": true, "inset": true,
+	"justify-content": true, "justify-items": true, "justify-self": true,
+	"left": true, "letter-spacing": true, "line-height": true, "list-style": true,
+	"margin": true, "margin-bottom": true, "margin-left": true, "margin-right": true, "margin-top": true,
+	"max-height": true, "max-width": true, "min-height": true, "min-width": true,
+	"mix-blend-mode": true, "object-fit": true, "object-position": true, "opacity": true, "order": true,
+	"outline": true, "outline-color": true, "outline-offset": true, "outline-style": true, "outline-width": true,
+	"overflow": true, "overflow-x": true, "overflow-y": true,
+	"padding": true, "padding-bottom": true, "padding-left": true, "padding-right": true, "padding-top": true,
+	"pointer-events": true, "position": true, "right": true, "row-gap": true,
+	"text-align": true, "text-decoration": true, "text-indent": true, "text-overflow": true,
+	"text-shadow": true, "text-transform": true, "top": true,
+	"transform": true, "transform-origin": true,
+	"transition": true, "transition-delay": true, "transition-duration": true,
+	"transition-property": true, "transition-timing-function": true,
+	"vertical-align": true, "visibility": true, "white-space": true, "width": true,
+	"will-change": true, "word-break": true, "word-spacing": true, "word-wrap": true, "z-index": true,
+}
+
+// forbiddenValues are rejected anywhere in a declaration value
+var forbiddenValues = []string{
+	"expression(", "javascript:", "vbscript:", "behavior", "-moz-binding",
+	"image-set(", "src(", "</",
+}
+
+// CSS sanitizes a stylesheet, keeping only allowed rules and declarations
+func CSS(src string) (string, *Report) {
+	report := &Report{}
+	return sanitizeStylesheet(stripComments(src), report), report
+}
+
+// sanitizeStylesheet rebuilds a list of rules
+func sanitizeStylesheet(src string, report *Report) string {
+	var out strings.Builder
+
+	for i := 0; i < len(src); {
+		// Skip whitespace and stray separators between rules
+		if isSpace(src[i]) || src[i] == ';' || src[i] == '}' {
+			i++
+			continue
+		}
+
+		// The prelude runs up to a block or, for statement at-rules, a semicolon
+		end := indexOutside(src[i:], "{;")
+		if end == -1 {
+			break
+		}
+		prelude := strings.TrimSpace(src[i : i+end])
+		if src[i+end] == ';' {
+			if strings.HasPrefix(prelude, "@") {
+				report.add(KindAtRule, atRuleName(prelude))
+			}
+			i += end + 1
+			continue
+		}
+
+		blockStart := i + end + 1
+		blockEnd := matchingBrace(src, blockStart)
+		body := src[blockStart:blockEnd]
+		i = min(blockEnd+1, len(src))
+
+		// A stylesheet may end up inside a <style> element
+		if strings.Contains(prelude, "<") {
+			report.add(KindValue, "selector")
+			continue
+		}
+
+		if strings.HasPrefix(prelude, "@") {
+			name := atRuleName(prelude)
+			if !isKeyframes(name) {
+				report.add(KindAtRule, name)
+				continue
+			}
+			inner := sanitizeStylesheet(body, report)
+			out.WriteString(prelude + " {\n" + inner + "}\n")
+			continue
+		}
+
+		declarations := sanitizeDeclarations(body, report)
+		if declarations == "" {
+			continue
+		}
+		out.WriteString(prelude + " {\n  " + strings.ReplaceAll(declarations, "; ", ";\n  ") + ";\n}\n")
+	}
+
+	return out.String()
+}
+
+// sanitizeDeclarations filters a declaration list such as a style attribute.
+// Declarations are joined with "; " and have no trailing semicolon.
+func sanitizeDeclarations(src string, report *Report) string {
+	var kept []string
+
+	for _, declaration := range splitOutside(stripComments(src), ';') {
+		name, value, ok := strings.Cut(declaration, ":")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			continue
+		}
+
+		property := strings.ToLower(unescape(name))
+		if !isAllowedProperty(name, property) {
+			report.add(KindProperty, property)
+			continue
+		}
+		if url, bad := checkValue(value); bad != "" {
+			if url != "" {
+				report.add(KindURL, url)
+			} else {
+				report.add(KindValue, property)
+			}
+			continue
+		}
+
+		kept = append(kept, name+": "+value)
+	}
+
+	return strings.Join(kept, "; ")
+}
+
+// isAllowedProperty checks a declaration name, as written and as its
+// lower-cased, unescaped property, against the allowlist
+func isAllowedProperty(name, property string) bool {
+	if strings.Contains(name, "<") {
+		return false
+	}
+	if strings.HasPrefix(property, "--") {
+		return customPropertyName.MatchString(name) // values are still checked
+	}
+	for _, prefix := range []string{"-webkit-", "-moz-", "-ms-", "-o-"} {
+		if strings.HasPrefix(property, prefix) {
+			property = strings.TrimPrefix(property, prefix)
+			break
+		}
+	}
+	return allowedProperties[property]
+}
+
+// checkValue inspects a declaration value after decoding CSS escapes. It
+// returns a non-empty reason when the value is unsafe, and the offending URL
+// when the reason is a URL.
+func checkValue(value string) (url, reason string) {
+	decoded := strings.ToLower(unescape(value))
+
+	for _, forbidden := range forbiddenValues {
+		if strings.Contains(decoded, forbidden) {
+			return "", forbidden
+		}
+	}
+
+	for rest := decoded; ; {
+		start := strings.Index(rest, "url(")
+		if start == -1 {
+			return "", ""
+		}
+		rest = rest[start+4:]
+		end := indexOutside(rest, ")")
+		if end == -1 {
+			end = len(rest)
+		}
+		target := strings.Trim(strings.TrimSpace(rest[:end]), `"'`)
+		if !isSafeURL(target) {
+			return target, "external url"
+		}
+		rest = rest[end:]
+	}
+}
+
+// isSafeURL allows only inline raster images
+func isSafeURL(url string) bool {
+	url = strings.ToLower(strings.TrimSpace(url))
+	for _, prefix := range []string{"data:image/png", "data:image/gif", "data:image/jpeg", "data:image/webp"} {
+		if strings.HasPrefix(url, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKeyframes reports whether an at-rule defines an animation
+func isKeyframes(name string) bool {
+	switch name {
+	case "@keyframes", "@-webkit-keyframes", "@-moz-keyframes":
+		return true
+	}
+	return false
+}
+
+// atRuleName returns the lower-cased, unescaped name of an at-rule prelude
+func atRuleName(prelude string) string {
+	end := strings.IndexFunc(prelude[1:], func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '(' || r == '"' || r == '\''
+	})
+	if end == -1 {
+		end = len(prelude) - 1
+	}
+	return strings.ToLower(unescape(prelude[:end+1]))
+}
+
+// stripComments removes /* */ comments outside of strings
+func stripComments(src string) string {
+	var out strings.Builder
+	var quote byte
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(src) {
+				out.WriteByte(c)
+				i++
+				c = src[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				return out.String()
+			}
+			i += end + 3
+			out.WriteByte(' ')
+			continue
+		}
+		out.WriteByte(c)
+	}
+
+	return out.String()
+}
+
+// indexOutside returns the index of the first of chars found outside of
+// strings, parentheses and nested blocks
+func indexOutside(src, chars string) int {
+	var quote byte
+	depth := 0
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case c == '"' || c == '\'':
+			quote = c
+		case depth == 0 && strings.IndexByte(chars, c) != -1:
+			return i
+		case c == '(' || c == '[':
+			depth++
+		case (c == ')' || c == ']') && depth > 0:
+			depth--
+		}
+	}
+
+	return -1
+}
+
+// splitOutside splits on sep outside of strings and parentheses
+func splitOutside(src string, sep byte) []string {
+	var parts []string
+	for {
+		i := indexOutside(src, string(sep))
+		if i == -1 {
+			return append(parts, src)
+		}
+		parts = append(parts, src[:i])
+		src = src[i+1:]
+	}
+}
+
+// matchingBrace returns the index of the brace closing the block that starts
+// at start, or len(src) when the block is unterminated
+func matchingBrace(src string, start int) int {
+	depth := 1
+	for i := start; i < len(src); {
+		next := indexOutside(src[i:], "{}")
+		if next == -1 {
+			return len(src)
+		}
+		i += next
+		if src[i] == '{' {
+			depth++
+		} else {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+	return len(src)
+}
+
+// unescape decodes CSS escapes such as "\65" or "\:" so that checks see what
+// the browser sees
+func unescape(src string) string {
+	if !strings.Contains(src, `\`) {
+		return src
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(src); i++ {
+		if src[i] != '\\' || i+1 == len(src) {
+			out.WriteByte(src[i])
+			continue
+		}
+
+		j := i + 1
+		for j < len(src) && j-i <= 6 && isHex(src[j]) {
+			j++
+		}
+		if j == i+1 {
+			// A simple escape like "\:", an escaped newline is dropped
+			if src[j] != '\n' {
+				out.WriteByte(src[j])
+			}
+			i = j
+			continue
+		}
+
+		code, _ := strconv.ParseUint(src[i+1:j], 16, 32)
+		if code == 0 || code > 0x10FFFF {
+			code = 0xFFFD
+		}
+		out.WriteRune(rune(code))
+		if j < len(src) && isSpace(src[j]) {
+			j++ // one whitespace terminates a hex escape
+		}
+		i = j - 1
+	}
+
+	return out.String()
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,199 @@
+package sanitize
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags are kept, with their allowed attributes
+var allowedTags = map[string]bool{
+	"div": true, "span": true, "p": true, "br": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"strong": true, "b": true, "em": true, "i": true, "u": true, "s": true,
+	"small": true, "sub": true, "sup": true, "mark": true,
+	"ul": true, "ol": true, "li": true,
+	"section": true, "header": true, "footer": true, "article": true, "aside": true, "main": true,
+	"figure": true, "figcaption": true, "label": true,
+	"progress": true, "meter": true, "img": true, "style": true,
+	"table": true, "thead": true, "tbody": true, "tr": true, "td": true, "th": true,
+}
+
+// droppedTags are removed together with their content. Other tags that are
+// not allowed are unwrapped and their children kept.
+var droppedTags = map[string]bool{
+	"script": true, "iframe": true, "frame": true, "frameset": true, "object": true,
+	"embed": true, "applet": true, "svg": true, "math": true, "template": true,
+	"noscript": true, "noembed": true, "noframes": true, "xmp": true, "plaintext": true,
+	"link": true, "meta": true, "base": true, "title": true, "head": true,
+	"form": true, "input": true, "button": true, "textarea": true, "select": true,
+	"audio": true, "video": true, "source": true, "track": true, "canvas": true,
+}
+
+// globalAttributes are allowed on every tag
+var globalAttributes = map[string]bool{
+	"class": true, "id": true, "title": true, "role": true, "lang": true,
+	"dir": true, "hidden": true, "style": true,
+}
+
+// tagAttributes are allowed on specific tags
+var tagAttributes = map[string]map[string]bool{
+	"img":      {"src": true, "alt": true, "width": true, "height": true},
+	"progress": {"value": true, "max": true},
+	"meter":    {"value": true, "min": true, "max": true, "low": true, "high": true, "optimum": true},
+	"ol":       {"start": true, "reversed": true},
+	"li":       {"value": true},
+	"td":       {"colspan": true, "rowspan": true},
+	"th":       {"colspan": true, "rowspan": true, "scope": true},
+}
+
+// HTML sanitizes a bar fragment, keeping only allowed tags and attributes.
+// Injection placeholders such as {total} are plain text and survive as-is.
+func HTML(src string) (string, *Report) {
+	report := &Report{}
+
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(src), context)
+	if err != nil {
+		report.add(KindTag, "document")
+		return "", report
+	}
+
+	var out strings.Builder
+	for _, node := range nodes {
+		context.AppendChild(node)
+	}
+	sanitizeChildren(context, report)
+	for node := context.FirstChild; node != nil; node = node.NextSibling {
+		if err := html.Render(&out, node); err != nil {
+			report.add(KindTag, "document")
+			return "", report
+		}
+	}
+
+	return strings.TrimSpace(out.String()), report
+}
+
+// Bar sanitizes the HTML and CSS of a bar and merges the reports
+func Bar(htmlSrc, cssSrc string) (string, string, *Report) {
+	cleanHTML, report := HTML(htmlSrc)
+	cleanCSS, cssReport := CSS(cssSrc)
+	report.Merge(cssReport)
+	return cleanHTML, strings.TrimSpace(cleanCSS), report
+}
+
+// sanitizeChildren filters the children of a node in place
+func sanitizeChildren(parent *html.Node, report *Report) {
+	for node := parent.FirstChild; node != nil; {
+		next := node.NextSibling
+
+		switch node.Type {
+		case html.TextNode, html.CommentNode:
+			// Text is escaped on render, comments cannot break out after parsing
+		case html.ElementNode:
+			next = sanitizeElement(parent, node, next, report)
+		default:
+			parent.RemoveChild(node)
+		}
+
+		node = next
+	}
+}
+
+// sanitizeElement filters one element and returns the node to visit next
+func sanitizeElement(parent, node, next *html.Node, report *Report) *html.Node {
+	tag := strings.ToLower(node.Data)
+
+	if node.Namespace != "" || droppedTags[tag] {
+		report.add(KindTag, tag)
+		parent.RemoveChild(node)
+		return next
+	}
+
+	if !allowedTags[tag] {
+		// Unwrap: move the children in place of the element and visit them
+		report.add(KindTag, tag)
+		first := node.FirstChild
+		for child := node.FirstChild; child != nil; child = node.FirstChild {
+			node.RemoveChild(child)
+			parent.InsertBefore(child, node)
+		}
+		parent.RemoveChild(node)
+		if first != nil {
+			return first
+		}
+		return next
+	}
+
+	if tag == "style" {
+		sanitizeStyleElement(parent, node, report)
+		return next
+	}
+
+	node.Attr = sanitizeAttributes(tag, node.Attr, report)
+	sanitizeChildren(node, report)
+	return next
+}
+
+// sanitizeStyleElement runs the CSS sanitizer over an inline stylesheet
+func sanitizeStyleElement(parent, node *html.Node, report *Report) {
+	var css strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			css.WriteString(child.Data)
+		}
+	}
+
+	clean, cssReport := CSS(css.String())
+	report.Merge(cssReport)
+	if strings.TrimSpace(clean) == "" {
+		parent.RemoveChild(node)
+		return
+	}
+
+	for child := node.FirstChild; child != nil; child = node.FirstChild {
+		node.RemoveChild(child)
+	}
+	node.Attr = nil
+	node.AppendChild(&html.Node{Type: html.TextNode, Data: clean})
+}
+
+// sanitizeAttributes keeps the allowed attributes of a tag
+func sanitizeAttributes(tag string, attrs []html.Attribute, report *Report) []html.Attribute {
+	kept := attrs[:0]
+
+	for _, attr := range attrs {
+		name := strings.ToLower(attr.Key)
+
+		if attr.Namespace != "" || !isAllowedAttribute(tag, name) {
+			report.add(KindAttribute, name)
+			continue
+		}
+
+		switch name {
+		case "style":
+			attr.Val = sanitizeDeclarations(attr.Val, report)
+			if attr.Val == "" {
+				continue
+			}
+		case "src":
+			if !isSafeURL(attr.Val) {
+				report.add(KindURL, attr.Val)
+				continue
+			}
+		}
+
+		kept = append(kept, attr)
+	}
+
+	return kept
+}
+
+// isAllowedAttribute checks an attribute against the global and tag allowlists
+func isAllowedAttribute(tag, name string) bool {
+	if globalAttributes[name] || tagAttributes[tag][name] {
+		return true
+	}
+	return strings.HasPrefix(name, "aria-") || strings.HasPrefix(name, "data-")
+}
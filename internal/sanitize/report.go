@@ -0,0 +1,83 @@
+package sanitize
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind classifies what was removed
+type Kind string
+
+const (
+	KindTag       Kind = "tag"
+	KindAttribute Kind = "attribute"
+	KindProperty  Kind = "property"
+	KindAtRule    Kind = "at-rule"
+	KindValue     Kind = "value"
+	KindURL       Kind = "url"
+)
+
+// maxReportedName bounds the length of names kept in a report
+const maxReportedName = 80
+
+// Removal is one kind of content dropped by the sanitizer
+type Removal struct {
+	Kind  Kind   `json:"kind"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Report lists everything the sanitizer removed
+type Report struct {
+	Removed []Removal `json:"removed"`
+}
+
+// Empty reports whether nothing was removed
+func (r *Report) Empty() bool {
+	return r == nil || len(r.Removed) == 0
+}
+
+// Merge adds the removals of another report
+func (r *Report) Merge(other *Report) {
+	if other == nil {
+		return
+	}
+	for _, removal := range other.Removed {
+		r.addN(removal.Kind, removal.Name, removal.Count)
+	}
+}
+
+// String summarizes the report, e.g. "tag script, attribute onclick x2"
+func (r *Report) String() string {
+	if r.Empty() {
+		return ""
+	}
+	parts := make([]string, 0, len(r.Removed))
+	for _, removal := range r.Removed {
+		part := fmt.Sprintf("%s %s", removal.Kind, removal.Name)
+		if removal.Count > 1 {
+			part += fmt.Sprintf(" x%d", removal.Count)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// add records one removal
+func (r *Report) add(kind Kind, name string) {
+	r.addN(kind, name, 1)
+}
+
+// addN records removals, merging repeats of the same kind and name
+func (r *Report) addN(kind Kind, name string, count int) {
+	if len(name) > maxReportedName {
+		name = name[:maxReportedName] + "..."
+	}
+	for i := range r.Removed {
+		if r.Removed[i].Kind == kind && r.Removed[i].Name == name {
+			r.Removed[i].Count += count
+			return
+		}
+	}
+	r.Removed = append(r.Removed, Removal{Kind: kind, Name: name, Count: count})
+}
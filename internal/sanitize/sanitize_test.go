@@ -0,0 +1,147 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML_RemovesDangerousContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+		removed  Removal
+	}{
+		{
+			name:     "Script tag with content",
+			html:     `<div>{total}<script>alert(1)</script></div>`,
+			expected: `<div>{total}</div>`,
+			removed:  Removal{Kind: KindTag, Name: "script", Count: 1},
+		},
+		{
+			name:     "Event handler attribute",
+			html:     `<div onclick="alert(1)" class="bar">{goal}</div>`,
+			expected: `<div class="bar">{goal}</div>`,
+			removed:  Removal{Kind: KindAttribute, Name: "onclick", Count: 1},
+		},
+		{
+			name:     "Entity encoded javascript URL in style",
+			html:     `<div style="background: url(&#106;avascript:alert(1)); color: red">x</div>`,
+			expected: `<div style="color: red">x</div>`,
+			removed:  Removal{Kind: KindValue, Name: "background", Count: 1},
+		},
+		{
+			name:     "External image",
+			html:     `<img src="https://evil.example/track.png" alt="x">`,
+			expected: `<img alt="x"/>`,
+			removed:  Removal{Kind: KindURL, Name: "https://evil.example/track.png", Count: 1},
+		},
+		{
+			name:     "Unknown tag is unwrapped",
+			html:     `<a href="https://evil.example"><span>{description}</span></a>`,
+			expected: `<span>{description}</span>`,
+			removed:  Removal{Kind: KindTag, Name: "a", Count: 1},
+		},
+		{
+			name:     "Script nested in unwrapped tag",
+			html:     `<font><b>ok</b><script>alert(1)</script></font>`,
+			expected: `<b>ok</b>`,
+			removed:  Removal{Kind: KindTag, Name: "script", Count: 1},
+		},
+		{
+			name:     "SVG with onload",
+			html:     `<svg onload="alert(1)"><circle r="5"></circle></svg><p>ok</p>`,
+			expected: `<p>ok</p>`,
+			removed:  Removal{Kind: KindTag, Name: "svg", Count: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, report := HTML(tt.html)
+
+			assert.Equal(t, tt.expected, result)
+			assert.Contains(t, report.Removed, tt.removed)
+		})
+	}
+}
+
+func TestHTML_KeepsPlaceholdersAndAllowedMarkup(t *testing.T) {
+	src := `<div class="donation-bar" data-theme="neon" aria-label="bar">` +
+		`<div class="progress-fill" style="width: {percentage}%"></div>` +
+		`<span>{total_formatted}</span><progress value="5" max="10"></progress></div>`
+
+	result, report := HTML(src)
+
+	assert.Equal(t, src, result)
+	assert.True(t, report.Empty())
+}
+
+func TestCSS_FiltersRulesAndDeclarations(t *testing.T) {
+	src := `@import url("https://evil.example/x.css");
+/* comment */
+.bar {
+	width: 800px;
+	background: url('http://evil.com');
+	behavior: url('evil.htc');
+	-moz-binding: url('evil.xml');
+	color: \65xpression(alert(1));
+	-webkit-transition: all 0.3s ease;
+}
+@media (max-width: 100px) { .bar { color: red; } }
+@keyframes pulse { from { opacity: 0.5; } to { opacity: 1; } }`
+
+	result, report := CSS(src)
+
+	assert.Equal(t, ".bar {\n  width: 800px;\n  -webkit-transition: all 0.3s ease;\n}\n"+
+		"@keyframes pulse {\nfrom {\n  opacity: 0.5;\n}\nto {\n  opacity: 1;\n}\n}\n", result)
+	assert.Equal(t, []Removal{
+		{Kind: KindAtRule, Name: "@import", Count: 1},
+		{Kind: KindURL, Name: "http://evil.com", Count: 1},
+		{Kind: KindProperty, Name: "behavior", Count: 1},
+		{Kind: KindProperty, Name: "-moz-binding", Count: 1},
+		{Kind: KindValue, Name: "color", Count: 1},
+		{Kind: KindAtRule, Name: "@media", Count: 1},
+	}, report.Removed)
+}
+
+func TestCSS_EscapedURLIsDetected(t *testing.T) {
+	result, report := CSS(`.a { background: u\72l(//evil.example/x.png); color: red; }`)
+
+	assert.Equal(t, ".a {\n  color: red;\n}\n", result)
+	assert.Equal(t, []Removal{{Kind: KindURL, Name: "//evil.example/x.png", Count: 1}}, report.Removed)
+}
+
+func TestCSS_AllowsInlineImages(t *testing.T) {
+	result, report := CSS(`.a { background-image: url("data:image/png;base64,AAAA"); }`)
+
+	assert.Equal(t, ".a {\n  background-image: url(\"data:image/png;base64,AAAA\");\n}\n", result)
+	assert.True(t, report.Empty())
+}
+
+func TestCSS_CustomPropertyCannotBreakOut(t *testing.T) {
+	result, report := CSS(".a { --x</style><img src=x onerror=alert(1)>: red; --bar-color_2: #fff }")
+
+	assert.Equal(t, ".a {\n  --bar-color_2: #fff;\n}\n", result)
+	assert.Equal(t, []Removal{{Kind: KindProperty, Name: "--x</style><img src=x onerror=alert(1)>", Count: 1}}, report.Removed)
+
+	for _, src := range []string{
+		`.a { --x\3c/style: red; }`,
+		`.a { \2d-x: red; }`,
+		`.a { --x y: red; }`,
+		`.a < .b { color: red; }`,
+	} {
+		result, report := CSS(src)
+		assert.Empty(t, result, src)
+		assert.False(t, report.Empty(), src)
+	}
+}
+
+func TestHTML_StyleElementCannotBreakOut(t *testing.T) {
+	result, report := HTML(`<style>.a { color: red; } .b</style><script>alert(1)</script>{ color: blue; }</style>`)
+
+	assert.NotContains(t, result, "<script")
+	assert.Contains(t, result, "<style>.a {\n  color: red;\n}\n</style>")
+	assert.False(t, report.Empty())
+}
@@ -7,96 +7,185 @@ import (
 	"fmt"
 	"log/slog"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"donationbars/internal/interfaces"
 	"donationbars/internal/models"
-
-	"github.com/sashabaranov/go-openai"
+	"donationbars/internal/render"
+	"donationbars/internal/sanitize"
 )
 
 type AIService struct {
-	client  *openai.Client
-	timeout time.Duration
+	provider interfaces.AIProviderInterface
+	timeout  time.Duration
 }
 
-func NewAIService(apiKey string, timeout time.Duration) interfaces.AIServiceInterface {
-	if apiKey == "" || apiKey == "your_openai_api_key_here" {
-		slog.Error("OpenAI API key missing or invalid",
-			"key_provided", apiKey != "",
-			"is_placeholder", apiKey == "your_openai_api_key_here")
-		return &AIService{client: nil, timeout: timeout}
+// NewAIService creates the AI service on top of a provider; a nil provider
+// leaves generation unavailable
+func NewAIService(provider interfaces.AIProviderInterface, timeout time.Duration) interfaces.AIServiceInterface {
+	if provider == nil {
+		slog.Error("AI provider missing, AI generation is disabled")
+		return &AIService{provider: nil, timeout: timeout}
 	}
 
 	slog.Info("AI Service initialized successfully",
 		"timeout", timeout,
-		"model", "gpt-4o-mini")
+		"provider", provider.Name())
 
 	return &AIService{
-		client:  openai.NewClient(apiKey),
-		timeout: timeout,
+		provider: provider,
+		timeout:  timeout,
 	}
 }
 
 // GenerateBar generates a donation bar using AI
 func (s *AIService) GenerateBar(req *models.GenerateBarRequest) (*models.AIGenerateResponse, error) {
-	if s.client == nil {
-		slog.Error("AI service unavailable - client not initialized")
-		return nil, errors.New("OpenAI API key eksik veya geçersiz. Lütfen .env dosyasında OPENAI_API_KEY değişkenini ayarlayın")
-	}
-
 	slog.Info("Starting AI bar generation",
 		"prompt_length", len(req.Prompt),
 		"language", req.Language,
 		"theme", req.Theme,
 		"timeout", s.timeout)
 
+	return s.complete(s.buildEnhancedPrompt(req), req, 0)
+}
+
+// GenerateVariations generates the requested number of designs concurrently.
+// Designs that fail parsing or validation are dropped; an error is returned
+// only when none succeeded.
+func (s *AIService) GenerateVariations(req *models.GenerateBarRequest) ([]*models.AIGenerateResponse, error) {
+	count := req.VariationCount()
+	if count == 1 {
+		result, err := s.GenerateBar(req)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.AIGenerateResponse{result}, nil
+	}
+
+	slog.Info("Starting AI variation generation",
+		"prompt_length", len(req.Prompt),
+		"variations", count,
+		"timeout", s.timeout)
+
+	basePrompt := s.buildEnhancedPrompt(req)
+	results := make([]*models.AIGenerateResponse, count)
+	errs := make([]error, count)
+
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = s.complete(basePrompt+variationHint(i, count, req.Language), req, i)
+		}(i)
+	}
+	wg.Wait()
+
+	// Keep the order of the variations so the chooser is stable
+	var succeeded []*models.AIGenerateResponse
+	var lastErr error
+	for i := range results {
+		if errs[i] != nil {
+			slog.Warn("AI variation discarded", "variation", i+1, "error", errs[i].Error())
+			lastErr = errs[i]
+			continue
+		}
+		succeeded = append(succeeded, results[i])
+	}
+
+	if len(succeeded) == 0 {
+		return nil, lastErr
+	}
+
+	slog.Info("AI variation generation completed",
+		"requested", count,
+		"succeeded", len(succeeded))
+
+	return succeeded, nil
+}
+
+// variationHint asks each variation for a visibly different design
+func variationHint(index, count int, language string) string {
+	if language == "en" {
+		return fmt.Sprintf("\n\nVARIATION %d/%d: Use a clearly different colour palette and layout than the other variations.", index+1, count)
+	}
+	return fmt.Sprintf("\n\nVARYASYON %d/%d: Diğer varyasyonlardan belirgin şekilde farklı bir renk paleti ve yerleşim kullan.", index+1, count)
+}
+
+// RefineBar asks the AI to change an existing bar's design following an
+// instruction; the result goes through the same validation as a new bar
+func (s *AIService) RefineBar(bar *models.DonationBar, instruction string) (*models.AIGenerateResponse, error) {
+	slog.Info("Starting AI bar refinement",
+		"bar_id", bar.ID.Hex(),
+		"instruction_length", len(instruction),
+		"revision", bar.Revision,
+		"timeout", s.timeout)
+
+	// Providers that do not read prompts work from the instruction and the bar settings
+	req := &models.GenerateBarRequest{
+		Prompt:        instruction,
+		Language:      bar.Language,
+		Currency:      bar.CurrencyCode(),
+		Theme:         bar.Theme,
+		InitialAmount: bar.InitialAmount,
+		GoalAmount:    bar.GoalAmount,
+		Goals:         bar.Goals,
+	}
+
+	return s.complete(s.buildRefinePrompt(bar, instruction), req, 0)
+}
+
+// complete sends a prompt to the provider and parses and validates the answer
+func (s *AIService) complete(prompt string, req *models.GenerateBarRequest, variation int) (*models.AIGenerateResponse, error) {
+	if s.provider == nil {
+		slog.Error("AI service unavailable - provider not initialized")
+		return nil, errors.New("AI sağlayıcısı yapılandırılmamış. Lütfen .env dosyasında AI_PROVIDER ve OPENAI_API_KEY değişkenlerini kontrol edin")
+	}
+
 	startTime := time.Now()
-	prompt := s.buildEnhancedPrompt(req)
 
 	// Create context with configured timeout
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	resp, err := s.client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4oMini,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
-			MaxTokens:   3000, // Increased for better quality
-			Temperature: 0.3,  // Lower for more consistent results
-		},
-	)
+	resp, err := s.provider.Complete(ctx, &models.AICompletionRequest{
+		Prompt:      prompt,
+		Bar:         req,
+		Variation:   variation,
+		MaxTokens:   3000, // Increased for better quality
+		Temperature: 0.3,  // Lower for more consistent results
+	})
 
 	duration := time.Since(startTime)
 
 	if err != nil {
-		slog.Error("OpenAI API request failed",
+		slog.Error("AI provider request failed",
+			"provider", s.provider.Name(),
 			"error", err.Error(),
 			"duration", duration,
 			"prompt_length", len(prompt))
-		return nil, fmt.Errorf("OpenAI API hatası: %v", err)
+		return nil, fmt.Errorf("AI sağlayıcı hatası (%s): %v", s.provider.Name(), err)
 	}
 
-	if len(resp.Choices) == 0 {
-		slog.Error("OpenAI API returned no choices",
+	if strings.TrimSpace(resp.Content) == "" {
+		slog.Error("AI provider returned an empty response",
+			"provider", s.provider.Name(),
 			"duration", duration)
 		return nil, errors.New("AI'dan yanıt alınamadı")
 	}
 
-	slog.Info("OpenAI API request completed",
+	slog.Info("AI provider request completed",
+		"provider", s.provider.Name(),
+		"model", resp.Model,
 		"duration", duration,
-		"response_length", len(resp.Choices[0].Message.Content),
-		"tokens_used", resp.Usage.TotalTokens)
+		"response_length", len(resp.Content),
+		"tokens_used", resp.TokensUsed)
 
 	// Parse the AI response with enhanced parsing
-	content := resp.Choices[0].Message.Content
+	content := resp.Content
 	result, err := s.parseAIResponseEnhanced(content, req.Language, req.Theme)
 	if err != nil {
 		slog.Error("Failed to parse AI response",
@@ -104,6 +193,8 @@ func (s *AIService) GenerateBar(req *models.GenerateBarRequest) (*models.AIGener
 			"response_length", len(content))
 		return nil, err
 	}
+	result.Metadata.Currency = strings.ToUpper(req.Currency)
+	result.TokensUsed = resp.TokensUsed
 
 	slog.Info("AI bar generation completed successfully",
 		"html_length", len(result.HTML),
@@ -114,11 +205,55 @@ func (s *AIService) GenerateBar(req *models.GenerateBarRequest) (*models.AIGener
 	return result, nil
 }
 
-// buildEnhancedPrompt creates an improved OpenAI prompt with better design guidance
+// buildRefinePrompt asks for a changed version of an existing design
+func (s *AIService) buildRefinePrompt(bar *models.DonationBar, instruction string) string {
+	langInstructions := "Tüm metinler Türkçe olmalı. Yüzde için '%' sembolü kullan."
+	if bar.Language == "en" {
+		langInstructions = "All texts should be in English. Use '%' symbol for percentage."
+	}
+
+	// Bars with stretch goals keep their stage fields through the refinement
+	stages := ""
+	if len(bar.Goals) > 0 {
+		stages = buildStagePrompt(bar.Goals, bar.CurrencyCode(), bar.Language)
+	}
+
+	return "Sen profesyonel bir OBS donation bar tasarımcısısın. Aşağıdaki MEVCUT tasarımı kullanıcının isteğine göre DÜZENLE.\n\n" +
+		"📝 DÜZENLEME İSTEĞİ: \"" + instruction + "\"\n\n" +
+		"🔧 KURALLAR:\n" +
+		"- Sadece istenen değişikliği yap, tasarımın geri kalanını ve yerleşimi koru\n" +
+		"- Mevcut injection alanlarını ({goal}, {total}, {percentage}, {remaining}, {description}, _formatted karşılıkları ve varsa {goal_label}, {goal_index}, {goals_total}) AYNEN koru, hiçbirini silme\n" +
+		"- Tutarlar için {goal_formatted}, {total_formatted} ve {remaining_formatted} kullan, yanına sembol yazma (para birimi: " + bar.CurrencyCode() + ")\n" +
+		"- width: max 800px, height: max 200px (max-width: 800px !important; max-height: 200px !important;)\n" +
+		"- @media queries, viewport units (vw, vh, vmin, vmax), JavaScript, harici kaynaklar, SVG, iframe, expression, behavior, @import YASAK\n" +
+		"- {percentage} kullanırken tek % kullan! Örnek: width: {percentage}%\n" +
+		"- " + langInstructions + "\n\n" +
+		stages +
+		"📄 MEVCUT HTML:\n" + bar.HTML + "\n\n" +
+		"🎨 MEVCUT CSS:\n" + bar.CSS + "\n\n" +
+		"⚠️ MUTLAKA JSON FORMATINDA YANIT VER:\n" +
+		"{\n" +
+		"  \"html\": \"[DÜZENLENMİŞ TAM HTML KOD]\",\n" +
+		"  \"css\": \"[DÜZENLENMİŞ TAM CSS KOD]\",\n" +
+		"  \"metadata\": {\n" +
+		"    \"language\": \"" + bar.Language + "\",\n" +
+		"    \"theme\": \"" + bar.Theme + "\",\n" +
+		"    \"injection\": true\n" +
+		"  }\n" +
+		"}\n\n" +
+		"SADECE JSON yanıtı ver, hiç açıklama yapma!"
+}
+
+// buildEnhancedPrompt creates an improved generation prompt with better design guidance
 func (s *AIService) buildEnhancedPrompt(req *models.GenerateBarRequest) string {
 	var langInstructions string
 	var designExamples string
 
+	currency := req.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency(req.Language)
+	}
+
 	if req.Language == "tr" {
 		langInstructions = "Tüm metinler Türkçe olmalı. Yüzde için '%' sembolü kullan."
 		designExamples = "ÖRNEK KALİTELİ TASARIM VE LAYOUT:\n" +
@@ -131,9 +266,9 @@ func (s *AIService) buildEnhancedPrompt(req *models.GenerateBarRequest) string {
 			"💡 İDEAL LAYOUT DÜZENİ:\n" +
 			"- Üstte: {description} açıklaması (ortalanmış)\n" +
 			"- Ortada: Progress bar + merkezi bilgiler\n" +
-			"- Progress bar üzerinde: {total} ₺ ve %{percentage}\n" +
+			"- Progress bar üzerinde: {total_formatted} ve %{percentage}\n" +
 			"- Altta sol köşe: Başlangıç tutarı\n" +
-			"- Altta sağ köşe: Hedef tutar {goal} ₺\n" +
+			"- Altta sağ köşe: Hedef tutar {goal_formatted}\n" +
 			"- Position: relative/absolute kullanarak konumlandır\n" +
 			"- Center overlay: z-index ile üstte göster"
 	} else {
@@ -148,9 +283,9 @@ func (s *AIService) buildEnhancedPrompt(req *models.GenerateBarRequest) string {
 			"💡 IDEAL LAYOUT STRUCTURE:\n" +
 			"- Top: {description} text (centered)\n" +
 			"- Middle: Progress bar + center info\n" +
-			"- On progress bar: {total} ₺, %{percentage} and {remaining} ₺\n" +
+			"- On progress bar: {total_formatted}, {percentage}% and {remaining_formatted}\n" +
 			"- Bottom left corner: Starting amount\n" +
-			"- Bottom right corner: Goal amount {goal} ₺\n" +
+			"- Bottom right corner: Goal amount {goal_formatted}\n" +
 			"- Position: use relative/absolute for positioning\n" +
 			"- Center overlay: show on top with z-index\n" +
 			"- ⚠️ CRITICAL: ALL 5 INJECTION FIELDS MUST BE PRESENT!"
@@ -164,14 +299,14 @@ func (s *AIService) buildEnhancedPrompt(req *models.GenerateBarRequest) string {
 		"      <div class=\"progress-fill\" style=\"width: {percentage}%\"></div>\n" +
 		"    </div>\n" +
 		"    <div class=\"center-info\">\n" +
-		"      <span class=\"amount\">{total} ₺</span>\n" +
+		"      <span class=\"amount\">{total_formatted}</span>\n" +
 		"      <span class=\"percentage\">%{percentage}</span>\n" +
-		"      <span class=\"remaining\">Kalan: {remaining} ₺</span>\n" +
+		"      <span class=\"remaining\">Kalan: {remaining_formatted}</span>\n" +
 		"    </div>\n" +
 		"  </div>\n" +
 		"  <div class=\"amounts-row\">\n" +
-		"    <span class=\"start-amount\">Başlangıç: {total} ₺</span>\n" +
-		"    <span class=\"goal-amount\">Hedef: {goal} ₺</span>\n" +
+		"    <span class=\"start-amount\">Başlangıç: {total_formatted}</span>\n" +
+		"    <span class=\"goal-amount\">Hedef: {goal_formatted}</span>\n" +
 		"  </div>\n" +
 		"</div>"
 
@@ -182,7 +317,12 @@ func (s *AIService) buildEnhancedPrompt(req *models.GenerateBarRequest) string {
 		"- {percentage}: Toplama oranı (% olmadan sadece sayı, örn: 75)\n" +
 		"- {remaining}: Kalan tutar (MUTLAKA ekle!)\n" +
 		"- {description}: Bar açıklama metni\n\n" +
+		"💱 PARA BİRİMİ: " + currency + "\n" +
+		"- Tutarları yazarken {goal_formatted}, {total_formatted} ve {remaining_formatted} kullan; bunlar {goal}, {total} ve {remaining} yerine geçer\n" +
+		"- Bu alanlar sembol ve binlik ayraçlarını kendisi ekler, yanına ₺/$ gibi sembol YAZMA\n" +
+		"- Sadece sembol gerekiyorsa {currency} kullan\n\n" +
 		"⚠️ KRİTİK: TÜM 5 INJECTION ALANI MUTLAKA HTML'DE YER ALMALI! {remaining} eksik olursa sistem çalışmaz!\n\n" +
+		buildStagePrompt(req.Goals, currency, req.Language) +
 		"📏 BOYUT KISITLAMALARI (KESİNLİKLE uyulmalı):\n" +
 		"- width: max 800px (max-width: 800px !important;)\n" +
 		"- height: max 200px (max-height: 200px !important;)\n" +
@@ -225,6 +365,36 @@ func (s *AIService) buildEnhancedPrompt(req *models.GenerateBarRequest) string {
 	return prompt
 }
 
+// buildStagePrompt describes the optional stretch goal fields. With goals in
+// the request it lists them and asks for a marker per stage.
+func buildStagePrompt(goals []models.Goal, currency, language string) string {
+	prompt := "🏁 AŞAMALI HEDEF ALANLARI (isteğe bağlı):\n" +
+		"- {goal_label}: Şu anki aşamanın adı\n" +
+		"- {goal_index}: Şu anki aşamanın sırası (1'den başlar)\n" +
+		"- {goals_total}: Toplam aşama sayısı\n" +
+		"- Aşamalı barlarda {goal}, {percentage} ve {remaining} şu anki aşamanın hedefine göre hesaplanır; hedefe ulaşılınca bar bir sonraki aşamaya geçer\n\n"
+	if len(goals) == 0 {
+		return prompt
+	}
+
+	prompt += "🪜 BU BARIN " + strconv.Itoa(len(goals)) + " AŞAMASI VAR:\n"
+	for i, goal := range goals {
+		prompt += "  " + strconv.Itoa(i+1) + ". " + render.FormatMoney(goal.Amount, currency, language)
+		if goal.Label != "" {
+			prompt += " - " + goal.Label
+		}
+		prompt += "\n"
+	}
+	heading := "Aşama {goal_index}/{goals_total}: {goal_label}"
+	if language == "en" {
+		heading = "Stage {goal_index}/{goals_total}: {goal_label}"
+	}
+	prompt += "- Progress bar'ın altına her aşama için eşit aralıklı bir işaret koy (nokta/çentik + yukarıdaki tutar ve etiket)\n" +
+		"- Şu anki aşamayı \"" + heading + "\" gibi bir başlıkla göster\n" +
+		"- Aşama işaretleri sabit metindir; {goal_formatted} her zaman şu anki aşamanın hedefini gösterir\n\n"
+	return prompt
+}
+
 // parseAIResponseEnhanced improved parsing with better JSON extraction
 func (s *AIService) parseAIResponseEnhanced(content, language, theme string) (*models.AIGenerateResponse, error) {
 	// Clean the content first
@@ -298,8 +468,15 @@ func (s *AIService) parseAIResponseEnhanced(content, language, theme string) (*m
 	slog.Debug("JSON parsed successfully!")
 
 	// Clean and validate the HTML/CSS
-	response.HTML = s.cleanAndValidateHTML(response.HTML)
-	response.CSS = s.cleanAndValidateCSS(response.CSS)
+	var htmlReport, cssReport *sanitize.Report
+	response.HTML, htmlReport = s.cleanAndValidateHTML(response.HTML)
+	response.CSS, cssReport = s.cleanAndValidateCSS(response.CSS)
+	htmlReport.Merge(cssReport)
+	if !htmlReport.Empty() {
+		slog.Warn("Sanitizer removed content from AI response",
+			"removed", htmlReport.String())
+		response.Report = htmlReport
+	}
 
 	// Enhanced validation
 	if !s.validateAIResponseEnhanced(&response) {
@@ -418,37 +595,31 @@ func min(a, b int) int {
 	return b
 }
 
-// cleanAndValidateHTML cleans and validates HTML content
-func (s *AIService) cleanAndValidateHTML(html string) string {
-	// Remove any potential script tags or dangerous content
-	html = regexp.MustCompile(`(?i)<script[^>]*>.*?</script>`).ReplaceAllString(html, "")
-	html = regexp.MustCompile(`(?i)javascript:`).ReplaceAllString(html, "")
-	html = regexp.MustCompile(`(?i)on\w+\s*=`).ReplaceAllString(html, "data-removed=")
+// cleanAndValidateHTML sanitizes HTML content and wraps it in the bar container
+func (s *AIService) cleanAndValidateHTML(html string) (string, *sanitize.Report) {
+	html, report := sanitize.HTML(html)
 
 	// Ensure proper structure
 	if !strings.Contains(html, "donation-bar") {
 		html = fmt.Sprintf(`<div class="donation-bar">%s</div>`, html)
 	}
 
-	return strings.TrimSpace(html)
+	return strings.TrimSpace(html), report
 }
 
-// cleanAndValidateCSS cleans and validates CSS content
-func (s *AIService) cleanAndValidateCSS(css string) string {
-	// Remove dangerous CSS properties
-	css = regexp.MustCompile(`(?i)expression\([^)]*\)`).ReplaceAllString(css, "")
-	css = regexp.MustCompile(`(?i)@import[^;]*;`).ReplaceAllString(css, "")
-	css = regexp.MustCompile(`(?i)url\(http[^)]*\)`).ReplaceAllString(css, "")
+// cleanAndValidateCSS sanitizes CSS content and enforces the size constraints
+func (s *AIService) cleanAndValidateCSS(css string) (string, *sanitize.Report) {
+	css, report := sanitize.CSS(css)
 
 	// Ensure size constraints are present
 	if !strings.Contains(css, "max-width") {
-		css = ".donation-bar { max-width: 800px !important; " + css
+		css = ".donation-bar { max-width: 800px !important; }\n" + css
 	}
 	if !strings.Contains(css, "max-height") {
 		css = strings.Replace(css, "max-width:", "max-height: 200px !important; max-width:", 1)
 	}
 
-	return strings.TrimSpace(css)
+	return strings.TrimSpace(css), report
 }
 
 // validateAIResponseEnhanced enhanced validation with stricter checks
@@ -547,12 +718,10 @@ func (s *AIService) validateDesignQuality(html, css string) bool {
 
 // validateInjections checks if all required injection fields are present
 func (s *AIService) validateInjections(html string) bool {
-	for _, injection := range models.RequiredInjections {
-		if !strings.Contains(html, injection) {
-			slog.Error("Missing injection",
-				"injection_string", injection)
-			return false
-		}
+	missing := render.MissingFields(html)
+	for _, injection := range missing {
+		slog.Error("Missing injection",
+			"injection_string", injection)
 	}
-	return true
+	return len(missing) == 0
 }
@@ -1,42 +1,36 @@
 package services
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"donationbars/internal/ai"
 	"donationbars/internal/models"
 )
 
-func TestNewAIService_WithValidKey(t *testing.T) {
+func TestNewAIService_WithOpenAIProvider(t *testing.T) {
 	timeout := 30 * time.Second
-	service := NewAIService("valid-api-key", timeout)
+	service := NewAIService(ai.NewOpenAIProvider("valid-api-key", ""), timeout)
 
 	if service == nil {
 		t.Error("Expected service to be created, got nil")
 	}
 }
 
-func TestNewAIService_WithEmptyKey(t *testing.T) {
+func TestNewAIService_WithNilProvider(t *testing.T) {
 	timeout := 30 * time.Second
-	service := NewAIService("", timeout)
+	service := NewAIService(nil, timeout)
 
 	if service == nil {
-		t.Error("Expected service to be created even with empty key, got nil")
+		t.Error("Expected service to be created even without a provider, got nil")
 	}
 }
 
-func TestNewAIService_WithPlaceholderKey(t *testing.T) {
+func TestAIService_GenerateBar_WithNilProvider(t *testing.T) {
 	timeout := 30 * time.Second
-	service := NewAIService("your_openai_api_key_here", timeout)
-
-	if service == nil {
-		t.Error("Expected service to be created even with placeholder key, got nil")
-	}
-}
-
-func TestAIService_GenerateBar_WithNilClient(t *testing.T) {
-	timeout := 30 * time.Second
-	service := NewAIService("", timeout) // This will create service with nil client
+	service := NewAIService(nil, timeout)
 
 	req := &models.GenerateBarRequest{
 		Prompt:        "Create a modern donation bar",
@@ -49,16 +43,136 @@ func TestAIService_GenerateBar_WithNilClient(t *testing.T) {
 	result, err := service.GenerateBar(req)
 
 	if err == nil {
-		t.Error("Expected error with nil client, got nil")
+		t.Error("Expected error with nil provider, got nil")
 	}
 
 	if result != nil {
-		t.Error("Expected nil result with nil client, got result")
+		t.Error("Expected nil result with nil provider, got result")
+	}
+}
+
+func TestAIService_GenerateBar_WithOfflineProvider(t *testing.T) {
+	service := NewAIService(ai.NewOfflineProvider(), 30*time.Second)
+
+	for _, language := range []string{"tr", "en"} {
+		req := &models.GenerateBarRequest{
+			Prompt:        "Cyberpunk temalı neon mavi donation bar",
+			Language:      language,
+			Currency:      "usd",
+			Theme:         "cyberpunk",
+			InitialAmount: 100.0,
+			GoalAmount:    1000.0,
+		}
+
+		first, err := service.GenerateBar(req)
+		if err != nil {
+			t.Fatalf("Expected offline generation to pass validation (%s), got %v", language, err)
+		}
+		second, _ := service.GenerateBar(req)
+
+		if !first.Metadata.HasInjections {
+			t.Errorf("Expected all injections in offline bar (%s)", language)
+		}
+		if first.Metadata.Currency != "USD" {
+			t.Errorf("Expected currency USD, got %s", first.Metadata.Currency)
+		}
+		if first.HTML != second.HTML || first.CSS != second.CSS {
+			t.Errorf("Expected offline generation to be deterministic (%s)", language)
+		}
+		if first.Report != nil {
+			t.Errorf("Expected sanitizer to keep offline output, removed %s", first.Report.String())
+		}
+	}
+}
+
+func TestAIService_GenerateBar_StretchGoals(t *testing.T) {
+	service := NewAIService(ai.NewOfflineProvider(), 30*time.Second)
+	req := &models.GenerateBarRequest{
+		Prompt:   "Aşamalı hedefleri olan mor bir donation bar",
+		Language: "tr",
+		Goals:    []models.Goal{{Amount: 500, Label: "Mikrofon"}, {Amount: 1500, Label: "Kamera"}},
+	}
+
+	prompt := service.(*AIService).buildEnhancedPrompt(req)
+	for _, want := range []string{"{goal_label}", "{goals_total}", "1. 500 ₺ - Mikrofon", "2. 1.500 ₺ - Kamera", "Aşama {goal_index}/{goals_total}: {goal_label}"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected prompt to contain %q", want)
+		}
+	}
+
+	result, err := service.GenerateBar(req)
+	if err != nil {
+		t.Fatalf("Expected offline generation to pass validation, got %v", err)
+	}
+	if !strings.Contains(result.HTML, "{goal_index}/{goals_total} · {goal_label}") {
+		t.Errorf("Expected a stage marker in the offline bar, got %q", result.HTML)
+	}
+	if result.Report != nil {
+		t.Errorf("Expected sanitizer to keep offline output, removed %s", result.Report.String())
+	}
+}
+
+func TestAIService_GenerateVariations_WithOfflineProvider(t *testing.T) {
+	service := NewAIService(ai.NewOfflineProvider(), 30*time.Second)
+	req := &models.GenerateBarRequest{
+		Prompt:     "Cyberpunk temalı neon mavi donation bar",
+		Language:   "tr",
+		Theme:      "cyberpunk",
+		GoalAmount: 1000.0,
+		Variations: 3,
+	}
+
+	results, err := service.GenerateVariations(req)
+	if err != nil {
+		t.Fatalf("Expected variations to pass validation, got %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 variations, got %d", len(results))
+	}
+
+	seen := map[string]bool{}
+	for _, result := range results {
+		if seen[result.CSS] {
+			t.Errorf("Expected every variation to have a different design")
+		}
+		seen[result.CSS] = true
+	}
+}
+
+// flakyProvider fails every odd variation
+type flakyProvider struct {
+	offline *ai.OfflineProvider
+}
+
+func (p *flakyProvider) Name() string { return "flaky" }
+
+func (p *flakyProvider) Complete(ctx context.Context, req *models.AICompletionRequest) (*models.AICompletion, error) {
+	if req.Variation%2 == 1 {
+		return &models.AICompletion{Content: "not json"}, nil
+	}
+	return p.offline.Complete(ctx, req)
+}
+
+func TestAIService_GenerateVariations_DiscardsFailures(t *testing.T) {
+	service := NewAIService(&flakyProvider{offline: ai.NewOfflineProvider()}, 30*time.Second)
+	req := &models.GenerateBarRequest{
+		Prompt:     "Minimal donation bar",
+		Language:   "en",
+		GoalAmount: 500.0,
+		Variations: 4,
+	}
+
+	results, err := service.GenerateVariations(req)
+	if err != nil {
+		t.Fatalf("Expected the valid variations to be kept, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected 2 of 4 variations to survive, got %d", len(results))
 	}
 }
 
 func TestAIService_ValidateInjections_ValidHTML(t *testing.T) {
-	service := &AIService{client: nil}
+	service := &AIService{provider: nil}
 
 	validHTML := `<div>
 		<span>{goal}</span>
@@ -74,7 +188,7 @@ func TestAIService_ValidateInjections_ValidHTML(t *testing.T) {
 }
 
 func TestAIService_ValidateInjections_MissingInjections(t *testing.T) {
-	service := &AIService{client: nil}
+	service := &AIService{provider: nil}
 
 	invalidHTML := `<div>
 		<span>{goal}</span>
@@ -88,7 +202,7 @@ func TestAIService_ValidateInjections_MissingInjections(t *testing.T) {
 }
 
 func TestAIService_ValidateCSSSizeConstraints(t *testing.T) {
-	service := &AIService{client: nil}
+	service := &AIService{provider: nil}
 
 	validCSS := `
 	.donation-bar {
@@ -105,7 +219,7 @@ func TestAIService_ValidateCSSSizeConstraints(t *testing.T) {
 }
 
 func TestAIService_ValidateCSSSizeConstraints_InvalidSize(t *testing.T) {
-	service := &AIService{client: nil}
+	service := &AIService{provider: nil}
 
 	invalidCSS := `
 	.donation-bar {
@@ -120,19 +234,25 @@ func TestAIService_ValidateCSSSizeConstraints_InvalidSize(t *testing.T) {
 }
 
 func TestAIService_CleanAndValidateHTML(t *testing.T) {
-	service := &AIService{client: nil}
+	service := &AIService{provider: nil}
 
 	dirtyHTML := `<div onclick="alert('xss')" style="background: url('http://evil.com')">
 		<script>alert('xss')</script>
-		Valid content {goal}
+		Valid content {goal} {total} {percentage} {remaining} {description}
 	</div>`
 
-	cleaned := service.cleanAndValidateHTML(dirtyHTML)
+	cleaned, report := service.cleanAndValidateHTML(dirtyHTML)
 
 	// Should remove dangerous attributes and scripts
 	if cleaned == dirtyHTML {
 		t.Error("Expected HTML to be cleaned, but it remained unchanged")
 	}
+	if strings.Contains(cleaned, "<script") || strings.Contains(cleaned, "onclick") || strings.Contains(cleaned, "evil.com") {
+		t.Errorf("Expected dangerous content to be removed, got %q", cleaned)
+	}
+	if report.Empty() {
+		t.Error("Expected sanitize report to list removed content")
+	}
 
 	// Should preserve valid injection
 	if !service.validateInjections(cleaned) {
@@ -141,7 +261,7 @@ func TestAIService_CleanAndValidateHTML(t *testing.T) {
 }
 
 func TestAIService_CleanAndValidateCSS(t *testing.T) {
-	service := &AIService{client: nil}
+	service := &AIService{provider: nil}
 
 	dirtyCSS := `
 	.bar {
@@ -152,10 +272,51 @@ func TestAIService_CleanAndValidateCSS(t *testing.T) {
 	}
 	`
 
-	cleaned := service.cleanAndValidateCSS(dirtyCSS)
+	cleaned, report := service.cleanAndValidateCSS(dirtyCSS)
 
 	// Should remove dangerous properties
 	if cleaned == dirtyCSS {
 		t.Error("Expected CSS to be cleaned, but it remained unchanged")
 	}
+	if strings.Contains(cleaned, "behavior") || strings.Contains(cleaned, "binding") || strings.Contains(cleaned, "evil.com") {
+		t.Errorf("Expected dangerous properties to be removed, got %q", cleaned)
+	}
+	if report.Empty() {
+		t.Error("Expected sanitize report to list removed content")
+	}
+}
+
+// recordingProvider keeps the last completion request it was sent
+type recordingProvider struct {
+	offline *ai.OfflineProvider
+	last    *models.AICompletionRequest
+}
+
+func (p *recordingProvider) Name() string { return "recording" }
+
+func (p *recordingProvider) Complete(ctx context.Context, req *models.AICompletionRequest) (*models.AICompletion, error) {
+	p.last = req
+	return p.offline.Complete(ctx, req)
+}
+
+func TestAIService_RefineBar_KeepsGoals(t *testing.T) {
+	provider := &recordingProvider{offline: ai.NewOfflineProvider()}
+	service := NewAIService(provider, 30*time.Second)
+	bar := &models.DonationBar{
+		Language:   "tr",
+		Currency:   "TRY",
+		GoalAmount: 1500,
+		Goals:      []models.Goal{{Amount: 500, Label: "Mikrofon"}, {Amount: 1500, Label: "Kamera"}},
+		HTML:       "<div>{goal} {total} {percentage} {remaining} {description}</div>",
+	}
+
+	if _, err := service.RefineBar(bar, "Renkleri koyulaştır"); err != nil {
+		t.Fatalf("Expected the refinement to succeed, got %v", err)
+	}
+	if provider.last == nil || len(provider.last.Bar.Goals) != 2 {
+		t.Fatalf("Expected the bar's goals to reach the provider, got %+v", provider.last)
+	}
+	if !strings.Contains(provider.last.Prompt, "Mikrofon") {
+		t.Error("Expected the refine prompt to list the bar's stages")
+	}
 }
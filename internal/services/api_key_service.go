@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxActiveAPIKeys bounds the number of unrevoked keys per user
+const maxActiveAPIKeys = 20
+
+// apiKeyDisplayLength is how much of a key is kept for display
+const apiKeyDisplayLength = len(models.APIKeyPrefix) + 6
+
+type APIKeyService struct {
+	repo   interfaces.APIKeyRepositoryInterface
+	users  interfaces.UserRepositoryInterface
+	config *config.Config
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(repo interfaces.APIKeyRepositoryInterface, users interfaces.UserRepositoryInterface, cfg *config.Config) interfaces.APIKeyServiceInterface {
+	return &APIKeyService{
+		repo:   repo,
+		users:  users,
+		config: cfg,
+	}
+}
+
+// CreateKey mints a key and returns it in plain text; it cannot be retrieved again
+func (s *APIKeyService) CreateKey(userID string, req *models.CreateAPIKeyRequest) (*models.APIKey, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, "", apperrors.ValidationError("name", "must not be empty")
+	}
+
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	existing, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, "", apperrors.DatabaseError("list api keys", err)
+	}
+	active := 0
+	for _, key := range existing {
+		if !key.IsRevoked() {
+			active++
+		}
+	}
+	if active >= maxActiveAPIKeys {
+		return nil, "", apperrors.ValidationError("api key", "too many active keys, revoke an unused one first")
+	}
+
+	secret := models.APIKeyPrefix + rand.Text()
+	key := &models.APIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    secret[:apiKeyDisplayLength],
+		KeyHash:   hashAPIKey(secret),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.Insert(ctx, key); err != nil {
+		return nil, "", apperrors.DatabaseError("insert api key", err)
+	}
+
+	slog.Info("API key created",
+		"user_id", userID,
+		"key_id", key.ID.Hex(),
+		"scopes", scopes)
+
+	return key, secret, nil
+}
+
+// ListKeys returns all keys of a user, including revoked ones
+func (s *APIKeyService) ListKeys(userID string) ([]*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	keys, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("list api keys", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey permanently disables a key
+func (s *APIKeyService) RevokeKey(userID, keyID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	if err := s.repo.Revoke(ctx, userID, keyID); err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NotFound("api key", keyID)
+		}
+		return apperrors.DatabaseError("revoke api key", err)
+	}
+
+	slog.Info("API key revoked", "user_id", userID, "key_id", keyID)
+	return nil
+}
+
+// Authenticate resolves a bearer key to its owner and the key itself
+func (s *APIKeyService) Authenticate(secret string) (*models.User, *models.APIKey, error) {
+	if !strings.HasPrefix(secret, models.APIKeyPrefix) {
+		return nil, nil, apperrors.Unauthorized("invalid api key")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	key, err := s.repo.FindByHash(ctx, hashAPIKey(secret))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, nil, apperrors.Unauthorized("invalid api key")
+		}
+		return nil, nil, apperrors.DatabaseError("find api key", err)
+	}
+
+	user, err := s.users.FindByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, nil, apperrors.Unauthorized("invalid api key")
+		}
+		return nil, nil, apperrors.DatabaseError("find user", err)
+	}
+
+	// Best effort, a failed timestamp update must not block the request
+	if err := s.repo.TouchLastUsed(ctx, key.ID.Hex()); err != nil {
+		slog.Warn("Failed to record API key usage",
+			"key_id", key.ID.Hex(),
+			"error", err.Error())
+	}
+
+	return user, key, nil
+}
+
+// normalizeScopes validates and de-duplicates requested scopes
+func normalizeScopes(requested []string) ([]string, error) {
+	var scopes []string
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if !slices.Contains(models.APIKeyScopes, scope) {
+			return nil, apperrors.ValidationError("scopes", "unknown scope "+scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, apperrors.ValidationError("scopes", "at least one scope is required")
+	}
+	return scopes, nil
+}
+
+// hashAPIKey derives the stored hash of a key
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/mocks"
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAPIKeyService_CreateKey_StoresHashOnly(t *testing.T) {
+	// Arrange
+	mockKeys := new(mocks.MockAPIKeyRepository)
+	mockUsers := new(mocks.MockUserRepository)
+	service := NewAPIKeyService(mockKeys, mockUsers, createTestConfig())
+
+	req := &models.CreateAPIKeyRequest{
+		Name:   "OBS eklentisi",
+		Scopes: []string{models.ScopeBarsRead, models.ScopeDonationsWrite, models.ScopeBarsRead},
+	}
+
+	// Mock expectations
+	mockKeys.On("FindByUserID", mock.Anything, "user-1").Return([]*models.APIKey{}, nil)
+	mockKeys.On("Insert", mock.Anything, mock.AnythingOfType("*models.APIKey")).Return(nil)
+
+	// Act
+	key, secret, err := service.CreateKey("user-1", req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, models.APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(secret, key.Prefix))
+	assert.Equal(t, hashAPIKey(secret), key.KeyHash)
+	assert.NotContains(t, key.KeyHash, secret)
+	assert.Equal(t, []string{models.ScopeBarsRead, models.ScopeDonationsWrite}, key.Scopes)
+	mockKeys.AssertExpectations(t)
+}
+
+func TestAPIKeyService_CreateKey_UnknownScope(t *testing.T) {
+	// Arrange
+	mockKeys := new(mocks.MockAPIKeyRepository)
+	mockUsers := new(mocks.MockUserRepository)
+	service := NewAPIKeyService(mockKeys, mockUsers, createTestConfig())
+
+	// Act
+	key, secret, err := service.CreateKey("user-1", &models.CreateAPIKeyRequest{Name: "x", Scopes: []string{"admin"}})
+
+	// Assert
+	assert.Nil(t, key)
+	assert.Empty(t, secret)
+	assert.ErrorIs(t, err, apperrors.ErrValidationFailed)
+	mockKeys.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	user := &models.User{ID: primitive.NewObjectID(), Email: "yayinci@example.com"}
+	key := &models.APIKey{ID: primitive.NewObjectID(), UserID: user.ID.Hex(), Scopes: []string{models.ScopeBarsRead}}
+	secret := models.APIKeyPrefix + "GECERLIANAHTAR"
+
+	tests := []struct {
+		name    string
+		secret  string
+		setup   func(keys *mocks.MockAPIKeyRepository, users *mocks.MockUserRepository)
+		wantErr bool
+	}{
+		{
+			name:   "Valid key",
+			secret: secret,
+			setup: func(keys *mocks.MockAPIKeyRepository, users *mocks.MockUserRepository) {
+				keys.On("FindByHash", mock.Anything, hashAPIKey(secret)).Return(key, nil)
+				users.On("FindByID", mock.Anything, user.ID.Hex()).Return(user, nil)
+				keys.On("TouchLastUsed", mock.Anything, key.ID.Hex()).Return(errors.New("timeout"))
+			},
+		},
+		{
+			name:   "Revoked or unknown key",
+			secret: secret,
+			setup: func(keys *mocks.MockAPIKeyRepository, users *mocks.MockUserRepository) {
+				keys.On("FindByHash", mock.Anything, hashAPIKey(secret)).Return(nil, apperrors.Missing("api key"))
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Missing prefix",
+			secret:  "session-token",
+			setup:   func(keys *mocks.MockAPIKeyRepository, users *mocks.MockUserRepository) {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockKeys := new(mocks.MockAPIKeyRepository)
+			mockUsers := new(mocks.MockUserRepository)
+			tt.setup(mockKeys, mockUsers)
+			service := NewAPIKeyService(mockKeys, mockUsers, createTestConfig())
+
+			// Act
+			gotUser, gotKey, err := service.Authenticate(tt.secret)
+
+			// Assert
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrUnauthorized)
+				assert.Nil(t, gotUser)
+				assert.Nil(t, gotKey)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, user, gotUser)
+				assert.Equal(t, key, gotKey)
+			}
+			mockKeys.AssertExpectations(t)
+			mockUsers.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyService_RevokeKey_NotFound(t *testing.T) {
+	// Arrange
+	mockKeys := new(mocks.MockAPIKeyRepository)
+	mockUsers := new(mocks.MockUserRepository)
+	service := NewAPIKeyService(mockKeys, mockUsers, createTestConfig())
+
+	mockKeys.On("Revoke", mock.Anything, "user-1", "key-1").Return(apperrors.Missing("api key"))
+
+	// Act
+	err := service.RevokeKey("user-1", "key-1")
+
+	// Assert
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
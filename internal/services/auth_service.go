@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// invalidCredentialsMessage does not reveal whether the e-mail exists
+const invalidCredentialsMessage = "e-posta veya şifre hatalı"
+
+type AuthService struct {
+	users    interfaces.UserRepositoryInterface
+	sessions interfaces.SessionStoreInterface
+	config   *config.Config
+}
+
+// NewAuthService creates a new account and session service
+func NewAuthService(users interfaces.UserRepositoryInterface, sessions interfaces.SessionStoreInterface, cfg *config.Config) interfaces.AuthServiceInterface {
+	return &AuthService{
+		users:    users,
+		sessions: sessions,
+		config:   cfg,
+	}
+}
+
+// Signup creates an account with a bcrypt hashed password
+func (s *AuthService) Signup(req *models.SignupRequest) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	email := normalizeEmail(req.Email)
+
+	_, err := s.users.FindByEmail(ctx, email)
+	if err == nil {
+		return nil, apperrors.Conflict("user", "email already registered")
+	}
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, apperrors.DatabaseError("find user", err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, apperrors.ValidationError("password", err.Error())
+	}
+
+	user := &models.User{
+		ID:           primitive.NewObjectID(),
+		Email:        email,
+		DisplayName:  strings.TrimSpace(req.DisplayName),
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.users.Insert(ctx, user); err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return nil, apperrors.Conflict("user", "email already registered")
+		}
+		return nil, apperrors.DatabaseError("insert user", err)
+	}
+
+	slog.Info("User signed up", "user_id", user.ID.Hex())
+
+	return user, nil
+}
+
+// Login checks the credentials and starts a session, returning its cookie token
+func (s *AuthService) Login(req *models.LoginRequest) (*models.User, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	user, err := s.users.FindByEmail(ctx, normalizeEmail(req.Email))
+	if err != nil {
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			return nil, "", apperrors.DatabaseError("find user", err)
+		}
+		// Spend the same time as a real check so unknown e-mails are not detectable
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(req.Password))
+		return nil, "", apperrors.Unauthorized(invalidCredentialsMessage)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, "", apperrors.Unauthorized(invalidCredentialsMessage)
+	}
+
+	token := rand.Text()
+	now := time.Now()
+	session := &models.Session{
+		ID:        hashSessionToken(token),
+		UserID:    user.ID.Hex(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.Session.TTL),
+	}
+
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, "", apperrors.DatabaseError("create session", err)
+	}
+
+	slog.Info("User logged in", "user_id", session.UserID)
+
+	return user, token, nil
+}
+
+// Logout ends the session of a cookie token
+func (s *AuthService) Logout(token string) error {
+	if token == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	if err := s.sessions.Delete(ctx, hashSessionToken(token)); err != nil {
+		return apperrors.DatabaseError("delete session", err)
+	}
+	return nil
+}
+
+// Authenticate resolves a cookie token to its user
+func (s *AuthService) Authenticate(token string) (*models.User, error) {
+	if token == "" {
+		return nil, apperrors.Unauthorized("oturum bulunamadı")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	session, err := s.sessions.Find(ctx, hashSessionToken(token))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.Unauthorized("oturum bulunamadı")
+		}
+		return nil, apperrors.DatabaseError("find session", err)
+	}
+
+	user, err := s.users.FindByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.Unauthorized("oturum bulunamadı")
+		}
+		return nil, apperrors.DatabaseError("find user", err)
+	}
+
+	return user, nil
+}
+
+// hashSessionToken derives the stored session ID from a cookie token
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeEmail lower-cases and trims an e-mail address
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash is compared against when the e-mail is unknown
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("donationbars"), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
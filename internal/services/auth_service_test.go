@@ -0,0 +1,165 @@
+package services
+
+import (
+	"testing"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/mocks"
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func createTestUser(t *testing.T, password string) *models.User {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+	return &models.User{
+		ID:           primitive.NewObjectID(),
+		Email:        "yayinci@example.com",
+		DisplayName:  "Yayıncı",
+		PasswordHash: string(hash),
+	}
+}
+
+func TestAuthService_Signup_Success(t *testing.T) {
+	// Arrange
+	mockUsers := new(mocks.MockUserRepository)
+	mockSessions := new(mocks.MockSessionStore)
+	service := NewAuthService(mockUsers, mockSessions, createTestConfig())
+
+	req := &models.SignupRequest{
+		Email:       "  Yayinci@Example.com ",
+		DisplayName: "Yayıncı",
+		Password:    "guclu-sifre-123",
+	}
+
+	// Mock expectations
+	mockUsers.On("FindByEmail", mock.Anything, "yayinci@example.com").Return(nil, apperrors.Missing("user"))
+	mockUsers.On("Insert", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+
+	// Act
+	user, err := service.Signup(req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "yayinci@example.com", user.Email)
+	assert.NotEqual(t, req.Password, user.PasswordHash)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)))
+	mockUsers.AssertExpectations(t)
+}
+
+func TestAuthService_Signup_EmailTaken(t *testing.T) {
+	// Arrange
+	mockUsers := new(mocks.MockUserRepository)
+	mockSessions := new(mocks.MockSessionStore)
+	service := NewAuthService(mockUsers, mockSessions, createTestConfig())
+
+	mockUsers.On("FindByEmail", mock.Anything, "yayinci@example.com").Return(&models.User{}, nil)
+
+	// Act
+	user, err := service.Signup(&models.SignupRequest{Email: "yayinci@example.com", DisplayName: "Yayıncı", Password: "guclu-sifre-123"})
+
+	// Assert
+	assert.Nil(t, user)
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "CONFLICT", appErr.Type)
+	mockUsers.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_Login_StoresHashedSession(t *testing.T) {
+	// Arrange
+	mockUsers := new(mocks.MockUserRepository)
+	mockSessions := new(mocks.MockSessionStore)
+	service := NewAuthService(mockUsers, mockSessions, createTestConfig())
+	user := createTestUser(t, "guclu-sifre-123")
+
+	var stored *models.Session
+	mockUsers.On("FindByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*models.Session")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*models.Session) }).
+		Return(nil)
+
+	// Act
+	result, token, err := service.Login(&models.LoginRequest{Email: user.Email, Password: "guclu-sifre-123"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, user, result)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, hashSessionToken(token), stored.ID)
+	assert.NotEqual(t, token, stored.ID)
+	assert.Equal(t, user.ID.Hex(), stored.UserID)
+	assert.True(t, stored.ExpiresAt.After(stored.CreatedAt))
+}
+
+func TestAuthService_Login_InvalidCredentials(t *testing.T) {
+	user := createTestUser(t, "guclu-sifre-123")
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		found    bool
+	}{
+		{name: "Wrong password", email: user.Email, password: "yanlis-sifre", found: true},
+		{name: "Unknown email", email: "yok@example.com", password: "guclu-sifre-123", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockUsers := new(mocks.MockUserRepository)
+			mockSessions := new(mocks.MockSessionStore)
+			service := NewAuthService(mockUsers, mockSessions, createTestConfig())
+
+			if tt.found {
+				mockUsers.On("FindByEmail", mock.Anything, tt.email).Return(user, nil)
+			} else {
+				mockUsers.On("FindByEmail", mock.Anything, tt.email).Return(nil, apperrors.Missing("user"))
+			}
+
+			// Act
+			result, token, err := service.Login(&models.LoginRequest{Email: tt.email, Password: tt.password})
+
+			// Assert
+			assert.Nil(t, result)
+			assert.Empty(t, token)
+			var appErr *apperrors.AppError
+			assert.ErrorAs(t, err, &appErr)
+			assert.Equal(t, "UNAUTHORIZED", appErr.Type)
+			mockSessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAuthService_Authenticate(t *testing.T) {
+	// Arrange
+	mockUsers := new(mocks.MockUserRepository)
+	mockSessions := new(mocks.MockSessionStore)
+	service := NewAuthService(mockUsers, mockSessions, createTestConfig())
+	user := createTestUser(t, "guclu-sifre-123")
+
+	mockSessions.On("Find", mock.Anything, hashSessionToken("valid-token")).
+		Return(&models.Session{UserID: user.ID.Hex()}, nil)
+	mockSessions.On("Find", mock.Anything, hashSessionToken("expired-token")).
+		Return(nil, apperrors.Missing("session"))
+	mockUsers.On("FindByID", mock.Anything, user.ID.Hex()).Return(user, nil)
+
+	// Act & Assert
+	result, err := service.Authenticate("valid-token")
+	assert.NoError(t, err)
+	assert.Equal(t, user, result)
+
+	_, err = service.Authenticate("expired-token")
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "UNAUTHORIZED", appErr.Type)
+
+	_, err = service.Authenticate("")
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "UNAUTHORIZED", appErr.Type)
+}
@@ -2,80 +2,92 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"donationbars/internal/config"
+	"donationbars/internal/diff"
 	apperrors "donationbars/internal/errors"
 	"donationbars/internal/interfaces"
 	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+	"donationbars/internal/render"
+	"donationbars/internal/sanitize"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type BarService struct {
-	repo        interfaces.BarRepositoryInterface
-	redisClient *config.RedisClient
-	config      *config.Config
+	repo      interfaces.BarRepositoryInterface
+	revisions interfaces.RevisionRepositoryInterface
+	quotas    interfaces.QuotaServiceInterface
+	limits    *ratelimit.Guard
+	broker    interfaces.EventBrokerInterface
+	events    interfaces.EventServiceInterface
+	config    *config.Config
 }
 
-// NewBarService creates a new bar service with repository dependency
-func NewBarService(repo interfaces.BarRepositoryInterface, redisClient *config.RedisClient, cfg *config.Config) interfaces.BarServiceInterface {
+// NewBarService creates a new bar service with repository dependencies; a nil
+// guard limits bar creation by the database count alone, nil events skip
+// milestone detection
+func NewBarService(repo interfaces.BarRepositoryInterface, revisions interfaces.RevisionRepositoryInterface, quotas interfaces.QuotaServiceInterface, limits *ratelimit.Guard, broker interfaces.EventBrokerInterface, events interfaces.EventServiceInterface, cfg *config.Config) interfaces.BarServiceInterface {
 	return &BarService{
-		repo:        repo,
-		redisClient: redisClient,
-		config:      cfg,
+		repo:      repo,
+		revisions: revisions,
+		quotas:    quotas,
+		limits:    limits,
+		broker:    broker,
+		events:    events,
+		config:    cfg,
 	}
 }
 
-// CheckRateLimitRedis checks rate limit using Redis
-func (s *BarService) checkRateLimitRedis(userID string) error {
-	if !s.redisClient.IsEnabled() {
-		// Fallback to database-based rate limiting
-		return s.CheckDailyRateLimit(userID)
+// checkCreateLimit checks the user's bar creation limit without using it up;
+// recordCreate does that once the bar exists. Without a limiter, or when it
+// is unreachable, the bars created today in the database are counted instead.
+func (s *BarService) checkCreateLimit(userID string) error {
+	if s.limits == nil {
+		return s.checkDailyCount(userID)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.RedisOperation)
 	defer cancel()
 
-	key := fmt.Sprintf("rate_limit:%s", userID)
-
-	// Use Redis pipeline for atomicity
-	pipe := s.redisClient.Client.Pipeline()
-	incrCmd := pipe.Incr(ctx, key)
-	expireCmd := pipe.Expire(ctx, key, 24*time.Hour)
-
-	_, err := pipe.Exec(ctx)
-	if err != nil {
-		slog.Warn("Redis rate limit check failed, falling back to database",
+	_, err := s.limits.Peek(ctx, ratelimit.ActionBarCreate, userID)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, apperrors.ErrRateLimitExceeded):
+		slog.Warn("Bar creation rate limit exceeded", "user_id", userID)
+		return err
+	default:
+		slog.Warn("Rate limiter unavailable, falling back to database",
 			"error", err.Error(),
 			"user_id", userID)
-		return s.CheckDailyRateLimit(userID)
-	}
-
-	count := incrCmd.Val()
-
-	// Set expiration only if this is the first request
-	if count == 1 {
-		expireCmd.Val()
+		return s.checkDailyCount(userID)
 	}
+}
 
-	if count > int64(s.config.RateLimitPerDay) {
-		slog.Warn("Rate limit exceeded via Redis",
-			"user_id", userID,
-			"count", count,
-			"limit", s.config.RateLimitPerDay)
-		return apperrors.RateLimitError(userID, s.config.RateLimitPerDay)
+// recordCreate counts a created bar against the user's creation limit. The
+// bar is already stored, so limiter failures are only logged.
+func (s *BarService) recordCreate(userID string) {
+	if s.limits == nil {
+		return
 	}
 
-	slog.Debug("Rate limit check passed via Redis",
-		"user_id", userID,
-		"count", count,
-		"limit", s.config.RateLimitPerDay)
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.RedisOperation)
+	defer cancel()
 
-	return nil
+	_, err := s.limits.Allow(ctx, ratelimit.ActionBarCreate, userID)
+	if err != nil && !errors.Is(err, apperrors.ErrRateLimitExceeded) {
+		slog.Warn("Failed to record bar creation", "user_id", userID, "error", err.Error())
+	}
 }
 
 // CreateBar creates a new donation bar
@@ -84,23 +96,30 @@ func (s *BarService) CreateBar(userID string, req *models.CreateBarRequest) (*mo
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
 	defer cancel()
 
-	// Check daily rate limit (enhanced with Redis support)
-	if err := s.checkRateLimitRedis(userID); err != nil {
+	// Check daily rate limit
+	if err := s.checkCreateLimit(userID); err != nil {
 		return nil, err
 	}
 
-	// Check user's total bar count
-	count, err := s.GetUserBarCount(userID)
-	if err != nil {
-		return nil, apperrors.DatabaseError("count user bars", err)
+	// Check the bar count against the user's plan
+	if err := s.quotas.CheckBarQuota(userID); err != nil {
+		return nil, err
 	}
 
-	if count >= int64(s.config.MaxBarsPerUser) {
-		return nil, apperrors.MaxBarsReached(userID, count, int64(s.config.MaxBarsPerUser))
+	goals, err := normalizeGoals(req.Goals)
+	if err != nil {
+		return nil, err
+	}
+	goalAmount := finalGoal(goals, req.GoalAmount)
+	if goalAmount <= 0 {
+		return nil, apperrors.ValidationError("goal_amount", "must be positive")
 	}
 
+	// Sanitize before validating so removed markup cannot carry injections
+	html, css, report := sanitizeBar(req.HTML, req.CSS, userID)
+
 	// Validate injections
-	if !s.validateInjections(req.HTML) {
+	if !s.validateInjections(html) {
 		return nil, apperrors.ValidationError("injection fields", "one or more required injection fields are missing")
 	}
 
@@ -110,23 +129,29 @@ func (s *BarService) CreateBar(userID string, req *models.CreateBarRequest) (*mo
 		UserID:             userID,
 		Name:               req.Name,
 		Description:        req.Description,
-		HTML:               req.HTML,
-		CSS:                req.CSS,
+		HTML:               html,
+		CSS:                css,
 		Language:           req.Language,
+		Currency:           normalizeCurrency(req.Currency, req.Language),
 		Theme:              req.Theme,
 		IsActive:           true,
 		CreatedAt:          time.Now(),
 		UpdatedAt:          time.Now(),
 		InitialAmount:      req.InitialAmount,
-		GoalAmount:         req.GoalAmount,
+		GoalAmount:         goalAmount,
+		Goals:              goals,
 		AIGenerated:        false,
-		HasValidInjections: s.validateInjections(req.HTML),
+		HasValidInjections: true,
+		OverlayToken:       generateOverlayToken(),
+		SanitizeReport:     report,
 	}
 
-	err = s.repo.Insert(ctx, bar)
-	if err != nil {
+	if err := s.repo.Insert(ctx, bar); err != nil {
 		return nil, apperrors.DatabaseError("insert bar", err)
 	}
+	s.recordCreate(userID)
+
+	s.recordRevision(ctx, nil, bar, userID, models.RevisionSourceManual, nil)
 
 	slog.Info("Bar created successfully",
 		"user_id", userID,
@@ -137,26 +162,29 @@ func (s *BarService) CreateBar(userID string, req *models.CreateBarRequest) (*mo
 }
 
 // CreateBarFromAI creates a bar from AI generation
-func (s *BarService) CreateBarFromAI(userID, prompt string, aiResponse *models.AIGenerateResponse, initialAmount, goalAmount float64) (*models.DonationBar, error) {
+func (s *BarService) CreateBarFromAI(userID, prompt string, aiResponse *models.AIGenerateResponse, initialAmount, goalAmount float64, goals []models.Goal) (*models.DonationBar, error) {
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
 	defer cancel()
 
-	// Check daily rate limit (enhanced with Redis support)
-	if err := s.checkRateLimitRedis(userID); err != nil {
+	goals, err := normalizeGoals(goals)
+	if err != nil {
 		return nil, err
 	}
 
-	// Check user's total bar count
-	count, err := s.GetUserBarCount(userID)
-	if err != nil {
-		return nil, apperrors.DatabaseError("count user bars", err)
+	// Check daily rate limit
+	if err := s.checkCreateLimit(userID); err != nil {
+		return nil, err
 	}
 
-	if count >= int64(s.config.MaxBarsPerUser) {
-		return nil, apperrors.MaxBarsReached(userID, count, int64(s.config.MaxBarsPerUser))
+	// Check the bar count against the user's plan
+	if err := s.quotas.CheckBarQuota(userID); err != nil {
+		return nil, err
 	}
 
+	// The HTML comes back through a form, so it is sanitized again
+	html, css, report := sanitizeBar(aiResponse.HTML, aiResponse.CSS, userID)
+
 	// Generate name from prompt (first 50 chars)
 	name := prompt
 	if len(name) > 50 {
@@ -167,25 +195,31 @@ func (s *BarService) CreateBarFromAI(userID, prompt string, aiResponse *models.A
 		ID:                 primitive.NewObjectID(),
 		UserID:             userID,
 		Name:               name,
-		Description:        "AI tarafından oluşturulan donation bar",
-		HTML:               aiResponse.HTML,
-		CSS:                aiResponse.CSS,
+		Description:        models.DefaultAIBarDescription,
+		HTML:               html,
+		CSS:                css,
 		Language:           aiResponse.Metadata.Language,
+		Currency:           normalizeCurrency(aiResponse.Metadata.Currency, aiResponse.Metadata.Language),
 		Theme:              aiResponse.Metadata.Theme,
 		IsActive:           true,
 		CreatedAt:          time.Now(),
 		UpdatedAt:          time.Now(),
 		InitialAmount:      initialAmount,
-		GoalAmount:         goalAmount,
+		GoalAmount:         finalGoal(goals, goalAmount),
+		Goals:              goals,
 		Prompt:             prompt,
 		AIGenerated:        true,
-		HasValidInjections: aiResponse.Metadata.HasInjections,
+		HasValidInjections: aiResponse.Metadata.HasInjections && s.validateInjections(html),
+		OverlayToken:       generateOverlayToken(),
+		SanitizeReport:     report,
 	}
 
-	err = s.repo.Insert(ctx, bar)
-	if err != nil {
+	if err := s.repo.Insert(ctx, bar); err != nil {
 		return nil, apperrors.DatabaseError("insert AI bar", err)
 	}
+	s.recordCreate(userID)
+
+	s.recordRevision(ctx, nil, bar, userID, models.RevisionSourceAIGenerate, nil)
 
 	slog.Info("AI bar created successfully",
 		"user_id", userID,
@@ -195,17 +229,41 @@ func (s *BarService) CreateBarFromAI(userID, prompt string, aiResponse *models.A
 	return bar, nil
 }
 
-// GetUserBars returns all bars for a user
-func (s *BarService) GetUserBars(userID string) ([]*models.DonationBar, error) {
+// ListUserBars returns one page of a user's bars, sorted and filtered by opts
+func (s *BarService) ListUserBars(userID string, opts *models.BarListOptions) (*models.BarPage, error) {
+	if opts == nil {
+		opts = &models.BarListOptions{}
+	}
+	if err := opts.Normalize(); err != nil {
+		return nil, apperrors.InvalidInput("cursor", opts.Cursor)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
 	defer cancel()
 
-	bars, err := s.repo.FindByUserID(ctx, userID)
+	page, err := s.repo.ListByUserID(ctx, userID, opts)
 	if err != nil {
 		return nil, apperrors.DatabaseError("find user bars", err)
 	}
 
-	return bars, nil
+	return page, nil
+}
+
+// CountUserBars returns the number of a user's bars that pass the filter
+func (s *BarService) CountUserBars(userID string, filter *models.BarFilter) (int64, error) {
+	if filter == nil {
+		filter = &models.BarFilter{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	count, err := s.repo.CountByFilter(ctx, userID, filter)
+	if err != nil {
+		return 0, apperrors.DatabaseError("count user bars", err)
+	}
+
+	return count, nil
 }
 
 // GetBar returns a specific bar by ID
@@ -215,13 +273,7 @@ func (s *BarService) GetBar(userID, barID string) (*models.DonationBar, error) {
 
 	bar, err := s.repo.FindByID(ctx, userID, barID)
 	if err != nil {
-		if err.Error() == "bar not found" {
-			return nil, apperrors.NotFound("bar", barID)
-		}
-		if err.Error() == "invalid bar ID format" {
-			return nil, apperrors.InvalidInput("bar ID", barID)
-		}
-		return nil, apperrors.DatabaseError("find bar", err)
+		return nil, mapBarError(err, barID, "find bar")
 	}
 
 	return bar, nil
@@ -232,17 +284,66 @@ func (s *BarService) UpdateBar(userID, barID string, req *models.UpdateBarReques
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
 	defer cancel()
 
-	bar, err := s.repo.Update(ctx, userID, barID, req)
-	if err != nil {
-		if err.Error() == "bar not found" {
-			return nil, apperrors.NotFound("bar", barID)
+	if req.Currency != nil {
+		currency := strings.ToUpper(*req.Currency)
+		req.Currency = &currency
+	}
+
+	if req.Goals != nil {
+		goals, err := normalizeGoals(*req.Goals)
+		if err != nil {
+			return nil, err
 		}
-		if err.Error() == "invalid bar ID format" {
-			return nil, apperrors.InvalidInput("bar ID", barID)
+		req.Goals = &goals
+		if len(goals) > 0 {
+			goal := finalGoal(goals, 0)
+			req.GoalAmount = &goal
 		}
-		return nil, apperrors.DatabaseError("update bar", err)
+	} else if req.GoalAmount != nil {
+		// Setting a single goal replaces the stretch goals
+		req.Goals = &[]models.Goal{}
+	}
+
+	// Amount changes can reach milestones, measured from the previous progress
+	var before *models.DonationBar
+	if req.InitialAmount != nil || req.GoalAmount != nil || req.Goals != nil {
+		before, _ = s.repo.FindByID(ctx, userID, barID)
+	}
+
+	bar, err := s.repo.Update(ctx, userID, barID, req)
+	if err != nil {
+		return nil, mapBarError(err, barID, "update bar")
+	}
+
+	publishBarUpdate(s.broker, bar, s.config.Timeouts.RedisOperation)
+	if before != nil {
+		notifyProgress(s.events, before, bar)
+	}
+
+	return bar, nil
+}
+
+// IncrementAmount adds an amount to the bar's starting amount. Concurrent
+// increments all count, where UpdateBar would let the last write win.
+func (s *BarService) IncrementAmount(userID, barID string, amount float64) (*models.DonationBar, error) {
+	if !(amount > 0) { // also catches NaN
+		return nil, apperrors.ValidationError("amount", "must be positive")
+	}
+	if amount > models.MaxDonationAmount {
+		return nil, apperrors.ValidationError("amount", fmt.Sprintf("must be at most %d", models.MaxDonationAmount))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	bar, err := s.repo.IncrementAmount(ctx, userID, barID, amount)
+	if err != nil {
+		return nil, mapBarError(err, barID, "increment bar amount")
 	}
 
+	publishBarUpdate(s.broker, bar, s.config.Timeouts.RedisOperation)
+	notifyProgress(s.events, withoutAmount(bar, amount), bar)
+
 	return bar, nil
 }
 
@@ -251,23 +352,225 @@ func (s *BarService) UpdateBarComplete(userID, barID string, req *models.CreateB
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
 	defer cancel()
 
+	req.HTML, req.CSS, _ = sanitizeBar(req.HTML, req.CSS, userID)
+
 	// Validate injections before update
 	if !s.validateInjections(req.HTML) {
 		return apperrors.ValidationError("injection fields", "one or more required injection fields are missing")
 	}
 
-	err := s.repo.UpdateComplete(ctx, userID, barID, req, isActive)
+	req.Currency = normalizeCurrency(req.Currency, req.Language)
+
+	goals, err := normalizeGoals(req.Goals)
+	if err != nil {
+		return err
+	}
+	req.Goals = goals
+	req.GoalAmount = finalGoal(goals, req.GoalAmount)
+
+	// The previous content is needed to tell whether this is a new revision
+	before, err := s.repo.FindByID(ctx, userID, barID)
+	if err != nil {
+		return mapBarError(err, barID, "find bar")
+	}
+
+	// The repository counts the revision in the same write when the content changed
+	bar, err := s.repo.UpdateComplete(ctx, userID, barID, req, isActive)
+	if err != nil {
+		return mapBarError(err, barID, "update complete bar")
+	}
+
+	if bar.Revision != before.Revision {
+		s.recordRevision(ctx, before, bar, userID, models.RevisionSourceManual, nil)
+	}
+
+	// Push the new amounts to live overlays
+	publishBarUpdate(s.broker, bar, s.config.Timeouts.RedisOperation)
+	notifyProgress(s.events, before, bar)
+
+	return nil
+}
+
+// ApplyRefinement stores an AI refined design as the next revision of a bar
+func (s *BarService) ApplyRefinement(userID, barID string, aiResponse *models.AIGenerateResponse) (*models.DonationBar, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	html, css, report := sanitizeBar(aiResponse.HTML, aiResponse.CSS, userID)
+
+	// A refinement must never break a working overlay
+	if !s.validateInjections(html) {
+		return nil, apperrors.ValidationError("injection fields", "one or more required injection fields are missing")
+	}
+
+	before, err := s.repo.FindByID(ctx, userID, barID)
+	if err != nil {
+		return nil, mapBarError(err, barID, "find bar")
+	}
+
+	bar, err := s.repo.UpdateContent(ctx, userID, barID, html, css)
+	if err != nil {
+		return nil, mapBarError(err, barID, "update bar content")
+	}
+	bar.SanitizeReport = report
+
+	s.recordRevision(ctx, before, bar, userID, models.RevisionSourceAIRefine, nil)
+
+	slog.Info("AI refinement applied",
+		"user_id", userID,
+		"bar_id", barID,
+		"revision", bar.Revision)
+
+	publishBarUpdate(s.broker, bar, s.config.Timeouts.RedisOperation)
+
+	return bar, nil
+}
+
+// GetRevisions returns the content history of a bar, newest first
+func (s *BarService) GetRevisions(userID, barID string) ([]*models.BarRevision, error) {
+	// Ownership check; revisions are stored by bar
+	if _, err := s.GetBar(userID, barID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	revisions, err := s.revisions.FindByBarID(ctx, barID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("find revisions", err)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision returns one revision of a bar
+func (s *BarService) GetRevision(userID, barID string, number int) (*models.BarRevision, error) {
+	if _, err := s.GetBar(userID, barID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	revision, err := s.revisions.FindByNumber(ctx, barID, number)
 	if err != nil {
-		if err.Error() == "bar not found" {
-			return apperrors.NotFound("bar", barID)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NotFound("revision", strconv.Itoa(number))
 		}
-		if err.Error() == "invalid bar ID format" {
-			return apperrors.InvalidInput("bar ID", barID)
+		return nil, apperrors.DatabaseError("find revision", err)
+	}
+
+	return revision, nil
+}
+
+// RestoreRevision puts an older revision's HTML/CSS back; the restore itself
+// is recorded as a new revision, so history is never rewritten
+func (s *BarService) RestoreRevision(userID, barID string, number int) (*models.DonationBar, error) {
+	revision, err := s.GetRevision(userID, barID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	// Sanitizer rules may have tightened since the revision was saved
+	html, css, report := sanitizeBar(revision.HTML, revision.CSS, userID)
+	if !s.validateInjections(html) {
+		return nil, apperrors.ValidationError("injection fields", "one or more required injection fields are missing")
+	}
+
+	before, err := s.repo.FindByID(ctx, userID, barID)
+	if err != nil {
+		return nil, mapBarError(err, barID, "find bar")
+	}
+
+	bar, err := s.repo.UpdateContent(ctx, userID, barID, html, css)
+	if err != nil {
+		return nil, mapBarError(err, barID, "update bar content")
+	}
+	bar.SanitizeReport = report
+
+	s.recordRevision(ctx, before, bar, userID, models.RevisionSourceRestore, &number)
+
+	slog.Info("Bar revision restored",
+		"user_id", userID,
+		"bar_id", barID,
+		"restored_from", number,
+		"revision", bar.Revision)
+
+	publishBarUpdate(s.broker, bar, s.config.Timeouts.RedisOperation)
+
+	return bar, nil
+}
+
+// recordRevision appends the bar's current content to its history. Bars
+// created before the history existed first get their previous content as an
+// "import" revision. The content change already happened, so failures are
+// logged rather than returned.
+func (s *BarService) recordRevision(ctx context.Context, before, after *models.DonationBar, authorID, source string, restoredFrom *int) {
+	barID := after.ID.Hex()
+
+	previousHTML, previousCSS := "", ""
+	if before != nil {
+		previousHTML, previousCSS = before.HTML, before.CSS
+
+		count, err := s.revisions.CountByBarID(ctx, barID)
+		if err != nil {
+			slog.Warn("Failed to count bar revisions", "bar_id", barID, "error", err.Error())
+		} else if count == 0 {
+			baseline := newRevision(before, before.UserID, models.RevisionSourceImport, "", "", nil)
+			baseline.CreatedAt = before.UpdatedAt
+			if err := s.revisions.Insert(ctx, baseline); err != nil {
+				slog.Warn("Failed to record baseline revision", "bar_id", barID, "error", err.Error())
+			}
 		}
-		return apperrors.DatabaseError("update complete bar", err)
 	}
 
-	return nil
+	revision := newRevision(after, authorID, source, previousHTML, previousCSS, restoredFrom)
+	if err := s.revisions.Insert(ctx, revision); err != nil {
+		slog.Error("Failed to record bar revision",
+			"bar_id", barID,
+			"revision", after.Revision,
+			"error", err.Error())
+	}
+}
+
+// newRevision snapshots a bar's content with a line summary against the previous content
+func newRevision(bar *models.DonationBar, authorID, source, previousHTML, previousCSS string, restoredFrom *int) *models.BarRevision {
+	htmlAdded, htmlRemoved := diff.Count(diff.Lines(previousHTML, bar.HTML))
+	cssAdded, cssRemoved := diff.Count(diff.Lines(previousCSS, bar.CSS))
+
+	return &models.BarRevision{
+		ID:           primitive.NewObjectID(),
+		BarID:        bar.ID,
+		UserID:       bar.UserID,
+		Revision:     bar.Revision,
+		AuthorID:     authorID,
+		Source:       source,
+		RestoredFrom: restoredFrom,
+		HTML:         bar.HTML,
+		CSS:          bar.CSS,
+		Summary: models.RevisionSummary{
+			HTMLAdded:   htmlAdded,
+			HTMLRemoved: htmlRemoved,
+			CSSAdded:    cssAdded,
+			CSSRemoved:  cssRemoved,
+		},
+		CreatedAt: time.Now(),
+	}
+}
+
+// mapBarError turns bar repository errors into application errors
+func mapBarError(err error, barID, operation string) error {
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return apperrors.NotFound("bar", barID)
+	}
+	if errors.Is(err, apperrors.ErrInvalidBarID) {
+		return apperrors.InvalidInput("bar ID", barID)
+	}
+	return apperrors.DatabaseError(operation, err)
 }
 
 // DeleteBar deletes a bar
@@ -277,16 +580,110 @@ func (s *BarService) DeleteBar(userID, barID string) error {
 
 	err := s.repo.Delete(ctx, userID, barID)
 	if err != nil {
-		if err.Error() == "bar not found" {
-			return apperrors.NotFound("bar", barID)
+		return mapBarError(err, barID, "delete bar")
+	}
+
+	return nil
+}
+
+// GetBarByOverlayToken returns an active bar by its public overlay token
+func (s *BarService) GetBarByOverlayToken(token string) (*models.DonationBar, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	bar, err := s.repo.FindByOverlayToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NotFound("overlay", "***")
 		}
-		if err.Error() == "invalid bar ID format" {
-			return apperrors.InvalidInput("bar ID", barID)
+		return nil, apperrors.DatabaseError("find overlay bar", err)
+	}
+
+	// Inactive bars are not served to OBS
+	if !bar.IsActive {
+		return nil, apperrors.NotFound("overlay", "***")
+	}
+
+	return bar, nil
+}
+
+// RegenerateOverlayToken issues a new overlay token, invalidating the old URL
+func (s *BarService) RegenerateOverlayToken(userID, barID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	token := generateOverlayToken()
+	err := s.repo.SetOverlayToken(ctx, userID, barID, token)
+	if err != nil {
+		return "", mapBarError(err, barID, "set overlay token")
+	}
+
+	slog.Info("Overlay token regenerated",
+		"user_id", userID,
+		"bar_id", barID)
+
+	// Closes live overlays still using the old URL
+	if bar, err := s.repo.FindByID(ctx, userID, barID); err == nil {
+		publishBarUpdate(s.broker, bar, s.config.Timeouts.RedisOperation)
+	}
+
+	return token, nil
+}
+
+// GetBarByWebhookToken returns a bar by its donation webhook token.
+// Inactive bars still receive donations; they are only hidden from OBS.
+func (s *BarService) GetBarByWebhookToken(token string) (*models.DonationBar, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	bar, err := s.repo.FindByWebhookToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NotFound("webhook", "***")
 		}
-		return apperrors.DatabaseError("delete bar", err)
+		return nil, apperrors.DatabaseError("find webhook bar", err)
 	}
 
-	return nil
+	return bar, nil
+}
+
+// RegenerateWebhook issues a new webhook token and signing secret,
+// invalidating the old URL and signatures
+func (s *BarService) RegenerateWebhook(userID, barID string) (*models.WebhookCredentials, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	credentials := &models.WebhookCredentials{
+		Token:  generateOverlayToken(),
+		Secret: models.WebhookSecretPrefix + rand.Text(),
+	}
+	err := s.repo.SetWebhook(ctx, userID, barID, credentials.Token, credentials.Secret)
+	if err != nil {
+		return nil, mapBarError(err, barID, "set webhook")
+	}
+
+	slog.Info("Webhook credentials regenerated",
+		"user_id", userID,
+		"bar_id", barID)
+
+	return credentials, nil
+}
+
+// ApplyDonation adds a donation recorded in the ledger to its bar's total
+// and pushes the new total to live overlays
+func (s *BarService) ApplyDonation(donation *models.Donation) (*models.DonationBar, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	barID := donation.BarID.Hex()
+	bar, err := s.repo.AddDonationTotal(ctx, barID, donation.Amount)
+	if err != nil {
+		return nil, mapBarError(err, barID, "update bar total")
+	}
+
+	publishBarUpdate(s.broker, bar, s.config.Timeouts.RedisOperation)
+	notifyProgress(s.events, withoutAmount(bar, donation.Amount), bar)
+	return bar, nil
 }
 
 // GetUserBarCount returns the total number of bars for a user
@@ -302,12 +699,14 @@ func (s *BarService) GetUserBarCount(userID string) (int64, error) {
 	return count, nil
 }
 
-// GetUserDailyBarCount returns the number of bars created by user today
+// GetUserDailyBarCount returns the number of bars created by user since
+// midnight in the rate limit time zone
 func (s *BarService) GetUserDailyBarCount(userID string) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
 	defer cancel()
 
-	count, err := s.repo.CountByUserIDToday(ctx, userID)
+	since := ratelimit.StartOfDay(time.Now(), s.config.RateLimit.Location())
+	count, err := s.repo.CountCreatedSince(ctx, userID, since)
 	if err != nil {
 		return 0, apperrors.DatabaseError("count daily bars", err)
 	}
@@ -315,27 +714,124 @@ func (s *BarService) GetUserDailyBarCount(userID string) (int64, error) {
 	return count, nil
 }
 
-// CheckDailyRateLimit checks if user has exceeded daily rate limit
+// CheckDailyRateLimit returns a rate limit error when the user cannot create another bar now
 func (s *BarService) CheckDailyRateLimit(userID string) error {
+	return s.checkCreateLimit(userID)
+}
+
+// checkDailyCount limits bar creation by the bars created today in the database
+func (s *BarService) checkDailyCount(userID string) error {
 	dailyCount, err := s.GetUserDailyBarCount(userID)
 	if err != nil {
 		return err
 	}
 
-	// günlük maksimum 5 bar
-	if dailyCount >= 5 {
-		return apperrors.RateLimitError(userID, 5)
+	limit := s.config.RateLimit.BarsPerDay
+	if dailyCount < int64(limit) {
+		return nil
 	}
 
-	return nil
+	now := time.Now()
+	reset := ratelimit.StartOfDay(now, s.config.RateLimit.Location()).AddDate(0, 0, 1)
+	policy := ratelimit.Policy{Limit: limit, Window: 24 * time.Hour}
+	return ratelimit.Exceeded(ratelimit.ActionBarCreate, policy, &ratelimit.Result{
+		Limit:      limit,
+		Reset:      reset,
+		RetryAfter: reset.Sub(now),
+	})
+}
+
+// notifyProgress hands a bar's state before and after a change to milestone
+// detection (no-op without an event service)
+func notifyProgress(events interfaces.EventServiceInterface, before, after *models.DonationBar) {
+	if events == nil || before == nil || after == nil {
+		return
+	}
+	events.Progressed(before, after)
+}
+
+// withoutAmount returns the bar as it was before amount was added to its total
+func withoutAmount(bar *models.DonationBar, amount float64) *models.DonationBar {
+	before := *bar
+	before.DonationTotal -= amount
+	return &before
+}
+
+// publishBarUpdate notifies live overlays about a bar's new state (best effort)
+func publishBarUpdate(broker interfaces.EventBrokerInterface, bar *models.DonationBar, timeout time.Duration) {
+	if broker == nil || bar == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := broker.Publish(ctx, models.NewBarUpdate(bar)); err != nil {
+		slog.Warn("Failed to publish bar update",
+			"bar_id", bar.ID.Hex(),
+			"error", err.Error())
+	}
+}
+
+// generateOverlayToken returns an unguessable token for public overlay URLs
+func generateOverlayToken() string {
+	return rand.Text()
 }
 
 // validateInjections checks if all required injection fields are present
 func (s *BarService) validateInjections(html string) bool {
-	for _, injection := range models.RequiredInjections {
-		if !strings.Contains(html, injection) {
-			return false
+	return render.HasRequiredFields(html)
+}
+
+// normalizeCurrency upper-cases a currency code, defaulting by language
+func normalizeCurrency(currency, language string) string {
+	if currency == "" {
+		return models.DefaultCurrency(language)
+	}
+	return strings.ToUpper(currency)
+}
+
+// normalizeGoals validates stretch goals, which must rise strictly, and trims
+// their labels. The result is never nil, so storing it clears old goals.
+func normalizeGoals(goals []models.Goal) ([]models.Goal, error) {
+	if len(goals) > models.MaxGoals {
+		return nil, apperrors.ValidationError("goals", fmt.Sprintf("a bar can have at most %d goals", models.MaxGoals))
+	}
+
+	normalized := make([]models.Goal, 0, len(goals))
+	for i, goal := range goals {
+		if goal.Amount <= 0 {
+			return nil, apperrors.ValidationError("goals", "amounts must be positive")
+		}
+		if i > 0 && goal.Amount <= goals[i-1].Amount {
+			return nil, apperrors.ValidationError("goals", "amounts must be in ascending order")
 		}
+		goal.Label = strings.TrimSpace(goal.Label)
+		if utf8.RuneCountInString(goal.Label) > models.MaxGoalLabelLength {
+			return nil, apperrors.ValidationError("goals", fmt.Sprintf("labels must be at most %d characters", models.MaxGoalLabelLength))
+		}
+		normalized = append(normalized, goal)
 	}
-	return true
+	return normalized, nil
+}
+
+// finalGoal returns the last stretch goal, or goal without stretch goals
+func finalGoal(goals []models.Goal, goal float64) float64 {
+	if len(goals) == 0 {
+		return goal
+	}
+	return goals[len(goals)-1].Amount
+}
+
+// sanitizeBar strips disallowed markup and styles, logging what was removed
+func sanitizeBar(html, css, userID string) (string, string, *sanitize.Report) {
+	html, css, report := sanitize.Bar(html, css)
+	if report.Empty() {
+		return html, css, nil
+	}
+
+	slog.Warn("Sanitizer removed content from bar",
+		"user_id", userID,
+		"removed", report.String())
+	return html, css, report
 }
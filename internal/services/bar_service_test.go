@@ -1,23 +1,41 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
 	"donationbars/internal/config"
 	apperrors "donationbars/internal/errors"
+	"donationbars/internal/events"
+	"donationbars/internal/interfaces"
 	"donationbars/internal/mocks"
 	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+	"donationbars/internal/repository/memory"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func createTestConfig() *config.Config {
 	return &config.Config{
-		MaxBarsPerUser:  5,
-		RateLimitPerDay: 5,
+		DefaultPlan: models.PlanFree,
+		RateLimit: config.RateLimitConfig{
+			BarsPerDay:         5,
+			AIPerDay:           20,
+			DonationsPerMinute: 60,
+			Timezone:           "UTC",
+		},
+		Session: config.SessionConfig{
+			TTL: 24 * time.Hour,
+		},
 		Timeouts: config.TimeoutConfig{
 			DatabaseRead:   5 * time.Second,
 			DatabaseWrite:  10 * time.Second,
@@ -26,19 +44,32 @@ func createTestConfig() *config.Config {
 	}
 }
 
-func createTestRedisClient() *config.RedisClient {
-	return &config.RedisClient{
-		Client:  nil,
-		Enabled: false, // Disabled for tests
-	}
+// createTestGuard returns a guard on a fresh memory limiter with the config's limits
+func createTestGuard(cfg *config.Config) *ratelimit.Guard {
+	return ratelimit.NewGuard(ratelimit.NewMemoryLimiter(), ratelimit.PoliciesFromConfig(cfg.RateLimit))
+}
+
+// createTestQuotaService returns a quota service where every user is on the
+// default plan and bars are counted by the given repository
+func createTestQuotaService(bars interfaces.BarRepositoryInterface) *QuotaService {
+	users := new(mocks.MockUserRepository)
+	users.On("FindByID", mock.Anything, mock.AnythingOfType("string")).Return(&models.User{}, nil).Maybe()
+	return NewQuotaService(users, bars, memory.NewUsageRepository(), createTestConfig()).(*QuotaService)
+}
+
+// createTestRevisionRepository accepts any revision writes
+func createTestRevisionRepository() *mocks.MockRevisionRepository {
+	revisions := new(mocks.MockRevisionRepository)
+	revisions.On("Insert", mock.Anything, mock.AnythingOfType("*models.BarRevision")).Return(nil).Maybe()
+	revisions.On("CountByBarID", mock.Anything, mock.AnythingOfType("string")).Return(int64(1), nil).Maybe()
+	return revisions
 }
 
 func TestBarService_CreateBar_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(mocks.MockBarRepository)
-	redisClient := createTestRedisClient()
 	cfg := createTestConfig()
-	service := NewBarService(mockRepo, redisClient, cfg)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, cfg)
 
 	userID := "test-user"
 	req := &models.CreateBarRequest{
@@ -53,7 +84,7 @@ func TestBarService_CreateBar_Success(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockRepo.On("CountByUserIDToday", mock.Anything, userID).Return(int64(2), nil)
+	mockRepo.On("CountCreatedSince", mock.Anything, userID, mock.Anything).Return(int64(2), nil)
 	mockRepo.On("CountByUserID", mock.Anything, userID).Return(int64(3), nil)
 	mockRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.DonationBar")).Return(nil)
 
@@ -68,15 +99,15 @@ func TestBarService_CreateBar_Success(t *testing.T) {
 	assert.Equal(t, userID, result.UserID)
 	assert.False(t, result.AIGenerated)
 	assert.True(t, result.HasValidInjections)
+	assert.NotEmpty(t, result.OverlayToken)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestBarService_CreateBar_RateLimitExceeded(t *testing.T) {
 	// Arrange
 	mockRepo := new(mocks.MockBarRepository)
-	redisClient := createTestRedisClient()
 	cfg := createTestConfig()
-	service := NewBarService(mockRepo, redisClient, cfg)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, cfg)
 
 	userID := "test-user"
 	req := &models.CreateBarRequest{
@@ -89,7 +120,7 @@ func TestBarService_CreateBar_RateLimitExceeded(t *testing.T) {
 	}
 
 	// Mock expectations - user has reached daily limit
-	mockRepo.On("CountByUserIDToday", mock.Anything, userID).Return(int64(5), nil)
+	mockRepo.On("CountCreatedSince", mock.Anything, userID, mock.Anything).Return(int64(5), nil)
 
 	// Act
 	result, err := service.CreateBar(userID, req)
@@ -104,12 +135,134 @@ func TestBarService_CreateBar_RateLimitExceeded(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestBarService_CreateBar_UsesLimiter(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	cfg := createTestConfig()
+	cfg.RateLimit.BarsPerDay = 1
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), createTestGuard(cfg), events.NewMemoryBroker(), nil, cfg)
+
+	userID := "test-user"
+	req := &models.CreateBarRequest{
+		Name:          "Test Bar",
+		HTML:          "<div>{goal} {total} {percentage} {remaining} {description}</div>",
+		CSS:           ".bar { width: 800px; }",
+		Language:      "tr",
+		InitialAmount: 100.0,
+		GoalAmount:    1000.0,
+	}
+
+	// The limiter alone decides, the daily count is not read
+	mockRepo.On("CountByUserID", mock.Anything, userID).Return(int64(0), nil)
+	mockRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.DonationBar")).Return(nil)
+
+	// Act
+	_, err := service.CreateBar(userID, req)
+	require.NoError(t, err)
+	_, err = service.CreateBar(userID, req)
+
+	// Assert
+	var exceeded *ratelimit.ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, ratelimit.ActionBarCreate, exceeded.Action)
+	assert.ErrorIs(t, service.CheckDailyRateLimit(userID), apperrors.ErrRateLimitExceeded)
+	mockRepo.AssertNotCalled(t, "CountCreatedSince", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNumberOfCalls(t, "Insert", 1)
+}
+
+func TestBarService_CreateBar_FailedCreateKeepsLimit(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	cfg := createTestConfig()
+	cfg.RateLimit.BarsPerDay = 1
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), createTestGuard(cfg), events.NewMemoryBroker(), nil, cfg)
+
+	userID := "test-user"
+	req := &models.CreateBarRequest{
+		Name:          "Test Bar",
+		HTML:          "<div>{goal}</div>",
+		CSS:           ".bar { width: 800px; }",
+		Language:      "tr",
+		InitialAmount: 100.0,
+		GoalAmount:    1000.0,
+	}
+
+	mockRepo.On("CountByUserID", mock.Anything, userID).Return(int64(0), nil)
+	mockRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.DonationBar")).Return(errors.New("connection reset")).Once()
+	mockRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.DonationBar")).Return(nil)
+
+	// Act: neither a rejected design nor a failed insert uses up the day's bar
+	_, err := service.CreateBar(userID, req)
+	assert.ErrorIs(t, err, apperrors.ErrValidationFailed)
+
+	req.HTML = "<div>{goal} {total} {percentage} {remaining} {description}</div>"
+	_, err = service.CreateBar(userID, req)
+	require.Error(t, err)
+	assert.NoError(t, service.CheckDailyRateLimit(userID))
+
+	_, err = service.CreateBar(userID, req)
+	require.NoError(t, err)
+
+	// Assert
+	assert.ErrorIs(t, service.CheckDailyRateLimit(userID), apperrors.ErrRateLimitExceeded)
+}
+
+// failingLimiter stands in for an unreachable Redis
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string, policy ratelimit.Policy) (*ratelimit.Result, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingLimiter) AllowN(ctx context.Context, key string, policy ratelimit.Policy, n int) (*ratelimit.Result, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingLimiter) Peek(ctx context.Context, key string, policy ratelimit.Policy) (*ratelimit.Result, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestBarService_CreateBar_FallsBackToDailyCount(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	cfg := createTestConfig()
+	cfg.RateLimit.Timezone = "Europe/Istanbul"
+	guard := ratelimit.NewGuard(failingLimiter{}, ratelimit.PoliciesFromConfig(cfg.RateLimit))
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), guard, events.NewMemoryBroker(), nil, cfg)
+
+	userID := "test-user"
+	req := &models.CreateBarRequest{
+		Name:          "Test Bar",
+		HTML:          "<div>{goal} {total} {percentage} {remaining} {description}</div>",
+		CSS:           ".bar { width: 800px; }",
+		Language:      "tr",
+		InitialAmount: 100.0,
+		GoalAmount:    1000.0,
+	}
+
+	// "Today" starts at midnight in the configured time zone
+	midnight := ratelimit.StartOfDay(time.Now(), cfg.RateLimit.Location())
+	mockRepo.On("CountCreatedSince", mock.Anything, userID, mock.MatchedBy(func(since time.Time) bool {
+		return since.Equal(midnight)
+	})).Return(int64(5), nil)
+
+	// Act
+	result, err := service.CreateBar(userID, req)
+
+	// Assert
+	assert.Nil(t, result)
+	var exceeded *ratelimit.ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	assert.True(t, exceeded.Result.Reset.Equal(midnight.AddDate(0, 0, 1)))
+	assert.Positive(t, exceeded.Result.RetryAfter)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestBarService_CreateBar_MaxBarsReached(t *testing.T) {
 	// Arrange
 	mockRepo := new(mocks.MockBarRepository)
-	redisClient := createTestRedisClient()
 	cfg := createTestConfig()
-	service := NewBarService(mockRepo, redisClient, cfg)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, cfg)
 
 	userID := "test-user"
 	req := &models.CreateBarRequest{
@@ -122,7 +275,7 @@ func TestBarService_CreateBar_MaxBarsReached(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockRepo.On("CountByUserIDToday", mock.Anything, userID).Return(int64(2), nil)
+	mockRepo.On("CountCreatedSince", mock.Anything, userID, mock.Anything).Return(int64(2), nil)
 	mockRepo.On("CountByUserID", mock.Anything, userID).Return(int64(5), nil) // Max reached
 
 	// Act
@@ -141,9 +294,8 @@ func TestBarService_CreateBar_MaxBarsReached(t *testing.T) {
 func TestBarService_CreateBar_InvalidInjections(t *testing.T) {
 	// Arrange
 	mockRepo := new(mocks.MockBarRepository)
-	redisClient := createTestRedisClient()
 	cfg := createTestConfig()
-	service := NewBarService(mockRepo, redisClient, cfg)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, cfg)
 
 	userID := "test-user"
 	req := &models.CreateBarRequest{
@@ -156,7 +308,7 @@ func TestBarService_CreateBar_InvalidInjections(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockRepo.On("CountByUserIDToday", mock.Anything, userID).Return(int64(2), nil)
+	mockRepo.On("CountCreatedSince", mock.Anything, userID, mock.Anything).Return(int64(2), nil)
 	mockRepo.On("CountByUserID", mock.Anything, userID).Return(int64(3), nil)
 
 	// Act
@@ -175,9 +327,8 @@ func TestBarService_CreateBar_InvalidInjections(t *testing.T) {
 func TestBarService_GetBar_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(mocks.MockBarRepository)
-	redisClient := createTestRedisClient()
 	cfg := createTestConfig()
-	service := NewBarService(mockRepo, redisClient, cfg)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, cfg)
 
 	userID := "test-user"
 	barID := "507f1f77bcf86cd799439011"
@@ -208,9 +359,8 @@ func TestBarService_GetBar_Success(t *testing.T) {
 func TestBarService_GetBar_NotFound(t *testing.T) {
 	// Arrange
 	mockRepo := new(mocks.MockBarRepository)
-	redisClient := createTestRedisClient()
 	cfg := createTestConfig()
-	service := NewBarService(mockRepo, redisClient, cfg)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, cfg)
 
 	userID := "test-user"
 	barID := "507f1f77bcf86cd799439011"
@@ -227,12 +377,163 @@ func TestBarService_GetBar_NotFound(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestBarService_GetBar_MapsRepositoryErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoErr  error
+		wantType string
+	}{
+		{"not found", apperrors.Missing("bar"), "NOT_FOUND"},
+		{"wrapped not found", fmt.Errorf("find bar: %w", apperrors.Missing("bar")), "NOT_FOUND"},
+		{"invalid ID", apperrors.ErrInvalidBarID, "INVALID_INPUT"},
+		{"database unavailable", apperrors.ErrDatabaseUnavailable, "DATABASE_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockBarRepository)
+			service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+			mockRepo.On("FindByID", mock.Anything, "test-user", "bar-1").Return(nil, tt.repoErr)
+
+			_, err := service.GetBar("test-user", "bar-1")
+
+			var appErr *apperrors.AppError
+			assert.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.wantType, appErr.Type)
+		})
+	}
+}
+
+func TestBarService_ListUserBars_AppliesDefaults(t *testing.T) {
+	mockRepo := new(mocks.MockBarRepository)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+	page := &models.BarPage{Bars: []*models.DonationBar{{Name: "Test Bar"}}}
+	mockRepo.On("ListByUserID", mock.Anything, "test-user", mock.MatchedBy(func(opts *models.BarListOptions) bool {
+		return opts.Sort == models.BarSortCreatedAt && opts.Order == models.SortAsc &&
+			opts.Limit == models.DefaultBarPageSize && opts.After == nil
+	})).Return(page, nil)
+
+	result, err := service.ListUserBars("test-user", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, page, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBarService_ListUserBars_FollowsCursor(t *testing.T) {
+	mockRepo := new(mocks.MockBarRepository)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+	// The cursor of a full page points at its last bar
+	first := &models.BarListOptions{Sort: models.BarSortName, Limit: 1}
+	first.Normalize()
+	last := &models.DonationBar{ID: primitive.NewObjectID(), Name: "Alpha"}
+	cursor := first.Page([]*models.DonationBar{last, {ID: primitive.NewObjectID(), Name: "Bravo"}}).NextCursor
+	require.NotEmpty(t, cursor)
+
+	mockRepo.On("ListByUserID", mock.Anything, "test-user", mock.MatchedBy(func(opts *models.BarListOptions) bool {
+		return opts.After != nil && opts.After.ID == last.ID && opts.After.Name == "Alpha"
+	})).Return(&models.BarPage{Bars: []*models.DonationBar{}}, nil)
+
+	_, err := service.ListUserBars("test-user", &models.BarListOptions{Sort: models.BarSortName, Limit: 1, Cursor: cursor})
+	assert.NoError(t, err)
+
+	// A cursor is only valid for the sort order it was issued for
+	_, err = service.ListUserBars("test-user", &models.BarListOptions{Sort: models.BarSortProgress, Cursor: cursor})
+	var appErr *apperrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperrors.TypeInvalidInput, appErr.Type)
+
+	_, err = service.ListUserBars("test-user", &models.BarListOptions{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+
+	mockRepo.AssertNumberOfCalls(t, "ListByUserID", 1)
+}
+
+func TestBarService_GetBarByOverlayToken_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+	token := "secret-token"
+	expectedBar := &models.DonationBar{
+		ID:           primitive.NewObjectID(),
+		Name:         "Overlay Bar",
+		IsActive:     true,
+		OverlayToken: token,
+	}
+
+	// Mock expectations
+	mockRepo.On("FindByOverlayToken", mock.Anything, token).Return(expectedBar, nil)
+
+	// Act
+	result, err := service.GetBarByOverlayToken(token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, expectedBar.ID, result.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBarService_GetBarByOverlayToken_InactiveBar(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+	token := "secret-token"
+	inactiveBar := &models.DonationBar{
+		ID:           primitive.NewObjectID(),
+		IsActive:     false,
+		OverlayToken: token,
+	}
+
+	// Mock expectations
+	mockRepo.On("FindByOverlayToken", mock.Anything, token).Return(inactiveBar, nil)
+
+	// Act
+	result, err := service.GetBarByOverlayToken(token)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "NOT_FOUND", appErr.Type)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBarService_RegenerateOverlayToken_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+	userID := "test-user"
+	barID := "507f1f77bcf86cd799439011"
+
+	// Mock expectations
+	mockRepo.On("SetOverlayToken", mock.Anything, userID, barID, mock.AnythingOfType("string")).Return(nil)
+	mockRepo.On("FindByID", mock.Anything, userID, barID).Return(&models.DonationBar{ID: primitive.NewObjectID(), UserID: userID}, nil)
+
+	// Act
+	first, err := service.RegenerateOverlayToken(userID, barID)
+	assert.NoError(t, err)
+	second, err := service.RegenerateOverlayToken(userID, barID)
+	assert.NoError(t, err)
+
+	// Assert
+	assert.NotEmpty(t, first)
+	assert.NotEqual(t, first, second)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestBarService_ValidateInjections(t *testing.T) {
 	// Arrange
 	mockRepo := new(mocks.MockBarRepository)
-	redisClient := createTestRedisClient()
 	cfg := createTestConfig()
-	service := NewBarService(mockRepo, redisClient, cfg)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, cfg)
 
 	tests := []struct {
 		name     string
@@ -258,3 +559,231 @@ func TestBarService_ValidateInjections(t *testing.T) {
 		})
 	}
 }
+
+func TestBarService_IncrementAmount_PublishesUpdate(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	broker := events.NewMemoryBroker()
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, broker, nil, createTestConfig())
+
+	userID := "test-user"
+	bar := &models.DonationBar{ID: primitive.NewObjectID(), UserID: userID, InitialAmount: 150, GoalAmount: 1000}
+	barID := bar.ID.Hex()
+	mockRepo.On("IncrementAmount", mock.Anything, userID, barID, 50.0).Return(bar, nil)
+
+	updates, unsubscribe := broker.Subscribe(barID)
+	defer unsubscribe()
+
+	// Act
+	result, err := service.IncrementAmount(userID, barID, 50)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 150.0, result.InitialAmount)
+	select {
+	case update := <-updates:
+		assert.Equal(t, 150.0, update.InitialAmount)
+	case <-time.After(time.Second):
+		t.Fatal("no bar update published")
+	}
+
+	_, err = service.IncrementAmount(userID, barID, 0)
+	assert.ErrorIs(t, err, apperrors.ErrValidationFailed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBarService_IncrementAmount_RejectsUnboundedAmounts(t *testing.T) {
+	mockRepo := new(mocks.MockBarRepository)
+	service := NewBarService(mockRepo, createTestRevisionRepository(), createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+	for _, amount := range []float64{models.MaxDonationAmount + 1, 1e308, math.Inf(1), math.NaN()} {
+		_, err := service.IncrementAmount("test-user", primitive.NewObjectID().Hex(), amount)
+		assert.ErrorIs(t, err, apperrors.ErrValidationFailed, "amount %v", amount)
+	}
+	mockRepo.AssertNotCalled(t, "IncrementAmount", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBarService_PublishesOverlayChanges(t *testing.T) {
+	// Arrange
+	repo := memory.NewBarRepository()
+	broker := events.NewMemoryBroker()
+	service := NewBarService(repo, createTestRevisionRepository(), createTestQuotaService(repo), nil, broker, nil, createTestConfig())
+
+	bar := newTestEventBar("test-user")
+	bar.OverlayToken = "ovl_old"
+	require.NoError(t, repo.Insert(t.Context(), bar))
+	barID := bar.ID.Hex()
+
+	updates, unsubscribe := broker.Subscribe(barID)
+	defer unsubscribe()
+	next := func() *models.BarUpdate {
+		select {
+		case update := <-updates:
+			return update
+		case <-time.After(time.Second):
+			t.Fatal("no bar update published")
+			return nil
+		}
+	}
+
+	// Act & Assert: a new design carries its revision
+	refined, err := service.ApplyRefinement("test-user", barID, &models.AIGenerateResponse{
+		HTML: "<section>{goal} {total} {percentage} {remaining} {description}</section>",
+		CSS:  ".bar { color: red; }",
+	})
+	require.NoError(t, err)
+	update := next()
+	assert.Equal(t, refined.Revision, update.Revision)
+	assert.Equal(t, models.HashOverlayToken("ovl_old"), update.OverlayTokenHash)
+
+	// A new overlay URL carries the new token's hash
+	token, err := service.RegenerateOverlayToken("test-user", barID)
+	require.NoError(t, err)
+	update = next()
+	assert.Equal(t, models.HashOverlayToken(token), update.OverlayTokenHash)
+	assert.NotEqual(t, models.HashOverlayToken("ovl_old"), update.OverlayTokenHash)
+	assert.NotContains(t, update.OverlayTokenHash, token)
+}
+
+func TestBarService_UpdateBar_StretchGoals(t *testing.T) {
+	// Arrange
+	repo := memory.NewBarRepository()
+	service := NewBarService(repo, createTestRevisionRepository(), createTestQuotaService(repo), nil, nil, nil, createTestConfig())
+
+	bar := newTestEventBar("test-user")
+	require.NoError(t, repo.Insert(t.Context(), bar))
+	barID := bar.ID.Hex()
+
+	// Act: the last goal becomes the goal amount
+	goals := []models.Goal{{Amount: 500, Label: " Mikrofon "}, {Amount: 1500, Label: "Kamera"}}
+	updated, err := service.UpdateBar("test-user", barID, &models.UpdateBarRequest{Goals: &goals})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []models.Goal{{Amount: 500, Label: "Mikrofon"}, {Amount: 1500, Label: "Kamera"}}, updated.Goals)
+	assert.Equal(t, 1500.0, updated.GoalAmount)
+	assert.Equal(t, models.Stage{Goal: models.Goal{Amount: 500, Label: "Mikrofon"}, Index: 1, Count: 2}, updated.CurrentStage())
+
+	for name, invalid := range map[string][]models.Goal{
+		"descending": {{Amount: 1500}, {Amount: 500}},
+		"duplicate":  {{Amount: 500}, {Amount: 500}},
+		"zero":       {{Amount: 0}},
+		"long label": {{Amount: 500, Label: strings.Repeat("ş", models.MaxGoalLabelLength+1)}},
+		"too many":   make([]models.Goal, models.MaxGoals+1),
+	} {
+		_, err = service.UpdateBar("test-user", barID, &models.UpdateBarRequest{Goals: &invalid})
+		assert.ErrorIs(t, err, apperrors.ErrValidationFailed, name)
+	}
+
+	// A single goal replaces the stretch goals
+	goal := 2000.0
+	updated, err = service.UpdateBar("test-user", barID, &models.UpdateBarRequest{GoalAmount: &goal})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Goals)
+	assert.Equal(t, 2000.0, updated.GoalAmount)
+}
+
+func TestBarService_UpdateBarComplete_RecordsRevision(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	revisions := new(mocks.MockRevisionRepository)
+	service := NewBarService(mockRepo, revisions, createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+	userID := "test-user"
+	before := &models.DonationBar{
+		ID:       primitive.NewObjectID(),
+		UserID:   userID,
+		HTML:     "<div>{goal} {total}\n{percentage} {remaining} {description}</div>",
+		CSS:      ".bar {\n  color: red;\n}", // As the sanitizer writes it
+		Revision: 2,
+	}
+	barID := before.ID.Hex()
+	req := &models.CreateBarRequest{
+		Name:     "Test Bar",
+		HTML:     "<div>{goal} {total}\n<b>{percentage}</b> {remaining} {description}</div>",
+		CSS:      before.CSS,
+		Language: "tr",
+	}
+	after := *before
+	after.HTML = req.HTML
+	after.Revision = 3
+
+	mockRepo.On("FindByID", mock.Anything, userID, barID).Return(before, nil)
+	mockRepo.On("UpdateComplete", mock.Anything, userID, barID, req, true).Return(&after, nil)
+	// No history yet: the old content is kept as an import first
+	revisions.On("CountByBarID", mock.Anything, barID).Return(int64(0), nil)
+	revisions.On("Insert", mock.Anything, mock.MatchedBy(func(r *models.BarRevision) bool {
+		return r.Source == models.RevisionSourceImport && r.Revision == 2 && r.HTML == before.HTML
+	})).Return(nil).Once()
+	revisions.On("Insert", mock.Anything, mock.MatchedBy(func(r *models.BarRevision) bool {
+		return r.Source == models.RevisionSourceManual && r.Revision == 3 && r.AuthorID == userID &&
+			r.Summary == models.RevisionSummary{HTMLAdded: 1, HTMLRemoved: 1}
+	})).Return(nil).Once()
+
+	// Act
+	err := service.UpdateBarComplete(userID, barID, req, true)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	revisions.AssertExpectations(t)
+}
+
+func TestBarService_RestoreRevision_AppendsRestore(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	revisions := new(mocks.MockRevisionRepository)
+	service := NewBarService(mockRepo, revisions, createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+	userID := "test-user"
+	current := &models.DonationBar{
+		ID:       primitive.NewObjectID(),
+		UserID:   userID,
+		HTML:     "<div>broken</div>",
+		Revision: 4,
+	}
+	barID := current.ID.Hex()
+	old := &models.BarRevision{
+		BarID:    current.ID,
+		Revision: 1,
+		HTML:     "<div>{goal} {total} {percentage} {remaining} {description}</div>",
+		CSS:      ".bar {\n  color: red;\n}",
+	}
+	restored := *current
+	restored.HTML, restored.CSS, restored.Revision = old.HTML, old.CSS, 5
+
+	mockRepo.On("FindByID", mock.Anything, userID, barID).Return(current, nil)
+	mockRepo.On("UpdateContent", mock.Anything, userID, barID, old.HTML, old.CSS).Return(&restored, nil)
+	revisions.On("FindByNumber", mock.Anything, barID, 1).Return(old, nil)
+	revisions.On("CountByBarID", mock.Anything, barID).Return(int64(4), nil)
+	revisions.On("Insert", mock.Anything, mock.MatchedBy(func(r *models.BarRevision) bool {
+		return r.Source == models.RevisionSourceRestore && r.Revision == 5 && r.RestoredFrom != nil && *r.RestoredFrom == 1
+	})).Return(nil)
+
+	// Act
+	bar, err := service.RestoreRevision(userID, barID, 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 5, bar.Revision)
+	revisions.AssertExpectations(t)
+}
+
+func TestBarService_GetRevision_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockBarRepository)
+	revisions := new(mocks.MockRevisionRepository)
+	service := NewBarService(mockRepo, revisions, createTestQuotaService(mockRepo), nil, events.NewMemoryBroker(), nil, createTestConfig())
+
+	bar := &models.DonationBar{ID: primitive.NewObjectID(), UserID: "test-user"}
+	mockRepo.On("FindByID", mock.Anything, "test-user", bar.ID.Hex()).Return(bar, nil)
+	revisions.On("FindByNumber", mock.Anything, bar.ID.Hex(), 9).Return(nil, apperrors.Missing("revision"))
+
+	// Act
+	_, err := service.GetRevision("test-user", bar.ID.Hex(), 9)
+
+	// Assert
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "NOT_FOUND", appErr.Type)
+}
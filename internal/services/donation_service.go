@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DonationService struct {
+	repo    interfaces.DonationRepositoryInterface
+	barRepo interfaces.BarRepositoryInterface
+	broker  interfaces.EventBrokerInterface
+	events  interfaces.EventServiceInterface
+	limits  *ratelimit.Guard
+	config  *config.Config
+}
+
+// NewDonationService creates a new donation ledger service; nil events skip
+// milestone detection
+func NewDonationService(repo interfaces.DonationRepositoryInterface, barRepo interfaces.BarRepositoryInterface, broker interfaces.EventBrokerInterface, events interfaces.EventServiceInterface, limits *ratelimit.Guard, cfg *config.Config) interfaces.DonationServiceInterface {
+	return &DonationService{
+		repo:    repo,
+		barRepo: barRepo,
+		broker:  broker,
+		events:  events,
+		limits:  limits,
+		config:  cfg,
+	}
+}
+
+// AddDonation records a donation in the ledger and refreshes the bar total
+func (s *DonationService) AddDonation(userID, barID string, req *models.CreateDonationRequest) (*models.Donation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	bar, err := s.findBar(ctx, userID, barID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(req.Amount > 0) { // also catches NaN
+		return nil, apperrors.ValidationError("amount", "must be positive")
+	}
+	if req.Amount > models.MaxDonationAmount {
+		return nil, apperrors.ValidationError("amount", fmt.Sprintf("must be at most %d", models.MaxDonationAmount))
+	}
+
+	donation := &models.Donation{
+		ID:        primitive.NewObjectID(),
+		BarID:     bar.ID,
+		UserID:    userID,
+		DonorName: strings.TrimSpace(req.DonorName),
+		Amount:    req.Amount,
+		Currency:  strings.ToUpper(req.Currency),
+		Message:   req.Message,
+		Source:    req.Source,
+		CreatedAt: time.Now(),
+	}
+	if donation.DonorName == "" {
+		donation.DonorName = models.AnonymousDonorName
+	}
+	if donation.Currency == "" {
+		donation.Currency = bar.CurrencyCode()
+	}
+	if donation.Currency != bar.CurrencyCode() {
+		return nil, apperrors.ValidationError("currency", fmt.Sprintf("bar accepts %s donations only", bar.CurrencyCode()))
+	}
+	if donation.Source == "" {
+		donation.Source = models.DefaultDonationSource
+	}
+
+	if err := allowAction(s.limits, ratelimit.ActionDonation, userID, 1, s.config.Timeouts.RedisOperation); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Insert(ctx, donation); err != nil {
+		return nil, apperrors.DatabaseError("insert donation", err)
+	}
+
+	// Incremented in place so concurrent donations (and webhooks) all count
+	bar, err = s.barRepo.AddDonationTotal(ctx, barID, donation.Amount)
+	if err != nil {
+		rollbackDonation(s.repo, donation, s.config.Timeouts.DatabaseWrite)
+		return nil, mapBarError(err, barID, "update bar total")
+	}
+	publishBarUpdate(s.broker, bar, s.config.Timeouts.RedisOperation)
+	notifyProgress(s.events, withoutAmount(bar, donation.Amount), bar)
+
+	slog.Info("Donation recorded",
+		"user_id", userID,
+		"bar_id", barID,
+		"donation_id", donation.ID.Hex(),
+		"amount", donation.Amount,
+		"source", donation.Source,
+		"donation_total", bar.DonationTotal)
+
+	return donation, nil
+}
+
+// GetDonations returns the donation ledger of a bar
+func (s *DonationService) GetDonations(userID, barID string) ([]*models.Donation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	if _, err := s.findBar(ctx, userID, barID); err != nil {
+		return nil, err
+	}
+
+	donations, err := s.repo.FindByBarID(ctx, barID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("find donations", err)
+	}
+
+	return donations, nil
+}
+
+// findBar makes sure the bar exists and belongs to the user
+func (s *DonationService) findBar(ctx context.Context, userID, barID string) (*models.DonationBar, error) {
+	bar, err := s.barRepo.FindByID(ctx, userID, barID)
+	if err != nil {
+		return nil, mapBarError(err, barID, "find bar")
+	}
+
+	return bar, nil
+}
+
+// rollbackDonation removes a ledger entry whose amount never reached its
+// bar's total, so the ledger and donation_total stay in agreement
+func rollbackDonation(repo interfaces.DonationRepositoryInterface, donation *models.Donation, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := repo.Delete(ctx, donation.ID.Hex()); err != nil {
+		slog.Error("Failed to roll back donation",
+			"donation_id", donation.ID.Hex(),
+			"bar_id", donation.BarID.Hex(),
+			"amount", donation.Amount,
+			"error", err.Error())
+	}
+}
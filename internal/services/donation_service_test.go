@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/events"
+	"donationbars/internal/mocks"
+	"donationbars/internal/models"
+	"donationbars/internal/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDonationService_AddDonation_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockDonationRepository)
+	mockBarRepo := new(mocks.MockBarRepository)
+	service := NewDonationService(mockRepo, mockBarRepo, events.NewMemoryBroker(), nil, nil, createTestConfig())
+
+	userID := "test-user"
+	barID := "507f1f77bcf86cd799439011"
+	bar := &models.DonationBar{ID: primitive.NewObjectID(), UserID: userID, Currency: "USD"}
+	req := &models.CreateDonationRequest{
+		Amount:  50.0,
+		Message: "Kolay gelsin!",
+	}
+
+	// Mock expectations
+	mockBarRepo.On("FindByID", mock.Anything, userID, barID).Return(bar, nil)
+	mockRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.Donation")).Return(nil)
+	mockBarRepo.On("AddDonationTotal", mock.Anything, barID, 50.0).Return(&models.DonationBar{ID: bar.ID, DonationTotal: 150}, nil)
+
+	// Act
+	result, err := service.AddDonation(userID, barID, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, bar.ID, result.BarID)
+	assert.Equal(t, 50.0, result.Amount)
+	assert.Equal(t, models.AnonymousDonorName, result.DonorName)
+	assert.Equal(t, "USD", result.Currency)
+	assert.Equal(t, models.DefaultDonationSource, result.Source)
+	mockRepo.AssertExpectations(t)
+	mockBarRepo.AssertExpectations(t)
+}
+
+func TestDonationService_AddDonation_RollsBackWhenTotalFails(t *testing.T) {
+	// Arrange
+	donations := memory.NewDonationRepository()
+	mockBarRepo := new(mocks.MockBarRepository)
+	service := NewDonationService(donations, mockBarRepo, events.NewMemoryBroker(), nil, nil, createTestConfig())
+
+	userID := "test-user"
+	bar := &models.DonationBar{ID: primitive.NewObjectID(), UserID: userID, Currency: "TRY"}
+	barID := bar.ID.Hex()
+	mockBarRepo.On("FindByID", mock.Anything, userID, barID).Return(bar, nil)
+	mockBarRepo.On("AddDonationTotal", mock.Anything, barID, 50.0).Return(nil, errors.New("connection reset"))
+
+	// Act
+	result, err := service.AddDonation(userID, barID, &models.CreateDonationRequest{Amount: 50})
+
+	// Assert: the donation is not left in the ledger without its amount in the total
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	ledger, err := donations.FindByBarID(t.Context(), barID)
+	require.NoError(t, err)
+	assert.Empty(t, ledger)
+	mockBarRepo.AssertExpectations(t)
+}
+
+func TestDonationService_AddDonation_BarNotFound(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockDonationRepository)
+	mockBarRepo := new(mocks.MockBarRepository)
+	service := NewDonationService(mockRepo, mockBarRepo, events.NewMemoryBroker(), nil, nil, createTestConfig())
+
+	userID := "test-user"
+	barID := "507f1f77bcf86cd799439011"
+
+	// Mock expectations
+	mockBarRepo.On("FindByID", mock.Anything, userID, barID).Return(nil, apperrors.Missing("bar"))
+
+	// Act
+	result, err := service.AddDonation(userID, barID, &models.CreateDonationRequest{Amount: 10})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "NOT_FOUND", appErr.Type)
+	mockRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	mockBarRepo.AssertExpectations(t)
+}
+
+func TestDonationService_AddDonation_CurrencyMismatch(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockDonationRepository)
+	mockBarRepo := new(mocks.MockBarRepository)
+	service := NewDonationService(mockRepo, mockBarRepo, events.NewMemoryBroker(), nil, nil, createTestConfig())
+
+	userID := "test-user"
+	barID := "507f1f77bcf86cd799439011"
+	bar := &models.DonationBar{ID: primitive.NewObjectID(), UserID: userID, Language: "tr"}
+
+	// Mock expectations
+	mockBarRepo.On("FindByID", mock.Anything, userID, barID).Return(bar, nil)
+
+	// Act
+	result, err := service.AddDonation(userID, barID, &models.CreateDonationRequest{Amount: 10, Currency: "eur"})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	var appErr *apperrors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "VALIDATION_ERROR", appErr.Type)
+	mockRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	mockBarRepo.AssertExpectations(t)
+}
+
+func TestDonationService_AddDonation_RejectsUnboundedAmounts(t *testing.T) {
+	for name, amount := range map[string]float64{
+		"too large": models.MaxDonationAmount + 1,
+		"huge":      1e308,
+		"infinite":  math.Inf(1),
+		"nan":       math.NaN(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			mockRepo := new(mocks.MockDonationRepository)
+			mockBarRepo := new(mocks.MockBarRepository)
+			service := NewDonationService(mockRepo, mockBarRepo, events.NewMemoryBroker(), nil, nil, createTestConfig())
+
+			userID := "test-user"
+			barID := "507f1f77bcf86cd799439011"
+			bar := &models.DonationBar{ID: primitive.NewObjectID(), UserID: userID, Currency: "TRY"}
+			mockBarRepo.On("FindByID", mock.Anything, userID, barID).Return(bar, nil)
+
+			// Act
+			result, err := service.AddDonation(userID, barID, &models.CreateDonationRequest{Amount: amount})
+
+			// Assert
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, apperrors.ErrValidationFailed)
+			mockRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+			mockBarRepo.AssertNotCalled(t, "AddDonationTotal", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestDonationService_GetDonations_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(mocks.MockDonationRepository)
+	mockBarRepo := new(mocks.MockBarRepository)
+	service := NewDonationService(mockRepo, mockBarRepo, events.NewMemoryBroker(), nil, nil, createTestConfig())
+
+	userID := "test-user"
+	barID := "507f1f77bcf86cd799439011"
+	bar := &models.DonationBar{ID: primitive.NewObjectID(), UserID: userID}
+	donations := []*models.Donation{
+		{ID: primitive.NewObjectID(), BarID: bar.ID, Amount: 25},
+		{ID: primitive.NewObjectID(), BarID: bar.ID, Amount: 75},
+	}
+
+	// Mock expectations
+	mockBarRepo.On("FindByID", mock.Anything, userID, barID).Return(bar, nil)
+	mockRepo.On("FindByBarID", mock.Anything, barID).Return(donations, nil)
+
+	// Act
+	result, err := service.GetDonations(userID, barID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	mockRepo.AssertExpectations(t)
+	mockBarRepo.AssertExpectations(t)
+}
@@ -0,0 +1,483 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+	"donationbars/internal/notify"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxHooksPerBar bounds the outgoing webhooks of one bar
+	maxHooksPerBar = 5
+
+	// deliveryLogSize is how many of a bar's latest deliveries are listed
+	deliveryLogSize = 50
+
+	// deliveryQueueSize bounds the deliveries waiting for a worker; the rest
+	// wait for the next sweep
+	deliveryQueueSize = 100
+
+	// deliverySweepInterval is how often stored deliveries are checked for due
+	// retries and for deliveries left over by a restart or a full queue
+	deliverySweepInterval = 5 * time.Second
+)
+
+type EventService struct {
+	bars       interfaces.BarRepositoryInterface
+	events     interfaces.BarEventRepositoryInterface
+	hooks      interfaces.OutgoingWebhookRepositoryInterface
+	deliveries interfaces.WebhookDeliveryRepositoryInterface
+	sender     *notify.Sender
+	config     *config.Config
+	queue      chan string
+	now        func() time.Time
+}
+
+// NewEventService creates the milestone event service; call Start to run
+// the delivery workers
+func NewEventService(bars interfaces.BarRepositoryInterface, events interfaces.BarEventRepositoryInterface, hooks interfaces.OutgoingWebhookRepositoryInterface, deliveries interfaces.WebhookDeliveryRepositoryInterface, cfg *config.Config) interfaces.EventServiceInterface {
+	return &EventService{
+		bars:       bars,
+		events:     events,
+		hooks:      hooks,
+		deliveries: deliveries,
+		sender:     notify.NewSender(cfg.OutgoingWebhooks.Timeout, cfg.OutgoingWebhooks.AllowPrivate),
+		config:     cfg,
+		queue:      make(chan string, deliveryQueueSize),
+		now:        time.Now,
+	}
+}
+
+// Progressed records the milestones a bar reached between two of its states
+// and queues their deliveries. Each goal of a bar with stretch goals has its
+// own milestones, so one donation can reach several. Each milestone is
+// recorded once per campaign, so of concurrent updates crossing it only one
+// fires it. Failures are logged; they must not fail the donation that caused
+// them.
+func (s *EventService) Progressed(before, after *models.DonationBar) {
+	moved := progressedStages(before, after)
+	if len(moved) == 0 {
+		return
+	}
+
+	bar := after
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	hooks, err := s.hooks.FindByBarID(ctx, bar.ID.Hex())
+	if err != nil {
+		slog.Warn("Failed to load outgoing webhooks", "bar_id", bar.ID.Hex(), "error", err.Error())
+		return
+	}
+	// Nobody is listening; milestones are not recorded either
+	if len(hooks) == 0 {
+		return
+	}
+
+	milestones := []int{models.GoalMilestone}
+	for _, hook := range hooks {
+		milestones = append(milestones, hook.Milestones...)
+	}
+
+	for _, p := range moved {
+		for _, milestone := range models.CrossedMilestones(p.previous, p.current, milestones) {
+			event := models.NewBarEvent(bar, p.stage, milestone)
+			if err := s.events.Insert(ctx, event); err != nil {
+				if !errors.Is(err, apperrors.ErrConflict) {
+					slog.Warn("Failed to record bar event",
+						"bar_id", bar.ID.Hex(),
+						"milestone", milestone,
+						"error", err.Error())
+				}
+				continue
+			}
+
+			slog.Info("Bar milestone reached",
+				"bar_id", bar.ID.Hex(),
+				"event_id", event.ID.Hex(),
+				"type", event.Type,
+				"milestone", milestone,
+				"stage", event.Stage,
+				"campaign", event.Campaign)
+
+			for _, hook := range hooks {
+				if hook.Wants(milestone) {
+					s.queueDelivery(ctx, bar, event, hook)
+				}
+			}
+		}
+	}
+}
+
+// stageProgress is how far a change moved a bar towards one of its goals,
+// in percent of that goal
+type stageProgress struct {
+	stage             models.Stage
+	previous, current float64
+}
+
+// progressedStages returns the goals up to the bar's current one whose
+// progress a change increased, in order. A goal is measured against the
+// previous state's goal at the same position, so raising a goal measures
+// from the progress the bar had towards the old one.
+func progressedStages(before, after *models.DonationBar) []stageProgress {
+	previousStages := before.Stages()
+	current := after.CurrentStage()
+
+	var moved []stageProgress
+	for i, stage := range after.Stages()[:current.Index] {
+		previousGoal := stage.Amount
+		if i < len(previousStages) {
+			previousGoal = previousStages[i].Amount
+		}
+
+		p := stageProgress{
+			stage:    stage,
+			previous: models.ProgressPercent(before.CurrentTotal(), previousGoal),
+			current:  models.ProgressPercent(after.CurrentTotal(), stage.Amount),
+		}
+		if p.current > p.previous {
+			moved = append(moved, p)
+		}
+	}
+	return moved
+}
+
+// queueDelivery stores a pending delivery of an event to a webhook and
+// hands it to the workers
+func (s *EventService) queueDelivery(ctx context.Context, bar *models.DonationBar, event *models.BarEvent, hook *models.OutgoingWebhook) {
+	payload, err := notify.Body(hook, bar, event)
+	if err != nil {
+		slog.Error("Failed to encode event payload",
+			"event_id", event.ID.Hex(),
+			"hook_id", hook.ID.Hex(),
+			"error", err.Error())
+		return
+	}
+
+	now := s.now()
+	delivery := &models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		HookID:        hook.ID,
+		BarID:         bar.ID,
+		UserID:        bar.UserID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Milestone:     event.Milestone,
+		URL:           hook.URL,
+		Payload:       string(payload),
+		Status:        models.DeliveryStatusPending,
+		Attempts:      []models.DeliveryAttempt{},
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.deliveries.Insert(ctx, delivery); err != nil {
+		slog.Warn("Failed to store webhook delivery",
+			"event_id", event.ID.Hex(),
+			"hook_id", hook.ID.Hex(),
+			"error", err.Error())
+		return
+	}
+
+	s.enqueue(delivery.ID.Hex())
+}
+
+// CreateHook adds an outgoing webhook to the user's bar. The returned
+// webhook carries its signing secret, which is not listed again.
+func (s *EventService) CreateHook(userID, barID string, req *models.CreateOutgoingWebhookRequest) (*models.OutgoingWebhook, error) {
+	if err := notify.ValidateURL(req.URL); err != nil {
+		return nil, apperrors.ValidationError("url", err.Error())
+	}
+
+	milestones := slices.Clone(models.DefaultMilestones)
+	if req.Milestones != nil {
+		milestones = slices.Clone(req.Milestones)
+		slices.Sort(milestones)
+		milestones = slices.Compact(milestones)
+	}
+	for _, milestone := range milestones {
+		if milestone <= 0 || milestone >= models.GoalMilestone {
+			return nil, apperrors.ValidationError("milestones", "must be between 1 and 99")
+		}
+	}
+
+	format := req.Format
+	if format == "" {
+		format = models.HookFormatJSON
+	}
+	if _, ok := notify.FindFormatter(format); !ok {
+		return nil, apperrors.ValidationError("format", "must be one of "+strings.Join(notify.Formats(), ", "))
+	}
+	if req.Language != "" && req.Language != "tr" && req.Language != "en" {
+		return nil, apperrors.ValidationError("language", "must be tr or en")
+	}
+	if utf8.RuneCountInString(req.Template) > notify.MaxTemplateLength {
+		return nil, apperrors.ValidationError("template", fmt.Sprintf("must be at most %d characters", notify.MaxTemplateLength))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	bar, err := s.bars.FindByID(ctx, userID, barID)
+	if err != nil {
+		return nil, mapBarError(err, barID, "find bar")
+	}
+
+	existing, err := s.hooks.FindByBarID(ctx, barID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("find outgoing webhooks", err)
+	}
+	if len(existing) >= maxHooksPerBar {
+		return nil, apperrors.ValidationError("webhooks", fmt.Sprintf("a bar can have at most %d outgoing webhooks", maxHooksPerBar))
+	}
+
+	hook := &models.OutgoingWebhook{
+		ID:         primitive.NewObjectID(),
+		BarID:      bar.ID,
+		UserID:     userID,
+		URL:        req.URL,
+		Secret:     models.WebhookSecretPrefix + rand.Text(),
+		Milestones: milestones,
+		Format:     format,
+		Language:   req.Language,
+		Template:   strings.TrimSpace(req.Template),
+		CreatedAt:  s.now(),
+	}
+	if err := s.hooks.Insert(ctx, hook); err != nil {
+		return nil, apperrors.DatabaseError("insert outgoing webhook", err)
+	}
+
+	slog.Info("Outgoing webhook created",
+		"user_id", userID,
+		"bar_id", barID,
+		"hook_id", hook.ID.Hex(),
+		"format", format,
+		"milestones", milestones)
+
+	return hook, nil
+}
+
+// ListHooks returns the outgoing webhooks of the user's bar
+func (s *EventService) ListHooks(userID, barID string) ([]*models.OutgoingWebhook, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	if _, err := s.bars.FindByID(ctx, userID, barID); err != nil {
+		return nil, mapBarError(err, barID, "find bar")
+	}
+
+	hooks, err := s.hooks.FindByBarID(ctx, barID)
+	if err != nil {
+		return nil, apperrors.DatabaseError("find outgoing webhooks", err)
+	}
+	return hooks, nil
+}
+
+// DeleteHook removes an outgoing webhook of the user's bar; its pending
+// deliveries fail on their next attempt
+func (s *EventService) DeleteHook(userID, barID, hookID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	if err := s.hooks.Delete(ctx, userID, barID, hookID); err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NotFound("webhook", hookID)
+		}
+		return apperrors.DatabaseError("delete outgoing webhook", err)
+	}
+
+	slog.Info("Outgoing webhook deleted",
+		"user_id", userID,
+		"bar_id", barID,
+		"hook_id", hookID)
+
+	return nil
+}
+
+// ListDeliveries returns the latest deliveries of the user's bar, newest first
+func (s *EventService) ListDeliveries(userID, barID string) ([]*models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	if _, err := s.bars.FindByID(ctx, userID, barID); err != nil {
+		return nil, mapBarError(err, barID, "find bar")
+	}
+
+	deliveries, err := s.deliveries.FindByBarID(ctx, barID, deliveryLogSize)
+	if err != nil {
+		return nil, apperrors.DatabaseError("find webhook deliveries", err)
+	}
+	return deliveries, nil
+}
+
+// Start runs the delivery workers and the sweeper until ctx is cancelled
+func (s *EventService) Start(ctx context.Context) {
+	for i := 0; i < s.config.OutgoingWebhooks.Workers; i++ {
+		go s.work(ctx)
+	}
+	go s.sweep(ctx)
+
+	slog.Info("Outgoing webhook workers started",
+		"workers", s.config.OutgoingWebhooks.Workers,
+		"max_attempts", s.config.OutgoingWebhooks.MaxAttempts)
+}
+
+// enqueue hands a delivery ID to the workers without blocking; a full queue
+// is picked up by the next sweep
+func (s *EventService) enqueue(deliveryID string) {
+	select {
+	case s.queue <- deliveryID:
+	default:
+		slog.Warn("Delivery queue full, delivery waits for the next sweep", "delivery_id", deliveryID)
+	}
+}
+
+// work sends queued deliveries until ctx is cancelled
+func (s *EventService) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case deliveryID := <-s.queue:
+			s.deliver(ctx, deliveryID)
+		}
+	}
+}
+
+// sweep queues due deliveries at startup and then periodically
+func (s *EventService) sweep(ctx context.Context) {
+	ticker := time.NewTicker(deliverySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		s.requeueDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// requeueDue enqueues pending deliveries whose next attempt is due
+func (s *EventService) requeueDue(ctx context.Context) {
+	deliveries, err := s.deliveries.FindDue(ctx, s.now(), deliveryQueueSize)
+	if err != nil {
+		slog.Warn("Failed to load due webhook deliveries", "error", err.Error())
+		return
+	}
+
+	for _, delivery := range deliveries {
+		s.enqueue(delivery.ID.Hex())
+	}
+}
+
+// deliver claims a delivery, makes one attempt and schedules the next one
+// with exponential backoff when it failed
+func (s *EventService) deliver(ctx context.Context, deliveryID string) {
+	cfg := s.config.OutgoingWebhooks
+
+	// The lease outlives the attempt, so a crashed worker's delivery is retried
+	now := s.now()
+	delivery, err := s.deliveries.Claim(ctx, deliveryID, now, now.Add(2*cfg.Timeout))
+	if err != nil {
+		// Another worker or instance got it first, or it is not due yet
+		if !errors.Is(err, apperrors.ErrConflict) {
+			slog.Warn("Failed to claim webhook delivery", "delivery_id", deliveryID, "error", err.Error())
+		}
+		return
+	}
+
+	hook, err := s.hooks.Find(ctx, delivery.HookID.Hex())
+	if err != nil {
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			// Retried when the lease runs out
+			slog.Warn("Failed to load outgoing webhook", "hook_id", delivery.HookID.Hex(), "error", err.Error())
+			return
+		}
+		delivery.Attempts = append(delivery.Attempts, models.DeliveryAttempt{At: now, Error: "webhook deleted"})
+		s.finish(delivery, models.DeliveryStatusFailed)
+		return
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	status, err := s.sender.Send(sendCtx, &notify.Message{
+		URL:        hook.URL,
+		Secret:     hook.Secret,
+		EventType:  delivery.EventType,
+		DeliveryID: delivery.ID.Hex(),
+		Body:       []byte(delivery.Payload),
+	})
+	if ctx.Err() != nil {
+		// Shutting down; the attempt is repeated once the lease runs out
+		return
+	}
+
+	attempt := models.DeliveryAttempt{
+		At:         now,
+		StatusCode: status,
+		DurationMS: s.now().Sub(now).Milliseconds(),
+	}
+	switch {
+	case err != nil:
+		attempt.Error = err.Error()
+	case !notify.Succeeded(status):
+		attempt.Error = fmt.Sprintf("unexpected response status %d", status)
+	}
+	delivery.Attempts = append(delivery.Attempts, attempt)
+
+	switch {
+	case attempt.Error == "":
+		s.finish(delivery, models.DeliveryStatusSucceeded)
+	case !notify.Retryable(status, err) || len(delivery.Attempts) >= cfg.MaxAttempts:
+		s.finish(delivery, models.DeliveryStatusFailed)
+	default:
+		delivery.NextAttemptAt = s.now().Add(notify.Backoff(cfg.BackoffBase, cfg.BackoffMax, len(delivery.Attempts)))
+		s.finish(delivery, models.DeliveryStatusPending)
+	}
+}
+
+// finish stores a delivery's status after an attempt
+func (s *EventService) finish(delivery *models.WebhookDelivery, status string) {
+	delivery.Status = status
+	delivery.UpdatedAt = s.now()
+
+	// The outcome must be stored even while shutting down
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	if err := s.deliveries.Save(ctx, delivery); err != nil {
+		slog.Error("Failed to store webhook delivery outcome",
+			"delivery_id", delivery.ID.Hex(),
+			"status", status,
+			"error", err.Error())
+		return
+	}
+
+	last := delivery.Attempts[len(delivery.Attempts)-1]
+	slog.Info("Webhook delivery attempted",
+		"delivery_id", delivery.ID.Hex(),
+		"hook_id", delivery.HookID.Hex(),
+		"status", status,
+		"attempt", len(delivery.Attempts),
+		"response_status", last.StatusCode,
+		"next_attempt_at", delivery.NextAttemptAt)
+}
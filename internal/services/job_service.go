@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// jobRetention is how long finished jobs can still be polled
+	jobRetention = 24 * time.Hour
+
+	// jobSweepInterval is how often stored jobs are re-checked for work, which
+	// picks up jobs left over by a restart or a full queue
+	jobSweepInterval = 30 * time.Second
+
+	// maxJobAttempts bounds how often an interrupted job is retried
+	maxJobAttempts = 3
+)
+
+type JobService struct {
+	store      interfaces.JobStoreInterface
+	aiService  interfaces.AIServiceInterface
+	barService interfaces.BarServiceInterface
+	quotas     interfaces.QuotaServiceInterface
+	limits     *ratelimit.Guard
+	config     *config.Config
+	queue      chan string
+}
+
+// NewJobService creates the generation job service; call Start to run the workers
+func NewJobService(store interfaces.JobStoreInterface, aiService interfaces.AIServiceInterface, barService interfaces.BarServiceInterface, quotas interfaces.QuotaServiceInterface, limits *ratelimit.Guard, cfg *config.Config) interfaces.JobServiceInterface {
+	return &JobService{
+		store:      store,
+		aiService:  aiService,
+		barService: barService,
+		quotas:     quotas,
+		limits:     limits,
+		config:     cfg,
+		queue:      make(chan string, cfg.Jobs.QueueSize),
+	}
+}
+
+// Submit stores a queued generation job and hands it to the worker pool
+func (s *JobService) Submit(userID string, req *models.GenerateBarRequest, saveBar bool) (*models.GenerationJob, error) {
+	if saveBar {
+		// Fail early instead of generating a bar that cannot be saved
+		if err := s.barService.CheckDailyRateLimit(userID); err != nil {
+			return nil, err
+		}
+		if err := s.quotas.CheckBarQuota(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkAI(userID, req.VariationCount()); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &models.GenerationJob{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Kind:      models.JobKindGenerate,
+		Status:    models.JobStatusQueued,
+		Request:   *req,
+		SaveBar:   saveBar,
+		CreatedAt: now,
+		ExpiresAt: now.Add(jobRetention),
+	}
+
+	return s.create(job)
+}
+
+// SubmitRefine queues a refinement of an existing bar; the result is saved as
+// the bar's next revision
+func (s *JobService) SubmitRefine(userID, barID string, req *models.RefineBarRequest) (*models.GenerationJob, error) {
+	bar, err := s.barService.GetBar(userID, barID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAI(userID, 1); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &models.GenerationJob{
+		ID:     primitive.NewObjectID(),
+		UserID: userID,
+		Kind:   models.JobKindRefine,
+		Status: models.JobStatusQueued,
+		Request: models.GenerateBarRequest{
+			Prompt:        req.Instruction,
+			Language:      bar.Language,
+			Currency:      bar.CurrencyCode(),
+			Theme:         bar.Theme,
+			InitialAmount: bar.InitialAmount,
+			GoalAmount:    bar.GoalAmount,
+			Goals:         bar.Goals,
+		},
+		SaveBar:     true,
+		Instruction: req.Instruction,
+		BarID:       barID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(jobRetention),
+	}
+
+	return s.create(job)
+}
+
+// checkAI checks the user's AI plan quota and rate limit for a job making
+// generations provider calls before the job is queued
+func (s *JobService) checkAI(userID string, generations int) error {
+	if err := s.quotas.CheckAIQuota(userID, int64(generations)); err != nil {
+		return err
+	}
+	return allowAction(s.limits, ratelimit.ActionAIGenerate, userID, generations, s.config.Timeouts.RedisOperation)
+}
+
+// create stores a queued job and hands it to the worker pool
+func (s *JobService) create(job *models.GenerationJob) (*models.GenerationJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	if err := s.store.Create(ctx, job); err != nil {
+		return nil, apperrors.DatabaseError("create job", err)
+	}
+
+	// Every variation of a queued job is a generation (provider call); its
+	// tokens are added when it runs
+	s.quotas.RecordAIUsage(job.UserID, int64(job.Request.VariationCount()), 0)
+
+	s.enqueue(job.ID.Hex())
+
+	slog.Info("Generation job queued",
+		"job_id", job.ID.Hex(),
+		"user_id", job.UserID,
+		"kind", job.Kind,
+		"save_bar", job.SaveBar)
+
+	return job, nil
+}
+
+// GetJob returns a job owned by the user
+func (s *JobService) GetJob(userID, jobID string) (*models.GenerationJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	job, err := s.store.Find(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NotFound("job", jobID)
+		}
+		return nil, apperrors.DatabaseError("find job", err)
+	}
+
+	// Other users' jobs are reported as missing, not forbidden
+	if job.UserID != userID {
+		return nil, apperrors.NotFound("job", jobID)
+	}
+
+	return job, nil
+}
+
+// Start runs the worker pool and the sweeper until ctx is cancelled
+func (s *JobService) Start(ctx context.Context) {
+	for i := 0; i < s.config.Jobs.Workers; i++ {
+		go s.work(ctx)
+	}
+	go s.sweep(ctx)
+
+	slog.Info("Generation workers started",
+		"workers", s.config.Jobs.Workers,
+		"queue_size", s.config.Jobs.QueueSize)
+}
+
+// enqueue hands a job ID to the workers without blocking; a full queue is
+// picked up by the next sweep
+func (s *JobService) enqueue(jobID string) {
+	select {
+	case s.queue <- jobID:
+	default:
+		slog.Warn("Generation queue full, job waits for the next sweep", "job_id", jobID)
+	}
+}
+
+// work processes queued job IDs until ctx is cancelled
+func (s *JobService) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case jobID := <-s.queue:
+			s.process(ctx, jobID)
+		}
+	}
+}
+
+// sweep re-queues stored unfinished jobs at startup and then periodically
+func (s *JobService) sweep(ctx context.Context) {
+	ticker := time.NewTicker(jobSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		s.requeueUnfinished(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// requeueUnfinished enqueues queued jobs and running jobs whose worker is gone
+func (s *JobService) requeueUnfinished(ctx context.Context) {
+	jobs, err := s.store.FindUnfinished(ctx)
+	if err != nil {
+		slog.Warn("Failed to load unfinished generation jobs", "error", err.Error())
+		return
+	}
+
+	staleBefore := s.staleBefore()
+	for _, job := range jobs {
+		stale := job.Status == models.JobStatusRunning && job.StartedAt != nil && job.StartedAt.Before(staleBefore)
+		if job.Status == models.JobStatusQueued || stale {
+			s.enqueue(job.ID.Hex())
+		}
+	}
+}
+
+// staleBefore is the start time before which a running job can no longer be
+// alive, since every generation is cancelled after the AI timeout
+func (s *JobService) staleBefore() time.Time {
+	return time.Now().Add(-2 * s.config.Timeouts.AI)
+}
+
+// process claims a job, runs the generation and stores the outcome
+func (s *JobService) process(ctx context.Context, jobID string) {
+	job, err := s.store.Claim(ctx, jobID, s.staleBefore())
+	if err != nil {
+		// Another worker or instance got it first, or it already finished
+		if !errors.Is(err, apperrors.ErrConflict) {
+			slog.Warn("Failed to claim generation job", "job_id", jobID, "error", err.Error())
+		}
+		return
+	}
+
+	if job.Attempts > maxJobAttempts {
+		s.finish(job, nil, "", "AI üretimi tekrar tekrar yarıda kesildi, lütfen yeniden deneyin")
+		return
+	}
+
+	slog.Info("Generation job started", "job_id", jobID, "kind", job.Kind, "attempt", job.Attempts)
+
+	if job.Kind == models.JobKindRefine {
+		s.refine(job)
+		return
+	}
+
+	results, err := s.aiService.GenerateVariations(&job.Request)
+	if err != nil {
+		s.finish(job, nil, "", err.Error())
+		return
+	}
+	s.recordTokens(job.UserID, results...)
+	if len(results) > 1 {
+		job.Results = results
+	}
+
+	// With several variations the API saves the first one; the web chooser
+	// lets the user pick instead
+	result := results[0]
+
+	barID := ""
+	if job.SaveBar {
+		req := job.Request
+		bar, err := s.barService.CreateBarFromAI(job.UserID, req.Prompt, result, req.InitialAmount, req.GoalAmount, req.Goals)
+		if err != nil {
+			s.finish(job, result, "", err.Error())
+			return
+		}
+		barID = bar.ID.Hex()
+	}
+
+	s.finish(job, result, barID, "")
+}
+
+// refine runs a refine job against the bar's current design
+func (s *JobService) refine(job *models.GenerationJob) {
+	bar, err := s.barService.GetBar(job.UserID, job.BarID)
+	if err != nil {
+		s.finish(job, nil, job.BarID, err.Error())
+		return
+	}
+
+	result, err := s.aiService.RefineBar(bar, job.Instruction)
+	if err != nil {
+		s.finish(job, nil, job.BarID, err.Error())
+		return
+	}
+	s.recordTokens(job.UserID, result)
+
+	if _, err := s.barService.ApplyRefinement(job.UserID, job.BarID, result); err != nil {
+		s.finish(job, result, job.BarID, err.Error())
+		return
+	}
+
+	s.finish(job, result, job.BarID, "")
+}
+
+// recordTokens adds the provider tokens of generated designs to the user's usage
+func (s *JobService) recordTokens(userID string, results ...*models.AIGenerateResponse) {
+	var tokens int64
+	for _, result := range results {
+		tokens += int64(result.TokensUsed)
+	}
+	if tokens > 0 {
+		s.quotas.RecordAIUsage(userID, 0, tokens)
+	}
+}
+
+// finish stores a terminal job status; an error message marks the job failed
+func (s *JobService) finish(job *models.GenerationJob, result *models.AIGenerateResponse, barID, errMessage string) {
+	now := time.Now()
+	job.Result = result
+	job.BarID = barID
+	job.Error = errMessage
+	job.FinishedAt = &now
+	job.ExpiresAt = now.Add(jobRetention)
+	job.Status = models.JobStatusSucceeded
+	if errMessage != "" {
+		job.Status = models.JobStatusFailed
+	}
+
+	// The outcome must be stored even while shutting down
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	if err := s.store.Finish(ctx, job); err != nil {
+		slog.Error("Failed to store generation job outcome",
+			"job_id", job.ID.Hex(),
+			"status", job.Status,
+			"error", err.Error())
+		return
+	}
+
+	slog.Info("Generation job finished",
+		"job_id", job.ID.Hex(),
+		"status", job.Status,
+		"bar_id", barID)
+}
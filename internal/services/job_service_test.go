@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"donationbars/internal/ai"
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/events"
+	"donationbars/internal/mocks"
+	"donationbars/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func createTestJobService(store *mocks.MockJobStore, barRepo *mocks.MockBarRepository) *JobService {
+	cfg := createTestConfig()
+	cfg.Timeouts.AI = 30 * time.Second
+	cfg.Jobs = config.JobsConfig{Workers: 1, QueueSize: 10}
+
+	quotas := createTestQuotaService(barRepo)
+	barService := NewBarService(barRepo, createTestRevisionRepository(), quotas, nil, events.NewMemoryBroker(), nil, cfg)
+	aiService := NewAIService(ai.NewOfflineProvider(), cfg.Timeouts.AI)
+	return NewJobService(store, aiService, barService, quotas, nil, cfg).(*JobService)
+}
+
+func createTestGenerateRequest() models.GenerateBarRequest {
+	return models.GenerateBarRequest{
+		Prompt:        "Neon temalı bir donation bar",
+		Language:      "tr",
+		Theme:         "neon",
+		InitialAmount: 100,
+		GoalAmount:    1000,
+	}
+}
+
+func TestJobService_Submit_QueuesJob(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	service := createTestJobService(mockStore, new(mocks.MockBarRepository))
+	req := createTestGenerateRequest()
+
+	mockStore.On("Create", mock.Anything, mock.AnythingOfType("*models.GenerationJob")).Return(nil)
+
+	// Act
+	job, err := service.Submit("user-1", &req, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobStatusQueued, job.Status)
+	assert.Equal(t, "user-1", job.UserID)
+	assert.Equal(t, job.ID.Hex(), <-service.queue)
+	mockStore.AssertExpectations(t)
+}
+
+func TestJobService_Submit_AILimit(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	service := createTestJobService(mockStore, new(mocks.MockBarRepository))
+	service.config.RateLimit.AIPerDay = 2
+	service.limits = createTestGuard(service.config)
+	req := createTestGenerateRequest()
+
+	mockStore.On("Create", mock.Anything, mock.AnythingOfType("*models.GenerationJob")).Return(nil)
+
+	// Act
+	_, err := service.Submit("user-1", &req, false)
+	assert.NoError(t, err)
+	_, err = service.Submit("user-1", &req, false)
+	assert.NoError(t, err)
+	_, err = service.Submit("user-1", &req, false)
+
+	// Assert
+	assert.ErrorIs(t, err, apperrors.ErrRateLimitExceeded)
+	mockStore.AssertNumberOfCalls(t, "Create", 2)
+
+	_, err = service.Submit("user-2", &req, false)
+	assert.NoError(t, err)
+}
+
+func TestJobService_Submit_AILimitCountsVariations(t *testing.T) {
+	// Arrange: the plan quota has room, the rate limit does not
+	mockStore := new(mocks.MockJobStore)
+	service := createTestJobService(mockStore, new(mocks.MockBarRepository))
+	service.config.RateLimit.AIPerDay = models.MaxAIVariations - 1
+	service.limits = createTestGuard(service.config)
+	single := createTestGenerateRequest()
+	variations := createTestGenerateRequest()
+	variations.Variations = models.MaxAIVariations
+
+	mockStore.On("Create", mock.Anything, mock.AnythingOfType("*models.GenerationJob")).Return(nil)
+
+	// Act
+	_, err := service.Submit("user-1", &single, false)
+	assert.NoError(t, err)
+	_, err = service.Submit("user-1", &variations, false)
+
+	// Assert: every variation is a provider call
+	assert.ErrorIs(t, err, apperrors.ErrRateLimitExceeded)
+	mockStore.AssertNumberOfCalls(t, "Create", 1)
+
+	// The refused job used up nothing
+	variations.Variations = models.MaxAIVariations - 2
+	_, err = service.Submit("user-1", &variations, false)
+	assert.NoError(t, err)
+}
+
+func TestJobService_Submit_AIQuota(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	service := createTestJobService(mockStore, new(mocks.MockBarRepository))
+	free, _ := models.FindPlan(models.PlanFree)
+	req := createTestGenerateRequest()
+
+	mockStore.On("Create", mock.Anything, mock.AnythingOfType("*models.GenerationJob")).Return(nil)
+
+	// Act
+	for range free.Quota.AIPerDay {
+		_, err := service.Submit("user-1", &req, false)
+		assert.NoError(t, err)
+	}
+	_, err := service.Submit("user-1", &req, false)
+
+	// Assert
+	assert.ErrorIs(t, err, apperrors.ErrQuotaExceeded)
+	mockStore.AssertNumberOfCalls(t, "Create", free.Quota.AIPerDay)
+}
+
+func TestJobService_Submit_AIQuotaCountsVariations(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	service := createTestJobService(mockStore, new(mocks.MockBarRepository))
+	free, _ := models.FindPlan(models.PlanFree)
+	single := createTestGenerateRequest()
+	variations := createTestGenerateRequest()
+	variations.Variations = models.MaxAIVariations
+
+	mockStore.On("Create", mock.Anything, mock.AnythingOfType("*models.GenerationJob")).Return(nil)
+
+	// Act: leave room for fewer generations than the variations need
+	for range free.Quota.AIPerDay - models.MaxAIVariations + 1 {
+		_, err := service.Submit("user-1", &single, false)
+		assert.NoError(t, err)
+	}
+	_, err := service.Submit("user-1", &variations, false)
+
+	// Assert
+	assert.ErrorIs(t, err, apperrors.ErrQuotaExceeded)
+	mockStore.AssertNumberOfCalls(t, "Create", free.Quota.AIPerDay-models.MaxAIVariations+1)
+
+	// Fewer variations that fit are queued and use up the rest of the day
+	variations.Variations = models.MaxAIVariations - 1
+	_, err = service.Submit("user-1", &variations, false)
+	assert.NoError(t, err)
+	_, err = service.Submit("user-1", &single, false)
+	assert.ErrorIs(t, err, apperrors.ErrQuotaExceeded)
+}
+
+func TestJobService_GetJob_OtherUser(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	service := createTestJobService(mockStore, new(mocks.MockBarRepository))
+	job := &models.GenerationJob{ID: primitive.NewObjectID(), UserID: "user-1", Status: models.JobStatusQueued}
+
+	mockStore.On("Find", mock.Anything, job.ID.Hex()).Return(job, nil)
+
+	// Act
+	found, err := service.GetJob("user-2", job.ID.Hex())
+
+	// Assert
+	assert.Nil(t, found)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func TestJobService_Process_SavesBar(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	mockRepo := new(mocks.MockBarRepository)
+	service := createTestJobService(mockStore, mockRepo)
+	job := &models.GenerationJob{
+		ID:       primitive.NewObjectID(),
+		UserID:   "user-1",
+		Status:   models.JobStatusRunning,
+		Request:  createTestGenerateRequest(),
+		SaveBar:  true,
+		Attempts: 1,
+	}
+
+	mockStore.On("Claim", mock.Anything, job.ID.Hex(), mock.AnythingOfType("time.Time")).Return(job, nil)
+	mockRepo.On("CountCreatedSince", mock.Anything, "user-1", mock.Anything).Return(int64(0), nil)
+	mockRepo.On("CountByUserID", mock.Anything, "user-1").Return(int64(0), nil)
+	mockRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.DonationBar")).Return(nil)
+	mockStore.On("Finish", mock.Anything, mock.MatchedBy(func(j *models.GenerationJob) bool {
+		return j.Status == models.JobStatusSucceeded && j.BarID != "" && j.Result != nil && j.Error == ""
+	})).Return(nil)
+
+	// Act
+	service.process(context.Background(), job.ID.Hex())
+
+	// Assert
+	mockStore.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestJobService_Process_KeepsVariations(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	service := createTestJobService(mockStore, new(mocks.MockBarRepository))
+	req := createTestGenerateRequest()
+	req.Variations = 2
+	job := &models.GenerationJob{
+		ID:       primitive.NewObjectID(),
+		UserID:   "user-1",
+		Status:   models.JobStatusRunning,
+		Request:  req,
+		Attempts: 1,
+	}
+
+	mockStore.On("Claim", mock.Anything, job.ID.Hex(), mock.AnythingOfType("time.Time")).Return(job, nil)
+	mockStore.On("Finish", mock.Anything, mock.MatchedBy(func(j *models.GenerationJob) bool {
+		return j.Status == models.JobStatusSucceeded && len(j.Results) == 2 && j.Result == j.Results[0] && j.BarID == ""
+	})).Return(nil)
+
+	// Act
+	service.process(context.Background(), job.ID.Hex())
+
+	// Assert
+	mockStore.AssertExpectations(t)
+}
+
+func TestJobService_Process_RefinesBar(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	mockRepo := new(mocks.MockBarRepository)
+	service := createTestJobService(mockStore, mockRepo)
+	barID := primitive.NewObjectID()
+	bar := &models.DonationBar{
+		ID:         barID,
+		UserID:     "user-1",
+		Name:       "Stream Goal",
+		HTML:       `<div class="bar">{total} / {goal} ({percentage}%)</div>`,
+		CSS:        `.bar { width: 100%; }`,
+		Language:   "tr",
+		Theme:      "classic",
+		GoalAmount: 1000,
+	}
+	job := &models.GenerationJob{
+		ID:          primitive.NewObjectID(),
+		UserID:      "user-1",
+		Kind:        models.JobKindRefine,
+		Status:      models.JobStatusRunning,
+		SaveBar:     true,
+		BarID:       barID.Hex(),
+		Instruction: "Daha neon yap",
+		Attempts:    1,
+	}
+	refined := *bar
+	refined.Revision = 1
+
+	mockStore.On("Claim", mock.Anything, job.ID.Hex(), mock.AnythingOfType("time.Time")).Return(job, nil)
+	mockRepo.On("FindByID", mock.Anything, "user-1", barID.Hex()).Return(bar, nil)
+	mockRepo.On("UpdateContent", mock.Anything, "user-1", barID.Hex(), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&refined, nil)
+	mockStore.On("Finish", mock.Anything, mock.MatchedBy(func(j *models.GenerationJob) bool {
+		return j.Status == models.JobStatusSucceeded && j.BarID == barID.Hex() && j.Result != nil && j.Error == ""
+	})).Return(nil)
+
+	// Act
+	service.process(context.Background(), job.ID.Hex())
+
+	// Assert
+	mockStore.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestJobService_Process_GivesUpAfterMaxAttempts(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	service := createTestJobService(mockStore, new(mocks.MockBarRepository))
+	job := &models.GenerationJob{
+		ID:       primitive.NewObjectID(),
+		UserID:   "user-1",
+		Status:   models.JobStatusRunning,
+		Request:  createTestGenerateRequest(),
+		Attempts: maxJobAttempts + 1,
+	}
+
+	mockStore.On("Claim", mock.Anything, job.ID.Hex(), mock.AnythingOfType("time.Time")).Return(job, nil)
+	mockStore.On("Finish", mock.Anything, mock.MatchedBy(func(j *models.GenerationJob) bool {
+		return j.Status == models.JobStatusFailed && j.Result == nil
+	})).Return(nil)
+
+	// Act
+	service.process(context.Background(), job.ID.Hex())
+
+	// Assert
+	mockStore.AssertExpectations(t)
+}
+
+func TestJobService_Process_SkipsUnclaimableJob(t *testing.T) {
+	// Arrange
+	mockStore := new(mocks.MockJobStore)
+	service := createTestJobService(mockStore, new(mocks.MockBarRepository))
+
+	mockStore.On("Claim", mock.Anything, "job-1", mock.AnythingOfType("time.Time")).Return(nil, apperrors.Wrap(apperrors.ErrConflict, "job not claimable"))
+
+	// Act
+	service.process(context.Background(), "job-1")
+
+	// Assert
+	mockStore.AssertNotCalled(t, "Finish", mock.Anything, mock.Anything)
+}
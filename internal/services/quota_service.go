@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+)
+
+type QuotaService struct {
+	users  interfaces.UserRepositoryInterface
+	bars   interfaces.BarRepositoryInterface
+	usage  interfaces.UsageRepositoryInterface
+	config *config.Config
+
+	mu      sync.Mutex
+	viewers map[string]int64 // Live overlay connections per user on this instance
+}
+
+// NewQuotaService creates the plan quota service
+func NewQuotaService(users interfaces.UserRepositoryInterface, bars interfaces.BarRepositoryInterface, usage interfaces.UsageRepositoryInterface, cfg *config.Config) interfaces.QuotaServiceInterface {
+	return &QuotaService{
+		users:   users,
+		bars:    bars,
+		usage:   usage,
+		config:  cfg,
+		viewers: make(map[string]int64),
+	}
+}
+
+// Plan returns the plan of a user
+func (s *QuotaService) Plan(userID string) (*models.Plan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NotFound("user", userID)
+		}
+		return nil, apperrors.DatabaseError("find user", err)
+	}
+
+	if plan, ok := models.FindPlan(user.PlanID(s.config.DefaultPlan)); ok {
+		return plan, nil
+	}
+
+	// A plan removed from the catalogue falls back to the default
+	slog.Warn("User has an unknown plan, using the default plan",
+		"user_id", userID,
+		"plan", user.Plan)
+	if plan, ok := models.FindPlan(s.config.DefaultPlan); ok {
+		return plan, nil
+	}
+	return models.Plans[0], nil
+}
+
+// CheckBarQuota returns an error when the user's plan allows no more bars
+func (s *QuotaService) CheckBarQuota(userID string) error {
+	plan, err := s.Plan(userID)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.countBars(userID)
+	if err != nil {
+		return err
+	}
+
+	if !models.Within(plan.Quota.MaxBars, count) {
+		return apperrors.MaxBarsReached(userID, count, int64(plan.Quota.MaxBars))
+	}
+	return nil
+}
+
+// CheckAIQuota returns an error when the user's plan has no room left for
+// the given number of AI generations today or this month, or the month's
+// token budget is spent
+func (s *QuotaService) CheckAIQuota(userID string, generations int64) error {
+	plan, err := s.Plan(userID)
+	if err != nil {
+		return err
+	}
+
+	today, month, err := s.currentUsage(userID)
+	if err != nil {
+		return err
+	}
+
+	quota := plan.Quota
+	switch {
+	case !models.Fits(quota.AIPerDay, today.Generations, generations):
+		return apperrors.QuotaExceeded("günlük AI üretim", plan.ID, quota.AIPerDay)
+	case !models.Fits(quota.AIPerMonth, month.Generations, generations):
+		return apperrors.QuotaExceeded("aylık AI üretim", plan.ID, quota.AIPerMonth)
+	case !models.Within(quota.TokensPerMonth, month.Tokens):
+		return apperrors.QuotaExceeded("aylık AI token", plan.ID, quota.TokensPerMonth)
+	}
+	return nil
+}
+
+// RecordAIUsage adds generations and tokens to the user's daily and monthly
+// usage. Failures are logged; usage is not worth failing a finished generation.
+func (s *QuotaService) RecordAIUsage(userID string, generations, tokens int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	day, month := s.periods(time.Now())
+	for _, period := range []string{day, month} {
+		if err := s.usage.Add(ctx, userID, period, generations, tokens); err != nil {
+			slog.Warn("Failed to record AI usage",
+				"user_id", userID,
+				"period", period,
+				"error", err.Error())
+		}
+	}
+}
+
+// AcquireOverlayViewer counts a live overlay connection of the user's bars
+// against the plan; call release when the connection closes. Connections are
+// counted per instance.
+func (s *QuotaService) AcquireOverlayViewer(userID string) (func(), error) {
+	plan, err := s.Plan(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !models.Within(plan.Quota.OverlayViewers, s.viewers[userID]) {
+		return nil, apperrors.QuotaExceeded("canlı overlay izleyici", plan.ID, plan.Quota.OverlayViewers)
+	}
+	s.viewers[userID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if s.viewers[userID]--; s.viewers[userID] <= 0 {
+				delete(s.viewers, userID)
+			}
+		})
+	}, nil
+}
+
+// GetQuota returns the user's plan with its current usage
+func (s *QuotaService) GetQuota(userID string) (*models.QuotaStatus, error) {
+	plan, err := s.Plan(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bars, err := s.countBars(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	today, month, err := s.currentUsage(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	viewers := s.viewers[userID]
+	s.mu.Unlock()
+
+	loc := s.config.RateLimit.Location()
+	midnight := ratelimit.StartOfDay(time.Now(), loc)
+	return &models.QuotaStatus{
+		Plan: plan,
+		Usage: models.Usage{
+			Bars:            bars,
+			AIToday:         today.Generations,
+			AIThisMonth:     month.Generations,
+			TokensThisMonth: month.Tokens,
+			OverlayViewers:  viewers,
+		},
+		DailyReset:   midnight.AddDate(0, 0, 1),
+		MonthlyReset: time.Date(midnight.Year(), midnight.Month()+1, 1, 0, 0, 0, 0, loc),
+	}, nil
+}
+
+func (s *QuotaService) countBars(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	count, err := s.bars.CountByUserID(ctx, userID)
+	if err != nil {
+		return 0, apperrors.DatabaseError("count user bars", err)
+	}
+	return count, nil
+}
+
+// currentUsage returns the user's usage of today and this month
+func (s *QuotaService) currentUsage(userID string) (today, month *models.UsagePeriod, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseRead)
+	defer cancel()
+
+	day, monthPeriod := s.periods(time.Now())
+	if today, err = s.usage.Find(ctx, userID, day); err != nil {
+		return nil, nil, apperrors.DatabaseError("find AI usage", err)
+	}
+	if month, err = s.usage.Find(ctx, userID, monthPeriod); err != nil {
+		return nil, nil, apperrors.DatabaseError("find AI usage", err)
+	}
+	return today, month, nil
+}
+
+// periods returns the day and month usage periods of t in the rate limit time zone
+func (s *QuotaService) periods(t time.Time) (day, month string) {
+	t = t.In(s.config.RateLimit.Location())
+	return t.Format(models.DayPeriodLayout), t.Format(models.MonthPeriodLayout)
+}
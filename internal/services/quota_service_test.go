@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/mocks"
+	"donationbars/internal/models"
+	"donationbars/internal/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestQuotaUser returns a quota service where userID is on plan
+func createTestQuotaUser(userID, plan string, bars *mocks.MockBarRepository) *QuotaService {
+	users := new(mocks.MockUserRepository)
+	users.On("FindByID", mock.Anything, userID).Return(&models.User{Plan: plan}, nil)
+	return NewQuotaService(users, bars, memory.NewUsageRepository(), createTestConfig()).(*QuotaService)
+}
+
+func TestQuotaService_Plan_FallsBackToDefault(t *testing.T) {
+	for _, plan := range []string{"", "retired"} {
+		service := createTestQuotaUser("user-1", plan, nil)
+
+		result, err := service.Plan("user-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.PlanFree, result.ID, "plan %q", plan)
+	}
+
+	service := createTestQuotaUser("user-1", models.PlanPro, nil)
+	result, err := service.Plan("user-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.PlanPro, result.ID)
+}
+
+func TestQuotaService_CheckBarQuota(t *testing.T) {
+	bars := new(mocks.MockBarRepository)
+	bars.On("CountByUserID", mock.Anything, "user-1").Return(int64(5), nil)
+
+	err := createTestQuotaUser("user-1", models.PlanFree, bars).CheckBarQuota("user-1")
+	assert.ErrorIs(t, err, apperrors.ErrMaxBarsReached)
+
+	err = createTestQuotaUser("user-1", models.PlanPro, bars).CheckBarQuota("user-1")
+	assert.NoError(t, err)
+}
+
+func TestQuotaService_CheckAIQuota(t *testing.T) {
+	service := createTestQuotaUser("user-1", models.PlanFree, nil)
+	free, _ := models.FindPlan(models.PlanFree)
+	_, month := service.periods(time.Now())
+	ctx := context.Background()
+
+	require.NoError(t, service.CheckAIQuota("user-1", 1))
+	require.NoError(t, service.CheckAIQuota("user-1", int64(free.Quota.AIPerDay)))
+	require.ErrorIs(t, service.CheckAIQuota("user-1", int64(free.Quota.AIPerDay)+1), apperrors.ErrQuotaExceeded)
+
+	// The daily limit applies first
+	service.RecordAIUsage("user-1", int64(free.Quota.AIPerDay), 0)
+	err := service.CheckAIQuota("user-1", 1)
+	require.ErrorIs(t, err, apperrors.ErrQuotaExceeded)
+	var appErr *apperrors.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "günlük AI üretim kotası doldu (5)", appErr.Message)
+
+	// The month counts every day of it
+	other := createTestQuotaUser("user-2", models.PlanFree, nil)
+	require.NoError(t, other.usage.Add(ctx, "user-2", month, int64(free.Quota.AIPerMonth), 0))
+	err = other.CheckAIQuota("user-2", 1)
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "aylık AI üretim kotası doldu (50)", appErr.Message)
+
+	// Tokens have their own monthly budget
+	other = createTestQuotaUser("user-2", models.PlanFree, nil)
+	other.RecordAIUsage("user-2", 0, int64(free.Quota.TokensPerMonth))
+	err = other.CheckAIQuota("user-2", 1)
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "aylık AI token kotası doldu (100000)", appErr.Message)
+}
+
+func TestQuotaService_AcquireOverlayViewer(t *testing.T) {
+	service := createTestQuotaUser("user-1", models.PlanFree, nil)
+
+	var releases []func()
+	for range 3 {
+		release, err := service.AcquireOverlayViewer("user-1")
+		require.NoError(t, err)
+		releases = append(releases, release)
+	}
+
+	_, err := service.AcquireOverlayViewer("user-1")
+	assert.ErrorIs(t, err, apperrors.ErrQuotaExceeded)
+
+	// Releasing twice frees a single slot
+	releases[0]()
+	releases[0]()
+	release, err := service.AcquireOverlayViewer("user-1")
+	require.NoError(t, err)
+	_, err = service.AcquireOverlayViewer("user-1")
+	assert.ErrorIs(t, err, apperrors.ErrQuotaExceeded)
+
+	release()
+	for _, release := range releases[1:] {
+		release()
+	}
+	assert.Empty(t, service.viewers)
+}
+
+func TestQuotaService_GetQuota(t *testing.T) {
+	bars := new(mocks.MockBarRepository)
+	bars.On("CountByUserID", mock.Anything, "user-1").Return(int64(2), nil)
+	service := createTestQuotaUser("user-1", models.PlanPro, bars)
+
+	service.RecordAIUsage("user-1", 1, 0)
+	service.RecordAIUsage("user-1", 0, 1200)
+	_, err := service.AcquireOverlayViewer("user-1")
+	require.NoError(t, err)
+
+	quota, err := service.GetQuota("user-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, models.PlanPro, quota.Plan.ID)
+	assert.Equal(t, models.Usage{Bars: 2, AIToday: 1, AIThisMonth: 1, TokensThisMonth: 1200, OverlayViewers: 1}, quota.Usage)
+	assert.True(t, quota.DailyReset.After(time.Now()))
+	assert.Equal(t, 1, quota.MonthlyReset.Day())
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/ratelimit"
+)
+
+// allowAction records requests of the user against the action's limit, all
+// or none of them. They are let through when the limiter is unreachable, so
+// an outage of Redis does not take AI generation and donation ingest down
+// with it.
+func allowAction(limits *ratelimit.Guard, action ratelimit.Action, userID string, requests int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err := limits.AllowN(ctx, action, userID, requests)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, apperrors.ErrRateLimitExceeded) {
+		slog.Warn("Rate limit exceeded", "action", action, "user_id", userID)
+		return err
+	}
+
+	slog.Warn("Rate limiter unavailable, allowing request",
+		"action", action,
+		"error", err.Error(),
+		"user_id", userID)
+	return nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"donationbars/internal/config"
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+	"donationbars/internal/ratelimit"
+	"donationbars/internal/webhooks"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limits of webhook donation fields, matching CreateDonationRequest
+const (
+	maxWebhookDonorName = 100
+	maxWebhookMessage   = 500
+)
+
+type WebhookService struct {
+	bars      interfaces.BarServiceInterface
+	donations interfaces.DonationRepositoryInterface
+	limits    *ratelimit.Guard
+	config    *config.Config
+	now       func() time.Time
+}
+
+// NewWebhookService creates the incoming donation webhook service. Webhook
+// donations count towards the bar owner's donation rate limit; a nil guard
+// leaves them unlimited.
+func NewWebhookService(bars interfaces.BarServiceInterface, donations interfaces.DonationRepositoryInterface, limits *ratelimit.Guard, cfg *config.Config) interfaces.WebhookServiceInterface {
+	return &WebhookService{
+		bars:      bars,
+		donations: donations,
+		limits:    limits,
+		config:    cfg,
+		now:       time.Now,
+	}
+}
+
+// Receive verifies a webhook delivery for the bar owning token and records
+// its donations. Events already recorded for the bar are skipped, so
+// providers can safely redeliver.
+func (s *WebhookService) Receive(providerName, token string, header http.Header, body []byte) (*models.WebhookResult, error) {
+	provider, ok := webhooks.Find(providerName)
+	if !ok {
+		return nil, apperrors.NotFound("webhook provider", providerName)
+	}
+
+	bar, err := s.bars.GetBarByWebhookToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := provider.Verify(header, body, bar.WebhookSecret, s.now()); err != nil {
+		slog.Warn("Webhook delivery rejected",
+			"provider", provider.Name(),
+			"bar_id", bar.ID.Hex(),
+			"error", err.Error())
+		return nil, apperrors.Unauthorized("invalid webhook signature")
+	}
+
+	events, err := provider.Parse(body)
+	if err != nil {
+		return nil, apperrors.InvalidInput("webhook payload", err.Error())
+	}
+
+	result := &models.WebhookResult{Total: bar.CurrentTotal()}
+	var limitErr error
+	for _, event := range events {
+		donation, err := s.newDonation(bar, provider.Name(), event)
+		if err != nil {
+			result.Ignored++
+			slog.Warn("Webhook event ignored",
+				"provider", provider.Name(),
+				"bar_id", bar.ID.Hex(),
+				"event_id", event.ID,
+				"error", err.Error())
+			continue
+		}
+
+		recorded, err := s.record(donation)
+		if errors.Is(err, apperrors.ErrRateLimitExceeded) {
+			// The rest of the batch is still tried: redeliveries do not count against the limit
+			limitErr = err
+			result.Rejected++
+			result.RejectedIDs = append(result.RejectedIDs, event.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if recorded == nil {
+			result.Duplicates++
+			continue
+		}
+
+		result.Accepted++
+		result.AcceptedIDs = append(result.AcceptedIDs, event.ID)
+		result.Total = recorded.CurrentTotal()
+	}
+
+	// With nothing recorded the whole delivery is refused, so the provider's
+	// retry covers it; otherwise the result lists the events to redeliver
+	if limitErr != nil && result.Accepted == 0 {
+		return nil, limitErr
+	}
+
+	slog.Info("Webhook delivery processed",
+		"provider", provider.Name(),
+		"bar_id", bar.ID.Hex(),
+		"accepted", result.Accepted,
+		"duplicates", result.Duplicates,
+		"ignored", result.Ignored,
+		"rejected", result.Rejected)
+
+	return result, nil
+}
+
+// record adds a donation to the ledger and the bar total. It returns a nil
+// bar when the event was recorded before. A donation that cannot be counted
+// is removed from the ledger again, so the provider's retry records it.
+func (s *WebhookService) record(donation *models.Donation) (*models.DonationBar, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeouts.DatabaseWrite)
+	defer cancel()
+
+	// The ledger's unique external ID is the replay protection
+	if err := s.donations.Insert(ctx, donation); err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return nil, nil
+		}
+		return nil, apperrors.DatabaseError("insert donation", err)
+	}
+
+	// Checked once the event is known to be new, so redeliveries cost nothing
+	if err := allowAction(s.limits, ratelimit.ActionDonation, donation.UserID, 1, s.config.Timeouts.RedisOperation); err != nil {
+		rollbackDonation(s.donations, donation, s.config.Timeouts.DatabaseWrite)
+		return nil, err
+	}
+
+	bar, err := s.bars.ApplyDonation(donation)
+	if err != nil {
+		rollbackDonation(s.donations, donation, s.config.Timeouts.DatabaseWrite)
+		return nil, err
+	}
+
+	return bar, nil
+}
+
+// newDonation validates a provider event against the bar
+func (s *WebhookService) newDonation(bar *models.DonationBar, source string, event webhooks.Event) (*models.Donation, error) {
+	if event.ID == "" {
+		return nil, apperrors.ValidationError("id", "event ID is required")
+	}
+	if !(event.Amount > 0) { // also catches NaN
+		return nil, apperrors.ValidationError("amount", "must be positive")
+	}
+	if event.Amount > webhooks.MaxAmount {
+		return nil, apperrors.ValidationError("amount", fmt.Sprintf("must be at most %d", webhooks.MaxAmount))
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(event.Currency))
+	if currency == "" {
+		currency = bar.CurrencyCode()
+	}
+	if currency != bar.CurrencyCode() {
+		return nil, apperrors.ValidationError("currency", "bar accepts "+bar.CurrencyCode()+" donations only")
+	}
+
+	donation := &models.Donation{
+		ID:         primitive.NewObjectID(),
+		BarID:      bar.ID,
+		UserID:     bar.UserID,
+		DonorName:  truncateRunes(strings.TrimSpace(event.DonorName), maxWebhookDonorName),
+		Amount:     event.Amount,
+		Currency:   currency,
+		Message:    truncateRunes(event.Message, maxWebhookMessage),
+		Source:     source,
+		ExternalID: event.ID,
+		CreatedAt:  s.now(),
+	}
+	if donation.DonorName == "" {
+		donation.DonorName = models.AnonymousDonorName
+	}
+
+	return donation, nil
+}
+
+// truncateRunes shortens s to at most n characters
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	apperrors "donationbars/internal/errors"
+	"donationbars/internal/events"
+	"donationbars/internal/interfaces"
+	"donationbars/internal/models"
+	"donationbars/internal/repository/memory"
+	"donationbars/internal/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestWebhookService returns a webhook service on memory repositories
+// with one webhook enabled TRY bar
+func createTestWebhookService(t *testing.T) (*WebhookService, *models.DonationBar, *models.WebhookCredentials) {
+	cfg := createTestConfig()
+	bars := memory.NewBarRepository()
+	donations := memory.NewDonationRepository()
+	barService := NewBarService(bars, createTestRevisionRepository(), createTestQuotaService(bars), nil, events.NewMemoryBroker(), nil, cfg)
+
+	bar, err := barService.CreateBar("user-1", &models.CreateBarRequest{
+		Name:          "Webhook Bar",
+		HTML:          "<div>{goal} {total} {percentage} {remaining} {description}</div>",
+		CSS:           ".bar { width: 800px; }",
+		Language:      "tr",
+		InitialAmount: 100,
+		GoalAmount:    1000,
+	})
+	require.NoError(t, err)
+
+	credentials, err := barService.RegenerateWebhook("user-1", bar.ID.Hex())
+	require.NoError(t, err)
+
+	return NewWebhookService(barService, donations, createTestGuard(cfg), cfg).(*WebhookService), bar, credentials
+}
+
+// failingApplyBars fails the first ApplyDonation calls like an unreachable database
+type failingApplyBars struct {
+	interfaces.BarServiceInterface
+	failures int
+}
+
+func (b *failingApplyBars) ApplyDonation(donation *models.Donation) (*models.DonationBar, error) {
+	if b.failures > 0 {
+		b.failures--
+		return nil, apperrors.DatabaseError("update bar total", errors.New("connection reset"))
+	}
+	return b.BarServiceInterface.ApplyDonation(donation)
+}
+
+// genericDelivery signs a generic webhook body with secret
+func genericDelivery(secret, body string) (http.Header, []byte) {
+	header := http.Header{}
+	header.Set(webhooks.SignatureHeader, webhooks.SignGeneric(secret, time.Now(), []byte(body)))
+	return header, []byte(body)
+}
+
+func TestWebhookService_Receive_RecordsOnce(t *testing.T) {
+	service, bar, credentials := createTestWebhookService(t)
+	header, body := genericDelivery(credentials.Secret, `[{"id":"evt_1","amount":50,"donor_name":"Ayşe"},{"id":"evt_2","amount":"25.5","currency":"try"}]`)
+
+	result, err := service.Receive("generic", credentials.Token, header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, &models.WebhookResult{Accepted: 2, AcceptedIDs: []string{"evt_1", "evt_2"}, Total: 175.5}, result)
+
+	// A redelivery changes nothing
+	result, err = service.Receive("generic", credentials.Token, header, body)
+	require.NoError(t, err)
+	assert.Equal(t, &models.WebhookResult{Duplicates: 2, Total: 175.5}, result)
+
+	donations, err := service.donations.FindByBarID(t.Context(), bar.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, donations, 2)
+	assert.Equal(t, "generic", donations[0].Source)
+	assert.Equal(t, "user-1", donations[0].UserID)
+	assert.ElementsMatch(t, []string{"evt_1", "evt_2"}, []string{donations[0].ExternalID, donations[1].ExternalID})
+}
+
+func TestWebhookService_Receive_IgnoresInvalidEvents(t *testing.T) {
+	service, _, credentials := createTestWebhookService(t)
+	header, body := genericDelivery(credentials.Secret, `[{"id":"","amount":5},{"id":"evt_1","amount":0},{"id":"evt_2","amount":5,"currency":"USD"},{"id":"evt_3","amount":5}]`)
+
+	result, err := service.Receive("generic", credentials.Token, header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, &models.WebhookResult{Accepted: 1, AcceptedIDs: []string{"evt_3"}, Ignored: 3, Total: 105}, result)
+}
+
+func TestWebhookService_Receive_Rejected(t *testing.T) {
+	service, _, credentials := createTestWebhookService(t)
+	header, body := genericDelivery(credentials.Secret, `{"id":"evt_1","amount":50}`)
+	forged, _ := genericDelivery("whsec_forged", string(body))
+	_, invalid := genericDelivery(credentials.Secret, `{"id":`)
+	invalidHeader, _ := genericDelivery(credentials.Secret, string(invalid))
+
+	tests := []struct {
+		name     string
+		provider string
+		token    string
+		header   http.Header
+		body     []byte
+		want     error
+	}{
+		{"unknown provider", "patreon", credentials.Token, header, body, apperrors.ErrNotFound},
+		{"unknown token", "generic", "not-a-token", header, body, apperrors.ErrNotFound},
+		{"forged signature", "generic", credentials.Token, forged, body, apperrors.ErrUnauthorized},
+		{"signature of another provider", "streamlabs", credentials.Token, header, body, apperrors.ErrUnauthorized},
+		{"malformed payload", "generic", credentials.Token, invalidHeader, invalid, apperrors.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.Receive(tt.provider, tt.token, tt.header, tt.body)
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestWebhookService_Receive_RetriesUncountedDonation(t *testing.T) {
+	service, bar, credentials := createTestWebhookService(t)
+	service.bars = &failingApplyBars{BarServiceInterface: service.bars, failures: 1}
+	header, body := genericDelivery(credentials.Secret, `{"id":"evt_1","amount":50}`)
+
+	result, err := service.Receive("generic", credentials.Token, header, body)
+	assert.Nil(t, result)
+	var appErr *apperrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperrors.TypeDatabaseError, appErr.Type) // 5xx, so the provider retries
+
+	donations, err := service.donations.FindByBarID(t.Context(), bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Empty(t, donations)
+
+	// The provider's retry is recorded, not skipped as a duplicate
+	result, err = service.Receive("generic", credentials.Token, header, body)
+	require.NoError(t, err)
+	assert.Equal(t, &models.WebhookResult{Accepted: 1, AcceptedIDs: []string{"evt_1"}, Total: 150}, result)
+}
+
+func TestWebhookService_Receive_RateLimitExceeded(t *testing.T) {
+	service, bar, credentials := createTestWebhookService(t)
+	cfg := createTestConfig()
+	cfg.RateLimit.DonationsPerMinute = 1
+	service.limits = createTestGuard(cfg)
+	header, body := genericDelivery(credentials.Secret, `[{"id":"evt_1","amount":50},{"id":"evt_2","amount":5},{"id":"evt_3","amount":7}]`)
+
+	// The event within the limit is kept and the rest are listed for redelivery
+	result, err := service.Receive("generic", credentials.Token, header, body)
+	require.NoError(t, err)
+	assert.Equal(t, &models.WebhookResult{
+		Accepted:    1,
+		AcceptedIDs: []string{"evt_1"},
+		Rejected:    2,
+		RejectedIDs: []string{"evt_2", "evt_3"},
+		Total:       150,
+	}, result)
+
+	donations, err := service.donations.FindByBarID(t.Context(), bar.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, donations, 1)
+	assert.Equal(t, "evt_1", donations[0].ExternalID)
+
+	// A redelivery does not count against the limit
+	header, body = genericDelivery(credentials.Secret, `{"id":"evt_1","amount":50}`)
+	result, err = service.Receive("generic", credentials.Token, header, body)
+	require.NoError(t, err)
+	assert.Equal(t, &models.WebhookResult{Duplicates: 1, Total: 150}, result)
+
+	// With nothing recorded the whole delivery is refused, so the provider retries it
+	header, body = genericDelivery(credentials.Secret, `[{"id":"evt_1","amount":50},{"id":"evt_2","amount":5}]`)
+	result, err = service.Receive("generic", credentials.Token, header, body)
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, apperrors.ErrRateLimitExceeded)
+}
+
+func TestWebhookService_Receive_Streamlabs(t *testing.T) {
+	service, _, credentials := createTestWebhookService(t)
+	body := []byte(`{"type":"donation","message":[{"id":96,"name":"Ayşe","amount":"10.00","currency":"TRY","message":"Kolay gelsin"}]}`)
+	header := http.Header{}
+	header.Set(webhooks.SignatureHeader, "sha256="+webhooks.Sign(credentials.Secret, body))
+
+	result, err := service.Receive("streamlabs", credentials.Token, header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Accepted)
+	assert.Equal(t, 110.0, result.Total)
+}
+
+func TestWebhookService_Receive_ConcurrentDeliveries(t *testing.T) {
+	service, bar, credentials := createTestWebhookService(t)
+
+	// Every event is delivered twice at the same time; each counts once
+	var wg sync.WaitGroup
+	for i := range 50 {
+		header, body := genericDelivery(credentials.Secret, fmt.Sprintf(`{"id":"evt_%d","amount":2}`, i))
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := service.Receive("generic", credentials.Token, header, body)
+				assert.NoError(t, err)
+			}()
+		}
+	}
+	wg.Wait()
+
+	updated, err := service.bars.GetBar("user-1", bar.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, updated.DonationTotal)
+}
@@ -0,0 +1,91 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureTolerance is how far a generic delivery's signed timestamp may be
+// from the server clock; older deliveries are rejected as replays
+const SignatureTolerance = 5 * time.Minute
+
+// Generic is the platform independent format, for custom integrations:
+//
+//	X-Webhook-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+//	{"id": "evt_1", "amount": 50, "currency": "TRY", "donor_name": "Ayşe", "message": "..."}
+//
+// A list of such objects is accepted as well.
+type Generic struct{}
+
+func (Generic) Name() string { return "generic" }
+
+// SignGeneric returns the signature header value of a generic delivery
+func SignGeneric(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + Sign(secret, []byte(t+"."+string(body)))
+}
+
+func (Generic) Verify(header http.Header, body []byte, secret string, now time.Time) error {
+	var timestamp, signature string
+	for part := range strings.SplitSeq(header.Get(SignatureHeader), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return fmt.Errorf("%w: timestamp outside the %s tolerance", ErrInvalidSignature, SignatureTolerance)
+	}
+
+	if !validSignature(secret, []byte(timestamp+"."+string(body)), signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type genericEvent struct {
+	ID        ID     `json:"id"`
+	Amount    Amount `json:"amount"`
+	Currency  string `json:"currency"`
+	DonorName string `json:"donor_name"`
+	Message   string `json:"message"`
+}
+
+func (Generic) Parse(body []byte) ([]Event, error) {
+	var payload []genericEvent
+	if trimmed := strings.TrimSpace(string(body)); strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, err
+		}
+	} else {
+		var event genericEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return nil, err
+		}
+		payload = append(payload, event)
+	}
+
+	events := make([]Event, 0, len(payload))
+	for _, e := range payload {
+		events = append(events, Event{
+			ID:        string(e.ID),
+			DonorName: e.DonorName,
+			Amount:    float64(e.Amount),
+			Currency:  e.Currency,
+			Message:   e.Message,
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// StreamElements accepts StreamElements activity events, forwarded by a relay
+// that signs the raw body ("X-Webhook-Signature: sha256=<hex>"):
+//
+//	{"_id": "...", "type": "tip", "data": {"tipId": "...", "username": "ayse", "displayName": "Ayşe", "amount": 5, "currency": "EUR", "message": "..."}}
+//
+// Replays are caught by the tip IDs.
+type StreamElements struct{}
+
+func (StreamElements) Name() string { return "streamelements" }
+
+func (StreamElements) Verify(header http.Header, body []byte, secret string, now time.Time) error {
+	return verifyBody(header, body, secret)
+}
+
+type streamElementsPayload struct {
+	ID   ID     `json:"_id"`
+	Type string `json:"type"`
+	Data struct {
+		TipID       ID     `json:"tipId"`
+		Username    string `json:"username"`
+		DisplayName string `json:"displayName"`
+		Amount      Amount `json:"amount"`
+		Currency    string `json:"currency"`
+		Message     string `json:"message"`
+	} `json:"data"`
+}
+
+func (StreamElements) Parse(body []byte) ([]Event, error) {
+	var payload streamElementsPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	if payload.Type != "tip" {
+		return nil, nil
+	}
+
+	data := payload.Data
+	event := Event{
+		ID:        string(data.TipID),
+		DonorName: data.DisplayName,
+		Amount:    float64(data.Amount),
+		Currency:  data.Currency,
+		Message:   data.Message,
+	}
+	if event.ID == "" {
+		event.ID = string(payload.ID)
+	}
+	if event.DonorName == "" {
+		event.DonorName = data.Username
+	}
+	return []Event{event}, nil
+}
@@ -0,0 +1,54 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Streamlabs accepts Streamlabs socket API events, forwarded by a relay that
+// signs the raw body ("X-Webhook-Signature: sha256=<hex>"):
+//
+//	{"type": "donation", "event_id": "...", "message": [{"id": 96, "name": "Ayşe", "amount": "10.00", "currency": "USD", "message": "..."}]}
+//
+// Replays are caught by the donation IDs.
+type Streamlabs struct{}
+
+func (Streamlabs) Name() string { return "streamlabs" }
+
+func (Streamlabs) Verify(header http.Header, body []byte, secret string, now time.Time) error {
+	return verifyBody(header, body, secret)
+}
+
+type streamlabsPayload struct {
+	Type    string `json:"type"`
+	Message []struct {
+		ID       ID     `json:"id"`
+		Name     string `json:"name"`
+		Amount   Amount `json:"amount"`
+		Currency string `json:"currency"`
+		Message  string `json:"message"`
+	} `json:"message"`
+}
+
+func (Streamlabs) Parse(body []byte) ([]Event, error) {
+	var payload streamlabsPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	if payload.Type != "donation" {
+		return nil, nil
+	}
+
+	events := make([]Event, 0, len(payload.Message))
+	for _, m := range payload.Message {
+		events = append(events, Event{
+			ID:        string(m.ID),
+			DonorName: m.Name,
+			Amount:    float64(m.Amount),
+			Currency:  m.Currency,
+			Message:   m.Message,
+		})
+	}
+	return events, nil
+}
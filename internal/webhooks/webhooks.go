@@ -0,0 +1,162 @@
+// Package webhooks turns donation platform webhook deliveries into donations.
+// Each provider adapter verifies the delivery's signature with the bar's
+// webhook secret and parses its payload into events.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"donationbars/internal/models"
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of every delivery
+const SignatureHeader = "X-Webhook-Signature"
+
+// ErrInvalidSignature is returned when a delivery is not signed with the bar's secret
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Event is one donation reported by a provider
+type Event struct {
+	ID        string // Provider event ID, the replay protection key
+	DonorName string
+	Amount    float64
+	Currency  string // Empty when the provider does not say
+	Message   string
+}
+
+// Provider adapts one donation platform's webhook format
+type Provider interface {
+	Name() string
+	// Verify checks the delivery's signature; now bounds signed timestamps
+	Verify(header http.Header, body []byte, secret string, now time.Time) error
+	// Parse returns the donations of a delivery; other event types yield none
+	Parse(body []byte) ([]Event, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{}
+)
+
+func init() {
+	Register(Generic{})
+	Register(Streamlabs{})
+	Register(StreamElements{})
+}
+
+// Register makes a provider available under its name, replacing any other
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name()] = p
+}
+
+// Find returns the provider registered under name
+func Find(name string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+// Names lists the registered providers in alphabetical order
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Sign returns the hex HMAC-SHA256 of message with secret
+func Sign(secret string, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyBody checks a "sha256=<hex>" signature of the raw body
+func verifyBody(header http.Header, body []byte, secret string) error {
+	signature, ok := strings.CutPrefix(header.Get(SignatureHeader), "sha256=")
+	if !ok || !validSignature(secret, body, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// validSignature compares a hex signature to the expected one in constant time
+func validSignature(secret string, message []byte, signature string) bool {
+	if secret == "" {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, message)), []byte(strings.ToLower(signature)))
+}
+
+// MaxAmount bounds a single webhook donation like any other donation
+const MaxAmount = models.MaxDonationAmount
+
+// Amount is a donation amount sent either as a JSON number or a string ("10.00")
+type Amount float64
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return fmt.Errorf("amount: %w", err)
+		}
+		number = json.Number(strings.TrimSpace(text))
+	}
+	if number == "" {
+		*a = 0 // null, rejected later as a non-positive amount
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(number.String(), 64)
+	if err != nil {
+		return fmt.Errorf("amount: %w", err)
+	}
+	// ParseFloat accepts "NaN" and "Inf", which no provider means
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("amount: %q is not a number", number)
+	}
+	if value > MaxAmount {
+		return fmt.Errorf("amount: %s exceeds %d", number, MaxAmount)
+	}
+	*a = Amount(value)
+	return nil
+}
+
+// ID is an event ID sent either as a JSON string or a number
+type ID string
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*id = ID(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("id: %w", err)
+	}
+	*id = ID(number.String())
+	return nil
+}
@@ -0,0 +1,153 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "whsec_test"
+
+func signedHeader(value string) http.Header {
+	header := http.Header{}
+	header.Set(SignatureHeader, value)
+	return header
+}
+
+func TestGeneric_Verify(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":"evt_1","amount":50}`)
+	provider := Generic{}
+
+	header := signedHeader(SignGeneric(testSecret, now.Add(-time.Minute), body))
+	assert.NoError(t, provider.Verify(header, body, testSecret, now))
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		secret string
+	}{
+		{"wrong secret", header, body, "other"},
+		{"no secret configured", header, body, ""},
+		{"tampered body", header, []byte(`{"id":"evt_1","amount":5000}`), testSecret},
+		{"missing header", http.Header{}, body, testSecret},
+		{"stale timestamp", signedHeader(SignGeneric(testSecret, now.Add(-SignatureTolerance-time.Second), body)), body, testSecret},
+		{"future timestamp", signedHeader(SignGeneric(testSecret, now.Add(SignatureTolerance+time.Second), body)), body, testSecret},
+		{"body signature", signedHeader("sha256=" + Sign(testSecret, body)), body, testSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := provider.Verify(tt.header, tt.body, tt.secret, now)
+			assert.True(t, errors.Is(err, ErrInvalidSignature), "got %v", err)
+		})
+	}
+}
+
+func TestBodySignature_Verify(t *testing.T) {
+	body := []byte(`{"type":"tip"}`)
+
+	for _, provider := range []Provider{Streamlabs{}, StreamElements{}} {
+		t.Run(provider.Name(), func(t *testing.T) {
+			assert.NoError(t, provider.Verify(signedHeader("sha256="+Sign(testSecret, body)), body, testSecret, time.Now()))
+			assert.ErrorIs(t, provider.Verify(signedHeader(Sign(testSecret, body)), body, testSecret, time.Now()), ErrInvalidSignature)
+			assert.ErrorIs(t, provider.Verify(signedHeader("sha256="+Sign("other", body)), body, testSecret, time.Now()), ErrInvalidSignature)
+		})
+	}
+}
+
+func TestProviders_Parse(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		body     string
+		want     []Event
+	}{
+		{
+			Generic{},
+			`{"id":"evt_1","amount":50.5,"currency":"TRY","donor_name":"Ayşe","message":"Kolay gelsin"}`,
+			[]Event{{ID: "evt_1", DonorName: "Ayşe", Amount: 50.5, Currency: "TRY", Message: "Kolay gelsin"}},
+		},
+		{
+			Generic{},
+			`[{"id":1,"amount":"10"},{"id":2,"amount":20}]`,
+			[]Event{{ID: "1", Amount: 10}, {ID: "2", Amount: 20}},
+		},
+		{
+			Streamlabs{},
+			`{"type":"donation","event_id":"e1","message":[{"id":96,"name":"Ayşe","amount":"10.00","currency":"USD","message":"hi"},{"id":97,"name":"Can","amount":5}]}`,
+			[]Event{
+				{ID: "96", DonorName: "Ayşe", Amount: 10, Currency: "USD", Message: "hi"},
+				{ID: "97", DonorName: "Can", Amount: 5},
+			},
+		},
+		{Streamlabs{}, `{"type":"follow","message":[{"name":"Ayşe"}]}`, nil},
+		{
+			StreamElements{},
+			`{"_id":"act_1","type":"tip","data":{"tipId":"tip_1","username":"ayse","displayName":"Ayşe","amount":5,"currency":"EUR","message":"hi"}}`,
+			[]Event{{ID: "tip_1", DonorName: "Ayşe", Amount: 5, Currency: "EUR", Message: "hi"}},
+		},
+		{
+			StreamElements{},
+			`{"_id":"act_2","type":"tip","data":{"username":"ayse","amount":"7.5"}}`,
+			[]Event{{ID: "act_2", DonorName: "ayse", Amount: 7.5}},
+		},
+		{StreamElements{}, `{"_id":"act_3","type":"subscriber","data":{"amount":3}}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider.Name(), func(t *testing.T) {
+			events, err := tt.provider.Parse([]byte(tt.body))
+			require.NoError(t, err)
+			if tt.want == nil {
+				assert.Empty(t, events)
+				return
+			}
+			assert.Equal(t, tt.want, events)
+		})
+	}
+
+	_, err := Generic{}.Parse([]byte(`{"id":"evt_1","amount":"ten"}`))
+	assert.Error(t, err)
+	_, err = Streamlabs{}.Parse([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestProviders_Parse_RejectsImplausibleAmounts(t *testing.T) {
+	bodies := map[string]string{
+		"generic":        `{"id":"evt_1","amount":%s}`,
+		"streamlabs":     `{"type":"donation","message":[{"id":96,"name":"Ayşe","amount":%s}]}`,
+		"streamelements": `{"_id":"act_1","type":"tip","data":{"username":"ayse","amount":%s}}`,
+	}
+	amounts := []string{`"NaN"`, `"nan"`, `"Inf"`, `"-Infinity"`, `"1e308"`, `1e308`, `1e400`, `1000000000.01`}
+
+	for name, body := range bodies {
+		provider, ok := Find(name)
+		require.True(t, ok)
+		for _, amount := range amounts {
+			t.Run(name+"/"+amount, func(t *testing.T) {
+				_, err := provider.Parse([]byte(fmt.Sprintf(body, amount)))
+				assert.Error(t, err)
+			})
+		}
+
+		events, err := provider.Parse([]byte(fmt.Sprintf(body, "1000000000")))
+		require.NoError(t, err, name)
+		require.Len(t, events, 1)
+		assert.Equal(t, float64(MaxAmount), events[0].Amount)
+	}
+}
+
+func TestFind(t *testing.T) {
+	assert.Equal(t, []string{"generic", "streamelements", "streamlabs"}, Names())
+
+	provider, ok := Find("streamlabs")
+	require.True(t, ok)
+	assert.Equal(t, "streamlabs", provider.Name())
+
+	_, ok = Find("patreon")
+	assert.False(t, ok)
+}